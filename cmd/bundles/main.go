@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/airgap"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+	"github.com/splitio/split-synchronizer/v5/splitio/util"
+
+	"github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const (
+	exitCodeSuccess = 0
+	exitCodeError   = 1
+	exitCodeUsage   = 2
+)
+
+const usage = `Usage:
+  split-bundles [options] list <bundles-dir>    print the manifests of the bundles pending upload as JSON
+  split-bundles [options] upload <bundles-dir>  upload the pending bundles to Split, oldest first
+
+Options:
+`
+
+func main() {
+	defaults := conf.GetDefaultAdvancedConfig()
+	apikey := flag.String("apikey", os.Getenv("SPLIT_BUNDLES_APIKEY"), "Split server side SDK key the bundles were recorded with. (Default: SPLIT_BUNDLES_APIKEY env var)")
+	eventsURL := flag.String("events-url", defaults.EventsURL, "Base url impressions & events are posted to")
+	telemetryURL := flag.String("telemetry-url", defaults.TelemetryServiceURL, "Base url sdk telemetry is posted to")
+	timeoutMs := flag.Int("timeout-ms", 30000, "Timeout for each request to Split")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var err error
+	switch args := flag.Args(); {
+	case len(args) == 2 && args[0] == "list":
+		err = list(args[1])
+	case len(args) == 2 && args[0] == "upload":
+		if *apikey == "" {
+			fmt.Fprintln(os.Stderr, "an apikey is required to upload bundles")
+			os.Exit(exitCodeUsage)
+		}
+		err = upload(args[1], *apikey, *eventsURL, *telemetryURL, time.Duration(*timeoutMs)*time.Millisecond)
+	default:
+		flag.Usage()
+		os.Exit(exitCodeUsage)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+	os.Exit(exitCodeSuccess)
+}
+
+func list(dir string) error {
+	paths, err := airgap.List(dir)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		Path     string          `json:"path"`
+		Manifest airgap.Manifest `json:"manifest"`
+	}
+	entries := make([]entry, 0, len(paths))
+	for _, path := range paths {
+		bundle, err := airgap.Open(path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{Path: path, Manifest: bundle.Manifest})
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+func upload(dir string, apikey string, eventsURL string, telemetryURL string, timeout time.Duration) error {
+	logger := logging.NewLogger(&logging.LoggerOptions{StandardLoggerFlags: 0, LogLevel: logging.LevelInfo})
+	uploader := airgap.NewUploader(logger, map[string]tasks.RawRecorder{
+		airgap.ServiceEvents:    tasks.NewHTTPRawRecorder(apikey, eventsURL, timeout, logger),
+		airgap.ServiceTelemetry: tasks.NewHTTPRawRecorder(apikey, telemetryURL, timeout, logger),
+	}, strconv.Itoa(int(util.HashAPIKey(apikey))))
+
+	uploaded, err := uploader.UploadAll(dir)
+	fmt.Printf("%d bundle(s) uploaded\n", uploaded)
+	return err
+}
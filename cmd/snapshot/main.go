@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
+)
+
+const (
+	exitCodeSuccess = 0
+	exitCodeError   = 1
+	exitCodeUsage   = 2
+)
+
+const usage = `Usage:
+  split-snapshot [options] inspect <snapshot-file>          print the metadata & contents of a proxy snapshot as JSON
+  split-snapshot [options] diff <old-snapshot> <new-snapshot>  print the differences between two proxy snapshots as JSON
+
+Options:
+`
+
+var keys *snapshot.Keys
+
+func main() {
+	verifyingKey := flag.String("verifying-key-fn", "", "PEM file with the ed25519 public key snapshots must be signed with")
+	encryptionKey := flag.String("encryption-key-fn", "", "File with the base64 AES key used to decrypt snapshots. (Default: SPLIT_SNAPSHOT_ENCRYPTION_KEY env var)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var err error
+	if keys, err = snapshot.LoadKeys("", *verifyingKey, *encryptionKey, "SPLIT_SNAPSHOT_ENCRYPTION_KEY"); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeUsage)
+	}
+
+	switch args := flag.Args(); {
+	case len(args) == 2 && args[0] == "inspect":
+		err = inspect(args[1])
+	case len(args) == 3 && args[0] == "diff":
+		err = diff(args[1], args[2])
+	default:
+		flag.Usage()
+		os.Exit(exitCodeUsage)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(exitCodeError)
+	}
+	os.Exit(exitCodeSuccess)
+}
+
+func inspect(path string) error {
+	contents, err := load(path)
+	if err != nil {
+		return err
+	}
+	return printJSON(contents)
+}
+
+func diff(fromPath string, toPath string) error {
+	from, err := load(fromPath)
+	if err != nil {
+		return err
+	}
+
+	to, err := load(toPath)
+	if err != nil {
+		return err
+	}
+	return printJSON(snapshots.Compare(from, to))
+}
+
+func load(path string) (*snapshots.Contents, error) {
+	snap, err := snapshot.DecodeFromFile(path, keys)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing snapshot file '%s': %w", path, err)
+	}
+
+	contents, err := snapshots.Inspect(snap)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot '%s': %w", path, err)
+	}
+	return contents, nil
+}
+
+func printJSON(data interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
@@ -4,11 +4,13 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/splitio/go-toolkit/v5/logging"
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/controllers"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	cstorage "github.com/splitio/split-synchronizer/v5/splitio/common/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
@@ -20,28 +22,37 @@ import (
 const baseAdminPath = "/admin"
 const baseInfoPath = "/info"
 const baseShutdownPath = "/shutdown"
+const baseMetricsPath = "/"
 
 // Options encapsulates dependencies & config options for the Admin server
 type Options struct {
-	Host                string
-	Port                int
-	Name                string
-	Proxy               bool
-	Username            string
-	Password            string
-	Logger              logging.LoggerInterface
-	Storages            adminCommon.Storages
-	ImpressionsEvCalc   evcalc.Monitor
-	EventsEvCalc        evcalc.Monitor
-	Runtime             common.Runtime
-	HcAppMonitor        application.MonitorIterface
-	HcServicesMonitor   services.MonitorIterface
-	Snapshotter         cstorage.Snapshotter
-	TLS                 *tls.Config
-	FullConfig          interface{}
-	FlagSpecVersion     string
-	LargeSegmentVersion string
-	Hash                string
+	Host                   string
+	Port                   int
+	Name                   string
+	Proxy                  bool
+	Username               string
+	Password               string
+	Logger                 logging.LoggerInterface
+	Storages               adminCommon.Storages
+	ImpressionsEvCalc      evcalc.Monitor
+	EventsEvCalc           evcalc.Monitor
+	Runtime                common.Runtime
+	HcAppMonitor           application.MonitorIterface
+	HcServicesMonitor      services.MonitorIterface
+	Snapshotter            cstorage.Snapshotter
+	SnapshotStorage        uint64 // storage type stamped on downloaded snapshots. defaults to boltdb
+	SnapshotKeys           *snapshot.Keys
+	TLS                    *tls.Config
+	FullConfig             interface{}
+	FlagSpecVersion        string
+	LargeSegmentVersion    string
+	Hash                   string
+	APIKeyUpdater          controllers.APIKeyUpdater
+	APIKeysGracePeriod     time.Duration
+	LatestSnapshot         controllers.LatestSnapshotProvider
+	SnapshotApplier        controllers.SnapshotApplier
+	SnapshotMaxUploadBytes int64 // size limit for uploaded snapshots. defaults to controllers.DefaultMaxUploadBytes
+	ExportBundles          controllers.PendingBundlesProvider
 }
 
 type AdminServer struct {
@@ -54,10 +65,12 @@ func NewServer(options *Options) (*AdminServer, error) {
 	admin := router.Group(baseAdminPath)
 	info := router.Group(baseInfoPath)
 	shutdown := router.Group(baseShutdownPath)
+	metrics := router.Group(baseMetricsPath)
 	if options.Username != "" && options.Password != "" {
 		admin = router.Group(baseAdminPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
 		info = router.Group(baseInfoPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
 		shutdown = router.Group(baseShutdownPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
+		metrics = router.Group(baseMetricsPath, gin.BasicAuth(gin.Accounts{options.Username: options.Password}))
 	}
 
 	dashboardController, err := controllers.NewDashboardController(
@@ -71,6 +84,7 @@ func NewServer(options *Options) (*AdminServer, error) {
 		options.HcAppMonitor,
 		options.FlagSpecVersion,
 		options.LargeSegmentVersion,
+		options.ExportBundles,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error instantiating dashboard controller: %w", err)
@@ -87,7 +101,7 @@ func NewServer(options *Options) (*AdminServer, error) {
 	)
 	healthcheckController.Register(router)
 
-	infoController := controllers.NewInfoController(options.Proxy, options.Runtime, options.FullConfig)
+	infoController := controllers.NewInfoController(options.Proxy, options.Runtime, options.FullConfig, options.LatestSnapshot)
 	infoController.Register(info)
 
 	observabilityController, err := controllers.NewObservabilityController(options.Proxy, options.Logger, options.Storages)
@@ -96,11 +110,44 @@ func NewServer(options *Options) (*AdminServer, error) {
 	}
 	observabilityController.Register(admin)
 
+	metricsController, err := controllers.NewMetricsController(
+		options.Proxy,
+		options.Logger,
+		options.Storages,
+		options.ImpressionsEvCalc,
+		options.EventsEvCalc,
+		options.HcAppMonitor,
+		options.HcServicesMonitor,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error instantiating metrics controller: %w", err)
+	}
+	metricsController.Register(metrics)
+
 	if options.Snapshotter != nil {
-		snapshotController := controllers.NewSnapshotController(options.Logger, options.Snapshotter, options.Hash)
+		applier := options.SnapshotApplier
+		if applier != nil && (options.Username == "" || options.Password == "") {
+			options.Logger.Warning("admin credentials not set. snapshots cannot be uploaded through the admin endpoint")
+			applier = nil
+		}
+		storageType := options.SnapshotStorage
+		if storageType == 0 {
+			storageType = snapshot.StorageBoltDB
+		}
+		snapshotController := controllers.NewSnapshotController(options.Logger, options.Snapshotter, storageType, options.Hash, applier,
+			options.SnapshotMaxUploadBytes, options.SnapshotKeys)
 		snapshotController.Register(admin)
 	}
 
+	if options.APIKeyUpdater != nil {
+		if options.Username != "" && options.Password != "" {
+			apikeysController := controllers.NewAPIKeysController(options.Logger, options.APIKeyUpdater, options.APIKeysGracePeriod)
+			apikeysController.Register(admin)
+		} else {
+			options.Logger.Warning("admin credentials not set. apikeys cannot be rotated through the admin endpoint")
+		}
+	}
+
 	return &AdminServer{
 		server: &http.Server{
 			Addr:      fmt.Sprintf("%s:%d", options.Host, options.Port),
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// APIKeyUpdater defines the interface for components capable of replacing the set of client apikeys accepted by the proxy
+type APIKeyUpdater interface {
+	ReplaceAPIKeys(apikeys []string, grace time.Duration) error
+}
+
+type apikeysUpdateRequest struct {
+	APIKeys         []string `json:"apikeys"`
+	GracePeriodSecs *int64   `json:"gracePeriodSecs"`
+}
+
+// APIKeysController bundles endpoints used to rotate the client apikeys accepted by the proxy
+type APIKeysController struct {
+	logger       logging.LoggerInterface
+	updater      APIKeyUpdater
+	defaultGrace time.Duration
+}
+
+// NewAPIKeysController constructs a new apikeys controller. If no grace period is specified in a request, `defaultGrace` is used
+func NewAPIKeysController(logger logging.LoggerInterface, updater APIKeyUpdater, defaultGrace time.Duration) *APIKeysController {
+	return &APIKeysController{logger: logger, updater: updater, defaultGrace: defaultGrace}
+}
+
+// Register mounts the endpoints int he provided router
+func (c *APIKeysController) Register(router gin.IRouter) {
+	router.PUT("/apikeys", c.replaceAPIKeys)
+}
+
+func (c *APIKeysController) replaceAPIKeys(ctx *gin.Context) {
+	// curl -u user:pass -X PUT http://localhost:3010/admin/apikeys -d '{"apikeys": ["key1", "key2"], "gracePeriodSecs": 300}'
+	var request apikeysUpdateRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid request body: %s", err)})
+		return
+	}
+
+	grace := c.defaultGrace
+	if request.GracePeriodSecs != nil {
+		if *request.GracePeriodSecs < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "grace period cannot be negative"})
+			return
+		}
+		grace = time.Duration(*request.GracePeriodSecs) * time.Second
+	}
+
+	if err := c.updater.ReplaceAPIKeys(request.APIKeys, grace); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, apikeys.ErrManagedByFile) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.logger.Info(fmt.Sprintf("client apikeys replaced via admin endpoint (%d keys)", len(request.APIKeys)))
+	ctx.JSON(http.StatusOK, gin.H{"apikeys": len(request.APIKeys), "gracePeriodSecs": int64(grace.Seconds())})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type apikeyUpdaterMock struct {
+	mock.Mock
+}
+
+func (m *apikeyUpdaterMock) ReplaceAPIKeys(apikeys []string, grace time.Duration) error {
+	return m.Called(apikeys, grace).Error(0)
+}
+
+func TestReplaceAPIKeys(t *testing.T) {
+	var updater apikeyUpdaterMock
+	updater.On("ReplaceAPIKeys", []string{"key1", "key2"}, 5*time.Minute).Return(nil).Once()
+	updater.On("ReplaceAPIKeys", []string{"key3"}, time.Duration(0)).Return(nil).Once()
+	updater.On("ReplaceAPIKeys", []string{}, 5*time.Minute).Return(errors.New("no apikeys found")).Once()
+	updater.On("ReplaceAPIKeys", []string{"key5"}, 5*time.Minute).Return(apikeys.ErrManagedByFile).Once()
+
+	ctrl := NewAPIKeysController(logging.NewLogger(nil), &updater, 5*time.Minute)
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/apikeys", strings.NewReader(body))
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp = put(`{"apikeys": ["key1", "key2"]}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"apikeys": 2, "gracePeriodSecs": 300}`, resp.Body.String())
+
+	resp = put(`{"apikeys": ["key3"], "gracePeriodSecs": 0}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"apikeys": 1, "gracePeriodSecs": 0}`, resp.Body.String())
+
+	resp = put(`{"apikeys": []}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = put(`{"apikeys": ["key4"], "gracePeriodSecs": -1}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = put(`{"apikeys": ["key5"]}`)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+
+	resp = put(`not json`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	updater.AssertExpectations(t)
+}
@@ -18,6 +18,11 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
 )
 
+// PendingBundlesProvider returns the number of export bundles waiting to be uploaded to Split
+type PendingBundlesProvider interface {
+	Pending() int
+}
+
 // DashboardController contains handlers for rendering the dashboard and its associated FE queries
 type DashboardController struct {
 	title               string
@@ -31,6 +36,7 @@ type DashboardController struct {
 	appMonitor          application.MonitorIterface
 	FlagSpecVersion     string
 	LargeSegmentVersion string
+	exportBundles       PendingBundlesProvider
 }
 
 // NewDashboardController instantiates a new dashboard controller
@@ -45,6 +51,7 @@ func NewDashboardController(
 	appMonitor application.MonitorIterface,
 	flagSpecVersion string,
 	largeSegmentVersion string,
+	exportBundles PendingBundlesProvider,
 ) (*DashboardController, error) {
 
 	toReturn := &DashboardController{
@@ -58,6 +65,7 @@ func NewDashboardController(
 		appMonitor:          appMonitor,
 		FlagSpecVersion:     flagSpecVersion,
 		LargeSegmentVersion: largeSegmentVersion,
+		exportBundles:       exportBundles,
 	}
 
 	var err error
@@ -117,6 +125,7 @@ func (c *DashboardController) renderDashboard() ([]byte, error) {
 		Stats:           *c.gatherStats(),
 		Health:          c.appMonitor.GetHealthStatus(),
 		FlagSpecVersion: c.FlagSpecVersion,
+		ExportBundles:   c.exportBundles != nil,
 	})
 
 	if err != nil {
@@ -135,6 +144,8 @@ func (c *DashboardController) gatherStats() *dashboard.GlobalStats {
 
 	upstreamOkReqs, upstreamErrorReqs := getUpstreamRequestCount(c.storages.LocalTelemetryStorage)
 	proxyOkReqs, proxyErrorReqs := getProxyRequestCount(c.storages.LocalTelemetryStorage)
+	rateLimited, rateLimitedTotal := getRateLimitedCount(c.storages.LocalTelemetryStorage)
+	spill, spillTotals := getSpillStats(c.storages.LocalTelemetryStorage)
 
 	impressionsLambda := float64(0)
 	if c.impressionsEvCalc != nil {
@@ -146,6 +157,11 @@ func (c *DashboardController) gatherStats() *dashboard.GlobalStats {
 		eventsLambda = c.eventsEvCalc.Lambda()
 	}
 
+	var pendingBundles int64
+	if c.exportBundles != nil {
+		pendingBundles = int64(c.exportBundles.Pending())
+	}
+
 	return &dashboard.GlobalStats{
 		FeatureFlags:           bundleSplitInfo(c.storages.SplitStorage),
 		Segments:               bundleSegmentInfo(c.storages.SplitStorage, c.storages.SegmentStorage),
@@ -167,5 +183,13 @@ func (c *DashboardController) gatherStats() *dashboard.GlobalStats {
 		LoggedMessages:         errorMessages,
 		Uptime:                 int64(c.runtime.Uptime().Seconds()),
 		FlagSets:               getFlagSetsInfo(c.storages.SplitStorage),
+		RateLimitedRequests:    rateLimitedTotal,
+		RateLimited:            rateLimited,
+		SpilledBulks:           spillTotals.Spilled,
+		ReplayedBulks:          spillTotals.Replayed,
+		ExpiredBulks:           spillTotals.Expired,
+		Spill:                  spill,
+		PendingExportBundles:   pendingBundles,
+		MembershipIndex:        getMembershipIndexStats(c.storages.SegmentStorage),
 	}
 }
@@ -331,6 +331,51 @@ func getUpstreamRequestCount(metrics storage.TelemetryRuntimeConsumer) (ok int64
 	return totalCount - errorCount, errorCount
 }
 
+func getRateLimitedCount(metrics storage.TelemetryRuntimeConsumer) (byGroup map[string]int64, total int64) {
+	asPeeker, ok := metrics.(proxyStorage.ProxyTelemetryPeeker)
+	if !ok { // This will be the case when runnning in producer mode
+		return nil, 0
+	}
+
+	byGroup = asPeeker.PeekRateLimited()
+	for _, count := range byGroup {
+		total += count
+	}
+	return byGroup, total
+}
+
+func getSpillStats(metrics storage.TelemetryRuntimeConsumer) (byQueue map[string]dashboard.SpillSummary, totals dashboard.SpillSummary) {
+	asPeeker, ok := metrics.(proxyStorage.ProxyTelemetryPeeker)
+	if !ok { // This will be the case when runnning in producer mode
+		return nil, totals
+	}
+
+	stats := asPeeker.PeekSpillStats()
+	byQueue = make(map[string]dashboard.SpillSummary, len(stats))
+	for queue, counts := range stats {
+		byQueue[queue] = dashboard.SpillSummary{Spilled: counts.Spilled, Replayed: counts.Replayed, Expired: counts.Expired}
+		totals.Spilled += counts.Spilled
+		totals.Replayed += counts.Replayed
+		totals.Expired += counts.Expired
+	}
+	return byQueue, totals
+}
+
+func getMembershipIndexStats(segmentStorage storage.SegmentStorageConsumer) *dashboard.MembershipIndexSummary {
+	asReporter, ok := segmentStorage.(proxyStorage.MySegmentsMemoryReporter)
+	if !ok { // This will be the case when runnning in producer mode
+		return nil
+	}
+
+	stats := asReporter.MySegmentsMemory()
+	return &dashboard.MembershipIndexSummary{
+		Keys:           int64(stats.Keys),
+		Segments:       int64(stats.Segments),
+		Memberships:    int64(stats.Memberships),
+		EstimatedBytes: stats.EstimatedBytes,
+	}
+}
+
 func getProxyRequestCount(metrics storage.TelemetryRuntimeConsumer) (ok int64, errored int64) {
 	asPeeker, k := metrics.(proxyStorage.ProxyTelemetryPeeker)
 	if !k { // This will be the case when runnning in producer mode
@@ -4,10 +4,13 @@ import (
 	"testing"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin/views/dashboard"
+	proxyStorage "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/storage/mocks"
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,3 +30,18 @@ func TestBundleRBInfo(t *testing.T) {
 	split.AssertExpectations(t)
 	rb.AssertExpectations(t)
 }
+
+func TestGetMembershipIndexStats(t *testing.T) {
+	assert.Nil(t, getMembershipIndexStats(&mocks.MockSegmentStorage{}))
+
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	segments := proxyStorage.NewProxySegmentStorage(dbw, logging.NewLogger(nil), false, 0)
+	assert.Nil(t, segments.Update("segment1", set.NewSet("k1", "k2"), set.NewSet(), 1))
+
+	stats := getMembershipIndexStats(segments)
+	assert.Equal(t, int64(2), stats.Keys)
+	assert.Equal(t, int64(1), stats.Segments)
+	assert.Equal(t, int64(1), stats.Memberships)
+	assert.Greater(t, stats.EstimatedBytes, int64(0))
+}
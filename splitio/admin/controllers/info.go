@@ -11,19 +11,26 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// LatestSnapshotProvider returns the path to the most recently written snapshot
+type LatestSnapshotProvider interface {
+	Latest() string
+}
+
 // InfoController contains handlers for system information purposes
 type InfoController struct {
-	proxy   bool
-	runtime common.Runtime
-	cfg     interface{}
+	proxy     bool
+	runtime   common.Runtime
+	cfg       interface{}
+	snapshots LatestSnapshotProvider
 }
 
 // NewInfoController constructs a new InfoController to be mounted on a gin router
-func NewInfoController(proxy bool, runtime common.Runtime, config interface{}) *InfoController {
+func NewInfoController(proxy bool, runtime common.Runtime, config interface{}, snapshots LatestSnapshotProvider) *InfoController {
 	return &InfoController{
-		proxy:   proxy,
-		runtime: runtime,
-		cfg:     config,
+		proxy:     proxy,
+		runtime:   runtime,
+		cfg:       config,
+		snapshots: snapshots,
 	}
 }
 
@@ -33,6 +40,18 @@ func (c *InfoController) Register(router gin.IRouter) {
 	router.GET("/version", c.version)
 	router.GET("/ping", c.ping)
 	router.GET("/config", c.config)
+	if c.snapshots != nil {
+		router.GET("/snapshot", c.latestSnapshot)
+	}
+}
+
+func (c *InfoController) latestSnapshot(ctx *gin.Context) {
+	latest := c.snapshots.Latest()
+	if latest == "" {
+		ctx.JSON(http.StatusNotFound, gin.H{"latest": nil})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"latest": latest})
 }
 
 func (c *InfoController) config(ctx *gin.Context) {
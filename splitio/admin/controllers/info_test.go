@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type latestSnapshotMock struct {
+	latest string
+}
+
+func (m *latestSnapshotMock) Latest() string { return m.latest }
+
+func TestLatestSnapshot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := &latestSnapshotMock{}
+	ctrl := NewInfoController(true, nil, nil, provider)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/snapshot", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	provider.latest = "/some/dir/split.proxy.123.snapshot"
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var body map[string]string
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, map[string]string{"latest": "/some/dir/split.proxy.123.snapshot"}, body)
+
+	// the endpoint is not mounted when snapshots are not being written
+	resp = httptest.NewRecorder()
+	ctx, router = gin.CreateTestContext(resp)
+	NewInfoController(true, nil, nil, nil).Register(router)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/snapshot", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
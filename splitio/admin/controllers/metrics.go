@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/admin/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services"
+	pstorage "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-split-commons/v9/telemetry"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// upper bounds (in milliseconds) of the latency buckets used by both split telemetry & local proxy telemetry.
+// The last bucket also holds every latency above it, so it's only accounted for in the +Inf bucket
+var latencyBuckets = [23]float64{
+	1.00, 1.50, 2.25, 3.38, 5.06, 7.59, 11.39, 17.09, 25.63, 38.44, 57.67, 86.50,
+	129.75, 194.62, 291.93, 437.89, 656.84, 985.26, 1477.89, 2216.84, 3325.26, 4987.89, 7481.83,
+}
+
+var proxyEndpointNames = map[int]string{
+	pstorage.AuthEndpoint:                          "auth",
+	pstorage.SplitChangesEndpoint:                  "splitChanges",
+	pstorage.SegmentChangesEndpoint:                "segmentChanges",
+	pstorage.MySegmentsEndpoint:                    "mySegments",
+	pstorage.ImpressionsBulkEndpoint:               "impressionsBulk",
+	pstorage.ImpressionsBulkBeaconEndpoint:         "impressionsBulkBeacon",
+	pstorage.ImpressionsCountEndpoint:              "impressionsCount",
+	pstorage.ImpressionsCountBeaconEndpoint:        "impressionsCountBeacon",
+	pstorage.EventsBulkEndpoint:                    "eventsBulk",
+	pstorage.EventsBulkBeaconEndpoint:              "eventsBulkBeacon",
+	pstorage.TelemetryConfigEndpoint:               "telemetryConfig",
+	pstorage.TelemetryRuntimeEndpoint:              "telemetryRuntime",
+	pstorage.TelemetryRuntimeBeaconEndpoint:        "telemetryBeaconRuntime",
+	pstorage.TelemetryKeysClientSideEndpoint:       "telemetryKeysClientSide",
+	pstorage.TelemetryKeysClientSideBeaconEndpoint: "telemetryKeysClientSideBeacon",
+	pstorage.TelemetryKeysServerSideEndpoint:       "telemetryKeysServerSide",
+	pstorage.OFREPEvaluationEndpoint:               "ofrepEvaluation",
+}
+
+var upstreamResourceNames = map[int]string{
+	telemetry.SplitSync:           "splitChanges",
+	telemetry.SegmentSync:         "segmentChanges",
+	telemetry.ImpressionSync:      "impressions",
+	telemetry.ImpressionCountSync: "impressionsCount",
+	telemetry.EventSync:           "events",
+	telemetry.TelemetrySync:       "telemetry",
+	telemetry.TokenSync:           "auth",
+}
+
+// MetricsController exposes internal metrics in prometheus text format
+type MetricsController struct {
+	handler gin.HandlerFunc
+}
+
+// NewMetricsController constructs a new metrics controller
+func NewMetricsController(
+	proxy bool,
+	logger logging.LoggerInterface,
+	storagePack common.Storages,
+	impressionsEvCalc evcalc.Monitor,
+	eventsEvCalc evcalc.Monitor,
+	appMonitor application.MonitorIterface,
+	servicesMonitor services.MonitorIterface,
+) (*MetricsController, error) {
+	namespace := "split_sync"
+	if proxy {
+		namespace = "split_proxy"
+	}
+
+	registry := prometheus.NewRegistry()
+	err := registry.Register(newMetricsCollector(
+		namespace,
+		storagePack,
+		impressionsEvCalc,
+		eventsEvCalc,
+		appMonitor,
+		servicesMonitor,
+	))
+	if err != nil {
+		return nil, err
+	}
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return &MetricsController{
+		handler: gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorLog: &promLogger{logger: logger}})),
+	}, nil
+}
+
+// Register mounts the controller endpoints onto the supplied router
+func (c *MetricsController) Register(router gin.IRouter) {
+	router.GET("/metrics", c.handler)
+}
+
+// metricsCollector builds metrics at scrape time from the storages & monitors already in place
+type metricsCollector struct {
+	storages        common.Storages
+	impEvCalc       evcalc.Monitor
+	evEvCalc        evcalc.Monitor
+	appMonitor      application.MonitorIterface
+	servicesMonitor services.MonitorIterface
+
+	// some telemetry (ie: upstream) is popped every time it's sent to split, so every counter exposed is accumulated
+	// in order to keep it monotonic, as prometheus expects
+	histograms map[string]*monotonicCounters[int]
+	counters   map[string]*monotonicCounters[string]
+	mutex      sync.Mutex
+
+	endpointLatency   *prometheus.Desc
+	endpointStatus    *prometheus.Desc
+	rateLimited       *prometheus.Desc
+	cacheHits         *prometheus.Desc
+	cacheMisses       *prometheus.Desc
+	cacheHitRatio     *prometheus.Desc
+	spilled           *prometheus.Desc
+	replayed          *prometheus.Desc
+	spillExpired      *prometheus.Desc
+	upstreamLatency   *prometheus.Desc
+	upstreamError     *prometheus.Desc
+	queueSize         *prometheus.Desc
+	evictionLambda    *prometheus.Desc
+	healthy           *prometheus.Desc
+	componentHealthy  *prometheus.Desc
+	dependencyHealthy *prometheus.Desc
+	servicesStatus    *prometheus.Desc
+}
+
+func newMetricsCollector(
+	namespace string,
+	storagePack common.Storages,
+	impressionsEvCalc evcalc.Monitor,
+	eventsEvCalc evcalc.Monitor,
+	appMonitor application.MonitorIterface,
+	servicesMonitor services.MonitorIterface,
+) *metricsCollector {
+	name := func(n string) string { return prometheus.BuildFQName(namespace, "", n) }
+	return &metricsCollector{
+		storages:        storagePack,
+		impEvCalc:       impressionsEvCalc,
+		evEvCalc:        eventsEvCalc,
+		appMonitor:      appMonitor,
+		servicesMonitor: servicesMonitor,
+		histograms:      make(map[string]*monotonicCounters[int]),
+		counters:        make(map[string]*monotonicCounters[string]),
+		endpointLatency: prometheus.NewDesc(name("endpoint_request_duration_seconds"),
+			"Latency of requests served by the proxy, by endpoint", []string{"endpoint"}, nil),
+		endpointStatus: prometheus.NewDesc(name("endpoint_requests_total"),
+			"Requests served by the proxy, by endpoint & status code", []string{"endpoint", "status"}, nil),
+		rateLimited: prometheus.NewDesc(name("rate_limited_requests_total"),
+			"Requests rejected due to rate limiting, by endpoint group", []string{"group"}, nil),
+		cacheHits: prometheus.NewDesc(name("cache_hits_total"),
+			"Requests served from the http response cache", nil, nil),
+		cacheMisses: prometheus.NewDesc(name("cache_misses_total"),
+			"Requests that could not be served from the http response cache", nil, nil),
+		cacheHitRatio: prometheus.NewDesc(name("cache_hit_ratio"),
+			"Ratio of requests served from the http response cache since startup", nil, nil),
+		spilled: prometheus.NewDesc(name("spilled_bulks_total"),
+			"Bulks persisted to disk because split servers were failing or the queue was full, by queue", []string{"queue"}, nil),
+		replayed: prometheus.NewDesc(name("replayed_bulks_total"),
+			"Bulks read back from disk & successfully posted to split servers, by queue", []string{"queue"}, nil),
+		spillExpired: prometheus.NewDesc(name("expired_bulks_total"),
+			"Bulks discarded from disk due to their age, by queue", []string{"queue"}, nil),
+		upstreamLatency: prometheus.NewDesc(name("upstream_request_duration_seconds"),
+			"Latency of requests made to split servers, by resource", []string{"resource"}, nil),
+		upstreamError: prometheus.NewDesc(name("upstream_errors_total"),
+			"Failed requests made to split servers, by resource & status code", []string{"resource", "status"}, nil),
+		queueSize: prometheus.NewDesc(name("queue_size"),
+			"Number of items pending to be sent to split servers", []string{"queue"}, nil),
+		evictionLambda: prometheus.NewDesc(name("eviction_lambda"),
+			"Ratio of items evicted vs items generated. Values below 1 mean that the queue is growing", []string{"queue"}, nil),
+		healthy: prometheus.NewDesc(name("healthy"),
+			"Whether the application is healthy (1) or not (0)", nil, nil),
+		componentHealthy: prometheus.NewDesc(name("component_healthy"),
+			"Whether each internal component is healthy (1) or not (0)", []string{"component"}, nil),
+		dependencyHealthy: prometheus.NewDesc(name("dependency_healthy"),
+			"Whether each external dependency is healthy (1) or not (0)", []string{"service"}, nil),
+		servicesStatus: prometheus.NewDesc(name("dependencies_status"),
+			"Overall status of external dependencies", []string{"status"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.endpointLatency, c.endpointStatus, c.rateLimited, c.cacheHits, c.cacheMisses, c.cacheHitRatio,
+		c.spilled, c.replayed, c.spillExpired,
+		c.upstreamLatency, c.upstreamError, c.queueSize, c.evictionLambda, c.healthy, c.componentHealthy,
+		c.dependencyHealthy, c.servicesStatus,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.collectProxy(ch)
+	c.collectUpstream(ch)
+	c.collectQueues(ch)
+	c.collectHealth(ch)
+}
+
+func (c *metricsCollector) collectProxy(ch chan<- prometheus.Metric) {
+	peeker, ok := c.storages.LocalTelemetryStorage.(pstorage.ProxyTelemetryPeeker)
+	if !ok { // This will be the case when runnning in producer mode
+		return
+	}
+
+	for endpoint, name := range proxyEndpointNames {
+		count, sum, buckets := toHistogram(c.histogram("endpoint_latency/"+name, peeker.PeekEndpointLatency(endpoint)))
+		ch <- prometheus.MustNewConstHistogram(c.endpointLatency, count, sum, buckets, name)
+
+		current := make(map[string]int64)
+		for status, hits := range peeker.PeekEndpointStatus(endpoint) {
+			current[strconv.Itoa(status)] = hits
+		}
+		for status, hits := range c.counter("endpoint_status/"+name, current) {
+			ch <- prometheus.MustNewConstMetric(c.endpointStatus, prometheus.CounterValue, float64(hits), name, status)
+		}
+	}
+
+	for group, count := range c.counter("rate_limited", peeker.PeekRateLimited()) {
+		ch <- prometheus.MustNewConstMetric(c.rateLimited, prometheus.CounterValue, float64(count), group)
+	}
+
+	rawHits, rawMisses := peeker.PeekCacheStats()
+	cache := c.counter("cache", map[string]int64{"hits": rawHits, "misses": rawMisses})
+	hits, misses := cache["hits"], cache["misses"]
+	ratio := 0.0
+	if total := hits + misses; total > 0 {
+		ratio = float64(hits) / float64(total)
+	}
+	ch <- prometheus.MustNewConstMetric(c.cacheHits, prometheus.CounterValue, float64(hits))
+	ch <- prometheus.MustNewConstMetric(c.cacheMisses, prometheus.CounterValue, float64(misses))
+	ch <- prometheus.MustNewConstMetric(c.cacheHitRatio, prometheus.GaugeValue, ratio)
+
+	spilled, replayed, expired := make(map[string]int64), make(map[string]int64), make(map[string]int64)
+	for queue, stats := range peeker.PeekSpillStats() {
+		spilled[queue], replayed[queue], expired[queue] = stats.Spilled, stats.Replayed, stats.Expired
+	}
+	for queue, count := range c.counter("spilled", spilled) {
+		ch <- prometheus.MustNewConstMetric(c.spilled, prometheus.CounterValue, float64(count), queue)
+	}
+	for queue, count := range c.counter("replayed", replayed) {
+		ch <- prometheus.MustNewConstMetric(c.replayed, prometheus.CounterValue, float64(count), queue)
+	}
+	for queue, count := range c.counter("expired", expired) {
+		ch <- prometheus.MustNewConstMetric(c.spillExpired, prometheus.CounterValue, float64(count), queue)
+	}
+}
+
+func (c *metricsCollector) collectUpstream(ch chan<- prometheus.Metric) {
+	peeker, ok := c.storages.LocalTelemetryStorage.(storage.TelemetryPeeker)
+	if !ok {
+		return
+	}
+
+	for resource, name := range upstreamResourceNames {
+		count, sum, buckets := toHistogram(c.histogram("upstream_latency/"+name, peeker.PeekHTTPLatencies(resource)))
+		ch <- prometheus.MustNewConstHistogram(c.upstreamLatency, count, sum, buckets, name)
+
+		current := make(map[string]int64)
+		for status, count := range peeker.PeekHTTPErrors(resource) {
+			current[strconv.Itoa(status)] = int64(count)
+		}
+		for status, count := range c.counter("upstream_errors/"+name, current) {
+			ch <- prometheus.MustNewConstMetric(c.upstreamError, prometheus.CounterValue, float64(count), name, status)
+		}
+	}
+}
+
+// histogram accumulates a set of latency buckets, so that they never go down even if the underlying storage is reset
+func (c *metricsCollector) histogram(family string, counts []int64) []int64 {
+	tracker, ok := c.histograms[family]
+	if !ok {
+		tracker = newMonotonicCounters[int]()
+		c.histograms[family] = tracker
+	}
+
+	current := make(map[int]int64, len(counts))
+	for idx, count := range counts {
+		current[idx] = count
+	}
+	accumulated := tracker.update(current)
+	toRet := make([]int64, len(latencyBuckets))
+	for idx := range toRet {
+		toRet[idx] = accumulated[idx]
+	}
+	return toRet
+}
+
+// counter accumulates a set of labelled counters, so that they never go down even if the underlying storage is reset
+func (c *metricsCollector) counter(family string, current map[string]int64) map[string]int64 {
+	tracker, ok := c.counters[family]
+	if !ok {
+		tracker = newMonotonicCounters[string]()
+		c.counters[family] = tracker
+	}
+	return tracker.update(current)
+}
+
+func (c *metricsCollector) collectQueues(ch chan<- prometheus.Metric) {
+	if c.storages.ImpressionStorage != nil {
+		ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(getImpressionSize(c.storages.ImpressionStorage)), "impressions")
+	}
+	if c.storages.EventStorage != nil {
+		ch <- prometheus.MustNewConstMetric(c.queueSize, prometheus.GaugeValue, float64(getEventsSize(c.storages.EventStorage)), "events")
+	}
+	if c.impEvCalc != nil {
+		ch <- prometheus.MustNewConstMetric(c.evictionLambda, prometheus.GaugeValue, getLambda(c.impEvCalc), "impressions")
+	}
+	if c.evEvCalc != nil {
+		ch <- prometheus.MustNewConstMetric(c.evictionLambda, prometheus.GaugeValue, getLambda(c.evEvCalc), "events")
+	}
+}
+
+func (c *metricsCollector) collectHealth(ch chan<- prometheus.Metric) {
+	if c.appMonitor != nil {
+		status := c.appMonitor.GetHealthStatus()
+		ch <- prometheus.MustNewConstMetric(c.healthy, prometheus.GaugeValue, boolToFloat(status.Healthy))
+		for _, item := range status.Items {
+			ch <- prometheus.MustNewConstMetric(c.componentHealthy, prometheus.GaugeValue, boolToFloat(item.Healthy), item.Name)
+		}
+	}
+
+	if c.servicesMonitor != nil {
+		status := c.servicesMonitor.GetHealthStatus()
+		ch <- prometheus.MustNewConstMetric(c.servicesStatus, prometheus.GaugeValue, 1, status.Status)
+		for _, item := range status.Items {
+			ch <- prometheus.MustNewConstMetric(c.dependencyHealthy, prometheus.GaugeValue, boolToFloat(item.Healthy), item.Service)
+		}
+	}
+}
+
+// toHistogram converts split-style latency buckets (in ms) into a cumulative prometheus histogram (in seconds).
+// Since individual latencies are not stored, the sum is approximated using the upper bound of each bucket
+func toHistogram(counts []int64) (count uint64, sum float64, buckets map[float64]uint64) {
+	buckets = make(map[float64]uint64, len(latencyBuckets)-1)
+	for idx, upperBound := range latencyBuckets {
+		if idx < len(counts) {
+			count += uint64(counts[idx])
+			sum += float64(counts[idx]) * upperBound / 1000
+		}
+		if idx < len(latencyBuckets)-1 {
+			buckets[upperBound/1000] = count
+		}
+	}
+	return count, sum, buckets
+}
+
+// monotonicCounters turns a set of counters that get periodically reset into ever-increasing ones
+type monotonicCounters[K comparable] struct {
+	base map[K]int64
+	last map[K]int64
+}
+
+func newMonotonicCounters[K comparable]() *monotonicCounters[K] {
+	return &monotonicCounters[K]{base: make(map[K]int64), last: make(map[K]int64)}
+}
+
+func (m *monotonicCounters[K]) update(current map[K]int64) map[K]int64 {
+	for key, last := range m.last {
+		if current[key] < last { // counter has been reset since the last scrape
+			m.base[key] += last
+		}
+	}
+	m.last = current
+
+	result := make(map[K]int64, len(m.base)+len(current))
+	for key, value := range m.base {
+		result[key] = value
+	}
+	for key, value := range current {
+		result[key] += value
+	}
+	return result
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// promLogger adapts the app logger to the interface expected by promhttp
+type promLogger struct {
+	logger logging.LoggerInterface
+}
+
+func (l *promLogger) Println(v ...interface{}) {
+	l.logger.Error(v...)
+}
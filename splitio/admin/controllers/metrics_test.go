@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/splitio/go-split-commons/v9/telemetry"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type appMonitorStub struct{ application.MonitorIterface }
+
+func (appMonitorStub) GetHealthStatus() application.HealthDto {
+	return application.HealthDto{Healthy: true, Items: []application.ItemDto{{Name: "Splits", Healthy: true}, {Name: "Segments", Healthy: false}}}
+}
+
+type servicesMonitorStub struct{ services.MonitorIterface }
+
+func (servicesMonitorStub) GetHealthStatus() services.HealthDto {
+	return services.HealthDto{Status: "healthy", Items: []services.ItemDto{{Service: "https://sdk.split.io", Healthy: true}}}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	localTelemetry := storage.NewProxyTelemetryFacade()
+	localTelemetry.RecordEndpointLatency(storage.SplitChangesEndpoint, 2*time.Millisecond)
+	localTelemetry.RecordEndpointLatency(storage.SplitChangesEndpoint, 20*time.Second)
+	localTelemetry.IncrEndpointStatus(storage.SplitChangesEndpoint, 200)
+	localTelemetry.IncrEndpointStatus(storage.SplitChangesEndpoint, 304)
+	localTelemetry.IncrRateLimited("cacheable")
+	localTelemetry.IncrCacheHit()
+	localTelemetry.IncrCacheHit()
+	localTelemetry.IncrCacheHit()
+	localTelemetry.IncrCacheMiss()
+	localTelemetry.RecordSyncLatency(telemetry.SplitSync, 100*time.Millisecond)
+	localTelemetry.RecordSyncError(telemetry.SegmentSync, 500)
+
+	controller, err := NewMetricsController(
+		true,
+		logging.NewLogger(nil),
+		adminCommon.Storages{LocalTelemetryStorage: localTelemetry},
+		nil,
+		nil,
+		appMonitorStub{},
+		servicesMonitorStub{},
+	)
+	assert.Nil(t, err)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	controller.Register(router)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/metrics", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	body, _ := io.ReadAll(resp.Body)
+	text := string(body)
+	assert.Contains(t, text, `split_proxy_endpoint_request_duration_seconds_bucket{endpoint="splitChanges",le="0.00225"} 1`)
+	assert.Contains(t, text, `split_proxy_endpoint_request_duration_seconds_bucket{endpoint="splitChanges",le="4.98789"} 1`)
+	assert.Contains(t, text, `split_proxy_endpoint_request_duration_seconds_bucket{endpoint="splitChanges",le="+Inf"} 2`)
+	assert.Contains(t, text, `split_proxy_endpoint_requests_total{endpoint="splitChanges",status="304"} 1`)
+	assert.Contains(t, text, `split_proxy_rate_limited_requests_total{group="cacheable"} 1`)
+	assert.Contains(t, text, `split_proxy_cache_hits_total 3`)
+	assert.Contains(t, text, `split_proxy_cache_misses_total 1`)
+	assert.Contains(t, text, `split_proxy_cache_hit_ratio 0.75`)
+	assert.Contains(t, text, `split_proxy_upstream_request_duration_seconds_count{resource="splitChanges"} 1`)
+	assert.Contains(t, text, `split_proxy_upstream_errors_total{resource="segmentChanges",status="500"} 1`)
+	assert.Contains(t, text, `split_proxy_healthy 1`)
+	assert.Contains(t, text, `split_proxy_component_healthy{component="Segments"} 0`)
+	assert.Contains(t, text, `split_proxy_dependency_healthy{service="https://sdk.split.io"} 1`)
+	assert.Contains(t, text, `split_proxy_dependencies_status{status="healthy"} 1`)
+	assert.NotContains(t, text, "split_proxy_queue_size")
+}
+
+func TestMonotonicCounters(t *testing.T) {
+	counters := newMonotonicCounters[int]()
+	assert.Equal(t, map[int]int64{1: 3}, counters.update(map[int]int64{1: 3}))
+	assert.Equal(t, map[int]int64{1: 5}, counters.update(map[int]int64{1: 5}))
+	assert.Equal(t, map[int]int64{1: 6, 2: 1}, counters.update(map[int]int64{1: 1, 2: 1})) // reset
+	assert.Equal(t, map[int]int64{1: 6, 2: 1}, counters.update(map[int]int64{}))           // reset with no new data
+	assert.Equal(t, map[int]int64{1: 8, 2: 1}, counters.update(map[int]int64{1: 2}))
+}
+
+func TestMetricsSurviveTelemetryResets(t *testing.T) {
+	first := storage.NewProxyTelemetryFacade()
+	first.IncrCacheHit()
+	first.IncrCacheHit()
+	first.IncrEndpointStatus(storage.SplitChangesEndpoint, 200)
+	first.IncrEndpointStatus(storage.SplitChangesEndpoint, 200)
+
+	collector := newMetricsCollector("split_proxy", adminCommon.Storages{LocalTelemetryStorage: first}, nil, nil, nil, nil)
+	registry := prometheus.NewPedanticRegistry()
+	assert.Nil(t, registry.Register(collector))
+
+	valueOf := func(name string) float64 {
+		families, err := registry.Gather()
+		assert.Nil(t, err)
+		for _, family := range families {
+			if family.GetName() == name {
+				return family.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		return -1
+	}
+
+	assert.Equal(t, 2.0, valueOf("split_proxy_cache_hits_total"))
+	assert.Equal(t, 2.0, valueOf("split_proxy_endpoint_requests_total"))
+
+	// simulate the underlying storage being reset
+	second := storage.NewProxyTelemetryFacade()
+	second.IncrCacheHit()
+	collector.storages.LocalTelemetryStorage = second
+	assert.Equal(t, 3.0, valueOf("split_proxy_cache_hits_total"))
+	assert.Equal(t, 2.0, valueOf("split_proxy_endpoint_requests_total"))
+}
@@ -49,6 +49,7 @@ type ProxyObservabilityController struct {
 	telemetry pstorage.TimeslicedProxyEndpointTelemetry
 	splits    observability.ObservableSplitStorage
 	segments  observability.ObservableSegmentStorage
+	history   pstorage.SplitHistoryReporter // nil if the feature flag storage doesn't keep a change history
 }
 
 // Register mounts the controller endpoints onto the supplied router
@@ -57,13 +58,18 @@ func (c *ProxyObservabilityController) Register(router gin.IRouter) {
 }
 
 func (c *ProxyObservabilityController) observability(ctx *gin.Context) {
-	ctx.JSON(200, gin.H{
+	response := gin.H{
 		"activeSplits":            c.splits.SplitNames(),
 		"activeSegments":          c.segments.NamesAndCount(),
 		"activeFlagSets":          c.splits.GetAllFlagSetNames(),
 		"proxyEndpointStats":      c.telemetry.TimeslicedReport(),
 		"proxyEndpointStatsTotal": c.telemetry.TotalMetricsReport(),
-	})
+		"proxyRateLimited":        c.telemetry.PeekRateLimited(),
+	}
+	if c.history != nil {
+		response["oldestServableChangeNumber"] = c.history.OldestServableChangeNumber()
+	}
+	ctx.JSON(200, response)
 }
 
 // NewObservabilityController constructs and returns the appropriate struct dependeing on whether the app is split-proxy or split-sync
@@ -93,11 +99,13 @@ func NewObservabilityController(proxy bool, logger logging.LoggerInterface, stor
 		return nil, fmt.Errorf("invalid local telemetry storage supplied: %T", storagePack.LocalTelemetryStorage)
 	}
 
+	history, _ := storagePack.SplitStorage.(pstorage.SplitHistoryReporter)
 	return &ProxyObservabilityController{
 		logger:    logger,
 		splits:    splitStorage,
 		segments:  segmentStorage,
 		telemetry: telemetry,
+		history:   history,
 	}, nil
 
 }
@@ -10,8 +10,10 @@ import (
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/observability"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
 	"github.com/splitio/go-split-commons/v9/storage/mocks"
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -227,3 +229,49 @@ func TestProxyObservabilityEndpoint(t *testing.T) {
 		t.Errorf("Active segments should be 1. Actual %d", len(result.ActiveSegments))
 	}
 }
+
+func TestProxyObservabilityEndpointSplitHistory(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	splitStorage := storage.NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false, 0, 0)
+	splitStorage.Update([]dtos.SplitDTO{{Name: "split1", ChangeNumber: 10, Status: "ACTIVE"}}, nil, 10)
+
+	oSegmentStorage, err := observability.NewObservableSegmentStorage(logger, splitStorage, &extMockSegmentStorage{MockSegmentStorage: &mocks.MockSegmentStorage{}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ctrl, err := NewObservabilityController(true, logger, adminCommon.Storages{
+		SplitStorage:          splitStorage,
+		SegmentStorage:        oSegmentStorage,
+		LocalTelemetryStorage: storage.NewTimeslicedProxyEndpointTelemetry(storage.NewProxyTelemetryFacade(), 50, 5),
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	ctrl.Register(router)
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/observability", nil)
+	router.ServeHTTP(resp, ctx.Request)
+
+	var result struct {
+		OldestServableChangeNumber int64 `json:"oldestServableChangeNumber"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Error("there should be no error ", err)
+	}
+
+	if result.OldestServableChangeNumber != 10 {
+		t.Errorf("oldest servable change number should be 10. Actual %d", result.OldestServableChangeNumber)
+	}
+}
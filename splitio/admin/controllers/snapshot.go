@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,26 +14,105 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
 )
 
+// SnapshotApplier defines the interface for components capable of replacing the proxy data with a snapshot's one
+type SnapshotApplier interface {
+	Apply(snap *snapshot.Snapshot) error
+}
+
+// DefaultMaxUploadBytes is the size limit for uploaded snapshots used when none is provided
+const DefaultMaxUploadBytes = 256 << 20
+
 // SnapshotController bundles endpoints associated to snapshot management
 type SnapshotController struct {
-	logger logging.LoggerInterface
-	db     storage.Snapshotter
-	hash   string
+	logger         logging.LoggerInterface
+	db             storage.Snapshotter
+	storageType    uint64
+	hash           string
+	applier        SnapshotApplier
+	maxUploadBytes int64
+	keys           *snapshot.Keys
 }
 
-// NewSnapshotController constructs a new snapshot controller
-func NewSnapshotController(logger logging.LoggerInterface, db storage.Snapshotter, hash string) *SnapshotController {
-	return &SnapshotController{logger: logger, db: db, hash: hash}
+// NewSnapshotController constructs a new snapshot controller. Uploading snapshots is only enabled if an applier is provided,
+// and bodies larger than `maxUploadBytes` (DefaultMaxUploadBytes if <= 0) are rejected.
+// Keys (optional) are used to sign/encrypt downloaded snapshots & verify/decrypt uploaded ones
+func NewSnapshotController(
+	logger logging.LoggerInterface,
+	db storage.Snapshotter,
+	storageType uint64,
+	hash string,
+	applier SnapshotApplier,
+	maxUploadBytes int64,
+	keys *snapshot.Keys,
+) *SnapshotController {
+	if maxUploadBytes <= 0 {
+		maxUploadBytes = DefaultMaxUploadBytes
+	}
+	return &SnapshotController{
+		logger:         logger,
+		db:             db,
+		storageType:    storageType,
+		hash:           hash,
+		applier:        applier,
+		maxUploadBytes: maxUploadBytes,
+		keys:           keys,
+	}
 }
 
 // Register mounts the endpoints int he provided router
 func (c *SnapshotController) Register(router gin.IRouter) {
 	router.GET("/snapshot", c.downloadSnapshot)
+	if c.applier != nil {
+		router.POST("/snapshot", c.uploadSnapshot)
+	}
+}
+
+func (c *SnapshotController) uploadSnapshot(ctx *gin.Context) {
+	// curl -u user:pass -X POST http://localhost:3010/admin/snapshot --data-binary @split.proxy.0001.snapshot
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxUploadBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("snapshot exceeds the max upload size of %d bytes", c.maxUploadBytes)})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("error reading request body: %s", err)})
+		return
+	}
+
+	snap, err := snapshot.Decode(body, c.keys)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid snapshot: %s", err)})
+		return
+	}
+
+	if snap.Meta().Storage != c.storageType {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unsupported snapshot storage type"})
+		return
+	}
+
+	if snap.Meta().Hash != c.hash {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "snapshot cfg (apikey, version, flagsets) does not match the running one"})
+		return
+	}
+
+	if err := c.applier.Apply(snap); err != nil {
+		c.logger.Error("error applying uploaded snapshot: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error applying snapshot"})
+		return
+	}
+
+	c.logger.Info("snapshot applied via admin endpoint")
+	ctx.JSON(http.StatusOK, gin.H{"applied": true})
 }
 
 func (c *SnapshotController) downloadSnapshot(ctx *gin.Context) {
 	// curl http://localhost:3010/admin/proxy/snapshot --output split.proxy.0001.snapshot.gz
-	snapshotName := fmt.Sprintf("split.proxy.%d.snapshot", time.Now().UnixNano())
+	prefix := "split.proxy"
+	if c.storageType == snapshot.StorageRedis {
+		prefix = "split.sync"
+	}
+	snapshotName := fmt.Sprintf("%s.%d.snapshot", prefix, time.Now().UnixNano())
 	b, err := c.db.GetRawSnapshot()
 	if err != nil {
 		c.logger.Error("error getting contents from db to build snapshot: ", err)
@@ -39,14 +120,14 @@ func (c *SnapshotController) downloadSnapshot(ctx *gin.Context) {
 		return
 	}
 
-	s, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: c.hash}, b)
+	s, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: c.storageType, Hash: c.hash}, b)
 	if err != nil {
 		c.logger.Error("error building snapshot: ", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error building snapshot"})
 		return
 	}
 
-	encodedSnap, err := s.Encode()
+	encodedSnap, err := s.Encode(c.keys)
 	if err != nil {
 		c.logger.Error("error encoding snapshot: ", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "error encoding snapshot"})
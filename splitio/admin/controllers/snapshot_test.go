@@ -2,9 +2,13 @@ package controllers
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
@@ -19,7 +23,7 @@ import (
 func TestDownloadProxySnapshot(t *testing.T) {
 	// Read DB snapshot for test
 	path := "../../../test/snapshot/proxy.snapshot"
-	snap, err := snapshot.DecodeFromFile(path)
+	snap, err := snapshot.DecodeFromFile(path, nil)
 	assert.Nil(t, err)
 
 	tmpDataFile, err := snap.WriteDataToTmpFile()
@@ -29,7 +33,7 @@ func TestDownloadProxySnapshot(t *testing.T) {
 	dbInstance, err := persistent.NewBoltWrapper(tmpDataFile, nil)
 	assert.Nil(t, err)
 
-	ctrl := NewSnapshotController(logging.NewLogger(nil), dbInstance, "123456")
+	ctrl := NewSnapshotController(logging.NewLogger(nil), dbInstance, snapshot.StorageBoltDB, "123456", nil, 0, nil)
 
 	resp := httptest.NewRecorder()
 	ctx, router := gin.CreateTestContext(resp)
@@ -41,7 +45,7 @@ func TestDownloadProxySnapshot(t *testing.T) {
 	responseBody, err := io.ReadAll(resp.Body)
 	assert.Nil(t, err)
 
-	snapRes, err := snapshot.Decode(responseBody)
+	snapRes, err := snapshot.Decode(responseBody, nil)
 	assert.Nil(t, err)
 
 	assert.Equal(t, uint64(1), snapRes.Meta().Version)
@@ -54,3 +58,107 @@ func TestDownloadProxySnapshot(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 0, bytes.Compare(dat, resData))
 }
+
+type snapshotApplierMock struct {
+	applied []*snapshot.Snapshot
+	err     error
+}
+
+func (m *snapshotApplierMock) Apply(snap *snapshot.Snapshot) error {
+	m.applied = append(m.applied, snap)
+	return m.err
+}
+
+func TestUploadProxySnapshot(t *testing.T) {
+	encoded, err := os.ReadFile("../../../test/snapshot/proxy.snapshot")
+	assert.Nil(t, err)
+	snap, err := snapshot.Decode(encoded, nil)
+	assert.Nil(t, err)
+
+	post := func(ctrl *SnapshotController, body []byte) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		ctx, router := gin.CreateTestContext(resp)
+		ctrl.Register(router)
+		ctx.Request, _ = http.NewRequest(http.MethodPost, "/snapshot", bytes.NewReader(body))
+		router.ServeHTTP(resp, ctx.Request)
+		return resp
+	}
+
+	applier := &snapshotApplierMock{}
+
+	// upload disabled without an applier
+	resp := post(NewSnapshotController(logging.NewLogger(nil), nil, snapshot.StorageBoltDB, snap.Meta().Hash, nil, 0, nil), encoded)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	ctrl := NewSnapshotController(logging.NewLogger(nil), nil, snapshot.StorageBoltDB, snap.Meta().Hash, applier, 0, nil)
+
+	resp = post(ctrl, []byte("garbage"))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, applier.applied)
+
+	resp = post(NewSnapshotController(logging.NewLogger(nil), nil, snapshot.StorageBoltDB, "someOtherHash", applier, 0, nil), encoded)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Empty(t, applier.applied)
+
+	resp = post(ctrl, encoded)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, applier.applied, 1)
+	assert.Equal(t, snap.Meta(), applier.applied[0].Meta())
+
+	applier.err = errors.New("something")
+	resp = post(ctrl, encoded)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// bodies over the limit are rejected without being read entirely
+	applier.err = nil
+	limited := NewSnapshotController(logging.NewLogger(nil), nil, snapshot.StorageBoltDB, snap.Meta().Hash, applier, int64(len(encoded)-1), nil)
+	resp = post(limited, encoded)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Len(t, applier.applied, 2)
+}
+
+func TestSignedSnapshots(t *testing.T) {
+	encoded, err := os.ReadFile("../../../test/snapshot/proxy.snapshot")
+	assert.Nil(t, err)
+	snap, err := snapshot.Decode(encoded, nil)
+	assert.Nil(t, err)
+	tmpDataFile, err := snap.WriteDataToTmpFile()
+	assert.Nil(t, err)
+	dbInstance, err := persistent.NewBoltWrapper(tmpDataFile, nil)
+	assert.Nil(t, err)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	keys := &snapshot.Keys{Signing: priv, Verifying: pub, Encryption: bytes.Repeat([]byte{1}, 16)}
+
+	applier := &snapshotApplierMock{}
+	ctrl := NewSnapshotController(logging.NewLogger(nil), dbInstance, snapshot.StorageBoltDB, snap.Meta().Hash, applier, 0, keys)
+	serve := func(method string, body []byte) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		ctx, router := gin.CreateTestContext(resp)
+		ctrl.Register(router)
+		ctx.Request, _ = http.NewRequest(method, "/snapshot", bytes.NewReader(body))
+		router.ServeHTTP(resp, ctx.Request)
+		return resp
+	}
+
+	// unsigned snapshots are rejected
+	resp := serve(http.MethodPost, encoded)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Empty(t, applier.applied)
+
+	// downloaded snapshots are signed & encrypted, and can be uploaded back
+	resp = serve(http.MethodGet, nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	downloaded := resp.Body.Bytes()
+
+	_, err = snapshot.Decode(downloaded, nil)
+	assert.ErrorIs(t, err, snapshot.ErrEncryptionKeyRequired)
+	decoded, err := snapshot.Decode(downloaded, keys)
+	assert.Nil(t, err)
+	assert.True(t, decoded.Meta().Encrypted)
+
+	resp = serve(http.MethodPost, downloaded)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, applier.applied, 1)
+}
@@ -411,6 +411,20 @@ const mainScript = `
     $('#events_lambda').html(stats.eventsLambda);
     $('#requests_ok').html(stats.requestsOk);
     $('#requests_error').html(stats.requestsErrored);
+    $('#requests_rate_limited').html(stats.rateLimitedRequests);
+    $('#requests_rate_limited_groups').html(
+      Object.entries(stats.rateLimited || {}).map(([group, count]) => group + ': ' + count).join(' | '));
+    $('#spilled_bulks').html(stats.spilledBulks);
+    $('#spilled_bulks_queues').html(
+      Object.entries(stats.spill || {}).map(([queue, counts]) => queue + ': ' + counts.spilled).join(' | '));
+    $('#replayed_bulks').html(stats.replayedBulks);
+    $('#expired_bulks').html(stats.expiredBulks);
+    $('#pending_export_bundles').html(stats.pendingExportBundles);
+    if (stats.membershipIndex) {
+      $('#membership_index_keys').html(stats.membershipIndex.keys);
+      $('#membership_index_memberships').html(stats.membershipIndex.memberships);
+      $('#membership_index_memory').html((stats.membershipIndex.estimatedBytes / (1024 * 1024)).toFixed(1) + ' MB');
+    }
     $('#backend_requests_ok').html(stats.backendRequestsOk);
     $('#backend_requests_error').html(stats.backendRequestsErrored);
   };
@@ -85,6 +85,7 @@ type RootObject struct {
 	ServicesHealth      services.HealthDto    `json:"servicesHealth"`
 	FlagSpecVersion     string
 	LargeSegmentVersion string
+	ExportBundles       bool // whether sdk data is being recorded into export bundles (air-gapped mode)
 }
 
 // GlobalStats runtime stats used to render the dashboard
@@ -109,6 +110,29 @@ type GlobalStats struct {
 	EventsLambda           float64                   `json:"eventsLambda"`
 	Uptime                 int64                     `json:"uptime"`
 	FlagSets               []FlagSetsSummary         `json:"flagSets"`
+	RateLimitedRequests    int64                     `json:"rateLimitedRequests"`
+	RateLimited            map[string]int64          `json:"rateLimited"`
+	SpilledBulks           int64                     `json:"spilledBulks"`
+	ReplayedBulks          int64                     `json:"replayedBulks"`
+	ExpiredBulks           int64                     `json:"expiredBulks"`
+	Spill                  map[string]SpillSummary   `json:"spill"`
+	PendingExportBundles   int64                     `json:"pendingExportBundles"`
+	MembershipIndex        *MembershipIndexSummary   `json:"membershipIndex,omitempty"`
+}
+
+// MembershipIndexSummary encapsulates the size & estimated memory usage of the index used to serve mySegments/memberships
+type MembershipIndexSummary struct {
+	Keys           int64 `json:"keys"`
+	Segments       int64 `json:"segments"`
+	Memberships    int64 `json:"memberships"`
+	EstimatedBytes int64 `json:"estimatedBytes"`
+}
+
+// SpillSummary encapsulates the spill-to-disk counters of a single queue
+type SpillSummary struct {
+	Spilled  int64 `json:"spilled"`
+	Replayed int64 `json:"replayed"`
+	Expired  int64 `json:"expired"`
 }
 
 // SplitSummary encapsulates a minimalistic view of feature flag properties to be presented in the dashboard
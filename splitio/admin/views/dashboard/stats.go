@@ -113,6 +113,29 @@ const cards = `
       {{end}}
     </div>
   
+    {{if .ProxyMode}}
+      <div class="row">
+        <div class="col-md-4">
+          <div class="gray2Box metricBox">
+            <h4>Keys in Segments</h4>
+            <h1 id="membership_index_keys" class="centerText"></h1>
+          </div>
+        </div>
+        <div class="col-md-4">
+          <div class="gray2Box metricBox">
+            <h4>Distinct Segment Memberships</h4>
+            <h1 id="membership_index_memberships" class="centerText"></h1>
+          </div>
+        </div>
+        <div class="col-md-4">
+          <div class="gray2Box metricBox">
+            <h4>Membership Index Memory <small>(estimated)</small></h4>
+            <h1 id="membership_index_memory" class="centerText"></h1>
+          </div>
+        </div>
+      </div>
+    {{end}}
+
     {{if not .ProxyMode}} 
       <div class="row">
         <div class="col-md-2">
@@ -321,20 +344,60 @@ const sdkStats = `
   <!-- SDK STATS -->
   <div role="tabpanel" class="tab-pane" id="sdk-stats">
     <div class="row">
-      <div class="col-md-6">
+      <div class="col-md-4">
         <div class="greenBox metricBox">
           <h4>Successful Requests</h4>
           <h1 id="requests_ok" class="centerText"></h1>
         </div>
       </div>
-      <div class="col-md-6">
+      <div class="col-md-4">
         <div class="redBox metricBox">
           <h4>Error Requests</h4>
           <h1 id="requests_error" class="centerText"></h1>
         </div>
       </div>
+      <div class="col-md-4">
+        <div class="bg-primary metricBox">
+          <h4>Rate-limited Requests</h4>
+          <h1 id="requests_rate_limited" class="centerText"></h1>
+          <p id="requests_rate_limited_groups" class="centerText"></p>
+        </div>
+      </div>
+    </div>
+
+    <div class="row">
+      <div class="col-md-4">
+        <div class="bg-primary metricBox">
+          <h4>Bulks Spilled to Disk</h4>
+          <h1 id="spilled_bulks" class="centerText"></h1>
+          <p id="spilled_bulks_queues" class="centerText"></p>
+        </div>
+      </div>
+      <div class="col-md-4">
+        <div class="greenBox metricBox">
+          <h4>Spilled Bulks Replayed</h4>
+          <h1 id="replayed_bulks" class="centerText"></h1>
+        </div>
+      </div>
+      <div class="col-md-4">
+        <div class="redBox metricBox">
+          <h4>Spilled Bulks Expired</h4>
+          <h1 id="expired_bulks" class="centerText"></h1>
+        </div>
+      </div>
     </div>
 
+    {{if .ExportBundles}}
+    <div class="row">
+      <div class="col-md-4">
+        <div class="bg-primary metricBox">
+          <h4>Export Bundles Pending Upload</h4>
+          <h1 id="pending_export_bundles" class="centerText"></h1>
+        </div>
+      </div>
+    </div>
+    {{end}}
+
     <div class="row">
       <div class="col-md-8">
         <div class="bg-primary metricBox">
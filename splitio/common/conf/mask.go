@@ -0,0 +1,25 @@
+package conf
+
+import "strings"
+
+const maskedValue = "xxxxxxxxxxxxxxx"
+
+// MaskSecrets hides the impression listener settings that may carry credentials (signing secret & header values),
+// so that the config can be safely displayed. Header names are kept. Since configs are usually shallow-copied
+// before being masked, slices are replaced rather than modified in place
+func (l *ImpressionListener) MaskSecrets() {
+	if l.HMACSecret != "" {
+		l.HMACSecret = maskedValue
+	}
+
+	if len(l.Headers) == 0 {
+		return
+	}
+
+	masked := make([]string, 0, len(l.Headers))
+	for _, header := range l.Headers {
+		name, _, _ := strings.Cut(header, ":")
+		masked = append(masked, strings.TrimSpace(name)+": "+maskedValue)
+	}
+	l.Headers = masked
+}
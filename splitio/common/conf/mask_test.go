@@ -0,0 +1,30 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpressionListenerMaskSecrets(t *testing.T) {
+	original := ImpressionListener{
+		Endpoint:   "http://listener",
+		HMACSecret: "secret",
+		Headers:    []string{"Authorization: Bearer token", "X-Tenant:tenant1"},
+	}
+
+	masked := original
+	masked.MaskSecrets()
+	assert.Equal(t, "http://listener", masked.Endpoint)
+	assert.Equal(t, maskedValue, masked.HMACSecret)
+	assert.Equal(t, []string{"Authorization: " + maskedValue, "X-Tenant: " + maskedValue}, masked.Headers)
+
+	// the original config is left untouched
+	assert.Equal(t, "secret", original.HMACSecret)
+	assert.Equal(t, []string{"Authorization: Bearer token", "X-Tenant:tenant1"}, original.Headers)
+
+	empty := ImpressionListener{}
+	empty.MaskSecrets()
+	assert.Empty(t, empty.HMACSecret)
+	assert.Nil(t, empty.Headers)
+}
@@ -21,13 +21,71 @@ type Admin struct {
 // Integrations configuration options
 type Integrations struct {
 	ImpressionListener ImpressionListener `json:"impressionListener" s-nested:"true"`
+	EventListener      EventListener      `json:"eventListener" s-nested:"true"`
 	Slack              Slack              `json:"slack" s-nested:"true"`
+	DataSinks          DataSinks          `json:"dataSinks" s-nested:"true"`
 }
 
 // ImpressionListener configuration options
 type ImpressionListener struct {
-	Endpoint  string `json:"endpoint" s-cli:"impression-listener-endpoint" s-def:"" s-desc:"HTTP endpoint to forward impressions to"`
-	QueueSize int64  `json:"queueSize" s-cli:"impression-listener-queue-size" s-def:"100" s-desc:"max number of impressions bulks to queue"`
+	Endpoint            string   `json:"endpoint" s-cli:"impression-listener-endpoint" s-def:"" s-desc:"HTTP endpoint to forward impressions to"`
+	QueueSize           int64    `json:"queueSize" s-cli:"impression-listener-queue-size" s-def:"100" s-desc:"max number of impressions bulks to queue"`
+	TimeoutMs           int64    `json:"timeoutMs" s-cli:"impression-listener-timeout-ms" s-def:"10000" s-desc:"Timeout for each request to the impression listener endpoint"`
+	MaxRetries          int64    `json:"maxRetries" s-cli:"impression-listener-max-retries" s-def:"3" s-desc:"Max number of retries for a bulk upon network errors or 408/429/5xx responses"`
+	RetryBaseBackoffMs  int64    `json:"retryBaseBackoffMs" s-cli:"impression-listener-retry-base-backoff-ms" s-def:"500" s-desc:"Wait before the first retry. Doubled on each subsequent one"`
+	RetryMaxBackoffMs   int64    `json:"retryMaxBackoffMs" s-cli:"impression-listener-retry-max-backoff-ms" s-def:"30000" s-desc:"Max wait between retries"`
+	BatchMaxImpressions int64    `json:"batchMaxImpressions" s-cli:"impression-listener-batch-max-impressions" s-def:"0" s-desc:"Coalesce bulks until this many impressions are accumulated (0 disables batching)"`
+	BatchMaxWaitMs      int64    `json:"batchMaxWaitMs" s-cli:"impression-listener-batch-max-wait-ms" s-def:"1000" s-desc:"Max time to hold impressions while coalescing bulks"`
+	Gzip                bool     `json:"gzip" s-cli:"impression-listener-gzip" s-def:"false" s-desc:"Gzip request bodies"`
+	Headers             []string `json:"headers" s-cli:"impression-listener-headers" s-def:"" s-desc:"Comma-separated list of 'Name: value' headers to add to each request"`
+	HeadersFile         string   `json:"headersFile" s-cli:"impression-listener-headers-file" s-def:"" s-desc:"File with one 'Name: value' header per line to add to each request. Reloaded when modified"`
+	HMACSecret          string   `json:"hmacSecret" s-cli:"impression-listener-hmac-secret" s-def:"" s-desc:"Sign requests with an HMAC-SHA256 of '<X-Split-Timestamp>.<body>' in the X-Split-Signature header"`
+}
+
+// EventListener configuration options
+type EventListener struct {
+	Endpoint           string   `json:"endpoint" s-cli:"event-listener-endpoint" s-def:"" s-desc:"HTTP endpoint to forward events to"`
+	QueueSize          int64    `json:"queueSize" s-cli:"event-listener-queue-size" s-def:"100" s-desc:"max number of event bulks to queue"`
+	TrafficTypes       []string `json:"trafficTypes" s-cli:"event-listener-traffic-types" s-def:"" s-desc:"Only forward events of these traffic types (empty means all)"`
+	EventTypes         []string `json:"eventTypes" s-cli:"event-listener-event-types" s-def:"" s-desc:"Only forward events of these event types (empty means all)"`
+	TimeoutMs          int64    `json:"timeoutMs" s-cli:"event-listener-timeout-ms" s-def:"10000" s-desc:"Timeout for each request to the event listener endpoint"`
+	MaxRetries         int64    `json:"maxRetries" s-cli:"event-listener-max-retries" s-def:"3" s-desc:"Max number of retries for a bulk upon network errors or 408/429/5xx responses"`
+	RetryBaseBackoffMs int64    `json:"retryBaseBackoffMs" s-cli:"event-listener-retry-base-backoff-ms" s-def:"500" s-desc:"Wait before the first retry. Doubled on each subsequent one"`
+	RetryMaxBackoffMs  int64    `json:"retryMaxBackoffMs" s-cli:"event-listener-retry-max-backoff-ms" s-def:"30000" s-desc:"Max wait between retries"`
+}
+
+// DataSinks configuration options
+type DataSinks struct {
+	QueueSize int64     `json:"queueSize" s-cli:"data-sinks-queue-size" s-def:"100" s-desc:"max number of impressions/events bulks to queue for local data sinks"`
+	File      FileSink  `json:"file" s-nested:"true" s-cli-prefix:"file-sink"`
+	Kafka     KafkaSink `json:"kafka" s-nested:"true" s-cli-prefix:"kafka-sink"`
+}
+
+// FileSink configuration options
+type FileSink struct {
+	Directory  string     `json:"directory" s-cli:"dir" s-def:"" s-desc:"Directory to write impressions & events to as NDJSON files (empty disables this sink)"`
+	Prefix     string     `json:"prefix" s-cli:"prefix" s-def:"split" s-desc:"Prefix for the names of the generated files"`
+	Gzip       bool       `json:"gzip" s-cli:"gzip" s-def:"true" s-desc:"Compress generated files with gzip"`
+	MaxSizeMb  int64      `json:"maxSizeMb" s-cli:"max-size-mb" s-def:"100" s-desc:"Rotate files after writing this many (uncompressed) megabytes"`
+	MaxAgeSecs int64      `json:"maxAgeSecs" s-cli:"max-age-secs" s-def:"3600" s-desc:"Rotate files after this many seconds"`
+	Filter     SinkFilter `json:"filter" s-nested:"true"`
+}
+
+// KafkaSink configuration options
+type KafkaSink struct {
+	Brokers          []string   `json:"brokers" s-cli:"brokers" s-def:"" s-desc:"Comma-separated list of kafka brokers to produce impressions & events to (empty disables this sink)"`
+	ImpressionsTopic string     `json:"impressionsTopic" s-cli:"impressions-topic" s-def:"split-impressions" s-desc:"Topic to produce impressions to"`
+	EventsTopic      string     `json:"eventsTopic" s-cli:"events-topic" s-def:"split-events" s-desc:"Topic to produce events to"`
+	TimeoutMs        int64      `json:"timeoutMs" s-cli:"timeout-ms" s-def:"10000" s-desc:"Max time to wait for a bulk to be acknowledged by the brokers"`
+	Filter           SinkFilter `json:"filter" s-nested:"true"`
+}
+
+// SinkFilter configuration options
+type SinkFilter struct {
+	Impressions  bool     `json:"impressions" s-cli:"impressions" s-def:"true" s-desc:"Write impressions to this sink"`
+	Events       bool     `json:"events" s-cli:"events" s-def:"true" s-desc:"Write events to this sink"`
+	FeatureFlags []string `json:"featureFlags" s-cli:"feature-flags" s-def:"" s-desc:"Only write impressions of these feature flags (empty means all)"`
+	EventTypes   []string `json:"eventTypes" s-cli:"event-types" s-def:"" s-desc:"Only write events of these types (empty means all)"`
 }
 
 // Slack configuration options
@@ -47,3 +105,20 @@ type TLS struct {
 	MinTLSVersion            string `json:"minTlsVersion" s-cli:"tls-min-tls-version" s-def:"1.3" s-desc:"Minimum TLS version to allow X.Y"`
 	AllowedCipherSuites      string `json:"allowedCipherSuites" s-cli:"tls-allowed-cipher-suites" s-def:"" s-desc:"Comma-separated list of cipher suites to allow"`
 }
+
+// Tracing configuration options
+type Tracing struct {
+	Enabled         bool   `json:"enabled" s-cli:"tracing-enabled" s-def:"false" s-desc:"Enable opentelemetry tracing"`
+	Exporter        string `json:"exporter" s-cli:"tracing-exporter" s-def:"otlp" s-desc:"Where to export spans to (otlp|stdout|file)"`
+	Endpoint        string `json:"endpoint" s-cli:"tracing-endpoint" s-def:"" s-desc:"OTLP/HTTP collector url. (Default: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or http://localhost:4318)"`
+	File            string `json:"file" s-cli:"tracing-file" s-def:"" s-desc:"File to write spans to when using the 'file' exporter"`
+	SamplingPercent int64  `json:"samplingPercent" s-cli:"tracing-sampling-percent" s-def:"100" s-desc:"Percentage of traces to sample when the parent span is not sampled already"`
+	ServiceName     string `json:"serviceName" s-cli:"tracing-service-name" s-def:"" s-desc:"Service name to report spans with. (Default: split-proxy or split-synchronizer)"`
+}
+
+// SnapshotSecurity configuration options for signing, verifying & encrypting snapshots
+type SnapshotSecurity struct {
+	SigningKeyFn    string `json:"signingKeyFn" s-cli:"snapshot-signing-key-fn" s-def:"" s-desc:"PEM file with an ed25519 private key (PKCS8) used to sign generated snapshots"`
+	VerifyingKeyFn  string `json:"verifyingKeyFn" s-cli:"snapshot-verifying-key-fn" s-def:"" s-desc:"PEM file with an ed25519 public key (PKIX). When set, snapshots without a valid signature are rejected"`
+	EncryptionKeyFn string `json:"encryptionKeyFn" s-cli:"snapshot-encryption-key-fn" s-def:"" s-desc:"File with a base64 AES key (16, 24 or 32 bytes) used to encrypt generated snapshots & decrypt loaded ones. (Default: SPLIT_PROXY_/SPLIT_SYNC_SNAPSHOT_ENCRYPTION_KEY env var)"`
+}
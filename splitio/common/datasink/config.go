@@ -0,0 +1,69 @@
+package datasink
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// FromConfig builds a dispatcher for the sinks enabled in the supplied config.
+// A nil dispatcher is returned if no sink is enabled
+func FromConfig(cfg *conf.DataSinks, clientID string, logger logging.LoggerInterface) (Dispatcher, error) {
+	var sinks []Sink
+	if fcfg := cfg.File; fcfg.Directory != "" {
+		sink, err := NewFileSink(FileSinkConfig{
+			Directory: fcfg.Directory,
+			Prefix:    fcfg.Prefix,
+			Gzip:      fcfg.Gzip,
+			MaxBytes:  fcfg.MaxSizeMb * 1024 * 1024,
+			MaxAge:    time.Duration(fcfg.MaxAgeSecs) * time.Second,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error building file sink: %w", err)
+		}
+		sinks = append(sinks, Filtered(sink, filterFromConfig(&fcfg.Filter)))
+	}
+
+	var brokers []string
+	for _, broker := range cfg.Kafka.Brokers {
+		if broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+
+	if kcfg := cfg.Kafka; len(brokers) > 0 {
+		sink, err := NewKafkaSink(KafkaSinkConfig{
+			Brokers:          brokers,
+			ImpressionsTopic: kcfg.ImpressionsTopic,
+			EventsTopic:      kcfg.EventsTopic,
+			ClientID:         clientID,
+			Timeout:          time.Duration(kcfg.TimeoutMs) * time.Millisecond,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error building kafka sink: %w", err)
+		}
+		sinks = append(sinks, Filtered(sink, filterFromConfig(&kcfg.Filter)))
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	dispatcher, err := NewDispatcher(sinks, int(cfg.QueueSize), logger)
+	if err != nil {
+		return nil, err
+	}
+	return dispatcher, nil
+}
+
+func filterFromConfig(cfg *conf.SinkFilter) Filter {
+	return Filter{
+		Impressions:  cfg.Impressions,
+		Events:       cfg.Events,
+		FeatureFlags: cfg.FeatureFlags,
+		EventTypes:   cfg.EventTypes,
+	}
+}
@@ -0,0 +1,195 @@
+package datasink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// suffix used while a file is being written. It's removed once the file is rotated or the sink is closed,
+// so that consumers can safely pick up any file without it
+const inProgressSuffix = ".inprogress"
+
+// FileSinkConfig bundles the options of a file sink
+type FileSinkConfig struct {
+	Directory string
+	Prefix    string
+	Gzip      bool
+	MaxBytes  int64         // rotate after writing this many (uncompressed) bytes
+	MaxAge    time.Duration // rotate after a file has been open for this long
+}
+
+// impressionRecord & eventRecord tag each line with the kind of data it holds
+type impressionRecord struct {
+	Kind string `json:"kind"`
+	*Impression
+}
+
+type eventRecord struct {
+	Kind string `json:"kind"`
+	*Event
+}
+
+// FileSink writes impressions & events as newline-delimited json to size/time rotated (optionally gzipped) files
+type FileSink struct {
+	config  FileSinkConfig
+	mutex   sync.Mutex
+	file    *os.File
+	gzipped *gzip.Writer
+	writer  *bufio.Writer
+	path    string
+	written int64
+	opened  time.Time
+	seq     int
+}
+
+// NewFileSink constructs a file sink, creating the target directory if necessary
+func NewFileSink(config FileSinkConfig) (*FileSink, error) {
+	if config.Directory == "" {
+		return nil, fmt.Errorf("a directory is required")
+	}
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory '%s': %w", config.Directory, err)
+	}
+	return &FileSink{config: config}, nil
+}
+
+// Name returns the name of the sink
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// WriteImpressions appends the impressions to the current file
+func (s *FileSink) WriteImpressions(imps []Impression) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for idx := range imps {
+		if err := s.writeRecord(impressionRecord{Kind: "impression", Impression: &imps[idx]}); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+// WriteEvents appends the events to the current file
+func (s *FileSink) WriteEvents(events []Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for idx := range events {
+		if err := s.writeRecord(eventRecord{Kind: "event", Event: &events[idx]}); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+// Close finishes the current file
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.finish()
+}
+
+func (s *FileSink) writeRecord(record interface{}) error {
+	serialized, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing record: %w", err)
+	}
+
+	if s.file != nil && s.shouldRotate() {
+		if err := s.finish(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	serialized = append(serialized, '\n')
+	if _, err := s.writer.Write(serialized); err != nil {
+		return fmt.Errorf("error writing to '%s': %w", s.path, err)
+	}
+	s.written += int64(len(serialized))
+	return nil
+}
+
+func (s *FileSink) shouldRotate() bool {
+	return (s.config.MaxBytes > 0 && s.written >= s.config.MaxBytes) ||
+		(s.config.MaxAge > 0 && time.Since(s.opened) >= s.config.MaxAge)
+}
+
+func (s *FileSink) open() error {
+	now := time.Now().UTC()
+	s.seq++
+	name := fmt.Sprintf("%s-%s-%d.ndjson", s.config.Prefix, now.Format("20060102T150405"), s.seq)
+	if s.config.Gzip {
+		name += ".gz"
+	}
+
+	s.path = filepath.Join(s.config.Directory, name)
+	file, err := os.OpenFile(s.path+inProgressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("error creating file '%s': %w", s.path, err)
+	}
+
+	var target io.Writer = file
+	if s.config.Gzip {
+		s.gzipped = gzip.NewWriter(file)
+		target = s.gzipped
+	}
+
+	s.file = file
+	s.writer = bufio.NewWriter(target)
+	s.written = 0
+	s.opened = now
+	return nil
+}
+
+// flush pushes buffered data to the file, so that nothing is held in memory between writes
+func (s *FileSink) flush() error {
+	if s.writer == nil {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("error writing to '%s': %w", s.path, err)
+	}
+	return nil
+}
+
+// finish closes the current file (if any) & removes the in-progress suffix
+func (s *FileSink) finish() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.flush()
+	if s.gzipped != nil {
+		if gerr := s.gzipped.Close(); err == nil && gerr != nil {
+			err = fmt.Errorf("error finishing gzip stream for '%s': %w", s.path, gerr)
+		}
+	}
+	if cerr := s.file.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("error closing '%s': %w", s.path, cerr)
+	}
+	if rerr := os.Rename(s.path+inProgressSuffix, s.path); err == nil && rerr != nil {
+		err = fmt.Errorf("error renaming '%s': %w", s.path, rerr)
+	}
+
+	s.file = nil
+	s.gzipped = nil
+	s.writer = nil
+	return err
+}
+
+var _ Sink = (*FileSink)(nil)
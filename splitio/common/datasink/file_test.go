@@ -0,0 +1,105 @@
+package datasink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		assert.Nil(t, err)
+		reader = gz
+	}
+
+	var toRet []map[string]interface{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &line))
+		toRet = append(toRet, line)
+	}
+	return toRet
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sinks")
+	sink, err := NewFileSink(FileSinkConfig{Directory: dir, Prefix: "test", Gzip: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "file", sink.Name())
+
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f1", KeyName: "k1", Treatment: "on", SDKVersion: "go-1.2.3"}}))
+	assert.Nil(t, sink.WriteEvents([]Event{{Key: "k1", EventTypeID: "click", Value: 2.0}}))
+
+	names := listDir(t, dir)
+	assert.Equal(t, 1, len(names))
+	assert.True(t, strings.HasSuffix(names[0], ".ndjson.gz"+inProgressSuffix))
+
+	assert.Nil(t, sink.Close())
+	names = listDir(t, dir)
+	assert.Equal(t, 1, len(names))
+	assert.True(t, strings.HasPrefix(names[0], "test-"))
+	assert.True(t, strings.HasSuffix(names[0], ".ndjson.gz"))
+
+	lines := readLines(t, filepath.Join(dir, names[0]))
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "impression", lines[0]["kind"])
+	assert.Equal(t, "f1", lines[0]["featureFlag"])
+	assert.Equal(t, "k1", lines[0]["keyName"])
+	assert.Equal(t, "go-1.2.3", lines[0]["sdkVersion"])
+	assert.Equal(t, "event", lines[1]["kind"])
+	assert.Equal(t, "click", lines[1]["eventTypeId"])
+	assert.Equal(t, 2.0, lines[1]["value"])
+
+	// closing twice is a no-op
+	assert.Nil(t, sink.Close())
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(FileSinkConfig{Directory: dir, Prefix: "test", MaxBytes: 1})
+	assert.Nil(t, err)
+
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f1"}, {FeatureFlag: "f2"}}))
+	assert.Nil(t, sink.WriteEvents([]Event{{Key: "k1"}}))
+	assert.Nil(t, sink.Close())
+
+	names := listDir(t, dir)
+	assert.Equal(t, 3, len(names))
+	for _, name := range names {
+		assert.True(t, strings.HasSuffix(name, ".ndjson"))
+		assert.Equal(t, 1, len(readLines(t, filepath.Join(dir, name))))
+	}
+}
+
+func TestFileSinkNoDirectory(t *testing.T) {
+	_, err := NewFileSink(FileSinkConfig{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,91 @@
+package datasink
+
+// Filter restricts the data written to a sink
+type Filter struct {
+	Impressions  bool     // whether impressions are written at all
+	Events       bool     // whether events are written at all
+	FeatureFlags []string // only write impressions for these feature flags (empty means all)
+	EventTypes   []string // only write events of these types (empty means all)
+}
+
+// filteredSink wraps a sink dropping the data not accepted by a filter
+type filteredSink struct {
+	Sink
+	impressions  bool
+	events       bool
+	featureFlags map[string]struct{}
+	eventTypes   map[string]struct{}
+}
+
+// Filtered returns a sink that only forwards to `sink` the data accepted by `filter`
+func Filtered(sink Sink, filter Filter) Sink {
+	return &filteredSink{
+		Sink:         sink,
+		impressions:  filter.Impressions,
+		events:       filter.Events,
+		featureFlags: toSet(filter.FeatureFlags),
+		eventTypes:   toSet(filter.EventTypes),
+	}
+}
+
+// WriteImpressions forwards the accepted impressions (if any) to the wrapped sink
+func (s *filteredSink) WriteImpressions(imps []Impression) error {
+	if !s.impressions {
+		return nil
+	}
+
+	if s.featureFlags != nil {
+		accepted := make([]Impression, 0, len(imps))
+		for idx := range imps {
+			if _, ok := s.featureFlags[imps[idx].FeatureFlag]; ok {
+				accepted = append(accepted, imps[idx])
+			}
+		}
+		imps = accepted
+	}
+
+	if len(imps) == 0 {
+		return nil
+	}
+	return s.Sink.WriteImpressions(imps)
+}
+
+// WriteEvents forwards the accepted events (if any) to the wrapped sink
+func (s *filteredSink) WriteEvents(events []Event) error {
+	if !s.events {
+		return nil
+	}
+
+	if s.eventTypes != nil {
+		accepted := make([]Event, 0, len(events))
+		for idx := range events {
+			if _, ok := s.eventTypes[events[idx].EventTypeID]; ok {
+				accepted = append(accepted, events[idx])
+			}
+		}
+		events = accepted
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+	return s.Sink.WriteEvents(events)
+}
+
+func toSet(items []string) map[string]struct{} {
+	if len(items) == 0 {
+		return nil
+	}
+
+	toRet := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item != "" {
+			toRet[item] = struct{}{}
+		}
+	}
+
+	if len(toRet) == 0 {
+		return nil
+	}
+	return toRet
+}
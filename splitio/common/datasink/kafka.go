@@ -0,0 +1,105 @@
+package datasink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaSinkConfig bundles the options of a kafka sink
+type KafkaSinkConfig struct {
+	Brokers          []string
+	ImpressionsTopic string
+	EventsTopic      string
+	ClientID         string
+	Timeout          time.Duration // max time to wait for a bulk to be acknowledged
+}
+
+// KafkaSink produces impressions & events as json messages to kafka (or any kafka-compatible broker).
+// Impressions are keyed by user key & events by their key, so that data for the same key lands in the same partition
+type KafkaSink struct {
+	client           *kgo.Client
+	impressionsTopic string
+	eventsTopic      string
+	timeout          time.Duration
+}
+
+// NewKafkaSink constructs a kafka sink. Connections are established lazily
+func NewKafkaSink(config KafkaSinkConfig) (*KafkaSink, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one broker is required")
+	}
+
+	opts := []kgo.Opt{kgo.SeedBrokers(config.Brokers...), kgo.ProducerBatchCompression(kgo.GzipCompression())}
+	if config.ClientID != "" {
+		opts = append(opts, kgo.ClientID(config.ClientID))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error building kafka client: %w", err)
+	}
+
+	return &KafkaSink{
+		client:           client,
+		impressionsTopic: config.ImpressionsTopic,
+		eventsTopic:      config.EventsTopic,
+		timeout:          config.Timeout,
+	}, nil
+}
+
+// Name returns the name of the sink
+func (s *KafkaSink) Name() string {
+	return "kafka"
+}
+
+// WriteImpressions produces one message per impression & waits until all of them are acknowledged
+func (s *KafkaSink) WriteImpressions(imps []Impression) error {
+	records := make([]*kgo.Record, 0, len(imps))
+	for idx := range imps {
+		value, err := json.Marshal(&imps[idx])
+		if err != nil {
+			return fmt.Errorf("error serializing impression: %w", err)
+		}
+		records = append(records, &kgo.Record{Topic: s.impressionsTopic, Key: []byte(imps[idx].KeyName), Value: value})
+	}
+	return s.produce(records)
+}
+
+// WriteEvents produces one message per event & waits until all of them are acknowledged
+func (s *KafkaSink) WriteEvents(events []Event) error {
+	records := make([]*kgo.Record, 0, len(events))
+	for idx := range events {
+		value, err := json.Marshal(&events[idx])
+		if err != nil {
+			return fmt.Errorf("error serializing event: %w", err)
+		}
+		records = append(records, &kgo.Record{Topic: s.eventsTopic, Key: []byte(events[idx].Key), Value: value})
+	}
+	return s.produce(records)
+}
+
+// Close releases the underlying client
+func (s *KafkaSink) Close() error {
+	s.client.Close()
+	return nil
+}
+
+func (s *KafkaSink) produce(records []*kgo.Record) error {
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	if err := s.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		return fmt.Errorf("error producing %d messages: %w", len(records), err)
+	}
+	return nil
+}
+
+var _ Sink = (*KafkaSink)(nil)
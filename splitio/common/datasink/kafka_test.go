@@ -0,0 +1,67 @@
+package datasink
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestKafkaSink(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "imps", "evs"))
+	assert.Nil(t, err)
+	defer cluster.Close()
+
+	sink, err := NewKafkaSink(KafkaSinkConfig{
+		Brokers:          cluster.ListenAddrs(),
+		ImpressionsTopic: "imps",
+		EventsTopic:      "evs",
+		ClientID:         "test",
+		Timeout:          5 * time.Second,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "kafka", sink.Name())
+
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f1", KeyName: "k1"}, {FeatureFlag: "f2", KeyName: "k2"}}))
+	assert.Nil(t, sink.WriteEvents([]Event{{Key: "k3", EventTypeID: "click"}}))
+	assert.Nil(t, sink.Close())
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(cluster.ListenAddrs()...), kgo.ConsumeTopics("imps", "evs"))
+	assert.Nil(t, err)
+	defer consumer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var records []*kgo.Record
+	for len(records) < 3 && ctx.Err() == nil {
+		fetches := consumer.PollFetches(ctx)
+		records = append(records, fetches.Records()...)
+	}
+	assert.Equal(t, 3, len(records))
+
+	byKey := make(map[string]*kgo.Record)
+	for _, record := range records {
+		byKey[string(record.Key)] = record
+	}
+
+	assert.Equal(t, "imps", byKey["k1"].Topic)
+	var imp Impression
+	assert.Nil(t, json.Unmarshal(byKey["k1"].Value, &imp))
+	assert.Equal(t, Impression{FeatureFlag: "f1", KeyName: "k1"}, imp)
+	assert.Equal(t, "imps", byKey["k2"].Topic)
+
+	assert.Equal(t, "evs", byKey["k3"].Topic)
+	var ev Event
+	assert.Nil(t, json.Unmarshal(byKey["k3"].Value, &ev))
+	assert.Equal(t, Event{Key: "k3", EventTypeID: "click"}, ev)
+}
+
+func TestKafkaSinkNoBrokers(t *testing.T) {
+	_, err := NewKafkaSink(KafkaSinkConfig{})
+	assert.NotNil(t, err)
+}
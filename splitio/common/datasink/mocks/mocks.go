@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+)
+
+type DispatcherMock struct {
+	SubmitImpressionsCall func(imps []datasink.Impression) error
+	SubmitEventsCall      func(events []datasink.Event) error
+	StartCall             func() error
+	StopCall              func(blocking bool) error
+}
+
+func (d *DispatcherMock) SubmitImpressions(imps []datasink.Impression) error {
+	return d.SubmitImpressionsCall(imps)
+}
+
+func (d *DispatcherMock) SubmitEvents(events []datasink.Event) error {
+	return d.SubmitEventsCall(events)
+}
+
+func (d *DispatcherMock) Start() error {
+	return d.StartCall()
+}
+
+func (d *DispatcherMock) Stop(blocking bool) error {
+	return d.StopCall(blocking)
+}
+
+var _ datasink.Dispatcher = (*DispatcherMock)(nil)
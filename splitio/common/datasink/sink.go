@@ -0,0 +1,265 @@
+package datasink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/struct/traits/lifecycle"
+)
+
+// ErrInvalidQueueSize is returned when attempting to construct a dispatcher with an invalid queue size
+var ErrInvalidQueueSize = errors.New("queue size must be at least 1")
+
+// ErrQueueFull is returned when attempting to push a bulk in a full queue
+var ErrQueueFull = errors.New("queue is full, cannot add bulk")
+
+// ErrAlreadyRunning is returned when attempting to start an already running dispatcher
+var ErrAlreadyRunning = errors.New("dispatcher is already running")
+
+// ErrNotRunning is returned when attempting to stop a non-running dispatcher
+var ErrNotRunning = errors.New("dispatcher is not running")
+
+// ErrStopped is returned when attempting to push a bulk into a dispatcher that has been stopped
+var ErrStopped = errors.New("dispatcher has been stopped, cannot add bulk")
+
+// Impression is a single impression, along with the metadata of the sdk that generated it
+type Impression struct {
+	FeatureFlag  string `json:"featureFlag"`
+	KeyName      string `json:"keyName"`
+	BucketingKey string `json:"bucketingKey,omitempty"`
+	Treatment    string `json:"treatment"`
+	Label        string `json:"label"`
+	ChangeNumber int64  `json:"changeNumber"`
+	Time         int64  `json:"time"`
+	Pt           int64  `json:"pt,omitempty"`
+	Properties   string `json:"properties,omitempty"`
+	SDKVersion   string `json:"sdkVersion"`
+	MachineIP    string `json:"machineIP,omitempty"`
+	MachineName  string `json:"machineName,omitempty"`
+}
+
+// Event is a single track event, along with the metadata of the sdk that generated it
+type Event struct {
+	Key             string                 `json:"key"`
+	TrafficTypeName string                 `json:"trafficTypeName"`
+	EventTypeID     string                 `json:"eventTypeId"`
+	Value           interface{}            `json:"value,omitempty"`
+	Timestamp       int64                  `json:"timestamp"`
+	Properties      map[string]interface{} `json:"properties,omitempty"`
+	SDKVersion      string                 `json:"sdkVersion"`
+	MachineIP       string                 `json:"machineIP,omitempty"`
+	MachineName     string                 `json:"machineName,omitempty"`
+}
+
+// Sink is a local destination for a copy of the impressions & events handled by split-sync/proxy
+type Sink interface {
+	Name() string
+	WriteImpressions(imps []Impression) error
+	WriteEvents(events []Event) error
+	Close() error
+}
+
+// ImpressionsFromDTOs flattens impressions grouped by feature flag
+func ImpressionsFromDTOs(groups []dtos.ImpressionsDTO, metadata *dtos.Metadata) []Impression {
+	count := 0
+	for idx := range groups {
+		count += len(groups[idx].KeyImpressions)
+	}
+
+	toRet := make([]Impression, 0, count)
+	for _, group := range groups {
+		for _, ki := range group.KeyImpressions {
+			toRet = append(toRet, Impression{
+				FeatureFlag:  group.TestName,
+				KeyName:      ki.KeyName,
+				BucketingKey: ki.BucketingKey,
+				Treatment:    ki.Treatment,
+				Label:        ki.Label,
+				ChangeNumber: ki.ChangeNumber,
+				Time:         ki.Time,
+				Pt:           ki.Pt,
+				Properties:   ki.Properties,
+				SDKVersion:   metadata.SDKVersion,
+				MachineIP:    metadata.MachineIP,
+				MachineName:  metadata.MachineName,
+			})
+		}
+	}
+	return toRet
+}
+
+// EventsFromDTOs attaches the sdk metadata to a list of events
+func EventsFromDTOs(events []dtos.EventDTO, metadata *dtos.Metadata) []Event {
+	toRet := make([]Event, 0, len(events))
+	for _, e := range events {
+		toRet = append(toRet, Event{
+			Key:             e.Key,
+			TrafficTypeName: e.TrafficTypeName,
+			EventTypeID:     e.EventTypeID,
+			Value:           e.Value,
+			Timestamp:       e.Timestamp,
+			Properties:      e.Properties,
+			SDKVersion:      metadata.SDKVersion,
+			MachineIP:       metadata.MachineIP,
+			MachineName:     metadata.MachineName,
+		})
+	}
+	return toRet
+}
+
+// Dispatcher asynchronously forwards impressions & events to a set of sinks
+type Dispatcher interface {
+	SubmitImpressions(imps []Impression) error
+	SubmitEvents(events []Event) error
+	Start() error
+	Stop(blocking bool) error
+}
+
+// bulk is a queued set of impressions or events
+type bulk struct {
+	impressions []Impression
+	events      []Event
+}
+
+// DispatcherImpl is an implementation of the Dispatcher interface
+type DispatcherImpl struct {
+	lifecycle lifecycle.Manager
+	sinks     []Sink
+	queue     chan bulk
+	logger    logging.LoggerInterface
+	stopped   bool
+	mutex     sync.RWMutex
+}
+
+// NewDispatcher constructs a dispatcher writing to the supplied sinks in a background goroutine
+func NewDispatcher(sinks []Sink, queueSize int, logger logging.LoggerInterface) (*DispatcherImpl, error) {
+	if queueSize < 1 {
+		return nil, ErrInvalidQueueSize
+	}
+
+	dispatcher := &DispatcherImpl{
+		sinks:  sinks,
+		queue:  make(chan bulk, queueSize),
+		logger: logger,
+	}
+	dispatcher.lifecycle.Setup()
+	return dispatcher, nil
+}
+
+// SubmitImpressions attempts to push an impressions bulk into the queue
+// Will fail if the queue is full
+func (d *DispatcherImpl) SubmitImpressions(imps []Impression) error {
+	if len(imps) == 0 {
+		return nil
+	}
+	return d.submit(bulk{impressions: imps})
+}
+
+// SubmitEvents attempts to push an events bulk into the queue
+// Will fail if the queue is full
+func (d *DispatcherImpl) SubmitEvents(events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return d.submit(bulk{events: events})
+}
+
+func (d *DispatcherImpl) submit(b bulk) error {
+	// the read lock guarantees that no bulk is queued after the bg task has drained the queue
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.stopped {
+		return ErrStopped
+	}
+
+	select {
+	case d.queue <- b:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Start the bg task that will take bulks from the queue and write them to the sinks
+func (d *DispatcherImpl) Start() error {
+	if !d.lifecycle.BeginInitialization() {
+		return ErrAlreadyRunning
+	}
+
+	go func() {
+		defer d.lifecycle.ShutdownComplete()
+		// whatever is still queued is written before the sinks are closed, even if shutdown
+		// was requested before the bg task got to run
+		defer d.close()
+		defer d.drain()
+		if !d.lifecycle.InitializationComplete() {
+			return
+		}
+
+		for {
+			select {
+			case <-d.lifecycle.ShutdownRequested():
+				return
+			case b := <-d.queue:
+				d.write(b)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop the bg task. Queued bulks are written before the sinks are closed, and further submissions are rejected
+func (d *DispatcherImpl) Stop(blocking bool) error {
+	d.mutex.Lock()
+	if !d.lifecycle.BeginShutdown() {
+		d.mutex.Unlock()
+		return ErrNotRunning
+	}
+	d.stopped = true
+	d.mutex.Unlock()
+
+	if blocking {
+		d.lifecycle.AwaitShutdownComplete()
+	}
+
+	return nil
+}
+
+func (d *DispatcherImpl) write(b bulk) {
+	for _, sink := range d.sinks {
+		var err error
+		if b.impressions != nil {
+			err = sink.WriteImpressions(b.impressions)
+		} else {
+			err = sink.WriteEvents(b.events)
+		}
+		if err != nil {
+			d.logger.Error(fmt.Sprintf("error writing to data sink '%s': %s", sink.Name(), err))
+		}
+	}
+}
+
+func (d *DispatcherImpl) drain() {
+	for {
+		select {
+		case b := <-d.queue:
+			d.write(b)
+		default:
+			return
+		}
+	}
+}
+
+func (d *DispatcherImpl) close() {
+	for _, sink := range d.sinks {
+		if err := sink.Close(); err != nil {
+			d.logger.Error(fmt.Sprintf("error closing data sink '%s': %s", sink.Name(), err))
+		}
+	}
+}
+
+var _ Dispatcher = (*DispatcherImpl)(nil)
@@ -0,0 +1,119 @@
+package datasink
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	mutex       sync.Mutex
+	impressions []Impression
+	events      []Event
+	closed      bool
+	err         error
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) WriteImpressions(imps []Impression) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.impressions = append(s.impressions, imps...)
+	return s.err
+}
+
+func (s *recordingSink) WriteEvents(events []Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, events...)
+	return s.err
+}
+
+func (s *recordingSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestConverters(t *testing.T) {
+	metadata := &dtos.Metadata{SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"}
+	imps := ImpressionsFromDTOs([]dtos.ImpressionsDTO{
+		{TestName: "f1", KeyImpressions: []dtos.ImpressionDTO{{KeyName: "k1", Treatment: "on", Label: "l1", ChangeNumber: 1, Time: 2}}},
+		{TestName: "f2", KeyImpressions: []dtos.ImpressionDTO{{KeyName: "k2", Treatment: "off"}, {KeyName: "k3", Pt: 5}}},
+	}, metadata)
+	assert.Equal(t, []Impression{
+		{FeatureFlag: "f1", KeyName: "k1", Treatment: "on", Label: "l1", ChangeNumber: 1, Time: 2, SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"},
+		{FeatureFlag: "f2", KeyName: "k2", Treatment: "off", SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"},
+		{FeatureFlag: "f2", KeyName: "k3", Pt: 5, SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"},
+	}, imps)
+
+	events := EventsFromDTOs([]dtos.EventDTO{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Value: 1.5, Timestamp: 3}}, metadata)
+	assert.Equal(t, []Event{
+		{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Value: 1.5, Timestamp: 3, SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"},
+	}, events)
+}
+
+func TestDispatcher(t *testing.T) {
+	_, err := NewDispatcher(nil, 0, logging.NewLogger(nil))
+	assert.ErrorIs(t, err, ErrInvalidQueueSize)
+
+	ok := &recordingSink{}
+	failing := &recordingSink{err: errors.New("some")}
+	dispatcher, err := NewDispatcher([]Sink{failing, ok}, 10, logging.NewLogger(nil))
+	assert.Nil(t, err)
+
+	// bulks submitted before starting are queued & written once the dispatcher runs
+	assert.Nil(t, dispatcher.SubmitImpressions([]Impression{{FeatureFlag: "f1", KeyName: "k1"}}))
+	assert.Nil(t, dispatcher.SubmitEvents([]Event{{Key: "k1", EventTypeID: "click"}}))
+	assert.Nil(t, dispatcher.SubmitEvents(nil))
+
+	assert.ErrorIs(t, dispatcher.Stop(true), ErrNotRunning)
+	assert.Nil(t, dispatcher.Start())
+	assert.ErrorIs(t, dispatcher.Start(), ErrAlreadyRunning)
+	assert.Nil(t, dispatcher.Stop(true))
+
+	// once stopped, nothing else is accepted
+	assert.ErrorIs(t, dispatcher.SubmitImpressions([]Impression{{FeatureFlag: "f2", KeyName: "k2"}}), ErrStopped)
+	assert.ErrorIs(t, dispatcher.SubmitEvents([]Event{{Key: "k2", EventTypeID: "click"}}), ErrStopped)
+
+	for _, sink := range []*recordingSink{ok, failing} {
+		assert.Equal(t, []Impression{{FeatureFlag: "f1", KeyName: "k1"}}, sink.impressions)
+		assert.Equal(t, []Event{{Key: "k1", EventTypeID: "click"}}, sink.events)
+		assert.True(t, sink.closed)
+	}
+}
+
+func TestDispatcherQueueFull(t *testing.T) {
+	dispatcher, err := NewDispatcher([]Sink{&recordingSink{}}, 1, logging.NewLogger(nil))
+	assert.Nil(t, err)
+	assert.Nil(t, dispatcher.SubmitImpressions([]Impression{{FeatureFlag: "f1"}}))
+	assert.ErrorIs(t, dispatcher.SubmitImpressions([]Impression{{FeatureFlag: "f2"}}), ErrQueueFull)
+	assert.ErrorIs(t, dispatcher.SubmitEvents([]Event{{Key: "k1"}}), ErrQueueFull)
+}
+
+func TestFilteredSink(t *testing.T) {
+	inner := &recordingSink{}
+	sink := Filtered(inner, Filter{Impressions: true, Events: true, FeatureFlags: []string{"f1", ""}, EventTypes: []string{""}})
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f1"}, {FeatureFlag: "f2"}}))
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f3"}}))
+	assert.Nil(t, sink.WriteEvents([]Event{{EventTypeID: "click"}, {EventTypeID: "view"}}))
+	assert.Equal(t, []Impression{{FeatureFlag: "f1"}}, inner.impressions)
+	assert.Equal(t, []Event{{EventTypeID: "click"}, {EventTypeID: "view"}}, inner.events)
+	assert.Equal(t, "recording", sink.Name())
+
+	inner = &recordingSink{}
+	sink = Filtered(inner, Filter{Impressions: false, Events: true, EventTypes: []string{"view"}})
+	assert.Nil(t, sink.WriteImpressions([]Impression{{FeatureFlag: "f1"}}))
+	assert.Nil(t, sink.WriteEvents([]Event{{EventTypeID: "click"}, {EventTypeID: "view"}}))
+	assert.Nil(t, inner.impressions)
+	assert.Equal(t, []Event{{EventTypeID: "view"}}, inner.events)
+
+	assert.Nil(t, sink.Close())
+	assert.True(t, inner.closed)
+}
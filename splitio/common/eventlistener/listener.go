@@ -0,0 +1,293 @@
+package eventlistener
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/struct/traits/lifecycle"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// ErrInvalidQueueSize is returned when attempting to construct a listener with an invalid queue size
+var ErrInvalidQueueSize = errors.New("queue size must be at least 1")
+
+// ErrQueueFull is returned when attempting to push an event bulk in a full queue
+var ErrQueueFull = errors.New("queue is full, cannot add event bulk")
+
+// ErrAlreadyRunning is returned when attempting to start an already running listener
+var ErrAlreadyRunning = errors.New("listener is already running")
+
+// ErrNotRunning is returned when attempting to stop a non-running listener
+var ErrNotRunning = errors.New("listener is not running")
+
+// EventBulkListener specifies the interface of a secondary event listener
+type EventBulkListener interface {
+	Submit(events []dtos.EventDTO, metadata *dtos.Metadata) error
+	Start() error
+	Stop(bool) error
+}
+
+// Filter restricts the events forwarded to the listener. Empty lists match everything
+type Filter struct {
+	TrafficTypes []string
+	EventTypes   []string
+}
+
+// Options bundles the settings of an event listener. Zero values disable the optional features
+type Options struct {
+	Endpoint   string
+	QueueSize  int
+	HTTPClient *http.Client
+	Logger     logging.LoggerInterface
+	Filter     *Filter
+
+	MaxRetries  int           // extra attempts made for a bulk after a network error or a 408/429/5xx response
+	BaseBackoff time.Duration // wait before the first retry. doubled on each subsequent one
+	MaxBackoff  time.Duration // upper bound for the wait between retries
+}
+
+// eventListenerPostBody bundles all the data posted by the event's listener
+type eventListenerPostBody struct {
+	Events      []dtos.EventDTO `json:"events"`
+	SdkVersion  string          `json:"sdkVersion"`
+	MachineIP   string          `json:"machineIP"`
+	MachineName string          `json:"machineName"`
+}
+
+// EventBulkListenerImpl is an implementation of the EventBulkListener interface
+type EventBulkListenerImpl struct {
+	lifecycle    lifecycle.Manager
+	endpoint     string
+	httpClient   *http.Client
+	logger       logging.LoggerInterface
+	queue        chan eventListenerPostBody
+	options      Options
+	trafficTypes map[string]struct{}
+	eventTypes   map[string]struct{}
+	stopping     bool // only accessed from the bg goroutine
+}
+
+// NewEventBulkListener constructs a new event listener
+func NewEventBulkListener(endpoint string, queueSize int, httpClient *http.Client, filter *Filter) (*EventBulkListenerImpl, error) {
+	return NewEventBulkListenerWithOptions(&Options{Endpoint: endpoint, QueueSize: queueSize, HTTPClient: httpClient, Filter: filter})
+}
+
+// NewEventBulkListenerWithOptions constructs a new event listener with filtering & retries, as specified in the options
+func NewEventBulkListenerWithOptions(options *Options) (*EventBulkListenerImpl, error) {
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	if options.QueueSize < 1 {
+		return nil, ErrInvalidQueueSize
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = logging.NewLogger(nil)
+	}
+
+	listener := &EventBulkListenerImpl{
+		endpoint:   options.Endpoint,
+		httpClient: httpClient,
+		logger:     logger,
+		queue:      make(chan eventListenerPostBody, options.QueueSize),
+		options:    *options,
+	}
+	if options.Filter != nil {
+		listener.trafficTypes = toSet(options.Filter.TrafficTypes)
+		listener.eventTypes = toSet(options.Filter.EventTypes)
+	}
+	listener.lifecycle.Setup()
+	return listener, nil
+}
+
+// FromConfig builds an event listener with the options in the supplied config
+func FromConfig(cfg *conf.EventListener, logger logging.LoggerInterface) (*EventBulkListenerImpl, error) {
+	return NewEventBulkListenerWithOptions(&Options{
+		Endpoint:    cfg.Endpoint,
+		QueueSize:   int(cfg.QueueSize),
+		HTTPClient:  &http.Client{Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond},
+		Logger:      logger,
+		Filter:      &Filter{TrafficTypes: cfg.TrafficTypes, EventTypes: cfg.EventTypes},
+		MaxRetries:  int(cfg.MaxRetries),
+		BaseBackoff: time.Duration(cfg.RetryBaseBackoffMs) * time.Millisecond,
+		MaxBackoff:  time.Duration(cfg.RetryMaxBackoffMs) * time.Millisecond,
+	})
+}
+
+// Submit attempts to push an event bulk into the queue, after dropping the events not matching the filter.
+// Will fail if the queue is full
+func (l *EventBulkListenerImpl) Submit(events []dtos.EventDTO, metadata *dtos.Metadata) error {
+	events = l.filter(events)
+	if len(events) == 0 {
+		return nil
+	}
+
+	select {
+	case l.queue <- eventListenerPostBody{
+		Events:      events,
+		SdkVersion:  metadata.SDKVersion,
+		MachineIP:   metadata.MachineIP,
+		MachineName: metadata.MachineName,
+	}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Start the bg task that will take bulks from the queue and post them
+func (l *EventBulkListenerImpl) Start() error {
+	if !l.lifecycle.BeginInitialization() {
+		return ErrAlreadyRunning
+	}
+
+	go func() {
+		defer l.lifecycle.ShutdownComplete()
+		defer l.drain()
+		if !l.lifecycle.InitializationComplete() {
+			return
+		}
+
+		for !l.stopping {
+			select {
+			case <-l.lifecycle.ShutdownRequested():
+				l.stopping = true
+			case events := <-l.queue:
+				l.send(&events)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop the bg task. Queued bulks are posted before exiting
+func (l *EventBulkListenerImpl) Stop(blocking bool) error {
+	if !l.lifecycle.BeginShutdown() {
+		return ErrNotRunning
+	}
+
+	if blocking {
+		l.lifecycle.AwaitShutdownComplete()
+	}
+
+	return nil
+}
+
+func (l *EventBulkListenerImpl) filter(events []dtos.EventDTO) []dtos.EventDTO {
+	if l.trafficTypes == nil && l.eventTypes == nil {
+		return events
+	}
+
+	// a new slice is always built, since callers may reuse the original one
+	toRet := make([]dtos.EventDTO, 0, len(events))
+	for idx := range events {
+		if !matches(l.trafficTypes, events[idx].TrafficTypeName) || !matches(l.eventTypes, events[idx].EventTypeID) {
+			continue
+		}
+		toRet = append(toRet, events[idx])
+	}
+	return toRet
+}
+
+// drain posts whatever is queued before exiting. No retries are performed at this point
+func (l *EventBulkListenerImpl) drain() {
+	l.stopping = true
+	for {
+		select {
+		case events := <-l.queue:
+			l.send(&events)
+		default:
+			return
+		}
+	}
+}
+
+func (l *EventBulkListenerImpl) send(events *eventListenerPostBody) {
+	if err := l.post(events); err != nil {
+		l.logger.Error(fmt.Sprintf("dropping bulk of %d events that couldn't be posted to the listener: %s", len(events.Events), err))
+	}
+}
+
+func (l *EventBulkListenerImpl) post(events *eventListenerPostBody) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("error serializing events: %w", err)
+	}
+
+	policy := retry.Policy{MaxRetries: l.options.MaxRetries, Backoff: retry.Backoff{Base: l.options.BaseBackoff, Max: l.options.MaxBackoff}}
+	return policy.Do(func() error { return l.attempt(data) }, l.sleep, func(attempt int, err error) {
+		l.logger.Debug(fmt.Sprintf("retrying event listener post (attempt %d) after: %s", attempt, err))
+	})
+}
+
+func (l *EventBulkListenerImpl) attempt(data []byte) error {
+	request, err := http.NewRequest("POST", l.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := l.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &retry.StatusError{Code: response.StatusCode}
+	}
+	return nil
+}
+
+// sleep waits for the supplied duration, returning false if the listener is shut down in the meantime
+func (l *EventBulkListenerImpl) sleep(wait time.Duration) bool {
+	if l.stopping {
+		return false
+	}
+
+	select {
+	case <-l.lifecycle.ShutdownRequested():
+		l.stopping = true
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+func matches(set map[string]struct{}, value string) bool {
+	if set == nil {
+		return true
+	}
+	_, ok := set[value]
+	return ok
+}
+
+func toSet(items []string) map[string]struct{} {
+	toRet := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		if item != "" {
+			toRet[item] = struct{}{}
+		}
+	}
+
+	if len(toRet) == 0 {
+		return nil
+	}
+	return toRet
+}
+
+var _ EventBulkListener = (*EventBulkListenerImpl)(nil)
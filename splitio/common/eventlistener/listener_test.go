@@ -0,0 +1,197 @@
+package eventlistener
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventListener(t *testing.T) {
+	bodies := make(chan eventListenerPostBody, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/someUrl", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		assert.Nil(t, err)
+
+		var parsed eventListenerPostBody
+		assert.Nil(t, json.Unmarshal(body, &parsed))
+		bodies <- parsed
+	}))
+	defer ts.Close()
+
+	listener, err := NewEventBulkListener(ts.URL+"/someUrl", 10, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Start())
+	assert.ErrorIs(t, listener.Start(), ErrAlreadyRunning)
+
+	value := 123.0
+	err = listener.Submit([]dtos.EventDTO{
+		{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Value: value, Timestamp: 1, Properties: map[string]interface{}{"a": "b"}},
+		{Key: "k2", TrafficTypeName: "account", EventTypeID: "view", Timestamp: 2},
+	}, &dtos.Metadata{SDKVersion: "go-1.1.1", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"})
+	assert.Nil(t, err)
+
+	select {
+	case body := <-bodies:
+		assert.Equal(t, "go-1.1.1", body.SdkVersion)
+		assert.Equal(t, "1.2.3.4", body.MachineIP)
+		assert.Equal(t, "ip-1-2-3-4", body.MachineName)
+		assert.Equal(t, []dtos.EventDTO{
+			{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Value: value, Timestamp: 1, Properties: map[string]interface{}{"a": "b"}},
+			{Key: "k2", TrafficTypeName: "account", EventTypeID: "view", Timestamp: 2},
+		}, body.Events)
+	case <-time.After(time.Second):
+		t.Error("events should have been posted")
+	}
+
+	assert.Nil(t, listener.Stop(true))
+	assert.ErrorIs(t, listener.Stop(true), ErrNotRunning)
+}
+
+func TestEventListenerFilter(t *testing.T) {
+	listener, err := NewEventBulkListener("http://localhost", 10, nil, &Filter{TrafficTypes: []string{"user", ""}, EventTypes: []string{"click", "view"}})
+	assert.Nil(t, err)
+
+	metadata := &dtos.Metadata{SDKVersion: "go-1.1.1"}
+	assert.Nil(t, listener.Submit([]dtos.EventDTO{
+		{Key: "k1", TrafficTypeName: "user", EventTypeID: "click"},
+		{Key: "k2", TrafficTypeName: "account", EventTypeID: "click"},
+		{Key: "k3", TrafficTypeName: "user", EventTypeID: "purchase"},
+		{Key: "k4", TrafficTypeName: "user", EventTypeID: "view"},
+	}, metadata))
+
+	// fully filtered-out bulks are not queued
+	assert.Nil(t, listener.Submit([]dtos.EventDTO{{Key: "k5", TrafficTypeName: "account", EventTypeID: "view"}}, metadata))
+
+	assert.Equal(t, 1, len(listener.queue))
+	body := <-listener.queue
+	assert.Equal(t, []dtos.EventDTO{
+		{Key: "k1", TrafficTypeName: "user", EventTypeID: "click"},
+		{Key: "k4", TrafficTypeName: "user", EventTypeID: "view"},
+	}, body.Events)
+
+	// empty filters match everything
+	listener, err = NewEventBulkListener("http://localhost", 10, nil, &Filter{TrafficTypes: []string{""}})
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Submit([]dtos.EventDTO{{Key: "k1", TrafficTypeName: "account", EventTypeID: "view"}}, metadata))
+	assert.Equal(t, 1, len(listener.queue))
+}
+
+func TestEventListenerQueueFull(t *testing.T) {
+	_, err := NewEventBulkListener("http://localhost", 0, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidQueueSize)
+
+	listener, err := NewEventBulkListener("http://localhost", 1, nil, nil)
+	assert.Nil(t, err)
+	metadata := &dtos.Metadata{SDKVersion: "go-1.1.1"}
+	assert.Nil(t, listener.Submit([]dtos.EventDTO{{Key: "k1"}}, metadata))
+	assert.ErrorIs(t, listener.Submit([]dtos.EventDTO{{Key: "k2"}}, metadata), ErrQueueFull)
+}
+
+func TestEventListenerRetries(t *testing.T) {
+	var calls int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt64(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 3:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	listener, err := NewEventBulkListenerWithOptions(&Options{
+		Endpoint:    ts.URL,
+		QueueSize:   10,
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	events := eventListenerPostBody{Events: []dtos.EventDTO{{Key: "k1"}}}
+	assert.Nil(t, listener.post(&events))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&calls))
+
+	// 4xx responses are not retried
+	assert.Equal(t, &retry.StatusError{Code: 400}, listener.post(&events))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&calls))
+}
+
+func TestEventListenerRetriesExhausted(t *testing.T) {
+	var calls int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	listener, err := NewEventBulkListenerWithOptions(&Options{Endpoint: ts.URL, QueueSize: 10, MaxRetries: 2, BaseBackoff: time.Millisecond})
+	assert.Nil(t, err)
+
+	events := eventListenerPostBody{Events: []dtos.EventDTO{{Key: "k1"}}}
+	assert.Equal(t, &retry.StatusError{Code: 500}, listener.post(&events))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&calls))
+}
+
+func TestEventListenerDrainsOnStop(t *testing.T) {
+	var posted int64
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		atomic.AddInt64(&posted, 1)
+	}))
+	defer ts.Close()
+
+	listener, err := NewEventBulkListener(ts.URL, 10, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Start())
+
+	metadata := &dtos.Metadata{SDKVersion: "go-1.1.1"}
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, listener.Submit([]dtos.EventDTO{{Key: "k1"}}, metadata))
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	assert.Nil(t, listener.Stop(true))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&posted))
+}
+
+func TestEventListenerFromConfig(t *testing.T) {
+	listener, err := FromConfig(&conf.EventListener{
+		Endpoint:   "http://localhost",
+		QueueSize:  5,
+		TimeoutMs:  1500,
+		MaxRetries: 2,
+		EventTypes: []string{"click"},
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1500*time.Millisecond, listener.httpClient.Timeout)
+	assert.Equal(t, 2, listener.options.MaxRetries)
+	assert.Equal(t, 5, cap(listener.queue))
+	assert.NotNil(t, listener.eventTypes)
+
+	listener, err = NewEventBulkListener("http://localhost", 1, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultTimeout, listener.httpClient.Timeout)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+)
+
+type EventBulkListenerMock struct {
+	SubmitCall func(events []dtos.EventDTO, metadata *dtos.Metadata) error
+	StartCall  func() error
+	StopCall   func(blocking bool) error
+}
+
+func (l *EventBulkListenerMock) Submit(events []dtos.EventDTO, metadata *dtos.Metadata) error {
+	return l.SubmitCall(events, metadata)
+}
+
+func (l *EventBulkListenerMock) Start() error {
+	return l.StartCall()
+}
+
+func (l *EventBulkListenerMock) Stop(blocking bool) error {
+	return l.StopCall(blocking)
+}
+
+var _ eventlistener.EventBulkListener = (*EventBulkListenerMock)(nil)
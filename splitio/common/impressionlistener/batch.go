@@ -0,0 +1,42 @@
+package impressionlistener
+
+// batch coalesces queued bulks sharing the same sdk metadata into a single post body
+type batch struct {
+	bodies []*impressionListenerPostBody
+	count  int
+}
+
+func (b *batch) empty() bool {
+	return len(b.bodies) == 0
+}
+
+func (b *batch) add(imps *impressionListenerPostBody) {
+	b.count += countImpressions(imps)
+	for _, body := range b.bodies {
+		if body.SdkVersion == imps.SdkVersion && body.MachineIP == imps.MachineIP && body.MachineName == imps.MachineName {
+			body.Impressions = append(body.Impressions, imps.Impressions...)
+			return
+		}
+	}
+
+	// the incoming slice is copied so that appending to it doesn't affect the submitter's backing array
+	body := *imps
+	body.Impressions = append([]ImpressionsForListener(nil), imps.Impressions...)
+	b.bodies = append(b.bodies, &body)
+}
+
+// take returns the accumulated bodies & resets the batch
+func (b *batch) take() []*impressionListenerPostBody {
+	toRet := b.bodies
+	b.bodies = nil
+	b.count = 0
+	return toRet
+}
+
+func countImpressions(imps *impressionListenerPostBody) int {
+	count := 0
+	for idx := range imps.Impressions {
+		count += len(imps.Impressions[idx].KeyImpressions)
+	}
+	return count
+}
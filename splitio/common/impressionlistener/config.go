@@ -0,0 +1,41 @@
+package impressionlistener
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// FromConfig builds an impression listener with the options in the supplied config
+func FromConfig(cfg *conf.ImpressionListener, logger logging.LoggerInterface) (*ImpressionBulkListenerImpl, error) {
+	headers := make(map[string]string, len(cfg.Headers))
+	for _, header := range cfg.Headers {
+		if header == "" {
+			continue
+		}
+		name, value, err := ParseHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = value
+	}
+
+	return NewImpressionBulkListenerWithOptions(&Options{
+		Endpoint:            cfg.Endpoint,
+		QueueSize:           int(cfg.QueueSize),
+		HTTPClient:          &http.Client{Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond},
+		Logger:              logger,
+		MaxRetries:          int(cfg.MaxRetries),
+		BaseBackoff:         time.Duration(cfg.RetryBaseBackoffMs) * time.Millisecond,
+		MaxBackoff:          time.Duration(cfg.RetryMaxBackoffMs) * time.Millisecond,
+		BatchMaxImpressions: int(cfg.BatchMaxImpressions),
+		BatchMaxWait:        time.Duration(cfg.BatchMaxWaitMs) * time.Millisecond,
+		Gzip:                cfg.Gzip,
+		Headers:             headers,
+		HeadersFile:         cfg.HeadersFile,
+		HMACSecret:          cfg.HMACSecret,
+	})
+}
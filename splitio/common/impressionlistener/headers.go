@@ -0,0 +1,78 @@
+package impressionlistener
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sign computes the value of the signature header for a request body sent at `timestamp`.
+// Receivers should recompute it over the raw (possibly gzipped) body & compare it using a constant-time function
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseHeader splits a `Name: value` string
+func ParseHeader(line string) (string, string, error) {
+	name, value, found := strings.Cut(line, ":")
+	name = strings.TrimSpace(name)
+	if !found || name == "" {
+		return "", "", fmt.Errorf("invalid header '%s'. Expected 'Name: value'", line)
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// fileHeaders reads headers from a file, reloading them whenever the file is modified,
+// so that credentials can be rotated without restarting the synchronizer
+type fileHeaders struct {
+	path    string
+	modTime time.Time
+	size    int64
+	headers http.Header
+}
+
+func (f *fileHeaders) get() (http.Header, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading headers file '%s': %w", f.path, err)
+	}
+
+	if f.headers != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.headers, nil
+	}
+
+	contents, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading headers file '%s': %w", f.path, err)
+	}
+
+	headers := make(http.Header)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, err := ParseHeader(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing headers file '%s': %w", f.path, err)
+		}
+		headers.Add(name, value)
+	}
+
+	f.headers = headers
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return headers, nil
+}
@@ -2,12 +2,18 @@ package impressionlistener
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/struct/traits/lifecycle"
 )
 
@@ -23,6 +29,14 @@ var ErrAlreadyRunning = errors.New("listener is already running")
 // ErrNotRunning is returned when attempting to stop a non-running listener
 var ErrNotRunning = errors.New("listener is not running")
 
+const (
+	// SignatureHeader holds the hex-encoded HMAC-SHA256 of `<timestamp>.<body>`, prefixed with `sha256=`
+	SignatureHeader = "X-Split-Signature"
+
+	// TimestampHeader holds the unix time (in milliseconds) at which the request was signed
+	TimestampHeader = "X-Split-Timestamp"
+)
+
 // ImpressionBulkListener speciefies the interface of a secondary impression listener
 type ImpressionBulkListener interface {
 	Submit(imps []ImpressionsForListener, metadata *dtos.Metadata) error
@@ -30,6 +44,26 @@ type ImpressionBulkListener interface {
 	Stop(bool) error
 }
 
+// Options bundles the settings of an impression listener. Zero values disable the optional features
+type Options struct {
+	Endpoint   string
+	QueueSize  int
+	HTTPClient *http.Client
+	Logger     logging.LoggerInterface
+
+	MaxRetries  int           // extra attempts made for a bulk after a network error or a 408/429/5xx response
+	BaseBackoff time.Duration // wait before the first retry. doubled on each subsequent one
+	MaxBackoff  time.Duration // upper bound for the wait between retries
+
+	BatchMaxImpressions int           // coalesce bulks until this many impressions are accumulated
+	BatchMaxWait        time.Duration // max time to hold a bulk while coalescing
+
+	Gzip        bool              // compress request bodies
+	Headers     map[string]string // static headers added to every request
+	HeadersFile string            // file with `Name: value` lines added to every request. re-read when modified
+	HMACSecret  string            // sign requests with this secret
+}
+
 // impressionListenerPostBody bundles all the data posted by the impression's listener
 type impressionListenerPostBody struct {
 	Impressions []ImpressionsForListener `json:"impressions"`
@@ -40,26 +74,58 @@ type impressionListenerPostBody struct {
 
 // ImpressionBulkListenerImpl is an implementation of the ImpressionBulkListener interface
 type ImpressionBulkListenerImpl struct {
-	lifecycle  lifecycle.Manager
-	endpoint   string
-	httpClient *http.Client
-	queue      chan impressionListenerPostBody
+	lifecycle   lifecycle.Manager
+	endpoint    string
+	httpClient  *http.Client
+	logger      logging.LoggerInterface
+	queue       chan impressionListenerPostBody
+	options     Options
+	headers     http.Header
+	fileHeaders *fileHeaders
+	batch       batch
+	stopping    bool // only accessed from the bg goroutine
 }
 
 // NewImpressionBulkListener constructs a new impression listner
 func NewImpressionBulkListener(endpoint string, queueSize int, httpClient *http.Client) (*ImpressionBulkListenerImpl, error) {
+	return NewImpressionBulkListenerWithOptions(&Options{Endpoint: endpoint, QueueSize: queueSize, HTTPClient: httpClient})
+}
+
+// NewImpressionBulkListenerWithOptions constructs a new impression listener with retries, batching, compression,
+// extra headers and/or request signing, as specified in the options
+func NewImpressionBulkListenerWithOptions(options *Options) (*ImpressionBulkListenerImpl, error) {
+	httpClient := options.HTTPClient
 	if httpClient == nil {
 		httpClient = &http.Client{}
 	}
 
-	if queueSize < 1 {
+	if options.QueueSize < 1 {
 		return nil, ErrInvalidQueueSize
 	}
 
+	logger := options.Logger
+	if logger == nil {
+		logger = logging.NewLogger(nil)
+	}
+
+	headers := make(http.Header, len(options.Headers))
+	for name, value := range options.Headers {
+		headers.Set(name, value)
+	}
+
 	listener := &ImpressionBulkListenerImpl{
-		endpoint:   endpoint,
+		endpoint:   options.Endpoint,
 		httpClient: httpClient,
-		queue:      make(chan impressionListenerPostBody, queueSize),
+		logger:     logger,
+		queue:      make(chan impressionListenerPostBody, options.QueueSize),
+		options:    *options,
+		headers:    headers,
+	}
+	if options.HeadersFile != "" {
+		listener.fileHeaders = &fileHeaders{path: options.HeadersFile}
+		if _, err := listener.fileHeaders.get(); err != nil {
+			return nil, err
+		}
 	}
 	listener.lifecycle.Setup()
 	return listener, nil
@@ -89,16 +155,33 @@ func (l *ImpressionBulkListenerImpl) Start() error {
 
 	go func() {
 		defer l.lifecycle.ShutdownComplete()
+		defer l.drain()
 		if !l.lifecycle.InitializationComplete() {
 			return
 		}
 
-		for {
+		var flushTimer <-chan time.Time
+		for !l.stopping {
 			select {
 			case <-l.lifecycle.ShutdownRequested():
-				return
+				l.stopping = true
 			case imps := <-l.queue:
-				l.post(imps)
+				if l.options.BatchMaxImpressions <= 0 {
+					l.send(&imps)
+					continue
+				}
+
+				if l.batch.empty() {
+					flushTimer = time.After(l.options.BatchMaxWait)
+				}
+				l.batch.add(&imps)
+				if l.batch.count >= l.options.BatchMaxImpressions {
+					l.flush()
+					flushTimer = nil
+				}
+			case <-flushTimer:
+				l.flush()
+				flushTimer = nil
 			}
 		}
 	}()
@@ -119,19 +202,112 @@ func (l *ImpressionBulkListenerImpl) Stop(blocking bool) error {
 	return nil
 }
 
-func (l *ImpressionBulkListenerImpl) post(imps impressionListenerPostBody) error {
+// drain posts whatever is pending or queued before exiting. No retries are performed at this point
+func (l *ImpressionBulkListenerImpl) drain() {
+	l.stopping = true
+	for {
+		select {
+		case imps := <-l.queue:
+			l.batch.add(&imps)
+		default:
+			l.flush()
+			return
+		}
+	}
+}
+
+func (l *ImpressionBulkListenerImpl) flush() {
+	for _, imps := range l.batch.take() {
+		l.send(imps)
+	}
+}
+
+func (l *ImpressionBulkListenerImpl) send(imps *impressionListenerPostBody) {
+	if err := l.post(imps); err != nil {
+		l.logger.Error(fmt.Sprintf("dropping bulk of %d impressions that couldn't be posted to the listener: %s", countImpressions(imps), err))
+	}
+}
+
+func (l *ImpressionBulkListenerImpl) post(imps *impressionListenerPostBody) error {
 	data, err := json.Marshal(imps)
 	if err != nil {
 		return fmt.Errorf("error serializing impressions: %w", err)
 	}
 
-	request, _ := http.NewRequest("POST", l.endpoint, bytes.NewBuffer(data))
+	if l.options.Gzip {
+		var buffer bytes.Buffer
+		gz := gzip.NewWriter(&buffer)
+		gz.Write(data)
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("error compressing impressions: %w", err)
+		}
+		data = buffer.Bytes()
+	}
+
+	policy := retry.Policy{MaxRetries: l.options.MaxRetries, Backoff: retry.Backoff{Base: l.options.BaseBackoff, Max: l.options.MaxBackoff}}
+	return policy.Do(func() error { return l.attempt(data) }, l.sleep, func(attempt int, err error) {
+		l.logger.Debug(fmt.Sprintf("retrying impression listener post (attempt %d) after: %s", attempt, err))
+	})
+}
+
+func (l *ImpressionBulkListenerImpl) attempt(data []byte) error {
+	request, err := http.NewRequest("POST", l.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	if l.options.Gzip {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+	copyHeaders(request.Header, l.headers)
+	if l.fileHeaders != nil {
+		fromFile, err := l.fileHeaders.get()
+		if err != nil {
+			return err
+		}
+		copyHeaders(request.Header, fromFile)
+	}
+	if l.options.HMACSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		request.Header.Set(TimestampHeader, timestamp)
+		request.Header.Set(SignatureHeader, Sign([]byte(l.options.HMACSecret), timestamp, data))
+	}
+
 	response, err := l.httpClient.Do(request)
 	if err != nil {
 		return err
 	}
 	response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &retry.StatusError{Code: response.StatusCode}
+	}
 	return nil
 }
 
+// sleep waits for the supplied duration, returning false if the listener is shut down in the meantime
+func (l *ImpressionBulkListenerImpl) sleep(wait time.Duration) bool {
+	if l.stopping {
+		return false
+	}
+
+	select {
+	case <-l.lifecycle.ShutdownRequested():
+		l.stopping = true
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+func copyHeaders(dst http.Header, src http.Header) {
+	for name, values := range src {
+		dst.Del(name)
+		for _, value := range values {
+			dst.Add(name, value)
+		}
+	}
+}
+
 var _ ImpressionBulkListener = (*ImpressionBulkListenerImpl)(nil)
@@ -0,0 +1,243 @@
+package impressionlistener
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMetadata = &dtos.Metadata{SDKVersion: "go-1.1.1", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"}
+
+func makeImpressions(testName string, count int) []ImpressionsForListener {
+	kis := make([]ImpressionForListener, 0, count)
+	for i := 0; i < count; i++ {
+		kis = append(kis, ImpressionForListener{KeyName: "k", Treatment: "on", Time: int64(i)})
+	}
+	return []ImpressionsForListener{{TestName: testName, KeyImpressions: kis}}
+}
+
+func TestImpressionListenerRetries(t *testing.T) {
+	var calls int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt64(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 3:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	listener, err := NewImpressionBulkListenerWithOptions(&Options{
+		Endpoint:    ts.URL,
+		QueueSize:   10,
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	imps := impressionListenerPostBody{Impressions: makeImpressions("t1", 1)}
+	assert.Nil(t, listener.post(&imps))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&calls))
+
+	// 4xx responses are not retried
+	assert.NotNil(t, listener.post(&imps))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&calls))
+}
+
+func TestImpressionListenerRetriesExhausted(t *testing.T) {
+	var calls int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	listener, err := NewImpressionBulkListenerWithOptions(&Options{Endpoint: ts.URL, QueueSize: 10, MaxRetries: 2, BaseBackoff: time.Millisecond})
+	assert.Nil(t, err)
+
+	imps := impressionListenerPostBody{Impressions: makeImpressions("t1", 1)}
+	assert.Equal(t, &retry.StatusError{Code: 500}, listener.post(&imps))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&calls))
+}
+
+func TestImpressionListenerBatching(t *testing.T) {
+	bodies := make(chan impressionListenerPostBody, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body impressionListenerPostBody
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies <- body
+	}))
+	defer ts.Close()
+
+	listener, err := NewImpressionBulkListenerWithOptions(&Options{
+		Endpoint:            ts.URL,
+		QueueSize:           10,
+		BatchMaxImpressions: 5,
+		BatchMaxWait:        time.Hour,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Start())
+	defer listener.Stop(true)
+
+	assert.Nil(t, listener.Submit(makeImpressions("t1", 2), testMetadata))
+	assert.Nil(t, listener.Submit(makeImpressions("t2", 2), testMetadata))
+	select {
+	case <-bodies:
+		t.Error("no post should be made until the threshold is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Nil(t, listener.Submit(makeImpressions("t3", 1), testMetadata))
+	select {
+	case body := <-bodies:
+		assert.Equal(t, "go-1.1.1", body.SdkVersion)
+		assert.Equal(t, 3, len(body.Impressions))
+		assert.Equal(t, "t1", body.Impressions[0].TestName)
+		assert.Equal(t, "t3", body.Impressions[2].TestName)
+	case <-time.After(time.Second):
+		t.Error("a coalesced bulk should have been posted")
+	}
+}
+
+func TestImpressionListenerBatchingByTime(t *testing.T) {
+	bodies := make(chan impressionListenerPostBody, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body impressionListenerPostBody
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies <- body
+	}))
+	defer ts.Close()
+
+	listener, err := NewImpressionBulkListenerWithOptions(&Options{
+		Endpoint:            ts.URL,
+		QueueSize:           10,
+		BatchMaxImpressions: 1000,
+		BatchMaxWait:        20 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Start())
+	defer listener.Stop(true)
+
+	// bulks with different metadata are posted separately
+	assert.Nil(t, listener.Submit(makeImpressions("t1", 2), testMetadata))
+	assert.Nil(t, listener.Submit(makeImpressions("t2", 2), &dtos.Metadata{SDKVersion: "java-1.2.3"}))
+
+	received := map[string]int{}
+	for i := 0; i < 2; i++ {
+		select {
+		case body := <-bodies:
+			received[body.SdkVersion] = len(body.Impressions)
+		case <-time.After(time.Second):
+			t.Error("bulks should have been posted once the max wait elapsed")
+		}
+	}
+	assert.Equal(t, map[string]int{"go-1.1.1": 1, "java-1.2.3": 1}, received)
+}
+
+func TestImpressionListenerFlushesOnStop(t *testing.T) {
+	var received int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body impressionListenerPostBody
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		atomic.AddInt64(&received, int64(len(body.Impressions)))
+	}))
+	defer ts.Close()
+
+	listener, err := NewImpressionBulkListenerWithOptions(&Options{
+		Endpoint:            ts.URL,
+		QueueSize:           10,
+		BatchMaxImpressions: 1000,
+		BatchMaxWait:        time.Hour,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, listener.Start())
+	assert.Nil(t, listener.Submit(makeImpressions("t1", 1), testMetadata))
+	assert.Nil(t, listener.Submit(makeImpressions("t2", 1), testMetadata))
+	assert.Nil(t, listener.Stop(true))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&received))
+}
+
+func TestImpressionListenerGzipHeadersAndSignature(t *testing.T) {
+	headersFile := filepath.Join(t.TempDir(), "headers")
+	assert.Nil(t, os.WriteFile(headersFile, []byte("# comment\nAuthorization: Bearer token1\n\nX-Tenant: t1\n"), 0600))
+
+	requests := make(chan *http.Request, 2)
+	rawBodies := make(chan []byte, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		requests <- r
+		rawBodies <- raw
+	}))
+	defer ts.Close()
+
+	listener, err := FromConfig(&conf.ImpressionListener{
+		Endpoint:    ts.URL,
+		QueueSize:   10,
+		TimeoutMs:   1000,
+		Gzip:        true,
+		Headers:     []string{"", "X-Static: some value", "X-Tenant: overridden"},
+		HeadersFile: headersFile,
+		HMACSecret:  "secret",
+	}, nil)
+	assert.Nil(t, err)
+
+	imps := impressionListenerPostBody{Impressions: makeImpressions("t1", 1), SdkVersion: "go-1.1.1"}
+	assert.Nil(t, listener.post(&imps))
+
+	r := <-requests
+	raw := <-rawBodies
+	assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "some value", r.Header.Get("X-Static"))
+	assert.Equal(t, "Bearer token1", r.Header.Get("Authorization"))
+	assert.Equal(t, "t1", r.Header.Get("X-Tenant"))
+	assert.Equal(t, Sign([]byte("secret"), r.Header.Get(TimestampHeader), raw), r.Header.Get(SignatureHeader))
+	assert.NotEqual(t, Sign([]byte("other"), r.Header.Get(TimestampHeader), raw), r.Header.Get(SignatureHeader))
+
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	assert.Nil(t, err)
+	var body impressionListenerPostBody
+	assert.Nil(t, json.NewDecoder(gz).Decode(&body))
+	assert.Equal(t, imps, body)
+
+	// the headers file is reloaded when modified
+	assert.Nil(t, os.WriteFile(headersFile, []byte("Authorization: Bearer token2-rotated\n"), 0600))
+	assert.Nil(t, listener.post(&imps))
+	r = <-requests
+	<-rawBodies
+	assert.Equal(t, "Bearer token2-rotated", r.Header.Get("Authorization"))
+	assert.Equal(t, "overridden", r.Header.Get("X-Tenant"))
+}
+
+func TestImpressionListenerInvalidHeaders(t *testing.T) {
+	_, err := FromConfig(&conf.ImpressionListener{Endpoint: "http://localhost", QueueSize: 1, Headers: []string{"no-colon"}}, nil)
+	assert.NotNil(t, err)
+
+	_, err = FromConfig(&conf.ImpressionListener{Endpoint: "http://localhost", QueueSize: 1, HeadersFile: "/nonexistent/headers"}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=4ef2732b0d632a6897af3a6d02a6de287f60d6c5f15dabdb0ceb045a34e3c5a7", Sign([]byte("secret"), "1700000000000", []byte(`{"a":1}`)))
+}
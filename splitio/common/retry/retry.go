@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// StatusError is returned when an endpoint answers with a non-2xx status code
+type StatusError struct {
+	Code int
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("endpoint returned status code %d", e.Code)
+}
+
+// IsRetryableStatus returns false for 4xx codes (the payload was rejected & posting it again won't help),
+// except for 408 & 429 which signal a transient condition
+func IsRetryableStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+	return code < 400 || code >= 500
+}
+
+// IsRetryable returns true for network errors & status errors signaling a transient condition
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	return IsRetryableStatus(statusErr.Code)
+}
+
+// Backoff computes exponentially growing waits with "equal jitter": half of each is fixed & the other half random
+type Backoff struct {
+	Base time.Duration // wait before the first retry. doubled on each subsequent one
+	Max  time.Duration // upper bound for the wait. <= 0 means unbounded
+}
+
+// Next returns the wait before the retry following the supplied (0-based) attempt
+func (b Backoff) Next(attempt int) time.Duration {
+	wait := b.Base
+	for i := 0; i < attempt && wait > 0 && wait < math.MaxInt64/2 && (b.Max <= 0 || wait < b.Max); i++ {
+		wait *= 2
+	}
+	if b.Max > 0 && wait > b.Max {
+		wait = b.Max
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// Policy defines how many times & how often a failed operation is retried
+type Policy struct {
+	MaxRetries int // extra attempts made after a retryable failure
+	Backoff    Backoff
+}
+
+// Do calls `fn` until it succeeds, fails with an error that is not retryable or runs out of retries. `sleep` is used
+// to wait before each retry & aborts the loop by returning false (ie: on shutdown). `onRetry` is optional
+func (p Policy) Do(fn func() error, sleep func(time.Duration) bool, onRetry func(attempt int, err error)) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= p.MaxRetries || !sleep(p.Backoff.Next(attempt)) {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt+1, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(errors.New("connection refused")))
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", &StatusError{Code: http.StatusServiceUnavailable})))
+	assert.True(t, IsRetryable(&StatusError{Code: http.StatusRequestTimeout}))
+	assert.True(t, IsRetryable(&StatusError{Code: http.StatusTooManyRequests}))
+	assert.False(t, IsRetryable(&StatusError{Code: http.StatusBadRequest}))
+	assert.False(t, IsRetryable(fmt.Errorf("wrapped: %w", &StatusError{Code: http.StatusUnauthorized})))
+}
+
+func TestBackoff(t *testing.T) {
+	backoff := Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+	for attempt, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		wait := backoff.Next(attempt)
+		expected *= time.Millisecond
+		assert.True(t, wait >= expected/2 && wait <= expected, "attempt %d: %s should be within [%s, %s]", attempt, wait, expected/2, expected)
+	}
+
+	assert.Equal(t, time.Duration(0), Backoff{}.Next(3))
+	assert.Greater(t, Backoff{Base: time.Second}.Next(1000), time.Duration(0))
+}
+
+func TestPolicyDo(t *testing.T) {
+	var waits []time.Duration
+	sleep := func(wait time.Duration) bool { waits = append(waits, wait); return true }
+	policy := Policy{MaxRetries: 2, Backoff: Backoff{Base: time.Millisecond}}
+
+	// retries exhausted
+	calls := 0
+	err := policy.Do(func() error { calls++; return &StatusError{Code: 500} }, sleep, nil)
+	assert.Equal(t, &StatusError{Code: 500}, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, waits, 2)
+
+	// non retryable errors are returned right away
+	calls = 0
+	err = policy.Do(func() error { calls++; return &StatusError{Code: 400} }, sleep, nil)
+	assert.Equal(t, &StatusError{Code: 400}, err)
+	assert.Equal(t, 1, calls)
+
+	// success after a retry
+	calls = 0
+	var retried []int
+	err = policy.Do(func() error {
+		if calls++; calls < 2 {
+			return errors.New("some network error")
+		}
+		return nil
+	}, sleep, func(attempt int, err error) { retried = append(retried, attempt) })
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, retried)
+
+	// an aborted sleep stops the loop
+	calls = 0
+	err = policy.Do(func() error { calls++; return &StatusError{Code: 503} }, func(time.Duration) bool { return false }, nil)
+	assert.Equal(t, &StatusError{Code: 503}, err)
+	assert.Equal(t, 1, calls)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,7 +15,7 @@ import (
 
 	"github.com/splitio/go-split-commons/v9/synchronizer"
 	"github.com/splitio/go-toolkit/v5/logging"
-	"github.com/splitio/go-toolkit/v5/sync"
+	gtSync "github.com/splitio/go-toolkit/v5/sync"
 )
 
 // ErrShutdownAlreadyRegistered is returned when trying to register the shutdown handler more than once
@@ -31,7 +32,7 @@ type Runtime interface {
 type RuntimeImpl struct {
 	proxy              bool
 	startup            time.Time
-	shutdownRegistered *sync.AtomicBool
+	shutdownRegistered *gtSync.AtomicBool
 	logger             logging.LoggerInterface
 	dashboardTitle     string
 	slackWriter        *log.SlackWriter
@@ -41,6 +42,9 @@ type RuntimeImpl struct {
 	osSignals          chan os.Signal
 	appMonitor         application.MonitorIterface
 	servicesMonitor    services.MonitorIterface
+	shutdownHooks      []func()
+	stopHooks          []func()
+	hooksMutex         sync.Mutex
 }
 
 // NewRuntime constructs a RuntimeImpl object
@@ -63,7 +67,7 @@ func NewRuntime(
 		syncManager:        syncManager,
 		impListener:        listener,
 		blocker:            make(chan struct{}),
-		shutdownRegistered: sync.NewAtomicBool(false),
+		shutdownRegistered: gtSync.NewAtomicBool(false),
 		osSignals:          make(chan os.Signal, 1),
 		appMonitor:         appMonitor,
 		servicesMonitor:    servicesMonitor,
@@ -88,6 +92,22 @@ func (r *RuntimeImpl) RegisterShutdownHandler() error {
 	return nil
 }
 
+// OnShutdown registers a function to be executed (in registration order) when a graceful shutdown begins,
+// before background synchronization is stopped
+func (r *RuntimeImpl) OnShutdown(hook func()) {
+	r.hooksMutex.Lock()
+	defer r.hooksMutex.Unlock()
+	r.shutdownHooks = append(r.shutdownHooks, hook)
+}
+
+// AfterStop registers a function to be executed (in registration order) once background synchronization has been
+// stopped & pending impressions/events have been flushed
+func (r *RuntimeImpl) AfterStop(hook func()) {
+	r.hooksMutex.Lock()
+	defer r.hooksMutex.Unlock()
+	r.stopHooks = append(r.stopHooks, hook)
+}
+
 // Uptime returns how long the sync has been running
 func (r *RuntimeImpl) Uptime() time.Duration {
 	return time.Now().Sub(r.startup)
@@ -101,10 +121,21 @@ func (r *RuntimeImpl) Shutdown() {
 		message, attachments := buildSlackShutdownMessage(r.dashboardTitle, false)
 		r.slackWriter.PostNow(message, attachments)
 	}
+
+	r.hooksMutex.Lock()
+	hooks, stopHooks := r.shutdownHooks, r.stopHooks
+	r.hooksMutex.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+
 	r.syncManager.Stop()
 	if r.impListener != nil {
 		r.impListener.Stop(true)
 	}
+	for _, hook := range stopHooks {
+		hook()
+	}
 	r.appMonitor.Stop()
 	r.servicesMonitor.Stop()
 
@@ -135,8 +166,6 @@ func buildSlackShutdownMessage(title string, kill bool) ([]byte, []log.SlackMess
 
 	var attach []log.SlackMessageAttachment
 	if title != "" {
-		fields := make([]log.SlackMessageAttachmentFields, 0)
-		fields = append(fields)
 		attach = []log.SlackMessageAttachment{log.SlackMessageAttachment{
 			Fallback: "Shutting Split-Sync down",
 			Color:    color,
@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnsignedSnapshot is returned when a verifying key is configured and the snapshot carries no signature
+var ErrUnsignedSnapshot = errors.New("snapshot is not signed")
+
+// ErrInvalidSignature is returned when the snapshot signature doesn't match the configured verifying key
+var ErrInvalidSignature = errors.New("snapshot signature is invalid")
+
+// ErrEncryptionKeyRequired is returned when decoding an encrypted snapshot without an encryption key
+var ErrEncryptionKeyRequired = errors.New("snapshot is encrypted and no encryption key was provided")
+
+// ErrDecryption is returned when an encrypted snapshot cannot be decrypted with the provided key
+var ErrDecryption = errors.New("snapshot cannot be decrypted")
+
+// Keys holds the (optional) keys used when encoding & decoding snapshots:
+//   - Signing: if set, encoded snapshots are signed with it
+//   - Verifying: if set, only snapshots with a valid signature are decoded
+//   - Encryption: AES-128/192/256 key. If set, encoded snapshots are encrypted with AES-GCM. Required to decode encrypted snapshots
+type Keys struct {
+	Signing    ed25519.PrivateKey
+	Verifying  ed25519.PublicKey
+	Encryption []byte
+}
+
+// LoadKeys reads the PEM-encoded ed25519 keys & the base64-encoded AES key from the given files.
+// Empty paths are skipped. If no encryption key file is provided, the key is read from the `encryptionKeyEnv` environment variable
+func LoadKeys(signingKeyFile string, verifyingKeyFile string, encryptionKeyFile string, encryptionKeyEnv string) (*Keys, error) {
+	var keys Keys
+	if signingKeyFile != "" {
+		parsed, err := parsePEMFile(signingKeyFile, x509.ParsePKCS8PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading signing key: %w", err)
+		}
+		pk, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key in '%s' is not an ed25519 private key", signingKeyFile)
+		}
+		keys.Signing = pk
+	}
+
+	if verifyingKeyFile != "" {
+		parsed, err := parsePEMFile(verifyingKeyFile, x509.ParsePKIXPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("error reading verifying key: %w", err)
+		}
+		pk, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("verifying key in '%s' is not an ed25519 public key", verifyingKeyFile)
+		}
+		keys.Verifying = pk
+	}
+
+	encoded := os.Getenv(encryptionKeyEnv)
+	if encryptionKeyFile != "" {
+		raw, err := os.ReadFile(encryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading encryption key: %w", err)
+		}
+		encoded = string(raw)
+	}
+
+	if encoded = strings.TrimSpace(encoded); encoded != "" {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key is not valid base64: %w", err)
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+		keys.Encryption = key
+	}
+
+	return &keys, nil
+}
+
+func parsePEMFile(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in '%s'", path)
+	}
+	return parse(block.Bytes)
+}
+
+// signedPayload builds the byte sequence covered by the signature: every metadata field but the signature itself, plus the data
+func signedPayload(meta Metadata, data []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, meta.Version)
+	binary.Write(&b, binary.LittleEndian, meta.Storage)
+	binary.Write(&b, binary.LittleEndian, uint64(len(meta.Hash)))
+	b.WriteString(meta.Hash)
+	binary.Write(&b, binary.LittleEndian, meta.Encrypted)
+	b.Write(data)
+	return b.Bytes()
+}
+
+func encrypt(key []byte, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+func decrypt(key []byte, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecryption
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -3,6 +3,7 @@ package snapshot
 import (
 	"bytes"
 	"compress/gzip"
+	"crypto/ed25519"
 	"encoding/binary"
 	"encoding/gob"
 	"errors"
@@ -20,6 +21,7 @@ import (
 const (
 	_ = iota
 	StorageBoltDB
+	StorageRedis
 )
 
 // ErrNonexistantFile represents an error when the snapshot passed in to be decoded is missing
@@ -39,9 +41,11 @@ var ErrMetadataRead = errors.New("snapshot metadata cannot be decoded")
 
 // Metadata represents the Snapshot metadata object
 type Metadata struct {
-	Version uint64
-	Storage uint64
-	Hash    string
+	Version   uint64
+	Storage   uint64
+	Hash      string
+	Encrypted bool   // set when encoding with an encryption key
+	Signature []byte // set when encoding with a signing key
 }
 
 // Snapshot represents a snapshot struct with metadata and data
@@ -80,7 +84,7 @@ func (s *Snapshot) Data() ([]byte, error) {
 	return data, nil
 }
 
-// Encode returns the bytes slice snapshot representation
+// Encode returns the bytes slice snapshot representation. If keys are provided, data is encrypted and/or signed accordingly
 // Snapshot Layout:
 //
 //				         |metadata-size|metadata|data|
@@ -88,9 +92,26 @@ func (s *Snapshot) Data() ([]byte, error) {
 //	        metadata-size: uint64 (8 bytes) specifies the amount of metadata bytes
 //	        metadata: Gob encoded of Metadata struct
 //	        data: Proxy data, byte slice. The Metadata have information about it, Storage, Gzipped and version.
-func (s *Snapshot) Encode() ([]byte, error) {
+//	              When encrypted, it's prefixed with the AES-GCM nonce. When signed, the signature covers metadata & data.
+func (s *Snapshot) Encode(keys *Keys) ([]byte, error) {
+	meta := s.meta
+	meta.Encrypted = false
+	meta.Signature = nil
+	data := s.data
+	if keys != nil && keys.Encryption != nil {
+		encrypted, err := encrypt(keys.Encryption, data)
+		if err != nil {
+			return nil, fmt.Errorf("error encrypting snapshot data: %w", err)
+		}
+		data = encrypted
+		meta.Encrypted = true
+	}
+
+	if keys != nil && keys.Signing != nil {
+		meta.Signature = ed25519.Sign(keys.Signing, signedPayload(meta, data))
+	}
 
-	metaBytes, err := metaToBytes(s.meta)
+	metaBytes, err := metaToBytes(meta)
 	if err != nil {
 		return nil, fmt.Errorf("%w | %s", ErrEncMetadata, err)
 	}
@@ -100,7 +121,7 @@ func (s *Snapshot) Encode() ([]byte, error) {
 		return nil, fmt.Errorf("%w | %s", ErrEncMetadata, err)
 	}
 
-	totalBytes := len(metaBytesLen) + len(metaBytes) + len(s.data)
+	totalBytes := len(metaBytesLen) + len(metaBytes) + len(data)
 	var snapbytes = make([]byte, totalBytes, totalBytes)
 
 	// copying metadata-size
@@ -116,8 +137,8 @@ func (s *Snapshot) Encode() ([]byte, error) {
 
 	// copying data
 	dataOffset := len(metaBytesLen) + len(metaBytes)
-	for i := 0; i < len(s.data); i++ {
-		snapbytes[dataOffset+i] = s.data[i]
+	for i := 0; i < len(data); i++ {
+		snapbytes[dataOffset+i] = data[i]
 	}
 
 	return snapbytes, nil
@@ -149,8 +170,8 @@ func (s *Snapshot) WriteDataToFile(path string) error {
 	return nil
 }
 
-// DecodeFromFile decodes a snapshot file from a given path
-func DecodeFromFile(path string) (*Snapshot, error) {
+// DecodeFromFile decodes a snapshot file from a given path, verifying & decrypting it with the provided keys
+func DecodeFromFile(path string, keys *Keys) (*Snapshot, error) {
 	snapshotFilePath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("error getting absolute path to snapshot: %w", err)
@@ -165,11 +186,12 @@ func DecodeFromFile(path string) (*Snapshot, error) {
 		return nil, fmt.Errorf("error reading snapshot file")
 	}
 
-	return Decode(snapshotBytes)
+	return Decode(snapshotBytes, keys)
 }
 
-// Decode decode a byte slice and returns the Snapshot object
-func Decode(snap []byte) (*Snapshot, error) {
+// Decode decode a byte slice and returns the Snapshot object.
+// If a verifying key is provided, snapshots without a valid signature are rejected. Encrypted snapshots require an encryption key
+func Decode(snap []byte, keys *Keys) (*Snapshot, error) {
 
 	if len(snap) < 8 {
 		return nil, ErrSnapshotSize
@@ -188,7 +210,26 @@ func Decode(snap []byte) (*Snapshot, error) {
 		return nil, fmt.Errorf("%w | %s", ErrMetadataRead, err)
 	}
 
-	return &Snapshot{meta: *metadata, data: snap[8+int(metadataSize):]}, nil
+	data := snap[8+int(metadataSize):]
+	if keys != nil && keys.Verifying != nil {
+		if len(metadata.Signature) == 0 {
+			return nil, ErrUnsignedSnapshot
+		}
+		if !ed25519.Verify(keys.Verifying, signedPayload(*metadata, data), metadata.Signature) {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	if metadata.Encrypted {
+		if keys == nil || keys.Encryption == nil {
+			return nil, ErrEncryptionKeyRequired
+		}
+		if data, err = decrypt(keys.Encryption, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Snapshot{meta: *metadata, data: data}, nil
 }
 
 func metaToBytes(meta Metadata) ([]byte, error) {
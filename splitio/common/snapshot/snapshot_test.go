@@ -1,6 +1,18 @@
 package snapshot
 
-import "testing"
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestSnapshot(t *testing.T) {
 	data4Test := []byte("Some Snapshot Data")
@@ -13,12 +25,12 @@ func TestSnapshot(t *testing.T) {
 		t.Error(err)
 	}
 
-	encoded, err := snapshot.Encode()
+	encoded, err := snapshot.Encode(nil)
 	if err != nil {
 		t.Error(err)
 	}
 
-	decodedSnapshot, err := Decode(encoded)
+	decodedSnapshot, err := Decode(encoded, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -41,3 +53,89 @@ func TestSnapshot(t *testing.T) {
 	}
 
 }
+
+func writeKeys(t *testing.T) (signing string, verifying string, encryption string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	assert.Nil(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	signing = filepath.Join(dir, "signing.pem")
+	verifying = filepath.Join(dir, "verifying.pem")
+	encryption = filepath.Join(dir, "encryption.key")
+	assert.Nil(t, os.WriteFile(signing, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	assert.Nil(t, os.WriteFile(verifying, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644))
+	assert.Nil(t, os.WriteFile(encryption, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))+"\n"), 0600))
+	return signing, verifying, encryption
+}
+
+func TestSnapshotSignedAndEncrypted(t *testing.T) {
+	signingFn, verifyingFn, encryptionFn := writeKeys(t)
+	keys, err := LoadKeys(signingFn, verifyingFn, encryptionFn, "")
+	assert.Nil(t, err)
+
+	data := []byte("some very secret snapshot data")
+	snap, err := New(Metadata{Version: 1, Storage: StorageBoltDB, Hash: "123"}, data)
+	assert.Nil(t, err)
+
+	encoded, err := snap.Encode(keys)
+	assert.Nil(t, err)
+	assert.False(t, bytes.Contains(encoded, snap.data))
+
+	decoded, err := Decode(encoded, keys)
+	assert.Nil(t, err)
+	assert.True(t, decoded.Meta().Encrypted)
+	assert.NotEmpty(t, decoded.Meta().Signature)
+	decodedData, err := decoded.Data()
+	assert.Nil(t, err)
+	assert.Equal(t, data, decodedData)
+
+	// encrypted snapshots can't be read without the key
+	_, err = Decode(encoded, nil)
+	assert.ErrorIs(t, err, ErrEncryptionKeyRequired)
+	_, err = Decode(encoded, &Keys{Encryption: bytes.Repeat([]byte{8}, 32)})
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	// tampering with either data or metadata invalidates the signature
+	tampered := append([]byte(nil), encoded...)
+	tampered[len(tampered)-1] ^= 0xFF
+	_, err = Decode(tampered, keys)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	decoded.meta.Hash = "456"
+	reSigned, err := decoded.Encode(&Keys{Signing: keys.Signing})
+	assert.Nil(t, err)
+	_, err = Decode(reSigned, &Keys{Verifying: keys.Verifying})
+	assert.Nil(t, err)
+	reSigned, err = decoded.Encode(nil)
+	assert.Nil(t, err)
+	_, err = Decode(reSigned, &Keys{Verifying: keys.Verifying})
+	assert.ErrorIs(t, err, ErrUnsignedSnapshot)
+
+	otherSigningFn, _, _ := writeKeys(t)
+	otherKeys, err := LoadKeys(otherSigningFn, "", "", "")
+	assert.Nil(t, err)
+	reSigned, err = snap.Encode(otherKeys)
+	assert.Nil(t, err)
+	_, err = Decode(reSigned, &Keys{Verifying: keys.Verifying})
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// encryption key from env var
+	t.Setenv("SOME_ENV_VAR", base64.StdEncoding.EncodeToString(keys.Encryption))
+	fromEnv, err := LoadKeys("", "", "", "SOME_ENV_VAR")
+	assert.Nil(t, err)
+	assert.Equal(t, keys.Encryption, fromEnv.Encryption)
+	_, err = Decode(encoded, fromEnv)
+	assert.Nil(t, err)
+
+	t.Setenv("SOME_ENV_VAR", base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = LoadKeys("", "", "", "SOME_ENV_VAR")
+	assert.NotNil(t, err)
+	_, err = LoadKeys(verifyingFn, "", "", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"errors"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/synchronizer"
+	"github.com/splitio/go-toolkit/v5/backoff"
+)
+
+var (
+	// ErrRetrying is returned when the initial sync fails but a snapshot is available, so it's retried in background
+	ErrRetrying = errors.New("error but snapshot available")
+
+	// ErrUnrecoverable is returned when the initial sync fails and there's no snapshot to serve data from
+	ErrUnrecoverable = errors.New("error and no snapshot available")
+)
+
+// StartBGSync attempts to start the sync manager, invoking `onReady` upon success.
+// If the first attempt fails and a snapshot was loaded, the manager is restarted in background with backoff until it succeeds
+func StartBGSync(m synchronizer.Manager, mstatus chan int, haveSnapshot bool, onReady func()) error {
+	attemptInit := func() bool {
+		go m.Start()
+		status := <-mstatus
+		switch status {
+		case synchronizer.Ready:
+			onReady()
+			return true
+		case synchronizer.Error:
+			return false
+		}
+		return false // should not reach here TODO:LOG!
+	}
+
+	if attemptInit() { // succeeeded at first try
+		return nil
+	}
+
+	if !haveSnapshot {
+		return ErrUnrecoverable
+	}
+
+	go func() {
+		boff := backoff.New(2, 10*time.Minute)
+		for !attemptInit() {
+			time.Sleep(boff.Next())
+		}
+	}()
+
+	return ErrRetrying
+
+}
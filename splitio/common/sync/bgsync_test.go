@@ -1,4 +1,4 @@
-package proxy
+package sync
 
 import (
 	"sync/atomic"
@@ -37,8 +37,8 @@ func TestSyncManagerInitializationRetriesWithSnapshot(t *testing.T) {
 
 	// No snapshot and error
 	complete := make(chan struct{}, 1)
-	err := startBGSync(sm, sm.c, false, func() { complete <- struct{}{} })
-	if err != errUnrecoverable {
+	err := StartBGSync(sm, sm.c, false, func() { complete <- struct{}{} })
+	if err != ErrUnrecoverable {
 		t.Error("should be an unrecoverable error. Got: ", err)
 	}
 
@@ -51,8 +51,8 @@ func TestSyncManagerInitializationRetriesWithSnapshot(t *testing.T) {
 
 	// Snapshot and error
 	atomic.StoreInt64(&sm.execCount, 0)
-	err = startBGSync(sm, sm.c, true, func() { complete <- struct{}{} })
-	if err != errRetrying {
+	err = StartBGSync(sm, sm.c, true, func() { complete <- struct{}{} })
+	if err != ErrRetrying {
 		t.Error("should be a retrying error. Got: ", err)
 	}
 
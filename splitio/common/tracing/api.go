@@ -0,0 +1,216 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service"
+	"github.com/splitio/go-split-commons/v9/service/api"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Since fetchers & recorders from commons don't accept a context, spans for outbound calls to Split
+// are started as new traces. Components handling incoming requests should start their own child spans
+// around these calls to keep them linked to the request being served.
+
+// WrapSplitAPI returns a copy of the supplied split api whose fetchers & recorders report a span for each call
+func WrapSplitAPI(splitAPI *api.SplitAPI) *api.SplitAPI {
+	return &api.SplitAPI{
+		AuthClient:          &tracedAuthClient{wrapped: splitAPI.AuthClient},
+		SplitFetcher:        WrapSplitFetcher(splitAPI.SplitFetcher),
+		SegmentFetcher:      &tracedSegmentFetcher{wrapped: splitAPI.SegmentFetcher},
+		ImpressionRecorder:  WrapImpressionsRecorder(splitAPI.ImpressionRecorder),
+		EventRecorder:       WrapEventsRecorder(splitAPI.EventRecorder),
+		TelemetryRecorder:   WrapTelemetryRecorder(splitAPI.TelemetryRecorder),
+		LargeSegmentFetcher: &tracedLargeSegmentFetcher{wrapped: splitAPI.LargeSegmentFetcher},
+	}
+}
+
+// WrapSplitFetcher returns a split fetcher that reports a span for each call
+func WrapSplitFetcher(fetcher service.SplitFetcher) service.SplitFetcher {
+	return &tracedSplitFetcher{wrapped: fetcher}
+}
+
+// WrapImpressionsRecorder returns an impressions recorder that reports a span for each call
+func WrapImpressionsRecorder(recorder service.ImpressionsRecorder) service.ImpressionsRecorder {
+	return &tracedImpressionsRecorder{wrapped: recorder}
+}
+
+// WrapEventsRecorder returns an events recorder that reports a span for each call
+func WrapEventsRecorder(recorder service.EventsRecorder) service.EventsRecorder {
+	return &tracedEventsRecorder{wrapped: recorder}
+}
+
+// WrapTelemetryRecorder returns a telemetry recorder that reports a span for each call
+func WrapTelemetryRecorder(recorder service.TelemetryRecorder) service.TelemetryRecorder {
+	return &tracedTelemetryRecorder{wrapped: recorder}
+}
+
+// RawRecorder defines the interface of recorders that forward already-serialized payloads
+type RawRecorder interface {
+	RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error
+}
+
+// WrapRawRecorder returns a raw recorder that reports a span for each call & propagates its context to split servers
+func WrapRawRecorder(recorder RawRecorder) RawRecorder {
+	return &tracedRawRecorder{wrapped: recorder}
+}
+
+func startClient(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := Tracer().Start(context.Background(), name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	return span
+}
+
+type tracedAuthClient struct {
+	wrapped service.AuthClient
+}
+
+func (t *tracedAuthClient) Authenticate() (*dtos.Token, error) {
+	span := startClient("GET /auth")
+	token, err := t.wrapped.Authenticate()
+	End(span, err)
+	return token, err
+}
+
+type tracedSplitFetcher struct {
+	wrapped service.SplitFetcher
+}
+
+func (t *tracedSplitFetcher) Fetch(fetchOptions *service.FlagRequestParams) (dtos.FFResponse, error) {
+	span := startClient("GET /splitChanges")
+	if fetchOptions != nil {
+		span.SetAttributes(attribute.Int64("split.since", fetchOptions.ChangeNumber()), attribute.Int64("split.rbSince", fetchOptions.ChangeNumberRB()))
+	}
+	response, err := t.wrapped.Fetch(fetchOptions)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("split.till", response.FFTill()), attribute.Int("split.count", len(response.FeatureFlags())))
+	}
+	End(span, err)
+	return response, err
+}
+
+func (t *tracedSplitFetcher) IsProxy() bool {
+	return t.wrapped.IsProxy()
+}
+
+type tracedSegmentFetcher struct {
+	wrapped service.SegmentFetcher
+}
+
+func (t *tracedSegmentFetcher) Fetch(name string, fetchOptions *service.SegmentRequestParams) (*dtos.SegmentChangesDTO, error) {
+	span := startClient("GET /segmentChanges", attribute.String("split.segment", name))
+	if fetchOptions != nil {
+		span.SetAttributes(attribute.Int64("split.since", fetchOptions.ChangeNumber()))
+	}
+	response, err := t.wrapped.Fetch(name, fetchOptions)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("split.till", response.Till))
+	}
+	End(span, err)
+	return response, err
+}
+
+type tracedLargeSegmentFetcher struct {
+	wrapped service.LargeSegmentFetcher
+}
+
+func (t *tracedLargeSegmentFetcher) Fetch(name string, fetchOptions *service.SegmentRequestParams) (*dtos.LargeSegmentRFDResponseDTO, error) {
+	span := startClient("GET /largeSegmentDefinition", attribute.String("split.largeSegment", name))
+	response, err := t.wrapped.Fetch(name, fetchOptions)
+	End(span, err)
+	return response, err
+}
+
+func (t *tracedLargeSegmentFetcher) DownloadFile(name string, rfdResponseDTO *dtos.LargeSegmentRFDResponseDTO) (*dtos.LargeSegment, error) {
+	span := startClient("GET largeSegmentFile", attribute.String("split.largeSegment", name))
+	response, err := t.wrapped.DownloadFile(name, rfdResponseDTO)
+	End(span, err)
+	return response, err
+}
+
+type tracedImpressionsRecorder struct {
+	wrapped service.ImpressionsRecorder
+}
+
+func (t *tracedImpressionsRecorder) Record(impressions []dtos.ImpressionsDTO, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	span := startClient("POST /testImpressions/bulk", attribute.Int("split.features", len(impressions)))
+	err := t.wrapped.Record(impressions, metadata, extraHeaders)
+	End(span, err)
+	return err
+}
+
+func (t *tracedImpressionsRecorder) RecordImpressionsCount(pf dtos.ImpressionsCountDTO, metadata dtos.Metadata) error {
+	span := startClient("POST /testImpressions/count", attribute.Int("split.counts", len(pf.PerFeature)))
+	err := t.wrapped.RecordImpressionsCount(pf, metadata)
+	End(span, err)
+	return err
+}
+
+type tracedEventsRecorder struct {
+	wrapped service.EventsRecorder
+}
+
+func (t *tracedEventsRecorder) Record(events []dtos.EventDTO, metadata dtos.Metadata) error {
+	span := startClient("POST /events/bulk", attribute.Int("split.events", len(events)))
+	err := t.wrapped.Record(events, metadata)
+	End(span, err)
+	return err
+}
+
+type tracedTelemetryRecorder struct {
+	wrapped service.TelemetryRecorder
+}
+
+func (t *tracedTelemetryRecorder) RecordConfig(config dtos.Config, metadata dtos.Metadata) error {
+	span := startClient("POST /metrics/config")
+	err := t.wrapped.RecordConfig(config, metadata)
+	End(span, err)
+	return err
+}
+
+func (t *tracedTelemetryRecorder) RecordStats(stats dtos.Stats, metadata dtos.Metadata) error {
+	span := startClient("POST /metrics/usage")
+	err := t.wrapped.RecordStats(stats, metadata)
+	End(span, err)
+	return err
+}
+
+func (t *tracedTelemetryRecorder) RecordUniqueKeys(uniques dtos.Uniques, metadata dtos.Metadata) error {
+	span := startClient("POST /keys/ss")
+	err := t.wrapped.RecordUniqueKeys(uniques, metadata)
+	End(span, err)
+	return err
+}
+
+type tracedRawRecorder struct {
+	wrapped RawRecorder
+}
+
+func (t *tracedRawRecorder) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	ctx, span := Tracer().Start(context.Background(), "POST "+url,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("split.payloadBytes", len(data))))
+
+	headers := make(map[string]string, len(extraHeaders)+2)
+	for k, v := range extraHeaders {
+		headers[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	err := t.wrapped.RecordRaw(url, data, metadata, headers)
+	End(span, err)
+	return err
+}
+
+var _ service.AuthClient = (*tracedAuthClient)(nil)
+var _ service.SplitFetcher = (*tracedSplitFetcher)(nil)
+var _ service.SegmentFetcher = (*tracedSegmentFetcher)(nil)
+var _ service.LargeSegmentFetcher = (*tracedLargeSegmentFetcher)(nil)
+var _ service.ImpressionsRecorder = (*tracedImpressionsRecorder)(nil)
+var _ service.EventsRecorder = (*tracedEventsRecorder)(nil)
+var _ service.TelemetryRecorder = (*tracedTelemetryRecorder)(nil)
+var _ RawRecorder = (*tracedRawRecorder)(nil)
@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/conf"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type rawRecorderMock struct {
+	headers map[string]string
+	err     error
+}
+
+func (r *rawRecorderMock) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	r.headers = extraHeaders
+	return r.err
+}
+
+func TestSetupValidation(t *testing.T) {
+	shutdown, err := Setup(&conf.Tracing{Enabled: false, Exporter: "nonexistant"}, "test")
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+
+	_, err = Setup(&conf.Tracing{Enabled: true, Exporter: "nonexistant"}, "test")
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+
+	_, err = Setup(&conf.Tracing{Enabled: true, Exporter: ExporterFile}, "test")
+	assert.ErrorIs(t, err, ErrNoFile)
+}
+
+func TestFileExporter(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := Setup(&conf.Tracing{Enabled: true, Exporter: ExporterFile, File: path, SamplingPercent: 100}, "test-service")
+	assert.Nil(t, err)
+
+	_, span := Start(context.Background(), "some-operation")
+	End(span, errors.New("something failed"))
+	assert.Nil(t, shutdown(context.Background()))
+
+	contents, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(contents), "some-operation"))
+	assert.True(t, strings.Contains(string(contents), "test-service"))
+	assert.True(t, strings.Contains(string(contents), "something failed"))
+}
+
+func TestRawRecorderPropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() { otel.SetTracerProvider(prevProvider); otel.SetTextMapPropagator(prevPropagator) }()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	wrapped := &rawRecorderMock{err: errors.New("some error")}
+	extraHeaders := map[string]string{"SplitSDKImpressionsMode": "optimized"}
+	err := WrapRawRecorder(wrapped).RecordRaw("/testImpressions/bulk", []byte("[]"), dtos.Metadata{}, extraHeaders)
+	assert.NotNil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "POST /testImpressions/bulk", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+	assert.Equal(t, "optimized", wrapped.headers["SplitSDKImpressionsMode"])
+	assert.Contains(t, wrapped.headers["traceparent"], spans[0].SpanContext().TraceID().String())
+	assert.Len(t, extraHeaders, 1) // the caller's map must not be modified
+}
@@ -8,17 +8,19 @@ import (
 
 // Main configuration options
 type Main struct {
-	Apikey           string            `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
-	IPAddressEnabled bool              `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
-	FlagSetsFilter   []string          `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
-	Initialization   Initialization    `json:"initialization" s-nested:"true"`
-	Storage          Storage           `json:"storage" s-nested:"true"`
-	Sync             Sync              `json:"sync" s-nested:"true"`
-	Admin            conf.Admin        `json:"admin" s-nested:"true"`
-	Integrations     conf.Integrations `json:"integrations" s-nested:"true"`
-	Logging          conf.Logging      `json:"logging" s-nested:"true"`
-	Healthcheck      Healthcheck       `json:"healthcheck" s-nested:"true"`
-	FlagSpecVersion  string            `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.3" s-desc:"Spec version for flags"`
+	Apikey           string                `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
+	IPAddressEnabled bool                  `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
+	FlagSetsFilter   []string              `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
+	Initialization   Initialization        `json:"initialization" s-nested:"true"`
+	Storage          Storage               `json:"storage" s-nested:"true"`
+	SnapshotSecurity conf.SnapshotSecurity `json:"snapshotSecurity" s-nested:"true"`
+	Sync             Sync                  `json:"sync" s-nested:"true"`
+	Admin            conf.Admin            `json:"admin" s-nested:"true"`
+	Integrations     conf.Integrations     `json:"integrations" s-nested:"true"`
+	Logging          conf.Logging          `json:"logging" s-nested:"true"`
+	Tracing          conf.Tracing          `json:"tracing" s-nested:"true"`
+	Healthcheck      Healthcheck           `json:"healthcheck" s-nested:"true"`
+	FlagSpecVersion  string                `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.3" s-desc:"Spec version for flags"`
 }
 
 // BuildAdvancedConfig generates a commons-compatible advancedconfig with default + overriden parameters
@@ -33,10 +35,9 @@ func (m *Main) BuildAdvancedConfig() *cconf.AdvancedConfig {
 
 // Initialization configuration options
 type Initialization struct {
-	TimeoutMs int64 `json:"timeoutMS" s-cli:"timeout-ms" s-def:"10000" s-desc:"How long to wait until the synchronizer is ready"`
-	// Coming soon
-	// Snapshot          string `json:"snapshot" s-cli:"snapshot" s-def:"" s-desc:"Snapshot file to use as a starting point"`
-	ForceFreshStartup bool `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
+	TimeoutMs         int64  `json:"timeoutMS" s-cli:"timeout-ms" s-def:"10000" s-desc:"How long to wait until the synchronizer is ready"`
+	Snapshot          string `json:"snapshot" s-cli:"snapshot" s-def:"" s-desc:"Snapshot file used to seed an empty storage if Split cannot be reached on startup"`
+	ForceFreshStartup bool   `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
 }
 
 // Storage configuration options
@@ -1,17 +1,25 @@
 package producer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/admin"
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	ssync "github.com/splitio/split-synchronizer/v5/splitio/common/sync"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/tracing"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/snapshots"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/task"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/worker"
@@ -42,6 +50,7 @@ const (
 	bfExpectedElemenets        = 10000000
 	bfFalsePositiveProbability = 0.01
 	bfCleaningPeriod           = 86400 // 6 hours
+	tracingShutdownTimeout     = 5 * time.Second
 )
 
 // Start initialize the producer mode
@@ -58,12 +67,23 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		return common.NewInitError(fmt.Errorf("error parsing client key from provided SDK key: %w", err), common.ExitInvalidApikey)
 	}
 
+	shutdownTracing, err := tracing.Setup(&cfg.Tracing, "split-synchronizer")
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up tracing: %w", err), common.ExitInvalidConfiguration)
+	}
+
 	// Setup fetchers & recorders
 	splitAPI := api.NewSplitAPI(cfg.Apikey, *advanced, logger, metadata)
+	if cfg.Tracing.Enabled {
+		splitAPI = tracing.WrapSplitAPI(splitAPI)
+	}
 
 	// Check if SDK key is valid
 	if !isValidApikey(splitAPI.SplitFetcher) {
-		return common.NewInitError(errors.New("invalid SDK key"), common.ExitInvalidApikey)
+		if cfg.Initialization.Snapshot == "" {
+			return common.NewInitError(errors.New("invalid SDK key"), common.ExitInvalidApikey)
+		}
+		logger.Warning("Could not validate the SDK key against Split servers. Continuing since a snapshot was provided")
 	}
 
 	// Redis Storages
@@ -112,6 +132,23 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		RuleBasedSegmentsStorage: redis.NewRuleBasedStorage(redisClient, logger),
 	}
 
+	snapshotKeys, err := snapshot.LoadKeys(
+		cfg.SnapshotSecurity.SigningKeyFn,
+		cfg.SnapshotSecurity.VerifyingKeyFn,
+		cfg.SnapshotSecurity.EncryptionKeyFn,
+		"SPLIT_SYNC_SNAPSHOT_ENCRYPTION_KEY",
+	)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error loading snapshot keys: %w", err), common.ExitInvalidConfiguration)
+	}
+
+	if cfg.Initialization.Snapshot != "" {
+		err = seedFromSnapshot(cfg, snapshotKeys, storages.SplitStorage, storages.RuleBasedSegmentsStorage, storages.SegmentStorage, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error seeding storage from snapshot: %w", err), common.ExitErrorDB)
+		}
+	}
+
 	// Healcheck Monitor
 	splitsConfig, segmentsConfig, storageConfig := getAppCounterConfigs(storages.SplitStorage)
 	appMonitor := hcApplication.NewMonitorImp(splitsConfig, segmentsConfig, nil, &storageConfig, logger)
@@ -161,16 +198,31 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	var impListener impressionlistener.ImpressionBulkListener
 	if cfg.Integrations.ImpressionListener.Endpoint != "" {
 		var err error
-		impListener, err = impressionlistener.NewImpressionBulkListener(
-			cfg.Integrations.ImpressionListener.Endpoint,
-			int(cfg.Integrations.ImpressionListener.QueueSize),
-			nil)
+		impListener, err = impressionlistener.FromConfig(&cfg.Integrations.ImpressionListener, logger)
 		if err != nil {
 			return common.NewInitError(fmt.Errorf("error instantiating impression listener: %w", err), common.ExitTaskInitialization)
 		}
 		impListener.Start()
 	}
 
+	var evListener eventlistener.EventBulkListener
+	if elcfg := cfg.Integrations.EventListener; elcfg.Endpoint != "" {
+		var err error
+		evListener, err = eventlistener.FromConfig(&elcfg, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error instantiating event listener: %w", err), common.ExitTaskInitialization)
+		}
+		evListener.Start()
+	}
+
+	dataSinks, err := datasink.FromConfig(&cfg.Integrations.DataSinks, metadata.SDKVersion, logger)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error instantiating data sinks: %w", err), common.ExitTaskInitialization)
+	}
+	if dataSinks != nil {
+		dataSinks.Start()
+	}
+
 	impManager := buildImpressionManager(cfg.Sync.ImpressionsMode, impListener, syncTelemetryStorage, impressionObserver, impressionsCounter)
 
 	// Impression & events pipelined tasks @{
@@ -181,6 +233,7 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		URL:                 advanced.EventsURL,
 		Apikey:              cfg.Apikey,
 		ImpressionsListener: impListener,
+		DataSinks:           dataSinks,
 		FetchSize:           int(cfg.Sync.Advanced.ImpressionsFetchSize),
 		ImpressionManager:   impManager,
 	})
@@ -209,6 +262,8 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		EvictionMonitor: eventEvictionMonitor,
 		Apikey:          cfg.Apikey,
 		FetchSize:       int(cfg.Sync.Advanced.EventsFetchSize),
+		EventsListener:  evListener,
+		DataSinks:       dataSinks,
 	})
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error instantiating events worker: %w", err), common.ExitTaskInitialization)
@@ -285,7 +340,9 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
 	}
 
-	rtm := common.NewRuntime(false, syncManager, logger, "Split Synchronizer", nil, nil, appMonitor, servicesMonitor)
+	// the impression listener is stopped by the runtime once the impressions pipeline has been flushed,
+	// so that pending (batched or retried) impressions are posted before exiting
+	rtm := common.NewRuntime(false, syncManager, logger, "Split Synchronizer", impListener, nil, appMonitor, servicesMonitor)
 
 	// --------------------------- ADMIN DASHBOARD ------------------------------
 
@@ -297,6 +354,7 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	cfgForAdmin := *cfg
 	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
 	cfgForAdmin.Storage.Redis.Pass = "xxxxxxxxxxxxxxx"
+	cfgForAdmin.Integrations.ImpressionListener.MaskSecrets()
 	adminServer, err := admin.NewServer(&admin.Options{
 		Host:              cfg.Admin.Host,
 		Port:              int(cfg.Admin.Port),
@@ -314,6 +372,10 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 		FullConfig:        cfgForAdmin,
 		TLS:               adminTLSConfig,
 		FlagSpecVersion:   cfg.FlagSpecVersion,
+		Snapshotter:       snapshots.NewExporter(storages.SplitStorage, storages.RuleBasedSegmentsStorage, storages.SegmentStorage),
+		SnapshotStorage:   snapshot.StorageRedis,
+		SnapshotKeys:      snapshotKeys,
+		Hash:              strconv.Itoa(int(util.HashAPIKey(cfg.Apikey + cfg.FlagSpecVersion + strings.Join(cfg.FlagSetsFilter, "::")))),
 	})
 	if err != nil {
 		panic(err.Error())
@@ -321,36 +383,55 @@ func Start(logger logging.LoggerInterface, cfg *conf.Main) error {
 	go adminServer.Start()
 
 	// Run Sync Manager
+	// If a snapshot was provided and the initial sync fails, SDKs are served from the seeded storage while
+	// the synchronizer keeps retrying in background
 	before := time.Now()
-	go syncManager.Start()
-	select {
-	case status := <-managerStatus:
-		switch status {
-		case synchronizer.Ready:
-			logger.Info("Synchronizer tasks started")
-			appMonitor.Start()
-			servicesMonitor.Start()
-			workers.TelemetryRecorder.SynchronizeConfig(
-				telemetry.InitConfig{
-					AdvancedConfig: *advanced,
-					TaskPeriods: cconf.TaskPeriods{
-						SplitSync:     int(cfg.Sync.SplitRefreshRateMs / 1000),
-						SegmentSync:   int(cfg.Sync.SegmentRefreshRateMs / 1000),
-						TelemetrySync: int(cfg.Sync.Advanced.InternalMetricsRateMs / 1000),
-					},
-					ImpressionsMode: cfg.Sync.ImpressionsMode,
-					ListenerEnabled: impListener != nil,
+	err = ssync.StartBGSync(syncManager, managerStatus, cfg.Initialization.Snapshot != "", func() {
+		logger.Info("Synchronizer tasks started")
+		appMonitor.Start()
+		servicesMonitor.Start()
+		workers.TelemetryRecorder.SynchronizeConfig(
+			telemetry.InitConfig{
+				AdvancedConfig: *advanced,
+				TaskPeriods: cconf.TaskPeriods{
+					SplitSync:     int(cfg.Sync.SplitRefreshRateMs / 1000),
+					SegmentSync:   int(cfg.Sync.SegmentRefreshRateMs / 1000),
+					TelemetrySync: int(cfg.Sync.Advanced.InternalMetricsRateMs / 1000),
 				},
-				time.Now().Sub(before).Milliseconds(),
-				map[string]int64{cfg.Apikey: 1},
-				nil,
-			)
-		case synchronizer.Error:
-			logger.Error("Initial synchronization failed. Either Split is unreachable or the SDK key is incorrect. Aborting execution.")
-			return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
-		}
+				ImpressionsMode: cfg.Sync.ImpressionsMode,
+				ListenerEnabled: impListener != nil,
+			},
+			time.Now().Sub(before).Milliseconds(),
+			map[string]int64{cfg.Apikey: 1},
+			nil,
+		)
+	})
+	switch err {
+	case ssync.ErrRetrying:
+		logger.Warning("Failed to perform initial sync with Split servers but continuing from snapshot. Will keep retrying in BG")
+	case ssync.ErrUnrecoverable:
+		logger.Error("Initial synchronization failed. Either Split is unreachable or the SDK key is incorrect. Aborting execution.")
+		return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
+	}
+
+	if evListener != nil {
+		// stopped once the events pipeline has been flushed, so that the last events are posted too
+		rtm.AfterStop(func() { evListener.Stop(true) })
+	}
+
+	if dataSinks != nil {
+		// stopped once the pipelines have been flushed, so that the last impressions & events are written too
+		rtm.AfterStop(func() { dataSinks.Stop(true) })
 	}
 
+	rtm.OnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("error flushing pending spans: ", err)
+		}
+	})
+
 	rtm.RegisterShutdownHandler()
 	rtm.Block()
 	return nil
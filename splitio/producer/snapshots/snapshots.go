@@ -0,0 +1,142 @@
+package snapshots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/storage"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+)
+
+// ErrUnsupportedStorage is returned when attempting to seed redis from a snapshot not exported by a synchronizer
+var ErrUnsupportedStorage = errors.New("snapshot storage type not supported")
+
+// Dataset is the content of a synchronizer snapshot: all the data required by SDKs to evaluate
+type Dataset struct {
+	Splits                        []dtos.SplitDTO            `json:"splits"`
+	SplitsChangeNumber            int64                      `json:"splitsChangeNumber"`
+	RuleBasedSegments             []dtos.RuleBasedSegmentDTO `json:"ruleBasedSegments"`
+	RuleBasedSegmentsChangeNumber int64                      `json:"ruleBasedSegmentsChangeNumber"`
+	Segments                      []Segment                  `json:"segments"`
+}
+
+// Segment holds the keys & change number of a segment
+type Segment struct {
+	Name         string   `json:"name"`
+	Keys         []string `json:"keys"`
+	ChangeNumber int64    `json:"changeNumber"`
+}
+
+// Exporter dumps the synchronizer storages into snapshot-ready bytes
+type Exporter struct {
+	splits    storage.SplitStorageConsumer
+	ruleBased storage.RuleBasedSegmentStorageConsumer
+	segments  storage.SegmentStorageConsumer
+}
+
+// NewExporter constructs a new exporter
+func NewExporter(
+	splits storage.SplitStorageConsumer,
+	ruleBased storage.RuleBasedSegmentStorageConsumer,
+	segments storage.SegmentStorageConsumer,
+) *Exporter {
+	return &Exporter{splits: splits, ruleBased: ruleBased, segments: segments}
+}
+
+// GetRawSnapshot serializes the current dataset. Implements storage.Snapshotter
+func (e *Exporter) GetRawSnapshot() ([]byte, error) {
+	splitsCN, err := e.splits.ChangeNumber()
+	if err != nil {
+		return nil, fmt.Errorf("error reading feature flags change number: %w", err)
+	}
+
+	rbCN, err := e.ruleBased.ChangeNumber()
+	if err != nil {
+		return nil, fmt.Errorf("error reading rule-based segments change number: %w", err)
+	}
+
+	dataset := Dataset{
+		Splits:                        e.splits.All(),
+		SplitsChangeNumber:            splitsCN,
+		RuleBasedSegments:             e.ruleBased.All(),
+		RuleBasedSegmentsChangeNumber: rbCN,
+		Segments:                      make([]Segment, 0),
+	}
+
+	names := e.splits.SegmentNames()
+	names.Add(e.ruleBased.Segments().List()...)
+	for _, name := range sortedStrings(names) {
+		cn, err := e.segments.ChangeNumber(name)
+		if err != nil || cn == -1 {
+			// segment referenced but not yet fetched
+			continue
+		}
+		dataset.Segments = append(dataset.Segments, Segment{Name: name, Keys: sortedStrings(e.segments.Keys(name)), ChangeNumber: cn})
+	}
+
+	serialized, err := json.Marshal(dataset)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing dataset: %w", err)
+	}
+	return serialized, nil
+}
+
+// Seed populates the storages with the dataset contained in a snapshot
+func Seed(
+	snap *snapshot.Snapshot,
+	splits storage.SplitStorageProducer,
+	ruleBased storage.RuleBasedSegmentStorageProducer,
+	segments storage.SegmentStorageProducer,
+) error {
+	if snap.Meta().Storage != snapshot.StorageRedis {
+		return ErrUnsupportedStorage
+	}
+
+	raw, err := snap.Data()
+	if err != nil {
+		return fmt.Errorf("error reading snapshot data: %w", err)
+	}
+
+	var dataset Dataset
+	if err := json.Unmarshal(raw, &dataset); err != nil {
+		return fmt.Errorf("error parsing snapshot data: %w", err)
+	}
+
+	// segments go first, so that no flag referencing a missing segment is ever visible to SDKs
+	for _, segment := range dataset.Segments {
+		keys := set.NewSet()
+		for _, key := range segment.Keys {
+			keys.Add(key)
+		}
+		if err := segments.Update(segment.Name, keys, set.NewSet(), segment.ChangeNumber); err != nil {
+			return fmt.Errorf("error storing segment '%s': %w", segment.Name, err)
+		}
+	}
+
+	if err := ruleBased.Update(dataset.RuleBasedSegments, nil, dataset.RuleBasedSegmentsChangeNumber); err != nil {
+		return fmt.Errorf("error storing rule-based segments: %w", err)
+	}
+
+	splits.Update(dataset.Splits, nil, dataset.SplitsChangeNumber)
+	return nil
+}
+
+func sortedStrings(s *set.ThreadUnsafeSet) []string {
+	if s == nil { // empty segments are reported as nil by some storages
+		return []string{}
+	}
+
+	toRet := make([]string, 0, s.Size())
+	for _, item := range s.List() {
+		if str, ok := item.(string); ok {
+			toRet = append(toRet, str)
+		}
+	}
+	sort.Strings(toRet)
+	return toRet
+}
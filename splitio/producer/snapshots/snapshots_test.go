@@ -0,0 +1,80 @@
+package snapshots
+
+import (
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-split-commons/v9/storage/inmemory/mutexmap"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func segmentMatcher(segment string) dtos.ConditionDTO {
+	return dtos.ConditionDTO{
+		MatcherGroup: dtos.MatcherGroupDTO{
+			Matchers: []dtos.MatcherDTO{{
+				MatcherType:        "IN_SEGMENT",
+				UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: segment},
+			}},
+		},
+	}
+}
+
+func TestExportAndSeed(t *testing.T) {
+	splits := mutexmap.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	ruleBased := mutexmap.NewRuleBasedSegmentsStorage()
+	segments := mutexmap.NewMMSegmentStorage()
+
+	splits.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "user", Conditions: []dtos.ConditionDTO{segmentMatcher("s1")}},
+		{Name: "f2", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "user", Conditions: []dtos.ConditionDTO{segmentMatcher("unfetched")}},
+	}, nil, 20)
+	ruleBased.Update([]dtos.RuleBasedSegmentDTO{
+		{Name: "rb1", ChangeNumber: 5, Status: "ACTIVE", Conditions: []dtos.RuleBasedConditionDTO{{
+			MatcherGroup: dtos.MatcherGroupDTO{Matchers: []dtos.MatcherDTO{{
+				MatcherType:        "IN_SEGMENT",
+				UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: "s2"},
+			}}},
+		}}},
+	}, nil, 5)
+	segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 100)
+	segments.Update("s2", set.NewSet("k3"), set.NewSet(), 200)
+
+	raw, err := NewExporter(splits, ruleBased, segments).GetRawSnapshot()
+	assert.Nil(t, err)
+
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageRedis, Hash: "123"}, raw)
+	assert.Nil(t, err)
+	encoded, err := snap.Encode(nil)
+	assert.Nil(t, err)
+	decoded, err := snapshot.Decode(encoded, nil)
+	assert.Nil(t, err)
+
+	newSplits := mutexmap.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	newRuleBased := mutexmap.NewRuleBasedSegmentsStorage()
+	newSegments := mutexmap.NewMMSegmentStorage()
+	assert.Nil(t, Seed(decoded, newSplits, newRuleBased, newSegments))
+
+	cn, _ := newSplits.ChangeNumber()
+	assert.Equal(t, int64(20), cn)
+	assert.ElementsMatch(t, splits.All(), newSplits.All())
+
+	cn, _ = newRuleBased.ChangeNumber()
+	assert.Equal(t, int64(5), cn)
+	assert.ElementsMatch(t, ruleBased.All(), newRuleBased.All())
+
+	cn, _ = newSegments.ChangeNumber("s1")
+	assert.Equal(t, int64(100), cn)
+	assert.True(t, newSegments.Keys("s1").IsEqual(set.NewSet("k1", "k2")))
+	cn, _ = newSegments.ChangeNumber("s2")
+	assert.Equal(t, int64(200), cn)
+	assert.True(t, newSegments.Keys("s2").IsEqual(set.NewSet("k3")))
+	assert.Nil(t, newSegments.Keys("unfetched"))
+
+	proxySnap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: "123"}, raw)
+	assert.Nil(t, err)
+	assert.ErrorIs(t, Seed(proxySnap, newSplits, newRuleBased, newSegments), ErrUnsupportedStorage)
+}
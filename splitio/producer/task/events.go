@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
@@ -25,6 +27,8 @@ type EventWorkerConfig struct {
 	Logger          logging.LoggerInterface
 	Storage         storage.EventMultiSdkConsumer
 	EvictionMonitor evcalc.Monitor
+	EventsListener  eventlistener.EventBulkListener
+	DataSinks       datasink.Dispatcher
 	URL             string
 	Apikey          string
 	FetchSize       int
@@ -41,6 +45,8 @@ type EventsPipelineWorker struct {
 	logger          logging.LoggerInterface
 	storage         storage.EventMultiSdkConsumer
 	evictionMonitor evcalc.Monitor
+	evListener      eventlistener.EventBulkListener
+	dataSinks       datasink.Dispatcher
 
 	url       string
 	apikey    string
@@ -55,6 +61,8 @@ func NewEventsWorker(cfg *EventWorkerConfig) (*EventsPipelineWorker, error) {
 		logger:          cfg.Logger,
 		evictionMonitor: cfg.EvictionMonitor,
 		storage:         cfg.Storage,
+		evListener:      cfg.EventsListener,
+		dataSinks:       cfg.DataSinks,
 		url:             cfg.URL + "/events/bulk",
 		apikey:          cfg.Apikey,
 		fetchSize:       int64(cfg.FetchSize),
@@ -93,6 +101,14 @@ func (i *EventsPipelineWorker) Process(raws [][]byte, sink chan<- interface{}) e
 		batches.add(&queueObj)
 	}
 
+	if i.evListener != nil {
+		i.sendEventsToListener(batches)
+	}
+
+	if i.dataSinks != nil {
+		i.sendEventsToSinks(batches)
+	}
+
 	for retIndex := range batches.groups {
 		sink <- batches.groups[retIndex]
 	}
@@ -121,6 +137,26 @@ func (i *EventsPipelineWorker) BuildRequest(data interface{}) (*http.Request, er
 	return req, nil
 }
 
+func (i *EventsPipelineWorker) sendEventsToListener(b *eventBatches) {
+	for _, group := range b.groups {
+		// events are copied, since the underlying slices will be reused as soon as they're posted to the BE
+		payload := make([]dtos.EventDTO, len(group.events))
+		copy(payload, group.events)
+		if err := i.evListener.Submit(payload, &group.metadata); err != nil {
+			i.logger.Error("error pushing events to listener: ", err.Error())
+		}
+	}
+}
+
+func (i *EventsPipelineWorker) sendEventsToSinks(b *eventBatches) {
+	for _, group := range b.groups {
+		// events are copied, since the underlying slices will be reused as soon as they're posted to the BE
+		if err := i.dataSinks.SubmitEvents(datasink.EventsFromDTOs(group.events, &group.metadata)); err != nil {
+			i.logger.Error("error pushing events to data sinks: ", err.Error())
+		}
+	}
+}
+
 type eventBatches struct {
 	groups eventsWithMetaSlice
 	index  metadataMap
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	elMock "github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener/mocks"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
@@ -125,6 +126,47 @@ func TestEventsMemoryIsProperlyReturned(t *testing.T) {
 	poolWrapper.validate(t)
 }
 
+func TestSendEventsToListener(t *testing.T) {
+	submitted := make(map[string][]dtos.EventDTO)
+	w, err := NewEventsWorker(&EventWorkerConfig{
+		EvictionMonitor: evcalc.New(1),
+		Logger:          logging.NewLogger(nil),
+		Storage:         mocks.MockEventStorage{},
+		URL:             "http://test",
+		Apikey:          "someApikey",
+		FetchSize:       100,
+		EventsListener: &elMock.EventBulkListenerMock{
+			SubmitCall: func(events []dtos.EventDTO, metadata *dtos.Metadata) error {
+				submitted[metadata.MachineName] = events
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Error("there should be no error. Got: ", err)
+	}
+
+	sinker := make(chan interface{}, 100)
+	w.Process(makeSerializedEvents(2, 3), sinker)
+	if len(submitted) != 2 {
+		t.Error("there should be 2 bulks submitted to the listener. Got: ", len(submitted))
+	}
+
+	for i := 0; i < 2; i++ {
+		ewm := (<-sinker).(eventsWithMetadata)
+		forListener := submitted[ewm.metadata.MachineName]
+		if len(forListener) != 3 || forListener[0].Key != "key_0" || forListener[2].Key != "key_2" {
+			t.Error("wrong events submitted to the listener: ", forListener)
+		}
+
+		// events handed to the listener must survive the worker's buffers being recycled
+		ewm.events[0].Key = "overwritten"
+		if forListener[0].Key != "key_0" {
+			t.Error("events submitted to the listener should be a copy")
+		}
+	}
+}
+
 func TestEventsIntegration(t *testing.T) {
 
 	var mtx sync.Mutex
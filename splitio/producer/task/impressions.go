@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
 
@@ -30,6 +31,7 @@ type ImpressionWorkerConfig struct {
 	Logger              logging.LoggerInterface
 	Storage             storage.ImpressionMultiSdkConsumer
 	ImpressionsListener impressionlistener.ImpressionBulkListener
+	DataSinks           datasink.Dispatcher
 	EvictionMonitor     evcalc.Monitor
 	URL                 string
 	Apikey              string
@@ -49,6 +51,7 @@ type ImpressionsPipelineWorker struct {
 	storage         storage.ImpressionMultiSdkConsumer
 	impManager      provisional.ImpressionManager
 	impListener     impressionlistener.ImpressionBulkListener
+	dataSinks       datasink.Dispatcher
 	evictionMonitor evcalc.Monitor
 
 	url       string
@@ -65,6 +68,7 @@ func NewImpressionWorker(cfg *ImpressionWorkerConfig) (*ImpressionsPipelineWorke
 		logger:          cfg.Logger,
 		storage:         cfg.Storage,
 		impListener:     cfg.ImpressionsListener,
+		dataSinks:       cfg.DataSinks,
 		impManager:      cfg.ImpressionManager,
 		url:             cfg.URL + "/testImpressions/bulk",
 		apikey:          cfg.Apikey,
@@ -119,6 +123,10 @@ func (i *ImpressionsPipelineWorker) Process(raws [][]byte, sink chan<- interface
 		i.sendImpressionsToListener(batches)
 	}
 
+	if i.dataSinks != nil {
+		i.sendImpressionsToSinks(batches)
+	}
+
 	for retIndex := range batches.groups {
 		sink <- batches.groups[retIndex]
 	}
@@ -178,6 +186,15 @@ func (i *ImpressionsPipelineWorker) sendImpressionsToListener(b *impBatches) {
 	}
 }
 
+func (i *ImpressionsPipelineWorker) sendImpressionsToSinks(b *impBatches) {
+	for _, group := range b.groups {
+		// impressions are copied, since the underlying slices will be reused as soon as imps are posted to the BE
+		if err := i.dataSinks.SubmitImpressions(datasink.ImpressionsFromDTOs(group.imps, &group.metadata)); err != nil {
+			i.logger.Error("error pushing impressions to data sinks: ", err.Error())
+		}
+	}
+}
+
 // This struct is used to maintain a slice of ready-to-post impression bulks, grouped by metadata,
 // and partitioned by bulk size. The index is used to access the latest bulk being built for a specific metadata.
 // This indirection helps avoid fetching the item from the map, updating it and storing it again which can be more expensive
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/evcalc"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	dsMock "github.com/splitio/split-synchronizer/v5/splitio/common/datasink/mocks"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
@@ -362,6 +364,45 @@ func TestSendImpressionsToListener(t *testing.T) {
 	mockListener.AssertExpectations(t)
 }
 
+func TestSendImpressionsToSinks(t *testing.T) {
+	var submitted []datasink.Impression
+	w, err := NewImpressionWorker(&ImpressionWorkerConfig{
+		EvictionMonitor: evcalc.New(1),
+		Logger:          logging.NewLogger(nil),
+		Storage:         mocks.MockImpressionStorage{},
+		URL:             "http://test",
+		Apikey:          "someApikey",
+		FetchSize:       100,
+		DataSinks: &dsMock.DispatcherMock{
+			SubmitImpressionsCall: func(imps []datasink.Impression) error {
+				submitted = append(submitted, imps...)
+				return nil
+			},
+		},
+		ImpressionManager: provisional.NewImpressionManager(strategy.NewOptimizedImpl(nil, nil, &inmemory.TelemetryStorage{}, false)),
+	})
+	assert.NoError(t, err)
+
+	batches := newImpBatches(w.pool)
+	batches.add(&dtos.ImpressionQueueObject{
+		Metadata:   dtos.Metadata{SDKVersion: "go-1.1.1", MachineIP: "1.2.3.4", MachineName: "test-machine"},
+		Impression: dtos.Impression{FeatureName: "test-feature", KeyName: "test-key", Treatment: "on", Time: 123, Label: "test-label", ChangeNumber: 456},
+	})
+
+	w.sendImpressionsToSinks(batches)
+	assert.Equal(t, []datasink.Impression{{
+		FeatureFlag:  "test-feature",
+		KeyName:      "test-key",
+		Treatment:    "on",
+		Time:         123,
+		Label:        "test-label",
+		ChangeNumber: 456,
+		SDKVersion:   "go-1.1.1",
+		MachineIP:    "1.2.3.4",
+		MachineName:  "test-machine",
+	}}, submitted)
+}
+
 func TestImpressionsIntegration(t *testing.T) {
 
 	var mtx sync.Mutex
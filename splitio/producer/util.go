@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	"github.com/splitio/split-synchronizer/v5/splitio/producer/conf"
+	"github.com/splitio/split-synchronizer/v5/splitio/producer/snapshots"
 	hcAppCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
 	hcServicesCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
 	"github.com/splitio/split-synchronizer/v5/splitio/util"
@@ -142,6 +144,37 @@ func sanitizeRedis(cfg *conf.Main, miscStorage *redis.MiscStorage, logger loggin
 	return nil
 }
 
+// seedFromSnapshot populates an empty storage with the contents of the configured snapshot
+func seedFromSnapshot(
+	cfg *conf.Main,
+	keys *snapshot.Keys,
+	splits storageCommon.SplitStorage,
+	ruleBased storageCommon.RuleBasedSegmentsStorage,
+	segments storageCommon.SegmentStorage,
+	logger logging.LoggerInterface,
+) error {
+	snap, err := snapshot.DecodeFromFile(cfg.Initialization.Snapshot, keys)
+	if err != nil {
+		return fmt.Errorf("error parsing snapshot file: %w", err)
+	}
+
+	currentHash := util.HashAPIKey(cfg.Apikey + cfg.FlagSpecVersion + strings.Join(cfg.FlagSetsFilter, "::"))
+	if snap.Meta().Hash != strconv.Itoa(int(currentHash)) {
+		return errors.New("snapshot cfg (apikey, version, flagsets) does not match the provided one")
+	}
+
+	if cn, _ := splits.ChangeNumber(); cn != -1 {
+		logger.Info("Storage already contains data. Snapshot will not be applied")
+		return nil
+	}
+
+	if err := snapshots.Seed(snap, splits, ruleBased, segments); err != nil {
+		return err
+	}
+	logger.Info("Storage seeded from snapshot at ", cfg.Initialization.Snapshot)
+	return nil
+}
+
 func getAppCounterConfigs(storage storageCommon.SplitStorage) (hcAppCounter.ThresholdConfig, hcAppCounter.ThresholdConfig, hcAppCounter.PeriodicConfig) {
 	splitsConfig := hcAppCounter.DefaultThresholdConfig("Splits")
 	segmentsConfig := hcAppCounter.DefaultThresholdConfig("Segments")
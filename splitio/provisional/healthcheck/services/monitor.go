@@ -63,6 +63,14 @@ func (m *MonitorImp) Stop() {
 	}
 }
 
+// AddCounter registers a counter fed externally (ie: by the components talking to a service)
+func (m *MonitorImp) AddCounter(c counter.ServicesCounterInterface) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.Counters = append(m.Counters, c)
+}
+
 // GetHealthStatus return services health
 func (m *MonitorImp) GetHealthStatus() HealthDto {
 	m.lock.RLock()
@@ -105,6 +105,20 @@ func (t *ActiveSegmentTracker) Update(name string, added int, removed int) {
 	t.activeSegmentMap[name] = current
 }
 
+// ReplaceAll discards the current counts and replaces them with the supplied ones
+func (t *ActiveSegmentTracker) ReplaceAll(counts map[string]int) {
+	fresh := make(map[string]int, len(counts)+1)
+	for name, count := range counts {
+		if count > 0 {
+			fresh[name] = count
+		}
+	}
+
+	t.mtx.Lock()
+	t.activeSegmentMap = fresh
+	t.mtx.Unlock()
+}
+
 // NamesAndCount returns a map of segment names to key count
 func (t *ActiveSegmentTracker) NamesAndCount() map[string]int {
 	t.mtx.RLock()
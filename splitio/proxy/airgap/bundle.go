@@ -0,0 +1,162 @@
+package airgap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	bundleVersion = 1
+	filePrefix    = "split.export."
+	fileSuffix    = ".bundle"
+	openFileName  = "split.export.open.ndjson" // records not yet sealed into a bundle
+	sealingSuffix = ".sealing"                 // appended to a bundle's name while the records for it are being sealed
+	uploadedDir   = "uploaded"
+)
+
+// Services records are uploaded to
+const (
+	ServiceEvents    = "events"    // impressions, impression counts & events
+	ServiceTelemetry = "telemetry" // sdk telemetry
+)
+
+// ErrChecksumMismatch is returned when the records of a bundle don't match the checksum computed when sealing it
+var ErrChecksumMismatch = errors.New("bundle checksum mismatch")
+
+// ErrUnsupportedVersion is returned when reading a bundle written in an unknown format
+var ErrUnsupportedVersion = errors.New("unsupported bundle version")
+
+// Record is a payload received from an sdk, along with everything needed to post it to Split as if it was forwarded live
+type Record struct {
+	Service     string            `json:"service"`
+	Path        string            `json:"path"`
+	Timestamp   int64             `json:"timestamp"`
+	SDKVersion  string            `json:"sdkVersion"`
+	MachineIP   string            `json:"machineIp"`
+	MachineName string            `json:"machineName"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     json.RawMessage   `json:"payload"`
+}
+
+// Manifest describes the contents of a sealed bundle
+type Manifest struct {
+	Version    int    `json:"version"`
+	ApikeyHash string `json:"apikeyHash"` // hash of the apikey of the proxy that recorded the data, checked before uploading
+	SealedAt   int64  `json:"sealedAt"`
+	Records    int    `json:"records"`
+	Checksum   string `json:"checksum"` // sha256 of the serialized records
+}
+
+// Bundle is a decoded export bundle
+// Bundle Layout (gzipped):
+//
+//	|manifest|\n|record|\n|record|\n...
+//
+// where the manifest & each record are JSON-encoded, and the checksum covers all the record lines
+type Bundle struct {
+	Manifest Manifest
+	Records  []Record
+}
+
+// Open reads & validates a sealed bundle
+func Open(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening bundle '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle '%s': %w", path, err)
+	}
+	defer gz.Close()
+
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle '%s': %w", path, err)
+	}
+
+	header, records, _ := bytes.Cut(raw, []byte("\n"))
+	var toRet Bundle
+	if err := json.Unmarshal(header, &toRet.Manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest of bundle '%s': %w", path, err)
+	}
+
+	if toRet.Manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, toRet.Manifest.Version)
+	}
+
+	if checksum(records) != toRet.Manifest.Checksum {
+		return nil, ErrChecksumMismatch
+	}
+
+	if toRet.Records, err = parseRecords(records); err != nil {
+		return nil, fmt.Errorf("error parsing records of bundle '%s': %w", path, err)
+	}
+	return &toRet, nil
+}
+
+// List returns the paths of the sealed bundles in a directory that haven't been uploaded yet, oldest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing bundles directory '%s': %w", dir, err)
+	}
+
+	var toRet []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), filePrefix) && strings.HasSuffix(entry.Name(), fileSuffix) {
+			toRet = append(toRet, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(toRet)
+	return toRet, nil
+}
+
+func encodeBundle(manifest Manifest, records []byte) ([]byte, error) {
+	header, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing manifest: %w", err)
+	}
+
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	gw.Write(header)
+	gw.Write([]byte("\n"))
+	gw.Write(records)
+	if err := gw.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing bundle: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func parseRecords(raw []byte) ([]Record, error) {
+	var toRet []Record
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, len(raw)+1) // a single record can be as large as the whole bundle
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		toRet = append(toRet, record)
+	}
+	return toRet, scanner.Err()
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package airgap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service"
+)
+
+// The fetchers below never reach Split servers. They answer from the local storages, which only change when a
+// snapshot is applied, so that the regular synchronization machinery can run unchanged.
+// A `since` older than the retained history is answered with the full payload (as if `since` were -1) rather than
+// with an empty diff, so that whoever asked ends up with the whole data set
+
+// SplitFetcher returns a feature flag fetcher backed by the local feature flag & rule-based segment storages
+func SplitFetcher(splits storage.ProxySplitStorage, rbs storage.ProxyRuleBasedSegmentsStorage) service.SplitFetcher {
+	return &splitFetcher{splits: splits, rbs: rbs}
+}
+
+// SegmentFetcher returns a segment fetcher backed by the local segment storage
+func SegmentFetcher(segments storage.ProxySegmentStorage) service.SegmentFetcher {
+	return &segmentFetcher{segments: segments}
+}
+
+// LargeSegmentFetcher returns a large segment fetcher that never yields changes
+func LargeSegmentFetcher() service.LargeSegmentFetcher {
+	return &largeSegmentFetcher{}
+}
+
+type splitFetcher struct {
+	splits storage.ProxySplitStorage
+	rbs    storage.ProxyRuleBasedSegmentsStorage
+}
+
+// Fetch implements service.SplitFetcher
+func (f *splitFetcher) Fetch(fetchOptions *service.FlagRequestParams) (dtos.FFResponse, error) {
+	sets, err := flagSetsOf(fetchOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := f.splits.ChangesSince(fetchOptions.ChangeNumber(), sets)
+	if errors.Is(err, storage.ErrSinceParamTooOld) {
+		flags, err = f.splits.ChangesSince(-1, sets)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feature flags from local storage: %w", err)
+	}
+
+	rbs, err := f.rbs.ChangesSince(fetchOptions.ChangeNumberRB())
+	if errors.Is(err, storage.ErrSinceParamTooOld) {
+		rbs, err = f.rbs.ChangesSince(-1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching rule-based segments from local storage: %w", err)
+	}
+
+	return &dtos.FFResponseV13{
+		SplitChanges: dtos.RuleChangesDTO{
+			FeatureFlags:      dtos.FeatureFlagsDTO{Since: flags.Since, Till: flags.Till, Splits: flags.Splits},
+			RuleBasedSegments: *rbs,
+		},
+	}, nil
+}
+
+// IsProxy implements service.SplitFetcher
+func (f *splitFetcher) IsProxy() bool {
+	return false
+}
+
+type segmentFetcher struct {
+	segments storage.ProxySegmentStorage
+}
+
+// Fetch implements service.SegmentFetcher
+func (f *segmentFetcher) Fetch(name string, fetchOptions *service.SegmentRequestParams) (*dtos.SegmentChangesDTO, error) {
+	payload, err := f.segments.ChangesSince(name, fetchOptions.ChangeNumber())
+	if errors.Is(err, storage.ErrSinceParamTooOld) {
+		payload, err = f.segments.ChangesSince(name, -1)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching segment '%s' from local storage: %w", name, err)
+	}
+	return payload, nil
+}
+
+type largeSegmentFetcher struct{}
+
+// Fetch implements service.LargeSegmentFetcher. A `304 Not Modified` is the only way of reporting no changes
+func (f *largeSegmentFetcher) Fetch(name string, fetchOptions *service.SegmentRequestParams) (*dtos.LargeSegmentRFDResponseDTO, error) {
+	return nil, &dtos.HTTPError{Code: http.StatusNotModified, Message: "air-gapped"}
+}
+
+// DownloadFile implements service.LargeSegmentFetcher
+func (f *largeSegmentFetcher) DownloadFile(name string, rfdResponseDTO *dtos.LargeSegmentRFDResponseDTO) (*dtos.LargeSegment, error) {
+	return nil, &dtos.HTTPError{Code: http.StatusNotModified, Message: "air-gapped"}
+}
+
+// flagSetsOf returns the flag sets requested in `fetchOptions`, which only exposes them as a query parameter
+func flagSetsOf(fetchOptions *service.FlagRequestParams) ([]string, error) {
+	request, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if err := fetchOptions.Apply(request); err != nil {
+		return nil, fmt.Errorf("error reading flag sets filter: %w", err)
+	}
+	if sets := request.URL.Query().Get("sets"); sets != "" {
+		return strings.Split(sets, ","), nil
+	}
+	return nil, nil
+}
+
+var _ service.SplitFetcher = (*splitFetcher)(nil)
+var _ service.SegmentFetcher = (*segmentFetcher)(nil)
+var _ service.LargeSegmentFetcher = (*largeSegmentFetcher)(nil)
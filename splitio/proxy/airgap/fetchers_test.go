@@ -0,0 +1,103 @@
+package airgap
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	"github.com/splitio/go-split-commons/v9/service"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFetcher(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	logger := logging.NewLogger(nil)
+
+	splits := storage.NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false, 0, 0)
+	splits.Update([]dtos.SplitDTO{{Name: "s1", ChangeNumber: 10, Status: "ACTIVE", Sets: []string{"a"}}, {Name: "s2", ChangeNumber: 10, Status: "ACTIVE"}}, nil, 10)
+	splits.Update([]dtos.SplitDTO{{Name: "s3", ChangeNumber: 20, Status: "ACTIVE", Sets: []string{"a"}}}, nil, 20)
+	rbs := storage.NewProxyRuleBasedSegmentsStorage(dbw, logger, false)
+	assert.Nil(t, rbs.Update([]dtos.RuleBasedSegmentDTO{{Name: "rb1", ChangeNumber: 10, Status: "ACTIVE"}}, nil, 10))
+	fetcher := SplitFetcher(splits, rbs)
+
+	// up to date, nothing to sync
+	ff, err := fetcher.Fetch(service.MakeFlagRequestParams().WithChangeNumber(20).WithChangeNumberRB(10))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), ff.FFSince())
+	assert.Equal(t, int64(20), ff.FFTill())
+	assert.Equal(t, int64(10), ff.RBTill())
+	assert.Empty(t, ff.FeatureFlags())
+	assert.Empty(t, ff.RuleBasedSegments())
+
+	// within the history, a regular diff
+	ff, err = fetcher.Fetch(service.MakeFlagRequestParams().WithChangeNumber(10).WithChangeNumberRB(10))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(20), ff.FFTill())
+	assert.Len(t, ff.FeatureFlags(), 1)
+	assert.Equal(t, "s3", ff.FeatureFlags()[0].Name)
+
+	// older than the history, the whole data set as if since were -1
+	ff, err = fetcher.Fetch(service.MakeFlagRequestParams().WithChangeNumber(5).WithChangeNumberRB(5))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), ff.FFSince())
+	assert.Equal(t, int64(20), ff.FFTill())
+	assert.Len(t, ff.FeatureFlags(), 3)
+	assert.Equal(t, int64(-1), ff.RBSince())
+	assert.Equal(t, int64(10), ff.RBTill())
+	assert.Len(t, ff.RuleBasedSegments(), 1)
+
+	// flag sets are honored
+	ff, err = fetcher.Fetch(service.MakeFlagRequestParams().WithChangeNumber(5).WithChangeNumberRB(10).WithFlagSetsFilter("a"))
+	assert.Nil(t, err)
+	names := make([]string, 0, len(ff.FeatureFlags()))
+	for _, split := range ff.FeatureFlags() {
+		names = append(names, split.Name)
+	}
+	assert.ElementsMatch(t, []string{"s1", "s3"}, names)
+}
+
+func TestSegmentFetcher(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	segments := storage.NewProxySegmentStorage(dbw, logging.NewLogger(nil), false, 100*time.Millisecond)
+	assert.Nil(t, segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 1000))
+	assert.Nil(t, segments.Update("s1", set.NewSet(), set.NewSet("k1"), 1050))
+	assert.Nil(t, segments.Update("s1", set.NewSet("k3"), set.NewSet(), 1200))
+	segments.CompactRemovedKeys()
+	fetcher := SegmentFetcher(segments)
+
+	// up to date, nothing to sync
+	segment, err := fetcher.Fetch("s1", service.MakeSegmentRequestParams().WithChangeNumber(1200))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1200), segment.Since)
+	assert.Equal(t, int64(1200), segment.Till)
+	assert.Empty(t, segment.Added)
+	assert.Empty(t, segment.Removed)
+
+	// older than the retained tombstones, every key as if since were -1
+	segment, err = fetcher.Fetch("s1", service.MakeSegmentRequestParams().WithChangeNumber(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), segment.Since)
+	assert.Equal(t, int64(1200), segment.Till)
+	assert.Equal(t, []string{"k2", "k3"}, segment.Added)
+	assert.Empty(t, segment.Removed)
+
+	_, err = fetcher.Fetch("s2", service.MakeSegmentRequestParams().WithChangeNumber(-1))
+	assert.ErrorIs(t, err, storage.ErrSegmentNotFound)
+}
+
+func TestLargeSegmentFetcherNeverYieldsChanges(t *testing.T) {
+	_, err := LargeSegmentFetcher().Fetch("ls1", service.MakeSegmentRequestParams())
+	httpErr, ok := err.(*dtos.HTTPError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotModified, httpErr.Code)
+}
@@ -0,0 +1,125 @@
+package airgap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const progressSuffix = ".progress"
+
+// ErrApikeyMismatch is returned when uploading a bundle recorded by a proxy configured with a different apikey
+var ErrApikeyMismatch = errors.New("bundle was recorded with a different apikey")
+
+// ErrUnknownService is returned when a bundle contains records for a service with no recorder configured
+var ErrUnknownService = errors.New("no recorder configured for service")
+
+// Uploader posts the records of sealed bundles to Split, with the metadata of the sdks that originally sent them
+type Uploader struct {
+	logger     logging.LoggerInterface
+	recorders  map[string]tasks.RawRecorder
+	apikeyHash string
+}
+
+// NewUploader constructs an uploader. Recorders are indexed by service (ServiceEvents, ServiceTelemetry)
+func NewUploader(logger logging.LoggerInterface, recorders map[string]tasks.RawRecorder, apikeyHash string) *Uploader {
+	return &Uploader{logger: logger, recorders: recorders, apikeyHash: apikeyHash}
+}
+
+// UploadAll uploads every pending bundle in a directory, oldest first, and returns how many were uploaded.
+// It stops at the first failure, so that data reaches Split in the same order it was recorded
+func (u *Uploader) UploadAll(dir string) (int, error) {
+	bundles, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	for idx, path := range bundles {
+		if err := u.Upload(path); err != nil {
+			return idx, fmt.Errorf("error uploading bundle '%s': %w", path, err)
+		}
+	}
+	return len(bundles), nil
+}
+
+// Upload posts the records of a bundle in order. Progress is persisted next to the bundle after each posted record,
+// so that an upload that fails or is interrupted (ie: the process is killed) can be resumed without posting records
+// twice. Fully uploaded bundles are moved to the `uploaded` subdirectory
+func (u *Uploader) Upload(path string) error {
+	bundle, err := Open(path)
+	if err != nil {
+		return err
+	}
+
+	if bundle.Manifest.ApikeyHash != u.apikeyHash {
+		return ErrApikeyMismatch
+	}
+
+	progressPath := path + progressSuffix
+	done, err := readProgress(progressPath)
+	if err != nil {
+		return err
+	}
+
+	for idx := done; idx < len(bundle.Records); idx++ {
+		record := &bundle.Records[idx]
+		recorder, ok := u.recorders[record.Service]
+		if !ok {
+			return fmt.Errorf("%w '%s'", ErrUnknownService, record.Service)
+		}
+
+		metadata := dtos.Metadata{SDKVersion: record.SDKVersion, MachineIP: record.MachineIP, MachineName: record.MachineName}
+		if err := recorder.RecordRaw(record.Path, record.Payload, metadata, record.Headers); err != nil {
+			return fmt.Errorf("error posting record #%d: %w", idx, err)
+		}
+
+		// if progress can't be saved, stop before posting anything else that could be posted twice on resume
+		if err := writeProgress(progressPath, idx+1); err != nil {
+			return fmt.Errorf("error saving upload progress after record #%d: %w", idx, err)
+		}
+	}
+
+	target := filepath.Join(filepath.Dir(path), uploadedDir)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("error creating directory for uploaded bundles: %w", err)
+	}
+	if err := os.Rename(path, filepath.Join(target, filepath.Base(path))); err != nil {
+		return fmt.Errorf("error moving uploaded bundle: %w", err)
+	}
+	os.Remove(progressPath)
+	u.logger.Info(fmt.Sprintf("bundle '%s' uploaded (%d records)", path, len(bundle.Records)))
+	return nil
+}
+
+// writeProgress replaces the progress file atomically, so that a crash never leaves it truncated
+func writeProgress(path string, done int) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(done)), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readProgress(path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error reading upload progress: %w", err)
+	}
+
+	done, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid upload progress in '%s': %w", path, err)
+	}
+	return done, nil
+}
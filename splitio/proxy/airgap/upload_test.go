@@ -0,0 +1,126 @@
+package airgap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type posted struct {
+	path     string
+	payload  string
+	metadata dtos.Metadata
+	headers  map[string]string
+}
+
+type recorderMock struct {
+	posts       []posted
+	failing     bool
+	interrupted bool
+}
+
+func (r *recorderMock) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	if r.interrupted {
+		panic("process killed")
+	}
+	if r.failing {
+		return errors.New("something")
+	}
+	r.posts = append(r.posts, posted{path: url, payload: string(data), metadata: metadata, headers: extraHeaders})
+	return nil
+}
+
+func TestUploadAll(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, MaxRecords: 2, ApikeyHash: "123"})
+	assert.Nil(t, err)
+
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "host"}
+	writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[1]`), metadata, nil)
+	writer.Recorder(ServiceTelemetry).RecordRaw("/metrics/usage", []byte(`[2]`), metadata, nil)
+	writer.Recorder(ServiceEvents).RecordRaw("/testImpressions/count", []byte(`[3]`), metadata, map[string]string{"a": "b"})
+	writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[4]`), metadata, nil)
+	assert.Equal(t, 2, writer.Pending())
+
+	events := &recorderMock{}
+	telemetry := &recorderMock{failing: true}
+	uploader := NewUploader(logging.NewLogger(nil), map[string]tasks.RawRecorder{ServiceEvents: events, ServiceTelemetry: telemetry}, "123")
+
+	// the first bundle fails halfway through, and nothing else is attempted
+	uploaded, err := uploader.UploadAll(dir)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, uploaded)
+	assert.Equal(t, []posted{{path: "/events/bulk", payload: "[1]", metadata: metadata}}, events.posts)
+	assert.Equal(t, 2, writer.Pending())
+
+	// resuming doesn't post the same record twice
+	telemetry.failing = false
+	uploaded, err = uploader.UploadAll(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, uploaded)
+	assert.Equal(t, []posted{{path: "/metrics/usage", payload: "[2]", metadata: metadata}}, telemetry.posts)
+	assert.Equal(t, []posted{
+		{path: "/events/bulk", payload: "[1]", metadata: metadata},
+		{path: "/testImpressions/count", payload: "[3]", metadata: metadata, headers: map[string]string{"a": "b"}},
+		{path: "/events/bulk", payload: "[4]", metadata: metadata},
+	}, events.posts)
+
+	assert.Equal(t, 0, writer.Pending())
+	archived, err := List(filepath.Join(dir, uploadedDir))
+	assert.Nil(t, err)
+	assert.Len(t, archived, 2)
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*"+progressSuffix))
+	assert.Empty(t, leftovers)
+}
+
+func TestUploadApikeyMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[1]`), dtos.Metadata{}, nil)
+	path, err := writer.Seal()
+	assert.Nil(t, err)
+
+	events := &recorderMock{}
+	uploader := NewUploader(logging.NewLogger(nil), map[string]tasks.RawRecorder{ServiceEvents: events}, "456")
+	assert.ErrorIs(t, uploader.Upload(path), ErrApikeyMismatch)
+	assert.Empty(t, events.posts)
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+}
+
+func TestUploadResumesAfterInterruption(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[1]`), dtos.Metadata{}, nil)
+	writer.Recorder(ServiceTelemetry).RecordRaw("/metrics/usage", []byte(`[2]`), dtos.Metadata{}, nil)
+	writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[3]`), dtos.Metadata{}, nil)
+	path, err := writer.Seal()
+	assert.Nil(t, err)
+
+	events := &recorderMock{}
+	telemetry := &recorderMock{interrupted: true}
+	uploader := NewUploader(logging.NewLogger(nil), map[string]tasks.RawRecorder{ServiceEvents: events, ServiceTelemetry: telemetry}, "123")
+
+	// the upload is interrupted without returning an error, so progress must already be on disk
+	assert.Panics(t, func() { uploader.Upload(path) })
+	done, err := readProgress(path + progressSuffix)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, done)
+
+	telemetry.interrupted = false
+	assert.Nil(t, uploader.Upload(path))
+	assert.Equal(t, []posted{
+		{path: "/events/bulk", payload: "[1]", metadata: dtos.Metadata{}},
+		{path: "/events/bulk", payload: "[3]", metadata: dtos.Metadata{}},
+	}, events.posts)
+	assert.Len(t, telemetry.posts, 1)
+}
@@ -0,0 +1,252 @@
+package airgap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// ErrNoDirectory is returned when attempting to construct a writer without a target directory
+var ErrNoDirectory = errors.New("a directory is required")
+
+// WriterConfig bundles the options of a bundle writer
+type WriterConfig struct {
+	Directory      string
+	SealPeriodSecs int
+	MaxRecords     int    // a bundle is sealed as soon as it reaches this many records (0 means no limit)
+	ApikeyHash     string // stamped on every bundle, so that it's not uploaded to a different environment
+}
+
+// Writer appends the data received from sdks to an open file, which is periodically sealed into an immutable bundle.
+// The open file survives restarts: records left in it by a previous run are sealed when the writer is constructed
+type Writer struct {
+	logger  logging.LoggerInterface
+	config  WriterConfig
+	mutex   sync.Mutex
+	records int // records in the open file
+	task    *asynctask.AsyncTask
+}
+
+// NewWriter constructs a bundle writer, creating the target directory if necessary
+func NewWriter(logger logging.LoggerInterface, config WriterConfig) (*Writer, error) {
+	if config.Directory == "" {
+		return nil, ErrNoDirectory
+	}
+
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, fmt.Errorf("error creating bundles directory '%s': %w", config.Directory, err)
+	}
+
+	toRet := &Writer{logger: logger, config: config}
+	if path, err := toRet.Seal(); err != nil {
+		return nil, fmt.Errorf("error sealing records from a previous run: %w", err)
+	} else if path != "" {
+		logger.Info("records from a previous run sealed into ", path)
+	}
+
+	toRet.task = asynctask.NewAsyncTask("bundle-sealer", func(logging.LoggerInterface) error {
+		_, err := toRet.Seal()
+		return err
+	}, config.SealPeriodSecs, nil, nil, logger)
+	return toRet, nil
+}
+
+// Start begins sealing bundles periodically
+func (w *Writer) Start() {
+	w.task.Start()
+}
+
+// Stop stops the periodic task
+func (w *Writer) Stop(blocking bool) error {
+	return w.task.Stop(blocking)
+}
+
+// Recorder returns a raw recorder that appends the payloads it receives to the open bundle, tagged with the service
+// they should be uploaded to
+func (w *Writer) Recorder(service string) tasks.RawRecorder {
+	return &recorder{writer: w, service: service}
+}
+
+// Pending returns the number of sealed bundles waiting to be uploaded
+func (w *Writer) Pending() int {
+	bundles, err := List(w.config.Directory)
+	if err != nil {
+		w.logger.Error("error listing export bundles: ", err)
+		return 0
+	}
+	return len(bundles)
+}
+
+// Seal moves the records in the open file into a new bundle, returning its path.
+// If there are no records, no bundle is written and an empty path is returned
+func (w *Writer) Seal() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err := w.resumeSealing(); err != nil {
+		return "", err
+	}
+
+	// the timestamp is zero-padded by nature (19 digits until 2286), so names sort chronologically
+	path := filepath.Join(w.config.Directory, fmt.Sprintf("%s%d%s", filePrefix, time.Now().UnixNano(), fileSuffix))
+
+	// the open file is first renamed after the bundle it's sealed into, so that its records are never in two places
+	// at once (see resumeSealing)
+	if err := os.Rename(filepath.Join(w.config.Directory, openFileName), path+sealingSuffix); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error moving open bundle aside for sealing: %w", err)
+	}
+	w.records = 0
+
+	sealed, err := w.sealInto(path)
+	if err != nil || !sealed {
+		return "", err
+	}
+	w.logger.Debug("export bundle sealed into ", path)
+	return path, nil
+}
+
+// resumeSealing finishes sealing the files left behind by a crash in the middle of Seal. Those whose bundle
+// was already written are just removed, so that their records are never uploaded twice
+func (w *Writer) resumeSealing() error {
+	entries, err := os.ReadDir(w.config.Directory)
+	if err != nil {
+		return fmt.Errorf("error listing bundles directory '%s': %w", w.config.Directory, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileSuffix+sealingSuffix) {
+			continue
+		}
+
+		path := filepath.Join(w.config.Directory, strings.TrimSuffix(entry.Name(), sealingSuffix))
+		if exists(path) || exists(filepath.Join(w.config.Directory, uploadedDir, filepath.Base(path))) {
+			if err := os.Remove(path + sealingSuffix); err != nil {
+				return fmt.Errorf("error removing records already sealed into '%s': %w", path, err)
+			}
+			continue
+		}
+
+		if sealed, err := w.sealInto(path); err != nil {
+			return err
+		} else if sealed {
+			w.logger.Info("records from an interrupted seal sealed into ", path)
+		}
+	}
+	return nil
+}
+
+// sealInto writes the records moved aside for sealing into the bundle at `path`, returning whether there were any
+func (w *Writer) sealInto(path string) (bool, error) {
+	sealingPath := path + sealingSuffix
+	raw, err := os.ReadFile(sealingPath)
+	if err != nil {
+		return false, fmt.Errorf("error reading records to seal: %w", err)
+	}
+
+	// discard a trailing partial record, which can only be the result of a crash
+	raw = raw[:bytes.LastIndexByte(raw, '\n')+1]
+	if len(raw) == 0 {
+		return false, os.Remove(sealingPath)
+	}
+
+	encoded, err := encodeBundle(Manifest{
+		Version:    bundleVersion,
+		ApikeyHash: w.config.ApikeyHash,
+		SealedAt:   time.Now().UnixMilli(),
+		Records:    bytes.Count(raw, []byte("\n")),
+		Checksum:   checksum(raw),
+	}, raw)
+	if err != nil {
+		return false, err
+	}
+
+	// write to a temporary file first, so that a crash never leaves a truncated bundle behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encoded, 0644); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("error writing bundle: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("error renaming bundle: %w", err)
+	}
+
+	if err := os.Remove(sealingPath); err != nil {
+		return false, fmt.Errorf("error removing records after sealing them into '%s': %w", path, err)
+	}
+	return true, nil
+}
+
+func (w *Writer) append(record *Record) error {
+	serialized, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing record: %w", err)
+	}
+
+	w.mutex.Lock()
+	path := filepath.Join(w.config.Directory, openFileName)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		w.mutex.Unlock()
+		return fmt.Errorf("error opening '%s': %w", path, err)
+	}
+	_, err = f.Write(append(serialized, '\n'))
+	f.Close()
+	if err != nil {
+		w.mutex.Unlock()
+		return fmt.Errorf("error writing to '%s': %w", path, err)
+	}
+	w.records++
+	full := w.config.MaxRecords > 0 && w.records >= w.config.MaxRecords
+	w.mutex.Unlock()
+
+	if full {
+		if _, err := w.Seal(); err != nil {
+			w.logger.Error("error sealing full export bundle: ", err)
+		}
+	}
+	return nil
+}
+
+type recorder struct {
+	writer  *Writer
+	service string
+}
+
+// RecordRaw implements tasks.RawRecorder
+func (r *recorder) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	if !json.Valid(data) {
+		return fmt.Errorf("payload for '%s' is not valid json", url)
+	}
+
+	return r.writer.append(&Record{
+		Service:     r.service,
+		Path:        url,
+		Timestamp:   time.Now().UnixMilli(),
+		SDKVersion:  metadata.SDKVersion,
+		MachineIP:   metadata.MachineIP,
+		MachineName: metadata.MachineName,
+		Headers:     extraHeaders,
+		Payload:     json.RawMessage(data),
+	})
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
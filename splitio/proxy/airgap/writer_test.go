@@ -0,0 +1,139 @@
+package airgap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterSealsBundles(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, MaxRecords: 3, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, writer.Pending())
+
+	path, err := writer.Seal()
+	assert.Nil(t, err)
+	assert.Equal(t, "", path) // nothing recorded yet
+
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "host"}
+	events := writer.Recorder(ServiceEvents)
+	telemetry := writer.Recorder(ServiceTelemetry)
+	assert.Nil(t, events.RecordRaw("/testImpressions/bulk", []byte(`[{"f":"f1"}]`), metadata, map[string]string{"SplitSDKImpressionsMode": "debug"}))
+	assert.Nil(t, telemetry.RecordRaw("/metrics/usage", []byte(`{"a":1}`), metadata, nil))
+	assert.NotNil(t, events.RecordRaw("/events/bulk", []byte(`not json`), metadata, nil))
+	assert.Equal(t, 0, writer.Pending())
+
+	// the third record fills the bundle
+	assert.Nil(t, events.RecordRaw("/events/bulk", []byte(`[{"e":"e1"}]`), metadata, nil))
+	assert.Equal(t, 1, writer.Pending())
+
+	bundles, err := List(dir)
+	assert.Nil(t, err)
+	bundle, err := Open(bundles[0])
+	assert.Nil(t, err)
+	assert.Equal(t, 1, bundle.Manifest.Version)
+	assert.Equal(t, "123", bundle.Manifest.ApikeyHash)
+	assert.Equal(t, 3, bundle.Manifest.Records)
+	assert.Len(t, bundle.Records, 3)
+
+	first := bundle.Records[0]
+	assert.Equal(t, ServiceEvents, first.Service)
+	assert.Equal(t, "/testImpressions/bulk", first.Path)
+	assert.Equal(t, "go-1.2.3", first.SDKVersion)
+	assert.Equal(t, "1.2.3.4", first.MachineIP)
+	assert.Equal(t, "host", first.MachineName)
+	assert.Equal(t, map[string]string{"SplitSDKImpressionsMode": "debug"}, first.Headers)
+	assert.JSONEq(t, `[{"f":"f1"}]`, string(first.Payload))
+	assert.Equal(t, ServiceTelemetry, bundle.Records[1].Service)
+	assert.Equal(t, "/events/bulk", bundle.Records[2].Path)
+
+	// records are sealed periodically even if the bundle is not full
+	assert.Nil(t, events.RecordRaw("/events/bulk", []byte(`[{"e":"e2"}]`), metadata, nil))
+	path, err = writer.Seal()
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", path)
+	assert.Equal(t, 2, writer.Pending())
+	bundles, _ = List(dir)
+	assert.Equal(t, path, bundles[1])
+}
+
+func TestWriterRecoversOpenBundle(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[]`), dtos.Metadata{}, nil))
+
+	// simulate a crash in the middle of a write
+	f, err := os.OpenFile(filepath.Join(dir, openFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	f.Write([]byte(`{"service":"ev`))
+	f.Close()
+
+	// records left behind are sealed on startup
+	writer, err = NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, writer.Pending())
+	bundles, _ := List(dir)
+	bundle, err := Open(bundles[0])
+	assert.Nil(t, err)
+	assert.Len(t, bundle.Records, 1)
+	_, err = os.Stat(filepath.Join(dir, openFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriterResumesInterruptedSeal(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Recorder(ServiceEvents).RecordRaw("/events/bulk", []byte(`[]`), dtos.Metadata{}, nil))
+	raw, err := os.ReadFile(filepath.Join(dir, openFileName))
+	assert.Nil(t, err)
+	sealed, err := writer.Seal()
+	assert.Nil(t, err)
+
+	// simulate a crash after writing the bundle but before removing the records sealed into it
+	assert.Nil(t, os.WriteFile(sealed+sealingSuffix, raw, 0644))
+
+	// simulate a crash before writing the bundle
+	interrupted := filepath.Join(dir, filePrefix+"1"+fileSuffix)
+	assert.Nil(t, os.WriteFile(interrupted+sealingSuffix, raw, 0644))
+
+	// and one after writing a bundle that has been uploaded since
+	uploaded := filepath.Join(dir, filePrefix+"2"+fileSuffix)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, uploadedDir), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, uploadedDir, filepath.Base(uploaded)), []byte{}, 0644))
+	assert.Nil(t, os.WriteFile(uploaded+sealingSuffix, raw, 0644))
+
+	// only the records that never made it into a bundle are sealed on startup
+	writer, err = NewWriter(logging.NewLogger(nil), WriterConfig{Directory: dir, SealPeriodSecs: 3600, ApikeyHash: "123"})
+	assert.Nil(t, err)
+	bundles, _ := List(dir)
+	assert.Equal(t, []string{interrupted, sealed}, bundles)
+	bundle, err := Open(interrupted)
+	assert.Nil(t, err)
+	assert.Len(t, bundle.Records, 1)
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*"+sealingSuffix))
+	assert.Empty(t, leftovers)
+}
+
+func TestOpenRejectsTamperedBundles(t *testing.T) {
+	dir := t.TempDir()
+	records := []byte(`{"service":"events","path":"/events/bulk","payload":[]}` + "\n")
+	encoded, err := encodeBundle(Manifest{Version: bundleVersion, Records: 1, Checksum: checksum([]byte("something else"))}, records)
+	assert.Nil(t, err)
+	path := filepath.Join(dir, filePrefix+"1"+fileSuffix)
+	assert.Nil(t, os.WriteFile(path, encoded, 0644))
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	encoded, _ = encodeBundle(Manifest{Version: 7, Records: 1, Checksum: checksum(records)}, records)
+	assert.Nil(t, os.WriteFile(path, encoded, 0644))
+	_, err = Open(path)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
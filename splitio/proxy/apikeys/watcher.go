@@ -0,0 +1,115 @@
+package apikeys
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"golang.org/x/exp/slices"
+)
+
+// ErrNoAPIKeys is returned when a key source doesn't contain any apikey
+var ErrNoAPIKeys = errors.New("no apikeys found")
+
+// ErrManagedByFile is returned when trying to replace apikeys through the admin api while they're read from a watched file
+var ErrManagedByFile = errors.New("client apikeys are managed by a watched file & cannot be replaced through the admin api")
+
+// ReadFile parses a file containing one apikey per line. Blank lines & lines starting with '#' are ignored
+func ReadFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening apikeys file: %w", err)
+	}
+	defer file.Close()
+
+	var toRet []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		toRet = append(toRet, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading apikeys file: %w", err)
+	}
+
+	if len(toRet) == 0 {
+		return nil, ErrNoAPIKeys
+	}
+
+	return normalize(toRet), nil
+}
+
+// FileWatcher periodically reads an apikeys file and invokes a callback whenever the set of keys changes
+type FileWatcher struct {
+	logger   logging.LoggerInterface
+	path     string
+	current  []string
+	onChange func([]string)
+	task     *asynctask.AsyncTask
+}
+
+// NewFileWatcher constructs a watcher for the supplied file. `initial` should contain the keys currently in use,
+// so that the callback is only invoked when the file contents differ
+func NewFileWatcher(
+	logger logging.LoggerInterface,
+	path string,
+	periodSecs int,
+	initial []string,
+	onChange func([]string),
+) *FileWatcher {
+	toRet := &FileWatcher{
+		logger:   logger,
+		path:     path,
+		current:  normalize(initial),
+		onChange: onChange,
+	}
+	toRet.task = asynctask.NewAsyncTask("apikeys-file-watcher", toRet.check, periodSecs, nil, nil, logger)
+	return toRet
+}
+
+// Start begins watching the file
+func (w *FileWatcher) Start() {
+	w.task.Start()
+}
+
+// Stop stops watching the file
+func (w *FileWatcher) Stop(blocking bool) error {
+	return w.task.Stop(blocking)
+}
+
+// IsRunning returns whether the watcher is active
+func (w *FileWatcher) IsRunning() bool {
+	return w.task.IsRunning()
+}
+
+func (w *FileWatcher) check(logger logging.LoggerInterface) error {
+	keys, err := ReadFile(w.path)
+	if err != nil {
+		// keep serving with the current keys rather than locking every sdk out
+		w.logger.Error(fmt.Sprintf("error reloading apikeys from %s. Current keys will be kept: %s", w.path, err))
+		return nil
+	}
+
+	if slices.Equal(keys, w.current) {
+		return nil
+	}
+
+	w.logger.Info(fmt.Sprintf("apikeys file %s changed. Reloading %d keys", w.path, len(keys)))
+	w.current = keys
+	w.onChange(keys)
+	return nil
+}
+
+func normalize(keys []string) []string {
+	toRet := slices.Clone(keys)
+	slices.Sort(toRet)
+	return slices.Compact(toRet)
+}
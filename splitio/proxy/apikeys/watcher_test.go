@@ -0,0 +1,48 @@
+package apikeys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apikeys")
+	assert.Nil(t, os.WriteFile(path, []byte("# current keys\nkey2\n\n  key1  \nkey2\n"), 0600))
+
+	keys, err := ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"key1", "key2"}, keys)
+
+	assert.Nil(t, os.WriteFile(path, []byte("# nothing here\n"), 0600))
+	_, err = ReadFile(path)
+	assert.ErrorIs(t, err, ErrNoAPIKeys)
+
+	_, err = ReadFile(filepath.Join(t.TempDir(), "nonexistant"))
+	assert.NotNil(t, err)
+}
+
+func TestFileWatcherCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apikeys")
+	assert.Nil(t, os.WriteFile(path, []byte("key1\nkey2\n"), 0600))
+
+	var calls [][]string
+	watcher := NewFileWatcher(logging.NewLogger(nil), path, 1, []string{"key2", "key1"}, func(keys []string) {
+		calls = append(calls, keys)
+	})
+
+	assert.Nil(t, watcher.check(nil))
+	assert.Empty(t, calls) // unchanged
+
+	assert.Nil(t, os.WriteFile(path, []byte("key3\n"), 0600))
+	assert.Nil(t, watcher.check(nil))
+	assert.Equal(t, [][]string{{"key3"}}, calls)
+
+	assert.Nil(t, os.WriteFile(path, []byte(""), 0600))
+	assert.Nil(t, watcher.check(nil))
+	assert.Equal(t, [][]string{{"key3"}}, calls) // empty files are ignored
+}
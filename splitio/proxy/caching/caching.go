@@ -3,6 +3,8 @@ package caching
 import (
 	"strings"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/compression"
+
 	"github.com/splitio/gincache"
 	"github.com/splitio/go-split-commons/v9/dtos"
 
@@ -26,7 +28,12 @@ const (
 	// AuthSurrogate key (having push disabled, it's safe to cache this and return it on all requests)
 	AuthSurrogate = "au"
 
+	// FlagSetScopeContextKey is the gin context key used to store the flag set scope of the client key issuing the request
+	FlagSetScopeContextKey = "fsscope"
+
 	segmentPrefix = "se::"
+
+	splitChangesPath = "/api/splitChanges"
 )
 
 const cacheSize = 1000000
@@ -42,12 +49,13 @@ func MakeSurrogateForMySegments(mysegments []dtos.MySegmentDTO) []string {
 	return nil
 }
 
-// MakeMySegmentsEntry create a cache entry key for mysegments
+// MakeMySegmentsEntry create a cache entry key for mysegments (one per content encoding)
 func MakeMySegmentsEntries(key string) []string {
-	return []string{
-		"/api/mySegments/" + key,
-		"gzip::/api/mySegments/" + key,
+	toRet := []string{"/api/mySegments/" + key}
+	for _, encoding := range compression.Supported {
+		toRet = append(toRet, encoding+"::/api/mySegments/"+key)
 	}
+	return toRet
 }
 
 // MakeProxyCache creates and configures a split-proxy-ready cache
@@ -64,15 +72,22 @@ func MakeProxyCache() *gincache.Middleware {
 }
 
 func keyFactoryFN(ctx *gin.Context) string {
+	// each encoding is cached separately, so that responses are compressed only once
 	var encodingPrefix string
-	if strings.Contains(ctx.Request.Header.Get("Accept-Encoding"), "gzip") {
-		encodingPrefix = "gzip::"
+	if encoding := compression.Negotiate(ctx.Request.Header.Get("Accept-Encoding")); encoding != compression.Identity {
+		encodingPrefix = encoding + "::"
 	}
 
 	if strings.HasPrefix(ctx.Request.URL.Path, "/api/auth") || strings.HasPrefix(ctx.Request.URL.Path, "/api/v2/auth") {
-		// For auth requests, since we don't support streaming yet, we only need a single entry in the table,
-		// so we strip the query-string which contains the user-list
+		// Auth responses are only cached when streaming is disabled, in which case we only need a single entry
+		// in the table, so we strip the query-string which contains the user-list
 		return encodingPrefix + ctx.Request.URL.Path
 	}
-	return encodingPrefix + ctx.Request.URL.Path + ctx.Request.URL.RawQuery
+
+	key := encodingPrefix + ctx.Request.URL.Path + ctx.Request.URL.RawQuery
+	if scope := ctx.GetString(FlagSetScopeContextKey); scope != "" && ctx.Request.URL.Path == splitChangesPath {
+		// client keys restricted to specific flag sets get different splitChanges responses for the same query
+		key += "::scope=" + scope
+	}
+	return key
 }
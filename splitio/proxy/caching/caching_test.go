@@ -23,17 +23,64 @@ func TestCacheKeysDoNotOverlap(t *testing.T) {
 	assert.NotEqual(t, keyFactoryFN(c1), keyFactoryFN(c2))
 }
 
+func TestCacheKeysFlagSetScopes(t *testing.T) {
+	url1, _ := url.Parse("http://proxy.split.io/api/splitChanges?since=-1")
+	c1 := &gin.Context{Request: &http.Request{URL: url1}}
+	c2 := &gin.Context{Request: &http.Request{URL: url1}}
+	c2.Set(FlagSetScopeContextKey, "0")
+	c3 := &gin.Context{Request: &http.Request{URL: url1}}
+	c3.Set(FlagSetScopeContextKey, "1")
+
+	assert.NotEqual(t, keyFactoryFN(c1), keyFactoryFN(c2))
+	assert.NotEqual(t, keyFactoryFN(c2), keyFactoryFN(c3))
+
+	// mySegments keys must remain untouched, since they're evicted individually
+	url2, _ := url.Parse("http://proxy.split.io/api/mySegments/k1")
+	c4 := &gin.Context{Request: &http.Request{URL: url2}}
+	c4.Set(FlagSetScopeContextKey, "0")
+	assert.Equal(t, MakeMySegmentsEntries("k1")[0], keyFactoryFN(c4))
+}
+
 func TestSegmentSurrogates(t *testing.T) {
 	assert.Equal(t, segmentPrefix+"segment1", MakeSurrogateForSegmentChanges("segment1"))
 	assert.NotEqual(t, MakeSurrogateForSegmentChanges("segment1"), MakeSurrogateForSegmentChanges("segment2"))
 }
 
 func TestMySegmentKeyGeneration(t *testing.T) {
-	entries := MakeMySegmentsEntries("k1")
-	assert.Equal(t, "/api/mySegments/k1", entries[0])
-	assert.Equal(t, "gzip::/api/mySegments/k1", entries[1])
+	assert.Equal(t, []string{
+		"/api/mySegments/k1",
+		"br::/api/mySegments/k1",
+		"zstd::/api/mySegments/k1",
+		"gzip::/api/mySegments/k1",
+	}, MakeMySegmentsEntries("k1"))
 }
 
 func TestMySegmentsSurrogates(t *testing.T) {
 	assert.Equal(t, []string(nil), MakeSurrogateForMySegments([]dtos.MySegmentDTO{{Name: "segment1"}, {Name: "segment2"}}))
 }
+
+func TestETags(t *testing.T) {
+	assert.Equal(t, "se-123", MakeSegmentChangesETag(123))
+
+	base := MakeSplitChangesETag(10, 20, []string{"a", "b"}, "1.3")
+	assert.Equal(t, base, MakeSplitChangesETag(10, 20, []string{"a", "b"}, "1.3"))
+	assert.NotEqual(t, base, MakeSplitChangesETag(11, 20, []string{"a", "b"}, "1.3"))
+	assert.NotEqual(t, base, MakeSplitChangesETag(10, 21, []string{"a", "b"}, "1.3"))
+	assert.NotEqual(t, base, MakeSplitChangesETag(10, 20, []string{"a"}, "1.3"))
+	assert.NotEqual(t, base, MakeSplitChangesETag(10, 20, []string{"a", "b"}, "1.1"))
+
+	assert.Equal(t, MakeMembershipsETag([]string{"s1", "s2"}, []string{"l1"}), MakeMembershipsETag([]string{"s2", "s1"}, []string{"l1"}))
+	assert.NotEqual(t, MakeMembershipsETag([]string{"s1", "s2"}, nil), MakeMembershipsETag([]string{"s1"}, []string{"s2"}))
+}
+
+func TestCacheKeysPerEncoding(t *testing.T) {
+	makeCtx := func(acceptEncoding string) *gin.Context {
+		u, _ := url.Parse("http://proxy.split.io/api/splitChanges?since=-1")
+		return &gin.Context{Request: &http.Request{URL: u, Header: http.Header{"Accept-Encoding": []string{acceptEncoding}}}}
+	}
+
+	assert.Equal(t, "/api/splitChangessince=-1", keyFactoryFN(makeCtx("")))
+	assert.Equal(t, "gzip::/api/splitChangessince=-1", keyFactoryFN(makeCtx("gzip, deflate")))
+	assert.Equal(t, "br::/api/splitChangessince=-1", keyFactoryFN(makeCtx("gzip, deflate, br")))
+	assert.Equal(t, "zstd::/api/splitChangessince=-1", keyFactoryFN(makeCtx("zstd, gzip")))
+}
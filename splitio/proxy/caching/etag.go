@@ -0,0 +1,40 @@
+package caching
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// MakeSplitChangesETag builds an entity tag for a splitChanges response. Responses for the same query only
+// change when new feature flags or rule-based segments are received, so change numbers are enough to identify them,
+// along with the filters that shape the response
+func MakeSplitChangesETag(till int64, rbTill int64, sets []string, spec string) string {
+	return "sp-" + strconv.FormatInt(till, 10) + "-" + strconv.FormatInt(rbTill, 10) + "-" + hashOf(spec, strings.Join(sets, ","))
+}
+
+// MakeSegmentChangesETag builds an entity tag for a segmentChanges response
+func MakeSegmentChangesETag(till int64) string {
+	return "se-" + strconv.FormatInt(till, 10)
+}
+
+// MakeMembershipsETag builds an entity tag for a memberships response. Since there are no per-key change numbers,
+// the segment names themselves are used
+func MakeMembershipsETag(segments []string, largeSegments []string) string {
+	segments = slices.Clone(segments)
+	slices.Sort(segments)
+	largeSegments = slices.Clone(largeSegments)
+	slices.Sort(largeSegments)
+	return "mem-" + hashOf(strings.Join(segments, ","), strings.Join(largeSegments, ","))
+}
+
+func hashOf(parts ...string) string {
+	hasher := fnv.New64a()
+	for _, part := range parts {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	return strconv.FormatUint(hasher.Sum64(), 36)
+}
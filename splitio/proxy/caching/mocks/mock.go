@@ -218,3 +218,29 @@ var _ largesegment.Updater = (*LargeSegmentUpdaterMock)(nil)
 var _ storage.SplitStorage = (*SplitStorageMock)(nil)
 var _ storage.SegmentStorage = (*SegmentStorageMock)(nil)
 var _ storage.LargeSegmentsStorage = (*LargeSegmentStorageMock)(nil)
+
+// ----
+
+type NotifierMock struct {
+	mock.Mock
+}
+
+// NotifySplitUpdate implements streaming.Notifier
+func (n *NotifierMock) NotifySplitUpdate(changeNumber int64) {
+	n.Called(changeNumber)
+}
+
+// NotifySplitKill implements streaming.Notifier
+func (n *NotifierMock) NotifySplitKill(splitName string, defaultTreatment string, changeNumber int64) {
+	n.Called(splitName, defaultTreatment, changeNumber)
+}
+
+// NotifyRuleBasedSegmentUpdate implements streaming.Notifier
+func (n *NotifierMock) NotifyRuleBasedSegmentUpdate(changeNumber int64) {
+	n.Called(changeNumber)
+}
+
+// NotifySegmentUpdate implements streaming.Notifier
+func (n *NotifierMock) NotifySegmentUpdate(segmentName string, changeNumber int64) {
+	n.Called(segmentName, changeNumber)
+}
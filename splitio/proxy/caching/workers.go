@@ -1,6 +1,8 @@
 package caching
 
 import (
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+
 	"github.com/splitio/gincache"
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/engine/grammar"
@@ -20,6 +22,7 @@ type CacheAwareSplitSynchronizer struct {
 	rbStorage    storage.RuleBasedSegmentsStorage
 	wrapped      split.Updater
 	cacheFlusher gincache.CacheFlusher
+	notifier     streaming.Notifier
 }
 
 // NewCacheAwareSplitSync constructs a split-sync wrapper that evicts cache on updates
@@ -34,12 +37,14 @@ func NewCacheAwareSplitSync(
 	flagSetsFilter flagsets.FlagSetFilter,
 	specVersion string,
 	ruleBuilder grammar.RuleBuilder,
+	notifier streaming.Notifier,
 ) *CacheAwareSplitSynchronizer {
 	return &CacheAwareSplitSynchronizer{
 		wrapped:      split.NewSplitUpdater(splitStorage, ruleBasedStorage, splitFetcher, logger, runtimeTelemetry, appMonitor, flagSetsFilter, ruleBuilder, false, specVersion),
 		splitStorage: splitStorage,
 		rbStorage:    ruleBasedStorage,
 		cacheFlusher: cacheFlusher,
+		notifier:     notifier,
 	}
 }
 
@@ -52,12 +57,7 @@ func (c *CacheAwareSplitSynchronizer) SynchronizeSplits(till *int64) (*split.Upd
 	if err != nil {
 		return nil, err
 	}
-	current, _ := c.splitStorage.ChangeNumber()
-	currentRB, _ := c.rbStorage.ChangeNumber()
-	if current > previous || (previous != -1 && current == -1) || currentRB > previousRB || (previousRB != -1 && currentRB == -1) {
-		// if the changenumber was updated, evict splitChanges responses from cache
-		c.cacheFlusher.EvictBySurrogate(SplitSurrogate)
-	}
+	c.handleChanges(previous, previousRB)
 	return result, err
 }
 
@@ -66,6 +66,9 @@ func (c *CacheAwareSplitSynchronizer) LocalKill(splitName string, defaultTreatme
 	c.wrapped.LocalKill(splitName, defaultTreatment, changeNumber)
 	// Since a feature flag was killed, unconditionally flush all feature flag changes
 	c.cacheFlusher.EvictBySurrogate(SplitSurrogate)
+	if c.notifier != nil {
+		c.notifier.NotifySplitKill(splitName, defaultTreatment, changeNumber)
+	}
 }
 
 // SynchronizeFeatureFlags synchronizes feature flags and if something changes, purges the cache appropriately
@@ -77,13 +80,29 @@ func (c *CacheAwareSplitSynchronizer) SynchronizeFeatureFlags(ffChange *dtos.Spl
 	if err != nil {
 		return nil, err
 	}
+	c.handleChanges(previous, previousRB)
+	return result, err
+}
+
+func (c *CacheAwareSplitSynchronizer) handleChanges(previous int64, previousRB int64) {
 	current, _ := c.splitStorage.ChangeNumber()
 	currentRB, _ := c.rbStorage.ChangeNumber()
 	if current > previous || (previous != -1 && current == -1) || currentRB > previousRB || (previousRB != -1 && currentRB == -1) {
 		// if the changenumber was updated, evict splitChanges responses from cache
 		c.cacheFlusher.EvictBySurrogate(SplitSurrogate)
 	}
-	return result, err
+
+	if c.notifier == nil {
+		return
+	}
+
+	// notify connected sdks only after the cache has been purged, so that they fetch fresh data
+	if current > previous {
+		c.notifier.NotifySplitUpdate(current)
+	}
+	if currentRB > previousRB {
+		c.notifier.NotifyRuleBasedSegmentUpdate(currentRB)
+	}
 }
 
 // CacheAwareSegmentSynchronizer wraps a segment-sync with cache-friendly logic
@@ -92,6 +111,7 @@ type CacheAwareSegmentSynchronizer struct {
 	splitStorage   storage.SplitStorage
 	segmentStorage storage.SegmentStorage
 	cacheFlusher   gincache.CacheFlusher
+	notifier       streaming.Notifier
 }
 
 // NewCacheAwareSegmentSync constructs a new cache-aware segment sync
@@ -104,12 +124,14 @@ func NewCacheAwareSegmentSync(
 	runtimeTelemetry storage.TelemetryRuntimeProducer,
 	cacheFlusher gincache.CacheFlusher,
 	appMonitor application.MonitorProducerInterface,
+	notifier streaming.Notifier,
 ) *CacheAwareSegmentSynchronizer {
 	return &CacheAwareSegmentSynchronizer{
 		wrapped:        segment.NewSegmentUpdater(splitStorage, segmentStorage, ruleBasedStorage, segmentFetcher, logger, runtimeTelemetry, appMonitor),
 		cacheFlusher:   cacheFlusher,
 		splitStorage:   splitStorage,
 		segmentStorage: segmentStorage,
+		notifier:       notifier,
 	}
 }
 
@@ -123,6 +145,9 @@ func (c *CacheAwareSegmentSynchronizer) SynchronizeSegment(name string, till *in
 	if current := result.NewChangeNumber; current > previous || (previous != -1 && current == -1) {
 		c.cacheFlusher.EvictBySurrogate(MakeSurrogateForSegmentChanges(name))
 		c.cacheFlusher.EvictBySurrogate(MembershipsSurrogate)
+		if c.notifier != nil && current > previous {
+			c.notifier.NotifySegmentUpdate(name, current)
+		}
 	}
 
 	// remove individual entries for each affected key
@@ -158,6 +183,9 @@ func (c *CacheAwareSegmentSynchronizer) SynchronizeSegments() (map[string]segmen
 			// if the segment was updated or the segment was removed, evict it
 			c.cacheFlusher.EvictBySurrogate(MakeSurrogateForSegmentChanges(segmentName))
 			c.cacheFlusher.EvictBySurrogate(MembershipsSurrogate)
+			if c.notifier != nil && ccn > pcn {
+				c.notifier.NotifySegmentUpdate(segmentName, ccn)
+			}
 		}
 
 		for idx := range result.UpdatedKeys {
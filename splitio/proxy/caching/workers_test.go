@@ -216,6 +216,8 @@ func TestCacheAwareSegmentSyncSingle(t *testing.T) {
 	cacheFlusher.On("EvictBySurrogate", MakeSurrogateForSegmentChanges("segment1")).Times(2)
 	cacheFlusher.On("Evict", "/api/mySegments/k1").Times(2)
 	cacheFlusher.On("Evict", "gzip::/api/mySegments/k1").Times(2)
+	cacheFlusher.On("Evict", "br::/api/mySegments/k1").Times(2)
+	cacheFlusher.On("Evict", "zstd::/api/mySegments/k1").Times(2)
 	cacheFlusher.On("EvictBySurrogate", MembershipsSurrogate).Times(2)
 
 	var segmentStorage mocks.SegmentStorageMock
@@ -248,6 +250,63 @@ func TestCacheAwareSegmentSyncSingle(t *testing.T) {
 	cacheFlusher.AssertExpectations(t)
 }
 
+func TestCacheAwareSyncNotifications(t *testing.T) {
+	var splitSyncMock mocks.SplitUpdaterMock
+	splitSyncMock.On("SynchronizeSplits", (*int64)(nil)).Return((*split.UpdateResult)(nil), error(nil)).Once()
+	splitSyncMock.On("LocalKill", "someSplit", "off", int64(5)).Return(nil).Once()
+
+	var rbsStorage commons.MockRuleBasedSegmentStorage
+	rbsStorage.On("ChangeNumber").Return(int64(1), error(nil)).Once()
+	rbsStorage.On("ChangeNumber").Return(int64(3), error(nil)).Once()
+
+	var storageMock mocks.SplitStorageMock
+	storageMock.On("ChangeNumber").Return(int64(1), error(nil)).Once()
+	storageMock.On("ChangeNumber").Return(int64(4), error(nil)).Once()
+
+	var cacheFlusherMock mocks.CacheFlusherMock
+	cacheFlusherMock.On("EvictBySurrogate", SplitSurrogate).Times(2)
+	cacheFlusherMock.On("EvictBySurrogate", MakeSurrogateForSegmentChanges("segment1")).Once()
+	cacheFlusherMock.On("EvictBySurrogate", MembershipsSurrogate).Once()
+
+	var notifier mocks.NotifierMock
+	notifier.On("NotifySplitUpdate", int64(4)).Once()
+	notifier.On("NotifyRuleBasedSegmentUpdate", int64(3)).Once()
+	notifier.On("NotifySplitKill", "someSplit", "off", int64(5)).Once()
+	notifier.On("NotifySegmentUpdate", "segment1", int64(2)).Once()
+
+	css := CacheAwareSplitSynchronizer{
+		splitStorage: &storageMock,
+		rbStorage:    &rbsStorage,
+		wrapped:      &splitSyncMock,
+		cacheFlusher: &cacheFlusherMock,
+		notifier:     &notifier,
+	}
+
+	_, err := css.SynchronizeSplits(nil)
+	assert.Nil(t, err)
+	css.LocalKill("someSplit", "off", 5)
+
+	var segmentUpdater mocks.SegmentUpdaterMock
+	segmentUpdater.On("SynchronizeSegment", "segment1", (*int64)(nil)).Return(&segment.UpdateResult{NewChangeNumber: 2}, nil).Once()
+	var segmentStorage mocks.SegmentStorageMock
+	segmentStorage.On("ChangeNumber", "segment1").Return(int64(1), nil).Once()
+
+	segSync := CacheAwareSegmentSynchronizer{
+		splitStorage:   &storageMock,
+		segmentStorage: &segmentStorage,
+		wrapped:        &segmentUpdater,
+		cacheFlusher:   &cacheFlusherMock,
+		notifier:       &notifier,
+	}
+	_, err = segSync.SynchronizeSegment("segment1", nil)
+	assert.Nil(t, err)
+
+	splitSyncMock.AssertExpectations(t)
+	segmentUpdater.AssertExpectations(t)
+	cacheFlusherMock.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
 func TestCacheAwareSegmentSyncSingleError(t *testing.T) {
 	var segmentUpdater mocks.SegmentUpdaterMock
 	expectedErr := assert.AnError
@@ -289,6 +348,8 @@ func TestCacheAwareSegmentSyncAllSegments(t *testing.T) {
 	cacheFlusher.On("EvictBySurrogate", MakeSurrogateForSegmentChanges("segment2")).Times(1)
 	cacheFlusher.On("Evict", "/api/mySegments/k1").Times(3)
 	cacheFlusher.On("Evict", "gzip::/api/mySegments/k1").Times(3)
+	cacheFlusher.On("Evict", "br::/api/mySegments/k1").Times(3)
+	cacheFlusher.On("Evict", "zstd::/api/mySegments/k1").Times(3)
 	cacheFlusher.On("EvictBySurrogate", MembershipsSurrogate).Times(3)
 
 	var segmentStorage mocks.SegmentStorageMock
@@ -0,0 +1,110 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported content encodings
+const (
+	Identity = ""
+	Gzip     = "gzip"
+	Brotli   = "br"
+	Zstd     = "zstd"
+)
+
+// Supported lists the available encodings in order of preference
+var Supported = []string{Brotli, Zstd, Gzip}
+
+// Encoder is implemented by all the compressing writers
+type Encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+var pools = map[string]*sync.Pool{
+	Gzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return w
+	}},
+	Brotli: {New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}},
+	Zstd: {New: func() interface{} {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+}
+
+// GetEncoder returns a pooled encoder writing to `w`. It should be returned to the pool with PutEncoder after being closed
+func GetEncoder(encoding string, w io.Writer) Encoder {
+	pool, ok := pools[encoding]
+	if !ok {
+		return nil
+	}
+	encoder := pool.Get().(Encoder)
+	encoder.Reset(w)
+	return encoder
+}
+
+// PutEncoder returns an encoder to the pool
+func PutEncoder(encoding string, encoder Encoder) {
+	if pool, ok := pools[encoding]; ok {
+		encoder.Reset(io.Discard)
+		pool.Put(encoder)
+	}
+}
+
+// Negotiate picks the best supported encoding for the supplied Accept-Encoding header. Client preferences (q-values)
+// take precedence, ties are broken using the server's order of preference. If no supported encoding is acceptable,
+// Identity is returned
+func Negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return Identity
+	}
+
+	weights := make(map[string]float64, len(Supported))
+	wildcard := -1.0
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		name, weight := parseItem(item)
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := Identity, 0.0
+	for _, encoding := range Supported {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
+
+func parseItem(item string) (string, float64) {
+	parts := strings.Split(item, ";")
+	name := strings.ToLower(strings.TrimSpace(parts[0]))
+	weight := 1.0
+	for _, param := range parts[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(param, "q=") {
+			continue
+		}
+		if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+			weight = parsed
+		}
+	}
+	return name, weight
+}
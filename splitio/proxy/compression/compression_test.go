@@ -0,0 +1,57 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, Identity, Negotiate(""))
+	assert.Equal(t, Identity, Negotiate("identity"))
+	assert.Equal(t, Identity, Negotiate("deflate"))
+	assert.Equal(t, Gzip, Negotiate("gzip"))
+	assert.Equal(t, Gzip, Negotiate("gzip, deflate"))
+	assert.Equal(t, Brotli, Negotiate("gzip, deflate, br"))
+	assert.Equal(t, Brotli, Negotiate("gzip, deflate, br, zstd"))
+	assert.Equal(t, Zstd, Negotiate("gzip, zstd"))
+	assert.Equal(t, Gzip, Negotiate("gzip;q=1.0, br;q=0.5"))
+	assert.Equal(t, Zstd, Negotiate("br;q=0, *"))
+	assert.Equal(t, Identity, Negotiate("*;q=0"))
+	assert.Equal(t, Gzip, Negotiate("GZIP"))
+}
+
+func TestEncoders(t *testing.T) {
+	payload := bytes.Repeat([]byte(`{"splits":[],"since":-1,"till":-1}`), 100)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		Gzip:   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		Brotli: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		Zstd:   func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+
+	for _, encoding := range Supported {
+		for i := 0; i < 2; i++ { // second iteration reuses pooled encoders
+			var buf bytes.Buffer
+			encoder := GetEncoder(encoding, &buf)
+			_, err := encoder.Write(payload)
+			assert.Nil(t, err)
+			assert.Nil(t, encoder.Close())
+			PutEncoder(encoding, encoder)
+			assert.Less(t, buf.Len(), len(payload))
+
+			reader, err := decoders[encoding](&buf)
+			assert.Nil(t, err)
+			decoded, err := io.ReadAll(reader)
+			assert.Nil(t, err)
+			assert.Equal(t, payload, decoded)
+		}
+	}
+
+	assert.Nil(t, GetEncoder("deflate", io.Discard))
+}
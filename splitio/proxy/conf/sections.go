@@ -9,20 +9,24 @@ import (
 
 // Main configuration options
 type Main struct {
-	Apikey                string            `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
-	IPAddressEnabled      bool              `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
-	FlagSetsFilter        []string          `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
-	FlagSetStrictMatching bool              `json:"flagSetStrictMatching" s-cli:"flag-sets-strict-matching" s-def:"false" s-desc:"filter sets not present in cache when building splitChanges responses"`
-	Initialization        Initialization    `json:"initialization" s-nested:"true"`
-	Server                Server            `json:"server" s-nested:"true"`
-	Admin                 conf.Admin        `json:"admin" s-nested:"true"`
-	Storage               Storage           `json:"storage" s-nested:"true"`
-	Sync                  Sync              `json:"sync" s-nested:"true"`
-	Integrations          conf.Integrations `json:"integrations" s-nested:"true"`
-	Logging               conf.Logging      `json:"logging" s-nested:"true"`
-	Healthcheck           Healthcheck       `json:"healthcheck" s-nested:"true"`
-	Observability         Observability     `json:"observability" s-nested:"true"`
-	FlagSpecVersion       string            `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.3" s-desc:"Spec version for flags"`
+	Apikey                string                `json:"apikey" s-cli:"apikey" s-def:"" s-desc:"Split server side SDK key"`
+	IPAddressEnabled      bool                  `json:"ipAddressEnabled" s-cli:"ip-address-enabled" s-def:"true" s-desc:"Bundle host's ip address when sending data to Split"`
+	FlagSetsFilter        []string              `json:"flagSetsFilter" s-cli:"flag-sets-filter" s-def:"" s-desc:"Flag Sets Filter provided"`
+	FlagSetStrictMatching bool                  `json:"flagSetStrictMatching" s-cli:"flag-sets-strict-matching" s-def:"false" s-desc:"filter sets not present in cache when building splitChanges responses"`
+	Initialization        Initialization        `json:"initialization" s-nested:"true"`
+	Localhost             Localhost             `json:"localhost" s-nested:"true"`
+	AirGap                AirGap                `json:"airGap" s-nested:"true"`
+	Server                Server                `json:"server" s-nested:"true"`
+	Admin                 conf.Admin            `json:"admin" s-nested:"true"`
+	Storage               Storage               `json:"storage" s-nested:"true"`
+	SnapshotSecurity      conf.SnapshotSecurity `json:"snapshotSecurity" s-nested:"true"`
+	Sync                  Sync                  `json:"sync" s-nested:"true"`
+	Integrations          conf.Integrations     `json:"integrations" s-nested:"true"`
+	Logging               conf.Logging          `json:"logging" s-nested:"true"`
+	Tracing               conf.Tracing          `json:"tracing" s-nested:"true"`
+	Healthcheck           Healthcheck           `json:"healthcheck" s-nested:"true"`
+	Observability         Observability         `json:"observability" s-nested:"true"`
+	FlagSpecVersion       string                `json:"flagSpecVersion" s-cli:"flag-spec-version" s-def:"1.3" s-desc:"Spec version for flags"`
 }
 
 // BuildAdvancedConfig generates a commons-compatible advancedconfig with default + overriden parameters
@@ -47,19 +51,88 @@ type Initialization struct {
 	ForceFreshStartup bool   `json:"forceFreshStartup" s-cli:"force-fresh-startup" s-def:"false" s-desc:"Wipe storage before starting the synchronizer"`
 }
 
+// Localhost configuration options for serving feature flags & segments from a local file, without a Split account
+type Localhost struct {
+	File          string `json:"file" s-cli:"localhost-file" s-def:"" s-desc:"YAML/JSON file with feature flags & segments to serve instead of syncing with Split. Enables localhost mode"`
+	RefreshRateMs int64  `json:"refreshRateMs" s-cli:"localhost-refresh-rate-ms" s-def:"1000" s-desc:"How often to check the localhost file for changes"`
+	OutputDir     string `json:"outputDir" s-cli:"localhost-output-dir" s-def:"split-localhost-data" s-desc:"Directory where impressions, events & telemetry received in localhost mode are written to"`
+}
+
+// AirGap configuration options for running without ever contacting Split. SDK data is recorded into export bundles,
+// to be uploaded later with the split-bundles tool
+type AirGap struct {
+	Enabled        bool   `json:"enabled" s-cli:"air-gapped" s-def:"false" s-desc:"Serve the data in the startup snapshot & never contact Split. Impressions, events & telemetry are recorded into export bundles"`
+	BundleDir      string `json:"bundleDir" s-cli:"air-gapped-bundle-dir" s-def:"split-export-bundles" s-desc:"Directory where export bundles are written to"`
+	SealPeriodSecs int64  `json:"sealPeriodSecs" s-cli:"air-gapped-seal-period-secs" s-def:"3600" s-desc:"How often to seal the recorded data into a new bundle"`
+	MaxRecords     int64  `json:"maxRecords" s-cli:"air-gapped-bundle-max-records" s-def:"10000" s-desc:"Seal a bundle as soon as it holds this many records (0 = no limit)"`
+}
+
 // Server configuration options
 type Server struct {
-	ClientApikeys []string `json:"apikeys" s-cli:"client-apikeys" s-def:"SDK_API_KEY" s-desc:"Apikeys that clients connecting to this proxy will use."`
-	Host          string   `json:"host" s-cli:"server-host" s-def:"0.0.0.0" s-desc:"Host/IP to start the proxy server on"`
-	Port          int64    `json:"port" s-cli:"server-port" s-def:"3000" s-desc:"Port to listten for incoming requests from SDKs"`
-	CacheSize     int64    `json:"httpCacheSize" s-cli:"http-cache-size" s-def:"1000000" s-desc:"How many responses to cache"`
-	TLS           conf.TLS `json:"tls" s-nested:"true" s-cli-prefix:"server"`
+	ClientApikeys     []string    `json:"apikeys" s-cli:"client-apikeys" s-def:"SDK_API_KEY" s-desc:"Apikeys that clients connecting to this proxy will use."`
+	Host              string      `json:"host" s-cli:"server-host" s-def:"0.0.0.0" s-desc:"Host/IP to start the proxy server on"`
+	Port              int64       `json:"port" s-cli:"server-port" s-def:"3000" s-desc:"Port to listten for incoming requests from SDKs"`
+	CacheSize         int64       `json:"httpCacheSize" s-cli:"http-cache-size" s-def:"1000000" s-desc:"How many responses to cache"`
+	TLS               conf.TLS    `json:"tls" s-nested:"true" s-cli-prefix:"server"`
+	Streaming         Streaming   `json:"streaming" s-nested:"true"`
+	RateLimit         RateLimit   `json:"rateLimit" s-nested:"true"`
+	KeyRotation       KeyRotation `json:"apikeyRotation" s-nested:"true"`
+	OFREP             OFREP       `json:"ofrep" s-nested:"true"`
+	ShutdownTimeoutMs int64       `json:"shutdownTimeoutMs" s-cli:"server-shutdown-timeout-ms" s-def:"10000" s-desc:"Max time to wait for in-flight requests & pending impressions/events/telemetry to be flushed on shutdown"`
+
+	// ClientKeyScopes can only be set via JSON config file
+	ClientKeyScopes []ClientKeyScope `json:"clientKeyScopes"`
+}
+
+// KeyRotation configuration options for replacing client apikeys without restarting the proxy
+type KeyRotation struct {
+	File            string `json:"file" s-cli:"client-apikeys-file" s-def:"" s-desc:"File with one client apikey per line, watched for changes. When set, it takes precedence over 'apikeys' and the admin api cannot replace keys"`
+	PollSecs        int64  `json:"pollSecs" s-cli:"client-apikeys-file-poll-secs" s-def:"10" s-desc:"How often to check the apikeys file for changes"`
+	GracePeriodSecs int64  `json:"gracePeriodSecs" s-cli:"client-apikeys-grace-period-secs" s-def:"0" s-desc:"How long replaced apikeys remain valid after a rotation"`
+}
+
+// OFREP configuration options for serving server-side flag evaluations following the OpenFeature Remote Evaluation Protocol
+type OFREP struct {
+	Enabled            bool `json:"enabled" s-cli:"ofrep-enabled" s-def:"false" s-desc:"Serve flag evaluations on /ofrep/v1/evaluate/flags"`
+	ImpressionsEnabled bool `json:"impressionsEnabled" s-cli:"ofrep-impressions-enabled" s-def:"true" s-desc:"Generate impressions for evaluations & forward them to Split"`
+}
+
+// RateLimit configuration options. Limits are applied per client apikey & endpoint group. A rate of 0 disables limiting
+type RateLimit struct {
+	RegularRps     int64 `json:"regularRps" s-cli:"rate-limit-regular-rps" s-def:"0" s-desc:"Max requests per second per apikey on non-cached endpoints (impressions, events, telemetry)"`
+	RegularBurst   int64 `json:"regularBurst" s-cli:"rate-limit-regular-burst" s-def:"0" s-desc:"Max burst per apikey on non-cached endpoints (Default: same as rps)"`
+	BeaconRps      int64 `json:"beaconRps" s-cli:"rate-limit-beacon-rps" s-def:"0" s-desc:"Max requests per second per client ip on beacon endpoints"`
+	BeaconBurst    int64 `json:"beaconBurst" s-cli:"rate-limit-beacon-burst" s-def:"0" s-desc:"Max burst per client ip on beacon endpoints (Default: same as rps)"`
+	CacheableRps   int64 `json:"cacheableRps" s-cli:"rate-limit-cacheable-rps" s-def:"0" s-desc:"Max requests per second per apikey on cached endpoints (splitChanges, segmentChanges, memberships, auth)"`
+	CacheableBurst int64 `json:"cacheableBurst" s-cli:"rate-limit-cacheable-burst" s-def:"0" s-desc:"Max burst per apikey on cached endpoints (Default: same as rps)"`
+}
+
+// ClientKeyScope restricts the flag sets that sdks using a specific client key can fetch.
+// Scoped apikeys don't need to be listed in `apikeys`. When StrictMatching is enabled, requests including
+// sets outside of the allowed ones are rejected. Otherwise such sets are ignored. FlagSets cannot be empty, and
+// are intersected with the global flag sets filter when one is configured.
+type ClientKeyScope struct {
+	Apikey         string   `json:"apikey"`
+	FlagSets       []string `json:"flagSets"`
+	StrictMatching bool     `json:"strictMatching"`
+}
+
+// Streaming configuration options for serving SSE notifications to sdks
+type Streaming struct {
+	Enabled       bool   `json:"enabled" s-cli:"streaming-server-enabled" s-def:"false" s-desc:"Serve streaming notifications to server-side sdks connected to this proxy"`
+	TokenSecret   string `json:"tokenSecret" s-cli:"streaming-server-token-secret" s-def:"" s-desc:"Secret used to sign streaming tokens. (Default: random per instance)"`
+	TokenTTLSecs  int64  `json:"tokenTtlSecs" s-cli:"streaming-server-token-ttl-secs" s-def:"3600" s-desc:"How long issued streaming tokens remain valid"`
+	KeepAliveSecs int64  `json:"keepAliveSecs" s-cli:"streaming-server-keepalive-secs" s-def:"30" s-desc:"How often to send keepalive messages to connected sdks"`
+	QueueSize     int64  `json:"queueSize" s-cli:"streaming-server-queue-size" s-def:"100" s-desc:"Max pending notifications per connected sdk before dropping it"`
 }
 
 // Storage configuration options
 type Storage struct {
 	Volatile   Volatile   `json:"volatile" s-nested:"true"`
 	Persistent Persistent `json:"persistent" s-nested:"true"`
+	Spill      Spill      `json:"spill" s-nested:"true"`
+	Snapshots  Snapshots  `json:"snapshots" s-nested:"true"`
+	History    History    `json:"history" s-nested:"true"`
 }
 
 // Volatile storage configuration options
@@ -68,7 +141,33 @@ type Volatile struct {
 
 // Persistent storage configuration options
 type Persistent struct {
-	Filename string `json:"filename" s-cli:"persistent-storage-fn" s-def:"" s-desc:"Where to store flags & user-generated data. (Default: temporary file)"`
+	Filename                       string `json:"filename" s-cli:"persistent-storage-fn" s-def:"" s-desc:"Where to store flags & user-generated data. (Default: temporary file)"`
+	SegmentTombstoneRetentionSecs  int64  `json:"segmentTombstoneRetentionSecs" s-cli:"segment-tombstone-retention-secs" s-def:"604800" s-desc:"How long keys removed from segments are remembered to report them to outdated sdks, which are served from Split past this window. 0 keeps them forever"`
+	SegmentTombstoneCompactionSecs int64  `json:"segmentTombstoneCompactionSecs" s-cli:"segment-tombstone-compaction-secs" s-def:"3600" s-desc:"How often removed segment keys past the retention window are discarded"`
+}
+
+// Spill configuration options for persisting impressions, events & telemetry that can't be posted to Split
+type Spill struct {
+	Filename   string `json:"filename" s-cli:"spill-fn" s-def:"" s-desc:"File where impressions, events & telemetry are kept when Split servers are failing or in-memory queues are full. Disabled when empty"`
+	MaxBytes   int64  `json:"maxBytes" s-cli:"spill-max-bytes" s-def:"268435456" s-desc:"Max amount of bytes kept on disk. New data is rejected when the limit is reached"`
+	MaxAgeSecs int64  `json:"maxAgeSecs" s-cli:"spill-max-age-secs" s-def:"86400" s-desc:"Max time to keep data on disk before it's discarded"`
+}
+
+// Snapshots configuration options for periodically dumping the storage into files that can be used with `-snapshot`,
+// and for uploading them through the admin endpoint
+type Snapshots struct {
+	Directory      string `json:"directory" s-cli:"snapshots-dir" s-def:"" s-desc:"Directory where snapshots are periodically written to. Disabled when empty"`
+	PeriodSecs     int64  `json:"periodSecs" s-cli:"snapshots-period-secs" s-def:"3600" s-desc:"How often to write a snapshot"`
+	Keep           int64  `json:"keep" s-cli:"snapshots-keep" s-def:"5" s-desc:"Number of snapshots to retain. Older ones are deleted (0 keeps all of them)"`
+	OnShutdown     bool   `json:"onShutdown" s-cli:"snapshots-on-shutdown" s-def:"true" s-desc:"Write a snapshot upon graceful shutdown"`
+	MaxUploadBytes int64  `json:"maxUploadBytes" s-cli:"snapshots-max-upload-bytes" s-def:"268435456" s-desc:"Max size of a snapshot uploaded through the admin endpoint. Larger ones are rejected"`
+}
+
+// History configuration options for the feature flag change history used to serve sdks with outdated change numbers.
+// The history is stored in snapshots, so it only survives a restart when booting from one (see Initialization.Snapshot)
+type History struct {
+	Capacity      int64 `json:"capacity" s-cli:"split-history-capacity" s-def:"10000" s-desc:"Max number of feature flags tracked in the change history. When exceeded, the oldest archived ones are forgotten (0 means no limit). The history is kept in snapshots & only survives a restart when booting from one"`
+	RetentionSecs int64 `json:"retentionSecs" s-cli:"split-history-retention-secs" s-def:"0" s-desc:"How long archived feature flags are remembered to report them to outdated sdks (0 keeps them forever). Older sdks are served after fetching from Split"`
 }
 
 // Sync configuration options
@@ -77,6 +176,14 @@ type Sync struct {
 	SegmentRefreshRateMs      int64        `json:"segmentRefreshRateMs" s-cli:"segment-refresh-rate-ms" s-def:"60000" s-desc:"How often to refresh segments"`
 	LargeSegmentRefreshRateMs int64        `json:"largeSegmentRefreshRateMs" s-cli:"largesegment-refresh-rate-ms" s-def:"600000" s-desc:"How often to refresh large segments"`
 	Advanced                  AdvancedSync `json:"advanced" s-nested:"true"`
+	Breaker                   Breaker      `json:"breaker" s-nested:"true"`
+}
+
+// Breaker configuration options for the circuit breakers protecting impressions, events & telemetry forwarding
+type Breaker struct {
+	FailureThreshold int64 `json:"failureThreshold" s-cli:"breaker-failure-threshold" s-def:"5" s-desc:"Consecutive failed posts to an endpoint before pausing the forwarding workers"`
+	BaseBackoffMs    int64 `json:"baseBackoffMs" s-cli:"breaker-base-backoff-ms" s-def:"1000" s-desc:"How long to pause posts to an endpoint the first time it fails"`
+	MaxBackoffMs     int64 `json:"maxBackoffMs" s-cli:"breaker-max-backoff-ms" s-def:"300000" s-desc:"Max time to pause posts to a failing endpoint"`
 }
 
 // AdvancedSync configuration options
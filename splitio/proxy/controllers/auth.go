@@ -3,15 +3,23 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
 	"github.com/gin-gonic/gin"
 )
 
 // AuthServerController bundles all request handler for sdk-server apis
-type AuthServerController struct{}
+type AuthServerController struct {
+	logger      logging.LoggerInterface
+	tokenIssuer *streaming.TokenIssuer
+}
 
-// NewAuthServerController instantiates a new sdk server controller
-func NewAuthServerController() *AuthServerController {
-	return &AuthServerController{}
+// NewAuthServerController instantiates a new sdk server controller.
+// If no token issuer is supplied, streaming is reported as disabled to all sdks
+func NewAuthServerController(logger logging.LoggerInterface, tokenIssuer *streaming.TokenIssuer) *AuthServerController {
+	return &AuthServerController{logger: logger, tokenIssuer: tokenIssuer}
 }
 
 // Register mounts the sdk-server endpoints onto the supplied router
@@ -20,7 +28,20 @@ func (c *AuthServerController) Register(router gin.IRouter) {
 	router.GET("/v2/auth", c.AuthV1)
 }
 
-// AuthV1 always returns pushEnabled = false and no token
+// AuthV1 returns a locally signed streaming token when streaming is enabled. Client-side sdks (which send
+// the `users` query param) are always told to use polling, since memberships channels are not served by the proxy
 func (c *AuthServerController) AuthV1(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+	if c.tokenIssuer == nil || len(ctx.QueryArray("users")) > 0 {
+		ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+		return
+	}
+
+	token, err := c.tokenIssuer.Issue()
+	if err != nil {
+		c.logger.Error("error issuing streaming token: ", err)
+		ctx.JSON(http.StatusOK, gin.H{"pushEnabled": false, "token": ""})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"pushEnabled": true, "token": token})
 }
@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
@@ -22,6 +24,8 @@ type EventsServerController struct {
 	impressionCountSink tasks.DeferredRecordingTask
 	eventsSink          tasks.DeferredRecordingTask
 	listener            impressionlistener.ImpressionBulkListener
+	eventListener       eventlistener.EventBulkListener
+	dataSinks           datasink.Dispatcher
 	apikeyValidator     func(string) bool
 }
 
@@ -32,6 +36,8 @@ func NewEventsServerController(
 	impressionCountSink tasks.DeferredRecordingTask,
 	eventsSink tasks.DeferredRecordingTask,
 	listener impressionlistener.ImpressionBulkListener,
+	eventListener eventlistener.EventBulkListener,
+	dataSinks datasink.Dispatcher,
 	apikeyValidator func(string) bool,
 ) *EventsServerController {
 	return &EventsServerController{
@@ -40,6 +46,8 @@ func NewEventsServerController(
 		impressionCountSink: impressionCountSink,
 		eventsSink:          eventsSink,
 		listener:            listener,
+		eventListener:       eventListener,
+		dataSinks:           dataSinks,
 		apikeyValidator:     apikeyValidator,
 	}
 }
@@ -73,10 +81,10 @@ func (c *EventsServerController) TestImpressionsBulk(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, nil)
 		return
 	}
-	if c.listener != nil {
-		// if we have a listener, schedule a goroutine to convert these impressions and
+	if c.listener != nil || c.dataSinks != nil {
+		// if we have a listener or local sinks, schedule a goroutine to convert these impressions and
 		// push them into the channel.
-		go c.submitImpressionsToListener(data, &metadata)
+		go c.forwardImpressions(data, &metadata)
 	}
 
 	err = c.impressionsSink.Stage(internal.NewRawImpressions(metadata, impressionsMode, data))
@@ -120,7 +128,12 @@ func (c *EventsServerController) TestImpressionsBeacon(ctx *gin.Context) {
 		return
 	}
 
-	err = c.impressionsSink.Stage(internal.NewRawImpressions(dtos.Metadata{SDKVersion: body.Sdk, MachineIP: "NA", MachineName: "NA"}, "", body.Entries))
+	beaconMetadata := dtos.Metadata{SDKVersion: body.Sdk, MachineIP: "NA", MachineName: "NA"}
+	if c.dataSinks != nil {
+		go c.submitImpressionsToSinks(body.Entries, &beaconMetadata)
+	}
+
+	err = c.impressionsSink.Stage(internal.NewRawImpressions(beaconMetadata, "", body.Entries))
 	if err != nil {
 		if err == tasks.ErrQueueFull {
 			ctx.AbortWithStatusJSON(500, "Impressions queue is full, please retry later.")
@@ -207,6 +220,10 @@ func (c *EventsServerController) EventsBulk(ctx *gin.Context) {
 		return
 	}
 
+	if c.eventListener != nil || c.dataSinks != nil {
+		go c.forwardEvents(data, &metadata)
+	}
+
 	err = c.eventsSink.Stage(internal.NewRawEvents(metadata, data))
 	if err != nil {
 		if err == tasks.ErrQueueFull {
@@ -245,7 +262,12 @@ func (c *EventsServerController) EventsBulkBeacon(ctx *gin.Context) {
 		return
 	}
 
-	err = c.eventsSink.Stage(internal.NewRawEvents(dtos.Metadata{SDKVersion: body.Sdk, MachineIP: "NA", MachineName: "NA"}, body.Entries))
+	beaconMetadata := dtos.Metadata{SDKVersion: body.Sdk, MachineIP: "NA", MachineName: "NA"}
+	if c.eventListener != nil || c.dataSinks != nil {
+		go c.forwardEvents(body.Entries, &beaconMetadata)
+	}
+
+	err = c.eventsSink.Stage(internal.NewRawEvents(beaconMetadata, body.Entries))
 	if err != nil {
 		if err == tasks.ErrQueueFull {
 			ctx.AbortWithStatusJSON(500, "Events queue is full, please retry later.")
@@ -261,11 +283,32 @@ func (c *EventsServerController) EventsBulkBeacon(ctx *gin.Context) {
 // This is meant to be used with legacy telemetry endpoints
 func (c *EventsServerController) DummyAlwaysOk(ctx *gin.Context) {}
 
-func (c *EventsServerController) submitImpressionsToListener(raw []byte, metadata *dtos.Metadata) {
+// forwardImpressions converts an impressions payload & pushes it to the listener and/or data sinks
+func (c *EventsServerController) forwardImpressions(raw []byte, metadata *dtos.Metadata) {
 	var parsed []dtos.ImpressionsDTO
 	err := json.Unmarshal(raw, &parsed)
 	if err != nil {
-		c.logger.Error("error when parsing impressions prior to being forwarded to the listener: ", err)
+		c.logger.Error("error when parsing impressions prior to being forwarded to the listener/data sinks: ", err)
+		return
+	}
+	forwardImpressions(c.logger, c.listener, c.dataSinks, parsed, metadata)
+}
+
+// forwardImpressions pushes a copy of the supplied impressions to the listener and/or data sinks, if any
+func forwardImpressions(
+	logger logging.LoggerInterface,
+	listener impressionlistener.ImpressionBulkListener,
+	dataSinks datasink.Dispatcher,
+	parsed []dtos.ImpressionsDTO,
+	metadata *dtos.Metadata,
+) {
+	if dataSinks != nil {
+		if err := dataSinks.SubmitImpressions(datasink.ImpressionsFromDTOs(parsed, metadata)); err != nil {
+			logger.Error("error pushing impressions to data sinks: ", err)
+		}
+	}
+
+	if listener == nil {
 		return
 	}
 
@@ -290,7 +333,42 @@ func (c *EventsServerController) submitImpressionsToListener(raw []byte, metadat
 		})
 	}
 
-	c.listener.Submit(forListener, metadata)
+	if err := listener.Submit(forListener, metadata); err != nil {
+		logger.Error("error pushing impressions to listener: ", err)
+	}
+}
+
+func (c *EventsServerController) submitImpressionsToSinks(raw []byte, metadata *dtos.Metadata) {
+	var parsed []dtos.ImpressionsDTO
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		c.logger.Error("error when parsing impressions prior to being forwarded to data sinks: ", err)
+		return
+	}
+
+	if err := c.dataSinks.SubmitImpressions(datasink.ImpressionsFromDTOs(parsed, metadata)); err != nil {
+		c.logger.Error("error pushing impressions to data sinks: ", err)
+	}
+}
+
+// forwardEvents parses an events payload & pushes it to the listener and/or data sinks
+func (c *EventsServerController) forwardEvents(raw []byte, metadata *dtos.Metadata) {
+	var parsed []dtos.EventDTO
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		c.logger.Error("error when parsing events prior to being forwarded to the listener/data sinks: ", err)
+		return
+	}
+
+	if c.dataSinks != nil {
+		if err := c.dataSinks.SubmitEvents(datasink.EventsFromDTOs(parsed, metadata)); err != nil {
+			c.logger.Error("error pushing events to data sinks: ", err)
+		}
+	}
+
+	if c.eventListener != nil {
+		if err := c.eventListener.Submit(parsed, metadata); err != nil {
+			c.logger.Error("error pushing events to listener: ", err)
+		}
+	}
 }
 
 // private dtos
@@ -8,6 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	dsMock "github.com/splitio/split-synchronizer/v5/splitio/common/datasink/mocks"
+	elMock "github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener/mocks"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
 	ilMock "github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener/mocks"
 	mw "github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
@@ -84,6 +87,8 @@ func TestPostImpressionsbulk(t *testing.T) {
 				return nil
 			},
 		},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -141,6 +146,8 @@ func TestPostImpressionsbulkWithProperties(t *testing.T) {
 		&mocks.DeferredRecordingTaskMock{}, // imp counts
 		&mocks.DeferredRecordingTaskMock{}, // events
 		&listener,
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -252,6 +259,8 @@ func TestPostEventsBulk(t *testing.T) {
 			},
 		}, // events
 		&ilMock.ImpressionBulkListenerMock{},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -311,6 +320,8 @@ func TestPostImpressionsCounts(t *testing.T) {
 		}, // imp counts
 		&mocks.MockDeferredRecordingTask{}, // events
 		&ilMock.ImpressionBulkListenerMock{},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -353,6 +364,8 @@ func TestPostLegacyMetrics(t *testing.T) {
 		&mocks.MockDeferredRecordingTask{}, // imp counts
 		&mocks.MockDeferredRecordingTask{}, // events
 		&ilMock.ImpressionBulkListenerMock{},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -466,6 +479,8 @@ func TestPostBeaconImpressionsbulk(t *testing.T) {
 				return nil
 			},
 		},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -541,6 +556,8 @@ func TestPostBeaconEventsBulk(t *testing.T) {
 			},
 		}, // events
 		&ilMock.ImpressionBulkListenerMock{},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -601,6 +618,8 @@ func TestPostBeaconImpressionsCounts(t *testing.T) {
 		}, // imp counts
 		&mocks.MockDeferredRecordingTask{}, // events
 		&ilMock.ImpressionBulkListenerMock{},
+		nil,
+		nil,
 		apikeyValidator.IsValid,
 	)
 	controller.Register(group, group)
@@ -628,3 +647,133 @@ func TestPostBeaconImpressionsCounts(t *testing.T) {
 		t.Error("Status code should be 200 and is ", resp.Code)
 	}
 }
+
+func TestDataSinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	logger := logging.NewLogger(nil)
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+
+	impsCh := make(chan []datasink.Impression, 1)
+	eventsCh := make(chan []datasink.Event, 1)
+	group := router.Group("/api")
+	controller := NewEventsServerController(
+		logger,
+		&mocks.MockDeferredRecordingTask{StageCall: func(interface{}) error { return nil }}, // impressions
+		&mocks.MockDeferredRecordingTask{}, // imp counts
+		&mocks.MockDeferredRecordingTask{StageCall: func(interface{}) error { return nil }}, // events
+		nil,
+		nil,
+		&dsMock.DispatcherMock{
+			SubmitImpressionsCall: func(imps []datasink.Impression) error {
+				impsCh <- imps
+				return nil
+			},
+			SubmitEventsCall: func(events []datasink.Event) error {
+				eventsCh <- events
+				return nil
+			},
+		},
+		apikeyValidator.IsValid,
+	)
+	controller.Register(group, group)
+
+	serialized, _ := json.Marshal([]dtos.ImpressionsDTO{
+		{TestName: "test1", KeyImpressions: []dtos.ImpressionDTO{{KeyName: "k1", Treatment: "on", Time: 1, ChangeNumber: 2, Label: "l1"}}},
+	})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/api/testImpressions/bulk", bytes.NewBuffer(serialized))
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	ctx.Request.Header.Set("SplitSDKVersion", "go-1.1.1")
+	ctx.Request.Header.Set("SplitSDKMachineIp", "1.2.3.4")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	select {
+	case imps := <-impsCh:
+		assert.Equal(t, []datasink.Impression{
+			{FeatureFlag: "test1", KeyName: "k1", Treatment: "on", Time: 1, ChangeNumber: 2, Label: "l1", SDKVersion: "go-1.1.1", MachineIP: "1.2.3.4"},
+		}, imps)
+	case <-time.After(time.Second):
+		t.Error("impressions should have been submitted to data sinks")
+	}
+
+	serialized, _ = json.Marshal([]dtos.EventDTO{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Timestamp: 1}})
+	resp = httptest.NewRecorder()
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/api/events/bulk", bytes.NewBuffer(serialized))
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	ctx.Request.Header.Set("SplitSDKVersion", "go-1.1.1")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	select {
+	case events := <-eventsCh:
+		assert.Equal(t, []datasink.Event{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Timestamp: 1, SDKVersion: "go-1.1.1"}}, events)
+	case <-time.After(time.Second):
+		t.Error("events should have been submitted to data sinks")
+	}
+}
+
+func TestEventListener(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	logger := logging.NewLogger(nil)
+	apikeyValidator := mw.NewAPIKeyValidator([]string{"someApiKey"})
+
+	type submitted struct {
+		events   []dtos.EventDTO
+		metadata *dtos.Metadata
+	}
+	submittedCh := make(chan submitted, 1)
+	group := router.Group("/api")
+	controller := NewEventsServerController(
+		logger,
+		&mocks.MockDeferredRecordingTask{}, // impressions
+		&mocks.MockDeferredRecordingTask{}, // imp counts
+		&mocks.MockDeferredRecordingTask{StageCall: func(interface{}) error { return nil }}, // events
+		nil,
+		&elMock.EventBulkListenerMock{
+			SubmitCall: func(events []dtos.EventDTO, metadata *dtos.Metadata) error {
+				submittedCh <- submitted{events: events, metadata: metadata}
+				return nil
+			},
+		},
+		nil,
+		apikeyValidator.IsValid,
+	)
+	controller.Register(group, group)
+
+	serialized, _ := json.Marshal([]dtos.EventDTO{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Timestamp: 1}})
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/api/events/bulk", bytes.NewBuffer(serialized))
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	ctx.Request.Header.Set("SplitSDKVersion", "go-1.1.1")
+	ctx.Request.Header.Set("SplitSDKMachineIp", "1.2.3.4")
+	ctx.Request.Header.Set("SplitSDKMachineName", "ip-1-2-3-4")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	select {
+	case s := <-submittedCh:
+		assert.Equal(t, []dtos.EventDTO{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Timestamp: 1}}, s.events)
+		assert.Equal(t, dtos.Metadata{SDKVersion: "go-1.1.1", MachineIP: "1.2.3.4", MachineName: "ip-1-2-3-4"}, *s.metadata)
+	case <-time.After(time.Second):
+		t.Error("events should have been submitted to the listener")
+	}
+
+	serialized, _ = json.Marshal(beaconMessage{Entries: serialized, Sdk: "js-1.2.3", Token: "someApiKey"})
+	resp = httptest.NewRecorder()
+	ctx.Request, _ = http.NewRequest(http.MethodPost, "/api/events/beacon", bytes.NewBuffer(serialized))
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 204, resp.Code)
+
+	select {
+	case s := <-submittedCh:
+		assert.Equal(t, []dtos.EventDTO{{Key: "k1", TrafficTypeName: "user", EventTypeID: "click", Timestamp: 1}}, s.events)
+		assert.Equal(t, dtos.Metadata{SDKVersion: "js-1.2.3", MachineIP: "NA", MachineName: "NA"}, *s.metadata)
+	case <-time.After(time.Second):
+		t.Error("beacon events should have been submitted to the listener")
+	}
+}
@@ -2,30 +2,72 @@ package middleware
 
 import (
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// APIKeyContextKey is used to store the (already validated) client apikey in the request context
+const APIKeyContextKey = "apikey"
+
+// keySet is an immutable snapshot of the accepted apikeys
+type keySet struct {
+	active   map[string]struct{}
+	retiring map[string]time.Time // replaced keys that remain valid until the associated deadline
+}
+
 // APIKeyValidator is a small component that validates apikeys
 type APIKeyValidator struct {
-	apikeys map[string]struct{}
+	keys  atomic.Pointer[keySet]
+	now   func() time.Time
+	mutex sync.Mutex // serializes updates. Reads are lock-free
 }
 
 // NewAPIKeyValidator instantiates an apikey validation component
 func NewAPIKeyValidator(apikeys []string) *APIKeyValidator {
-	toRet := &APIKeyValidator{apikeys: make(map[string]struct{})}
+	toRet := &APIKeyValidator{now: time.Now}
+	toRet.keys.Store(&keySet{active: toSet(apikeys)})
+	return toRet
+}
 
-	for _, key := range apikeys {
-		toRet.apikeys[key] = struct{}{}
+// Replace atomically swaps the set of accepted apikeys. Keys no longer present remain valid for the
+// supplied grace period, so that clients can be rotated without downtime
+func (v *APIKeyValidator) Replace(apikeys []string, grace time.Duration) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	now := v.now()
+	current := v.keys.Load()
+	next := &keySet{active: toSet(apikeys), retiring: make(map[string]time.Time)}
+
+	for key, deadline := range current.retiring {
+		if _, ok := next.active[key]; !ok && deadline.After(now) {
+			next.retiring[key] = deadline
+		}
 	}
 
-	return toRet
+	if grace > 0 {
+		for key := range current.active {
+			if _, ok := next.active[key]; !ok {
+				next.retiring[key] = now.Add(grace)
+			}
+		}
+	}
+
+	v.keys.Store(next)
 }
 
 // IsValid checks if an apikey is valid
 func (v *APIKeyValidator) IsValid(apikey string) bool {
-	_, ok := v.apikeys[apikey]
-	return ok
+	current := v.keys.Load()
+	if _, ok := current.active[apikey]; ok {
+		return true
+	}
+
+	deadline, ok := current.retiring[apikey]
+	return ok && v.now().Before(deadline)
 }
 
 // AsMiddleware is a function to be used as a gin middleware
@@ -33,5 +75,15 @@ func (v *APIKeyValidator) AsMiddleware(ctx *gin.Context) {
 	auth := strings.Split(ctx.Request.Header.Get("Authorization"), " ")
 	if len(auth) != 2 || auth[0] != "Bearer" || !v.IsValid(auth[1]) {
 		ctx.AbortWithStatus(401)
+		return
 	}
+	ctx.Set(APIKeyContextKey, auth[1])
+}
+
+func toSet(apikeys []string) map[string]struct{} {
+	toRet := make(map[string]struct{}, len(apikeys))
+	for _, key := range apikeys {
+		toRet[key] = struct{}{}
+	}
+	return toRet
 }
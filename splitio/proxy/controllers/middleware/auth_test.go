@@ -4,8 +4,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAuthMiddleWare(t *testing.T) {
@@ -39,3 +41,35 @@ func TestAuthMiddleWare(t *testing.T) {
 		t.Error("Status code should be 401 and is ", resp.Code)
 	}
 }
+
+func TestAPIKeyReplacement(t *testing.T) {
+	now := time.Now()
+	validator := NewAPIKeyValidator([]string{"apikey1", "apikey2"})
+	validator.now = func() time.Time { return now }
+
+	validator.Replace([]string{"apikey2", "apikey3"}, 0)
+	assert.False(t, validator.IsValid("apikey1"))
+	assert.True(t, validator.IsValid("apikey2"))
+	assert.True(t, validator.IsValid("apikey3"))
+
+	validator.Replace([]string{"apikey4"}, time.Minute)
+	assert.True(t, validator.IsValid("apikey2"))
+	assert.True(t, validator.IsValid("apikey3"))
+	assert.True(t, validator.IsValid("apikey4"))
+
+	now = now.Add(30 * time.Second)
+	validator.Replace([]string{"apikey3", "apikey5"}, time.Minute)
+	assert.True(t, validator.IsValid("apikey2")) // still within the first grace period
+	assert.True(t, validator.IsValid("apikey3")) // active again
+	assert.True(t, validator.IsValid("apikey4")) // retired now, with a new grace period
+	assert.True(t, validator.IsValid("apikey5"))
+
+	now = now.Add(31 * time.Second)
+	assert.False(t, validator.IsValid("apikey2"))
+	assert.True(t, validator.IsValid("apikey3"))
+	assert.True(t, validator.IsValid("apikey4"))
+
+	now = now.Add(30 * time.Second)
+	assert.False(t, validator.IsValid("apikey4"))
+	assert.True(t, validator.IsValid("apikey5"))
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const cacheMissKey = "cacheMiss"
+
+// CacheTrackingMiddleware keeps track of the hits & misses of the response cache.
+// `Track` must be placed right before the caching middleware & `MarkMiss` right after it.
+// Requests reaching `MarkMiss` were not served from the cache
+type CacheTrackingMiddleware struct {
+	tracker storage.ProxyEndpointTelemetry
+}
+
+// NewCacheTrackingMiddleware instantiates a new cache hit/miss tracking middleware
+func NewCacheTrackingMiddleware(tracker storage.ProxyEndpointTelemetry) *CacheTrackingMiddleware {
+	return &CacheTrackingMiddleware{tracker: tracker}
+}
+
+// Track is the function to be invoked before the caching middleware
+func (m *CacheTrackingMiddleware) Track(ctx *gin.Context) {
+	if ctx.Request.Method == http.MethodOptions {
+		return
+	}
+
+	ctx.Next()
+	if ctx.GetBool(cacheMissKey) {
+		m.tracker.IncrCacheMiss()
+		return
+	}
+	m.tracker.IncrCacheHit()
+}
+
+// MarkMiss is the function to be invoked after the caching middleware
+func (m *CacheTrackingMiddleware) MarkMiss(ctx *gin.Context) {
+	ctx.Set(cacheMissKey, true)
+}
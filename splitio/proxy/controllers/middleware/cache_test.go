@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/splitio/gincache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheTrackingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	telemetry := storage.NewProxyTelemetryFacade()
+	cache := gincache.New(&gincache.Options{
+		Size:       10,
+		KeyFactory: func(ctx *gin.Context) string { return ctx.Request.URL.String() },
+	})
+	tracker := NewCacheTrackingMiddleware(telemetry)
+
+	router := gin.New()
+	router.Use(tracker.Track, cache.Handle, tracker.MarkMiss)
+	router.GET("/api/test", func(ctx *gin.Context) { ctx.String(200, "ok") })
+
+	doRequest := func(method string, path string) {
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	doRequest(http.MethodGet, "/api/test")
+	doRequest(http.MethodGet, "/api/test")
+	doRequest(http.MethodGet, "/api/test")
+	doRequest(http.MethodGet, "/api/test?a=1")
+	doRequest(http.MethodOptions, "/api/test")
+
+	hits, misses := telemetry.PeekCacheStats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(2), misses)
+}
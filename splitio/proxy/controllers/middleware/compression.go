@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"io"
+	"strings"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/compression"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Compress negotiates a content encoding (brotli, zstd or gzip) with the client and compresses the response body accordingly.
+// When placed after the caching middleware, responses are compressed once and each encoding is cached separately
+func Compress(ctx *gin.Context) {
+	encoding := compression.Negotiate(ctx.Request.Header.Get("Accept-Encoding"))
+	if encoding == compression.Identity || strings.Contains(ctx.Request.Header.Get("Connection"), "Upgrade") {
+		ctx.Next()
+		return
+	}
+
+	ctx.Header("Content-Encoding", encoding)
+	ctx.Writer.Header().Add("Vary", "Accept-Encoding")
+
+	original := ctx.Writer
+	encoder := compression.GetEncoder(encoding, original)
+	writer := &compressWriter{ResponseWriter: original, encoder: encoder}
+	ctx.Writer = writer
+	defer func() {
+		if !writer.written {
+			// do not write any compression framing when there's no body (ie: 304s)
+			encoder.Reset(io.Discard)
+		}
+		encoder.Close()
+		compression.PutEncoder(encoding, encoder)
+		ctx.Writer = original
+	}()
+	ctx.Next()
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	encoder compression.Encoder
+	written bool
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.Header().Del("Content-Length")
+	w.written = true
+	return w.encoder.Write(data)
+}
+
+func (w *compressWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+func (w *compressWriter) Flush() {
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConditionalGet replaces 200 responses with an empty 304 Not Modified when the ETag set by the handler (or restored
+// from the http cache) matches the If-None-Match request header. It must be placed before the caching middleware,
+// so that full responses are still stored in the cache
+func ConditionalGet(ctx *gin.Context) {
+	ifNoneMatch := ctx.Request.Header.Get("If-None-Match")
+	if ifNoneMatch == "" || (ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead) {
+		ctx.Next()
+		return
+	}
+
+	ctx.Writer = &conditionalWriter{ResponseWriter: ctx.Writer, ifNoneMatch: ifNoneMatch}
+	ctx.Next()
+}
+
+type conditionalWriter struct {
+	gin.ResponseWriter
+	ifNoneMatch string
+	notModified bool
+}
+
+func (w *conditionalWriter) WriteHeader(code int) {
+	if code == http.StatusOK && ETagMatches(w.ifNoneMatch, w.Header().Get("ETag")) {
+		w.notModified = true
+		w.Header().Del("Content-Length")
+		code = http.StatusNotModified
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *conditionalWriter) Write(data []byte) (int, error) {
+	if w.notModified {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *conditionalWriter) WriteString(data string) (int, error) {
+	if w.notModified {
+		return len(data), nil
+	}
+	return w.ResponseWriter.WriteString(data)
+}
+
+// ETagMatches compares an If-None-Match header against the current ETag (using weak comparison as mandated by RFC-9110)
+func ETagMatches(ifNoneMatch string, etag string) bool {
+	if etag == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
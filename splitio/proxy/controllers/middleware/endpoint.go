@@ -33,6 +33,7 @@ const (
 	pathTelemetryKeysClientSideBeaconV1 = "/api/v1/keys/cs/beacon"
 	pathTelemetryKeysServerSide         = "/api/keys/ss"
 	pathTelemetryKeysServerSideV1       = "/api/v1/keys/ss"
+	pathOFREPEvaluate                   = "/ofrep/v1/evaluate/flags"
 )
 
 // SetEndpoint stores the endpoint in the context for future middleware querying
@@ -73,6 +74,8 @@ func SetEndpoint(ctx *gin.Context) {
 			ctx.Set(EndpointKey, storage.SegmentChangesEndpoint)
 		} else if strings.HasPrefix(path, pathMySegments) {
 			ctx.Set(EndpointKey, storage.MySegmentsEndpoint)
+		} else if strings.HasPrefix(path, pathOFREPEvaluate) {
+			ctx.Set(EndpointKey, storage.OFREPEvaluationEndpoint)
 		}
 	}
 }
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HoldLock returns a middleware that holds `lock` while the rest of the chain is executed
+func HoldLock(lock sync.Locker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		lock.Lock()
+		defer lock.Unlock()
+		ctx.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/ratelimit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware rejects requests exceeding the configured rate for an endpoint group
+type RateLimitMiddleware struct {
+	group   string
+	limiter *ratelimit.Limiter
+	tracker storage.ProxyEndpointTelemetry
+}
+
+// NewRateLimitMiddleware instantiates a new rate limiting middleware for a specific endpoint group
+func NewRateLimitMiddleware(group string, limiter *ratelimit.Limiter, tracker storage.ProxyEndpointTelemetry) *RateLimitMiddleware {
+	return &RateLimitMiddleware{group: group, limiter: limiter, tracker: tracker}
+}
+
+// Handle is the function to be invoked for every request being handled.
+// Requests are limited by client apikey. Since beacon requests carry the apikey in the body,
+// they're limited by the ip of the peer connected to the proxy instead. Forwarding headers are ignored since
+// they can be set by the client to escape the limit
+func (m *RateLimitMiddleware) Handle(ctx *gin.Context) {
+	key := ctx.GetString(APIKeyContextKey)
+	if key == "" {
+		key = ctx.RemoteIP()
+	}
+
+	allowed, retryAfter := m.limiter.Allow(key)
+	if allowed {
+		return
+	}
+
+	if m.tracker != nil {
+		m.tracker.IncrRateLimited(m.group)
+	}
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"code": http.StatusTooManyRequests, "message": "rate limit exceeded"})
+}
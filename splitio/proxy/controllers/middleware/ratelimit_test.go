@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/ratelimit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	telemetry := storage.NewProxyTelemetryFacade()
+	authMW := NewAPIKeyValidator([]string{"apikey1", "apikey2"})
+	rlMW := NewRateLimitMiddleware(ratelimit.GroupRegular, ratelimit.New(1, 1), telemetry)
+
+	router := gin.New()
+	router.GET("/api/test", authMW.AsMiddleware, rlMW.Handle, func(ctx *gin.Context) {})
+
+	doRequest := func(apikey string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/test", nil)
+		req.Header.Set("Authorization", "Bearer "+apikey)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, 200, doRequest("apikey1").Code)
+
+	resp := doRequest("apikey1")
+	assert.Equal(t, 429, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+
+	assert.Equal(t, 200, doRequest("apikey2").Code)
+	assert.Equal(t, map[string]int64{ratelimit.GroupRegular: 1}, telemetry.PeekRateLimited())
+}
+
+func TestRateLimitMiddlewareIgnoresForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rlMW := NewRateLimitMiddleware(ratelimit.GroupBeacon, ratelimit.New(1, 1), nil)
+
+	router := gin.New()
+	router.POST("/api/beacon", rlMW.Handle, func(ctx *gin.Context) {})
+
+	doRequest := func(forwardedFor string) int {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/api/beacon", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(t, 200, doRequest("1.1.1.1"))
+	assert.Equal(t, 429, doRequest("2.2.2.2"))
+}
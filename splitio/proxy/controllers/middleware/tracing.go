@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceRequest starts a server span for every incoming request, continuing the trace
+// propagated by the caller (if any) via w3c trace-context headers
+func TraceRequest(ctx *gin.Context) {
+	parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+	name := ctx.Request.Method
+	if route := ctx.FullPath(); route != "" {
+		name += " " + route
+	}
+
+	spanCtx, span := tracing.Tracer().Start(parent, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", ctx.Request.Method),
+			attribute.String("http.route", ctx.FullPath()),
+			attribute.String("url.path", ctx.Request.URL.Path),
+			attribute.String("client.address", ctx.ClientIP()),
+		))
+	defer span.End()
+
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// Traced wraps a middleware so that a span is reported for each execution. Middlewares that call `ctx.Next()`
+// will have every handler executed downstream as part of their span
+func Traced(name string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := ctx.Request.Context()
+		spanCtx, span := tracing.Start(parent, name)
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		handler(ctx)
+		if ctx.IsAborted() {
+			span.SetAttributes(attribute.Bool("http.aborted", true))
+		}
+		span.End()
+
+		// restore the parent so that the next handlers in the chain are not reported as children of this one
+		ctx.Request = ctx.Request.WithContext(parent)
+	}
+}
+
+// TraceHandler reports a span for the controller function handling the request.
+// It must be the last middleware in the chain
+func TraceHandler(ctx *gin.Context) {
+	handlerName := ctx.HandlerName()
+	if idx := strings.LastIndex(handlerName, "/"); idx != -1 {
+		handlerName = handlerName[idx+1:]
+	}
+	Traced(strings.TrimSuffix(handlerName, "-fm"), func(ctx *gin.Context) { ctx.Next() })(ctx)
+}
+
+// Tracer returns a function that wraps middlewares with `Traced` when tracing is enabled, or leaves them untouched otherwise
+func Tracer(enabled bool) func(name string, handler gin.HandlerFunc) gin.HandlerFunc {
+	if !enabled {
+		return func(_ string, handler gin.HandlerFunc) gin.HandlerFunc { return handler }
+	}
+	return Traced
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type handlerStruct struct{}
+
+func (handlerStruct) SomeHandler(ctx *gin.Context) { ctx.String(200, "ok") }
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() { otel.SetTracerProvider(prevProvider); otel.SetTextMapPropagator(prevPropagator) }()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	traced := Tracer(true)
+	router := gin.New()
+	router.Use(TraceRequest)
+	router.Use(traced("first", func(ctx *gin.Context) {}))
+	router.Use(traced("second", func(ctx *gin.Context) { ctx.Next() }))
+	router.Use(TraceHandler)
+	router.GET("/api/test/:id", handlerStruct{}.SomeHandler)
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/test/123", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, 200, resp.Code)
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = span
+	}
+	assert.Len(t, byName, 4)
+
+	root := byName["GET /api/test/:id"]
+	assert.NotNil(t, root)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", root.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", root.Parent().SpanID().String())
+
+	// middlewares are siblings unless they wrap the rest of the chain
+	assert.Equal(t, root.SpanContext().SpanID(), byName["first"].Parent().SpanID())
+	assert.Equal(t, root.SpanContext().SpanID(), byName["second"].Parent().SpanID())
+	assert.Equal(t, byName["second"].SpanContext().SpanID(), byName["middleware.handlerStruct.SomeHandler"].Parent().SpanID())
+}
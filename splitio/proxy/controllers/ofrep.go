@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/tracing"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+
+	"github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// OFREP evaluation reasons
+const (
+	ofrepReasonTargetingMatch = "TARGETING_MATCH"
+	ofrepReasonDefault        = "DEFAULT"
+	ofrepReasonDisabled       = "DISABLED"
+	ofrepReasonError          = "ERROR"
+)
+
+// OFREP error codes
+const (
+	ofrepErrorParse            = "PARSE_ERROR"
+	ofrepErrorTargetingMissing = "TARGETING_KEY_MISSING"
+	ofrepErrorInvalidContext   = "INVALID_CONTEXT"
+	ofrepErrorFlagNotFound     = "FLAG_NOT_FOUND"
+	ofrepErrorGeneral          = "GENERAL"
+)
+
+const (
+	ofrepTargetingKey = "targetingKey"
+	ofrepBucketingKey = "bucketingKey"
+)
+
+var errTargetingKeyMissing = errors.New("targetingKey is required in the evaluation context")
+
+// EvaluationFlags exposes the feature flags available for evaluation
+type EvaluationFlags interface {
+	Split(splitName string) *dtos.SplitDTO
+	SplitNames() []string
+}
+
+// OFREPController serves server-side flag evaluations following the OpenFeature Remote Evaluation Protocol
+type OFREPController struct {
+	logger             logging.LoggerInterface
+	evaluator          evaluator.Interface
+	flags              EvaluationFlags
+	keyScopes          *flagsets.KeyScopes
+	impressionsSink    tasks.DeferredRecordingTask
+	impressionsEnabled bool
+	listener           impressionlistener.ImpressionBulkListener
+	dataSinks          datasink.Dispatcher
+}
+
+// NewOFREPController instantiates a new ofrep controller
+func NewOFREPController(
+	logger logging.LoggerInterface,
+	evaluator evaluator.Interface,
+	flags EvaluationFlags,
+	keyScopes *flagsets.KeyScopes,
+	impressionsSink tasks.DeferredRecordingTask,
+	impressionsEnabled bool,
+	listener impressionlistener.ImpressionBulkListener,
+	dataSinks datasink.Dispatcher,
+) *OFREPController {
+	return &OFREPController{
+		logger:             logger,
+		evaluator:          evaluator,
+		flags:              flags,
+		keyScopes:          keyScopes,
+		impressionsSink:    impressionsSink,
+		impressionsEnabled: impressionsEnabled,
+		listener:           listener,
+		dataSinks:          dataSinks,
+	}
+}
+
+// Register mounts the ofrep endpoints onto the supplied router
+func (c *OFREPController) Register(router gin.IRouter) {
+	router.POST("/evaluate/flags/:key", c.EvaluateFlag)
+	router.POST("/evaluate/flags", c.EvaluateFlags)
+}
+
+type ofrepRequest struct {
+	Context map[string]interface{} `json:"context"`
+}
+
+type ofrepEvaluation struct {
+	Key          string                 `json:"key"`
+	Reason       string                 `json:"reason,omitempty"`
+	Variant      string                 `json:"variant,omitempty"`
+	Value        interface{}            `json:"value,omitempty"`
+	ErrorCode    string                 `json:"errorCode,omitempty"`
+	ErrorDetails string                 `json:"errorDetails,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type ofrepBulkResponse struct {
+	Flags []ofrepEvaluation `json:"flags"`
+}
+
+type ofrepError struct {
+	ErrorCode    string `json:"errorCode"`
+	ErrorDetails string `json:"errorDetails,omitempty"`
+}
+
+// evaluationContext holds the parsed targeting key, bucketing key & attributes of an evaluation request
+type evaluationContext struct {
+	key          string
+	bucketingKey *string
+	attributes   map[string]interface{}
+}
+
+// EvaluateFlag evaluates a single feature flag for the supplied context
+func (c *OFREPController) EvaluateFlag(ctx *gin.Context) {
+	flag := ctx.Param("key")
+	evCtx, errCode, err := parseEvaluationContext(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ofrepEvaluation{Key: flag, ErrorCode: errCode, ErrorDetails: err.Error()})
+		return
+	}
+
+	if !c.isInScope(ctx, flag) {
+		ctx.JSON(http.StatusNotFound, ofrepEvaluation{Key: flag, ErrorCode: ofrepErrorFlagNotFound, ErrorDetails: "flag not found"})
+		return
+	}
+
+	_, span := tracing.Start(ctx.Request.Context(), "Evaluator.EvaluateFeature", attribute.String("split.flag", flag))
+	result := c.evaluator.EvaluateFeature(evCtx.key, evCtx.bucketingKey, flag, evCtx.attributes)
+	span.End()
+
+	evaluation := toOFREPEvaluation(flag, result)
+	switch evaluation.ErrorCode {
+	case ofrepErrorFlagNotFound:
+		ctx.JSON(http.StatusNotFound, evaluation)
+		return
+	case ofrepErrorGeneral:
+		ctx.JSON(http.StatusBadRequest, evaluation)
+		return
+	}
+
+	c.stageImpressions(ctx, evCtx, map[string]evaluator.Result{flag: *result})
+	ctx.JSON(http.StatusOK, evaluation)
+}
+
+// EvaluateFlags evaluates all the feature flags available to the requesting client key for the supplied context
+func (c *OFREPController) EvaluateFlags(ctx *gin.Context) {
+	evCtx, errCode, err := parseEvaluationContext(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, ofrepError{ErrorCode: errCode, ErrorDetails: err.Error()})
+		return
+	}
+
+	var results evaluator.Results
+	_, span := tracing.Start(ctx.Request.Context(), "Evaluator.EvaluateFeatures")
+	if scope := c.keyScopes.For(ctx.GetString(middleware.APIKeyContextKey)); scope != nil {
+		sets, _ := scope.Sanitize(nil)
+		results = c.evaluator.EvaluateFeatureByFlagSets(evCtx.key, evCtx.bucketingKey, sets, evCtx.attributes)
+	} else {
+		results = c.evaluator.EvaluateFeatures(evCtx.key, evCtx.bucketingKey, c.flags.SplitNames(), evCtx.attributes)
+	}
+	span.SetAttributes(attribute.Int("split.flags", len(results.Evaluations)))
+	span.End()
+
+	response := ofrepBulkResponse{Flags: make([]ofrepEvaluation, 0, len(results.Evaluations))}
+	for flag, result := range results.Evaluations {
+		response.Flags = append(response.Flags, toOFREPEvaluation(flag, &result))
+	}
+	sort.Slice(response.Flags, func(i, j int) bool { return response.Flags[i].Key < response.Flags[j].Key })
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.logger.Error("error serializing bulk evaluation response: ", err)
+		ctx.JSON(http.StatusInternalServerError, ofrepError{ErrorCode: ofrepErrorGeneral})
+		return
+	}
+
+	// evaluations are deterministic, so the same body is returned as long as flags & context remain unchanged
+	hasher := fnv.New64a()
+	hasher.Write(body)
+	etag := `"` + strconv.FormatUint(hasher.Sum64(), 16) + `"`
+	ctx.Header("ETag", etag)
+	if middleware.ETagMatches(ctx.Request.Header.Get("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	// impressions are only recorded for evaluations actually delivered to the client
+	c.stageImpressions(ctx, evCtx, results.Evaluations)
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// isInScope checks whether the requested flag belongs to the flag sets allowed for the requesting client key (if restricted)
+func (c *OFREPController) isInScope(ctx *gin.Context, flag string) bool {
+	scope := c.keyScopes.For(ctx.GetString(middleware.APIKeyContextKey))
+	if scope == nil {
+		return true
+	}
+
+	split := c.flags.Split(flag)
+	return split != nil && scope.AllowsAny(split.Sets)
+}
+
+// stageImpressions builds an impressions bulk for the supplied evaluations & pushes it into the impressions sink,
+// as well as the impression listener & data sinks, same as impressions posted by sdks
+func (c *OFREPController) stageImpressions(ctx *gin.Context, evCtx *evaluationContext, results map[string]evaluator.Result) {
+	if !c.impressionsEnabled || c.impressionsSink == nil {
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	bulk := make([]dtos.ImpressionsDTO, 0, len(results))
+	for flag, result := range results {
+		if result.ImpressionsDisabled || result.Label == impressionlabels.SplitNotFound {
+			continue
+		}
+		impression := dtos.ImpressionDTO{
+			KeyName:      evCtx.key,
+			Treatment:    result.Treatment,
+			Time:         now,
+			ChangeNumber: result.SplitChangeNumber,
+			Label:        result.Label,
+		}
+		if evCtx.bucketingKey != nil {
+			impression.BucketingKey = *evCtx.bucketingKey
+		}
+		bulk = append(bulk, dtos.ImpressionsDTO{TestName: flag, KeyImpressions: []dtos.ImpressionDTO{impression}})
+	}
+
+	if len(bulk) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(bulk)
+	if err != nil {
+		c.logger.Error("error serializing impressions for evaluations: ", err)
+		return
+	}
+
+	metadata := metadataFromHeaders(ctx)
+	if metadata.SDKVersion == "" {
+		metadata.SDKVersion = "ofrep-proxy-" + splitio.Version
+	}
+	if c.listener != nil || c.dataSinks != nil {
+		go forwardImpressions(c.logger, c.listener, c.dataSinks, bulk, &metadata)
+	}
+
+	if err := c.impressionsSink.Stage(internal.NewRawImpressions(metadata, conf.ImpressionsModeDebug, payload)); err != nil {
+		c.logger.Error("error staging impressions for evaluations: ", err)
+	}
+}
+
+// parseEvaluationContext reads the evaluation context from the request body & converts its values into
+// the types expected by the matchers
+func parseEvaluationContext(ctx *gin.Context) (*evaluationContext, string, error) {
+	var body ofrepRequest
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, ofrepErrorParse, fmt.Errorf("error parsing request body: %w", err)
+	}
+
+	raw, ok := body.Context[ofrepTargetingKey]
+	if !ok {
+		return nil, ofrepErrorTargetingMissing, errTargetingKeyMissing
+	}
+	key, ok := raw.(string)
+	if !ok || key == "" {
+		return nil, ofrepErrorInvalidContext, errors.New("targetingKey must be a non-empty string")
+	}
+
+	evCtx := &evaluationContext{key: key, attributes: make(map[string]interface{}, len(body.Context))}
+	for name, value := range body.Context {
+		switch name {
+		case ofrepTargetingKey:
+		case ofrepBucketingKey:
+			bucketingKey, ok := value.(string)
+			if !ok {
+				return nil, ofrepErrorInvalidContext, errors.New("bucketingKey must be a string")
+			}
+			evCtx.bucketingKey = &bucketingKey
+		default:
+			evCtx.attributes[name] = normalizeAttribute(value)
+		}
+	}
+	return evCtx, "", nil
+}
+
+// normalizeAttribute converts json numbers into int64 (when possible) and lists of strings into []string
+func normalizeAttribute(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if asInt, err := typed.Int64(); err == nil {
+			return asInt
+		}
+		asFloat, _ := typed.Float64()
+		return asFloat
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			asString, ok := item.(string)
+			if !ok {
+				return value
+			}
+			items = append(items, asString)
+		}
+		return items
+	}
+	return value
+}
+
+func toOFREPEvaluation(flag string, result *evaluator.Result) ofrepEvaluation {
+	if result.Label == impressionlabels.SplitNotFound {
+		return ofrepEvaluation{Key: flag, ErrorCode: ofrepErrorFlagNotFound, ErrorDetails: "flag not found"}
+	}
+
+	evaluation := ofrepEvaluation{
+		Key:      flag,
+		Reason:   reasonFor(result),
+		Variant:  result.Treatment,
+		Value:    result.Treatment,
+		Metadata: map[string]interface{}{"label": result.Label, "changeNumber": result.SplitChangeNumber},
+	}
+	if result.Config != nil {
+		evaluation.Metadata["config"] = *result.Config
+	}
+	if evaluation.Reason == ofrepReasonError {
+		evaluation.ErrorCode = ofrepErrorGeneral
+		evaluation.ErrorDetails = result.Label
+	}
+	return evaluation
+}
+
+func reasonFor(result *evaluator.Result) string {
+	switch {
+	case result.Treatment == evaluator.Control:
+		return ofrepReasonError
+	case result.Label == impressionlabels.Killed:
+		return ofrepReasonDisabled
+	case result.Label == impressionlabels.NoConditionMatched, result.Label == impressionlabels.NotInSplit:
+		return ofrepReasonDefault
+	}
+	return ofrepReasonTargetingMatch
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	dsMock "github.com/splitio/split-synchronizer/v5/splitio/common/datasink/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	ilMock "github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
+	pFlagsets "github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks/mocks"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
+	"github.com/splitio/go-split-commons/v9/flagsets"
+	inmemory "github.com/splitio/go-split-commons/v9/storage/inmemory/mutexmap"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOFREPEvaluateFlag(t *testing.T) {
+	var staged []*internal.RawImpressions
+	router, _ := setupOFREPRouter(t, nil, &staged)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user1","age":21}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var evaluation ofrepEvaluation
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+	assert.Equal(t, "adults", evaluation.Key)
+	assert.Equal(t, "on", evaluation.Variant)
+	assert.Equal(t, "on", evaluation.Value)
+	assert.Equal(t, ofrepReasonTargetingMatch, evaluation.Reason)
+	assert.Equal(t, `{"color":"blue"}`, evaluation.Metadata["config"])
+	assert.Equal(t, "age >= 18", evaluation.Metadata["label"])
+
+	resp = ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user2","age":12,"bucketingKey":"bk"}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	evaluation = ofrepEvaluation{}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+	assert.Equal(t, "off", evaluation.Variant)
+	assert.Equal(t, ofrepReasonDefault, evaluation.Reason)
+	assert.Nil(t, evaluation.Metadata["config"])
+
+	resp = ofrepPost(router, "/ofrep/v1/evaluate/flags/killed", `{"context":{"targetingKey":"user1"}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+	assert.Equal(t, ofrepReasonDisabled, evaluation.Reason)
+
+	assert.Len(t, staged, 3)
+	var bulk []dtos.ImpressionsDTO
+	assert.Nil(t, json.Unmarshal(staged[1].Payload, &bulk))
+	assert.Len(t, bulk, 1)
+	assert.Equal(t, "adults", bulk[0].TestName)
+	assert.Equal(t, "user2", bulk[0].KeyImpressions[0].KeyName)
+	assert.Equal(t, "bk", bulk[0].KeyImpressions[0].BucketingKey)
+	assert.Equal(t, "off", bulk[0].KeyImpressions[0].Treatment)
+	assert.Equal(t, int64(123), bulk[0].KeyImpressions[0].ChangeNumber)
+	assert.Equal(t, "go-1.1.1", staged[1].Metadata.SDKVersion)
+	assert.Equal(t, "debug", staged[1].Mode)
+}
+
+func TestOFREPEvaluateFlagErrors(t *testing.T) {
+	var staged []*internal.RawImpressions
+	router, _ := setupOFREPRouter(t, nil, &staged)
+
+	cases := []struct {
+		body   string
+		flag   string
+		status int
+		code   string
+	}{
+		{body: `{"context":{"targetingKey":"user1"}}`, flag: "nonexistent", status: http.StatusNotFound, code: ofrepErrorFlagNotFound},
+		{body: `{"context":{"age":21}}`, flag: "adults", status: http.StatusBadRequest, code: ofrepErrorTargetingMissing},
+		{body: `{"context":{"targetingKey":123}}`, flag: "adults", status: http.StatusBadRequest, code: ofrepErrorInvalidContext},
+		{body: `{"context":`, flag: "adults", status: http.StatusBadRequest, code: ofrepErrorParse},
+	}
+
+	for _, tc := range cases {
+		resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/"+tc.flag, tc.body, "")
+		assert.Equal(t, tc.status, resp.Code, tc.body)
+		var evaluation ofrepEvaluation
+		assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &evaluation))
+		assert.Equal(t, tc.code, evaluation.ErrorCode, tc.body)
+		assert.Equal(t, tc.flag, evaluation.Key)
+	}
+	assert.Empty(t, staged)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user1"}}`, "wrongkey")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestOFREPEvaluateFlagsBulk(t *testing.T) {
+	var staged []*internal.RawImpressions
+	router, _ := setupOFREPRouter(t, nil, &staged)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"user1","age":30,"roles":["admin"]}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var bulk ofrepBulkResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &bulk))
+	assert.Len(t, bulk.Flags, 3)
+	assert.Equal(t, "admins", bulk.Flags[0].Key)
+	assert.Equal(t, "on", bulk.Flags[0].Variant)
+	assert.Equal(t, "adults", bulk.Flags[1].Key)
+	assert.Equal(t, "on", bulk.Flags[1].Variant)
+	assert.Equal(t, "killed", bulk.Flags[2].Key)
+	assert.Equal(t, ofrepReasonDisabled, bulk.Flags[2].Reason)
+
+	assert.Len(t, staged, 1)
+	var imps []dtos.ImpressionsDTO
+	assert.Nil(t, json.Unmarshal(staged[0].Payload, &imps))
+	assert.Len(t, imps, 3)
+
+	etag := resp.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest(http.MethodPost, "/ofrep/v1/evaluate/flags", strings.NewReader(`{"context":{"targetingKey":"user1","age":30,"roles":["admin"]}}`))
+	req.Header.Set("Authorization", "Bearer someApiKey")
+	req.Header.Set("If-None-Match", etag)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.Bytes())
+	assert.Len(t, staged, 1) // nothing is recorded for unmodified responses
+}
+
+func TestOFREPScopedClientKey(t *testing.T) {
+	var staged []*internal.RawImpressions
+	router, _ := setupOFREPRouter(t, []pFlagsets.ScopeConfig{{Apikey: "scopedKey", FlagSets: []string{"backend"}}}, &staged)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/admins", `{"context":{"targetingKey":"user1"}}`, "scopedKey")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	resp = ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user1","age":30}}`, "scopedKey")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = ofrepPost(router, "/ofrep/v1/evaluate/flags", `{"context":{"targetingKey":"user1","age":30}}`, "scopedKey")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var bulk ofrepBulkResponse
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &bulk))
+	assert.Len(t, bulk.Flags, 1)
+	assert.Equal(t, "adults", bulk.Flags[0].Key)
+}
+
+func TestOFREPImpressionsDisabled(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	splitStorage, ev := setupOFREPEvaluator(logger)
+	sink := &mocks.MockDeferredRecordingTask{StageCall: func(interface{}) error {
+		t.Error("impressions should not be staged")
+		return nil
+	}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/ofrep/v1")
+	NewOFREPController(logger, ev, splitStorage, pFlagsets.NewKeyScopes(nil, nil), sink, false, nil, nil).Register(group)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user1"}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestOFREPImpressionsForwarded(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	splitStorage, ev := setupOFREPEvaluator(logger)
+	sink := &mocks.MockDeferredRecordingTask{StageCall: func(interface{}) error { return nil }}
+
+	toListener := make(chan []impressionlistener.ImpressionsForListener, 1)
+	listener := &ilMock.ImpressionBulkListenerMock{SubmitCall: func(imps []impressionlistener.ImpressionsForListener, _ *dtos.Metadata) error {
+		toListener <- imps
+		return nil
+	}}
+	toSinks := make(chan []datasink.Impression, 1)
+	dataSinks := &dsMock.DispatcherMock{SubmitImpressionsCall: func(imps []datasink.Impression) error {
+		toSinks <- imps
+		return nil
+	}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/ofrep/v1")
+	NewOFREPController(logger, ev, splitStorage, pFlagsets.NewKeyScopes(nil, nil), sink, true, listener, dataSinks).Register(group)
+
+	resp := ofrepPost(router, "/ofrep/v1/evaluate/flags/adults", `{"context":{"targetingKey":"user1","age":30}}`, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	select {
+	case imps := <-toListener:
+		assert.Len(t, imps, 1)
+		assert.Equal(t, "adults", imps[0].TestName)
+		assert.Equal(t, "user1", imps[0].KeyImpressions[0].KeyName)
+	case <-time.After(time.Second):
+		t.Error("impressions should be forwarded to the listener")
+	}
+
+	select {
+	case imps := <-toSinks:
+		assert.Len(t, imps, 1)
+	case <-time.After(time.Second):
+		t.Error("impressions should be forwarded to the data sinks")
+	}
+}
+
+func TestNormalizeAttribute(t *testing.T) {
+	assert.Equal(t, int64(3), normalizeAttribute(json.Number("3")))
+	assert.Equal(t, 3.5, normalizeAttribute(json.Number("3.5")))
+	assert.Equal(t, []string{"a", "b"}, normalizeAttribute([]interface{}{"a", "b"}))
+	assert.Equal(t, []interface{}{"a", true}, normalizeAttribute([]interface{}{"a", true}))
+	assert.Equal(t, true, normalizeAttribute(true))
+	assert.Equal(t, "x", normalizeAttribute("x"))
+}
+
+func setupOFREPRouter(t *testing.T, scopes []pFlagsets.ScopeConfig, staged *[]*internal.RawImpressions) (*gin.Engine, *inmemory.MMSplitStorage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+	splitStorage, ev := setupOFREPEvaluator(logger)
+
+	sink := &mocks.MockDeferredRecordingTask{StageCall: func(raw interface{}) error {
+		*staged = append(*staged, raw.(*internal.RawImpressions))
+		return nil
+	}}
+
+	keyScopes := pFlagsets.NewKeyScopes(scopes, nil)
+	router := gin.New()
+	group := router.Group("/ofrep/v1")
+	group.Use(middleware.NewAPIKeyValidator(append(keyScopes.Apikeys(), "someApiKey")).AsMiddleware)
+	NewOFREPController(logger, ev, splitStorage, keyScopes, sink, true, nil, nil).Register(group)
+	return router, splitStorage
+}
+
+func setupOFREPEvaluator(logger logging.LoggerInterface) (*inmemory.MMSplitStorage, *evaluator.Evaluator) {
+	splitStorage := inmemory.NewMMSplitStorage(flagsets.NewFlagSetFilter(nil))
+	splitStorage.Update([]dtos.SplitDTO{
+		ofrepTestSplit("adults", []string{"backend"}, false, dtos.MatcherDTO{
+			MatcherType:  "GREATER_THAN_OR_EQUAL_TO",
+			KeySelector:  &dtos.KeySelectorDTO{TrafficType: "user", Attribute: ofrepStrRef("age")},
+			UnaryNumeric: &dtos.UnaryNumericMatcherDataDTO{DataType: "NUMBER", Value: 18},
+		}, "age >= 18"),
+		ofrepTestSplit("admins", []string{"frontend"}, false, dtos.MatcherDTO{
+			MatcherType: "PART_OF_SET",
+			KeySelector: &dtos.KeySelectorDTO{TrafficType: "user", Attribute: ofrepStrRef("roles")},
+			Whitelist:   &dtos.WhitelistMatcherDataDTO{Whitelist: []string{"admin"}},
+		}, "admin role"),
+		ofrepTestSplit("killed", []string{"frontend"}, true, dtos.MatcherDTO{
+			MatcherType: "ALL_KEYS",
+			KeySelector: &dtos.KeySelectorDTO{TrafficType: "user"},
+		}, "default rule"),
+	}, nil, 123)
+
+	ev := evaluator.NewEvaluator(
+		splitStorage,
+		inmemory.NewMMSegmentStorage(),
+		inmemory.NewRuleBasedSegmentsStorage(),
+		inmemory.NewLargeSegmentsStorage(),
+		engine.NewEngine(logger),
+		logger,
+		adminCommon.ProducerFeatureFlagsRules,
+		adminCommon.ProducerRuleBasedSegmentRules,
+		dtos.NewFallbackTreatmentCalculatorImp(nil))
+	return splitStorage, ev
+}
+
+func ofrepTestSplit(name string, sets []string, killed bool, matcher dtos.MatcherDTO, label string) dtos.SplitDTO {
+	return dtos.SplitDTO{
+		Name:              name,
+		Status:            "ACTIVE",
+		Killed:            killed,
+		DefaultTreatment:  "off",
+		TrafficTypeName:   "user",
+		TrafficAllocation: 100,
+		Algo:              2,
+		Seed:              1234,
+		ChangeNumber:      123,
+		Sets:              sets,
+		Configurations:    map[string]string{"on": `{"color":"blue"}`},
+		Conditions: []dtos.ConditionDTO{{
+			ConditionType: "ROLLOUT",
+			Label:         label,
+			MatcherGroup:  dtos.MatcherGroupDTO{Combiner: "AND", Matchers: []dtos.MatcherDTO{matcher}},
+			Partitions:    []dtos.PartitionDTO{{Treatment: "on", Size: 100}},
+		}},
+	}
+}
+
+func ofrepPost(router *gin.Engine, path string, body string, apikey string) *httptest.ResponseRecorder {
+	if apikey == "" {
+		apikey = "someApiKey"
+	}
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apikey)
+	req.Header.Set("SplitSDKVersion", "go-1.1.1")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func ofrepStrRef(s string) *string {
+	return &s
+}
@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/common/tracing"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 
@@ -20,6 +23,8 @@ import (
 	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 )
 
@@ -27,10 +32,12 @@ import (
 type SdkServerController struct {
 	logger                logging.LoggerInterface
 	fetcher               service.SplitFetcher
+	segmentFetcher        service.SegmentFetcher
 	proxySplitStorage     storage.ProxySplitStorage
 	proxyRBSegmentStorage storage.ProxyRuleBasedSegmentsStorage
 	proxySegmentStorage   storage.ProxySegmentStorage
 	fsmatcher             flagsets.FlagSetMatcher
+	keyScopes             *flagsets.KeyScopes
 	versionFilter         specs.SplitVersionFilter
 	largeSegmentStorage   cmnStorage.LargeSegmentsStorage
 	specVersion           string
@@ -40,20 +47,24 @@ type SdkServerController struct {
 func NewSdkServerController(
 	logger logging.LoggerInterface,
 	fetcher service.SplitFetcher,
+	segmentFetcher service.SegmentFetcher,
 	proxySplitStorage storage.ProxySplitStorage,
 	proxySegmentStorage storage.ProxySegmentStorage,
 	proxyRBSegmentStorage storage.ProxyRuleBasedSegmentsStorage,
 	fsmatcher flagsets.FlagSetMatcher,
+	keyScopes *flagsets.KeyScopes,
 	largeSegmentStorage cmnStorage.LargeSegmentsStorage,
 	specVersion string,
 ) *SdkServerController {
 	return &SdkServerController{
 		logger:                logger,
 		fetcher:               fetcher,
+		segmentFetcher:        segmentFetcher,
 		proxySplitStorage:     proxySplitStorage,
 		proxySegmentStorage:   proxySegmentStorage,
 		proxyRBSegmentStorage: proxyRBSegmentStorage,
 		fsmatcher:             fsmatcher,
+		keyScopes:             keyScopes,
 		versionFilter:         specs.NewSplitVersionFilter(),
 		largeSegmentStorage:   largeSegmentStorage,
 		specVersion:           specVersion,
@@ -71,24 +82,31 @@ func (c *SdkServerController) Register(router gin.IRouter) {
 func (c *SdkServerController) Memberships(ctx *gin.Context) {
 	c.logger.Debug(fmt.Sprintf("Headers: %v", ctx.Request.Header))
 	key := ctx.Param("key")
+	_, span := tracing.Start(ctx.Request.Context(), "ProxySegmentStorage.SegmentsFor")
 	segmentList, err := c.proxySegmentStorage.SegmentsFor(key)
+	tracing.End(span, err)
 	if err != nil {
 		c.logger.Error(fmt.Sprintf("error fetching segments for user '%s': %s", key, err.Error()))
 		ctx.JSON(http.StatusInternalServerError, gin.H{})
 		return
 	}
 
+	// memberships are sorted so that the body is byte-for-byte stable for a given etag
+	segmentList = sortedCopy(segmentList)
 	mySegments := make([]dtos.Segment, 0, len(segmentList))
 	for _, segmentName := range segmentList {
 		mySegments = append(mySegments, dtos.Segment{Name: segmentName})
 	}
 
-	lsList := c.largeSegmentStorage.LargeSegmentsForUser(key)
+	_, span = tracing.Start(ctx.Request.Context(), "LargeSegmentsStorage.LargeSegmentsForUser")
+	lsList := sortedCopy(c.largeSegmentStorage.LargeSegmentsForUser(key))
+	span.End()
 	myLargeSegments := make([]dtos.Segment, 0, len(lsList))
 	for _, name := range lsList {
 		myLargeSegments = append(myLargeSegments, dtos.Segment{Name: name})
 	}
 
+	setETag(ctx, caching.MakeMembershipsETag(segmentList, lsList))
 	payoad := dtos.MembershipsResponseDTO{
 		MySegments: dtos.Memberships{
 			Segments: mySegments,
@@ -119,14 +137,16 @@ func (c *SdkServerController) SplitChanges(ctx *gin.Context) {
 	if fq, ok := ctx.GetQuery("sets"); ok {
 		rawSets = strings.Split(fq, ",")
 	}
-	sets := c.fsmatcher.Sanitize(rawSets)
-	if !slices.Equal(sets, rawSets) {
-		c.logger.Warning(fmt.Sprintf("SDK [%s] is sending flagsets unordered or with duplicates.", ctx.Request.Header.Get("SplitSDKVersion")))
+	sets, err := c.sanitizeSets(ctx, rawSets)
+	if err != nil {
+		c.logger.Warning(fmt.Sprintf("SDK [%s] requested flag sets %v outside of it's client key scope.", ctx.Request.Header.Get("SplitSDKVersion"), rawSets))
+		ctx.JSON(http.StatusForbidden, gin.H{"code": 403, "message": err.Error()})
+		return
 	}
 
 	c.logger.Debug(fmt.Sprintf("SDK Fetches Feature Flags Since: %d, RBSince: %d", since, rbsince))
 
-	rules, err := c.fetchRulesSince(since, rbsince, sets)
+	rules, err := c.fetchRulesSince(ctx.Request.Context(), since, rbsince, sets)
 	if err != nil {
 		c.logger.Error("error fetching splitChanges payload from storage: ", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -142,6 +162,7 @@ func (c *SdkServerController) SplitChanges(ctx *gin.Context) {
 	}
 
 	rules.FeatureFlags.Splits = c.patchUnsupportedMatchers(rules.FeatureFlags.Splits, spec)
+	setETag(ctx, caching.MakeSplitChangesETag(rules.FeatureFlags.Till, rules.RuleBasedSegments.Till, sets, spec))
 
 	if spec == specs.FLAG_V1_3 {
 		ctx.JSON(http.StatusOK, rules)
@@ -168,7 +189,13 @@ func (c *SdkServerController) SegmentChanges(ctx *gin.Context) {
 
 	segmentName := ctx.Param("name")
 	c.logger.Debug(fmt.Sprintf("SDK Fetches Segment: %s Since: %d", segmentName, since))
+	_, span := tracing.Start(ctx.Request.Context(), "ProxySegmentStorage.ChangesSince", attribute.String("split.segment", segmentName), attribute.Int64("split.since", since))
 	payload, err := c.proxySegmentStorage.ChangesSince(segmentName, since)
+	endStorageSpan(span, err)
+	if errors.Is(err, storage.ErrSinceParamTooOld) {
+		// keys removed back then are no longer known, so the diff is fetched from split servers
+		payload, err = c.fetchSegmentSince(ctx.Request.Context(), segmentName, since)
+	}
 	if err != nil {
 		if errors.Is(err, storage.ErrSegmentNotFound) {
 			c.logger.Error("the following segment was requested and is not present: ", segmentName)
@@ -181,6 +208,7 @@ func (c *SdkServerController) SegmentChanges(ctx *gin.Context) {
 		return
 	}
 
+	setETag(ctx, caching.MakeSegmentChangesETag(payload.Till))
 	ctx.JSON(http.StatusOK, payload)
 	ctx.Set(caching.SurrogateContextKey, []string{caching.MakeSurrogateForSegmentChanges(segmentName)})
 	ctx.Set(caching.StickyContextKey, true)
@@ -190,7 +218,9 @@ func (c *SdkServerController) SegmentChanges(ctx *gin.Context) {
 func (c *SdkServerController) MySegments(ctx *gin.Context) {
 	c.logger.Debug(fmt.Sprintf("Headers: %v", ctx.Request.Header))
 	key := ctx.Param("key")
+	_, span := tracing.Start(ctx.Request.Context(), "ProxySegmentStorage.SegmentsFor")
 	segmentList, err := c.proxySegmentStorage.SegmentsFor(key)
+	tracing.End(span, err)
 	if err != nil {
 		c.logger.Error(fmt.Sprintf("error fetching segments for user '%s': %s", key, err.Error()))
 		ctx.JSON(http.StatusInternalServerError, gin.H{})
@@ -205,9 +235,44 @@ func (c *SdkServerController) MySegments(ctx *gin.Context) {
 	ctx.Set(caching.SurrogateContextKey, caching.MakeSurrogateForMySegments(mySegments))
 }
 
-func (c *SdkServerController) fetchRulesSince(since int64, rbsince int64, sets []string) (*dtos.RuleChangesDTO, error) {
+// sortedCopy returns a sorted copy of the input, leaving the original (which may be shared by the storage) untouched
+func sortedCopy(items []string) []string {
+	toRet := slices.Clone(items)
+	slices.Sort(toRet)
+	return toRet
+}
+
+// setETag sets a strong entity tag for the response. Each content encoding is a different representation
+// and therefore gets a different tag
+func setETag(ctx *gin.Context, tag string) {
+	if encoding := ctx.Writer.Header().Get("Content-Encoding"); encoding != "" {
+		tag += "-" + encoding
+	}
+	ctx.Header("ETag", `"`+tag+`"`)
+}
+
+// sanitizeSets applies the client key's scope if there's one (already intersected with the global flag sets filter),
+// or the global flag set matcher otherwise
+func (c *SdkServerController) sanitizeSets(ctx *gin.Context, rawSets []string) ([]string, error) {
+	if scope := c.keyScopes.For(ctx.GetString(middleware.APIKeyContextKey)); scope != nil {
+		return scope.Sanitize(rawSets)
+	}
+
+	sets := c.fsmatcher.Sanitize(rawSets)
+	if !slices.Equal(sets, rawSets) {
+		c.logger.Warning(fmt.Sprintf("SDK [%s] is sending flagsets unordered or with duplicates.", ctx.Request.Header.Get("SplitSDKVersion")))
+	}
+	return sets, nil
+}
+
+func (c *SdkServerController) fetchRulesSince(ctx context.Context, since int64, rbsince int64, sets []string) (*dtos.RuleChangesDTO, error) {
+	_, span := tracing.Start(ctx, "ProxySplitStorage.ChangesSince", attribute.Int64("split.since", since))
 	splits, err := c.proxySplitStorage.ChangesSince(since, sets)
+	endStorageSpan(span, err)
+
+	_, span = tracing.Start(ctx, "ProxyRuleBasedSegmentsStorage.ChangesSince", attribute.Int64("split.since", rbsince))
 	rbs, rbsErr := c.proxyRBSegmentStorage.ChangesSince(rbsince)
+	endStorageSpan(span, rbsErr)
 	if err != nil && !errors.Is(err, storage.ErrSinceParamTooOld) {
 		return nil, fmt.Errorf("unexpected error fetching feature flag changes from storage: %w", err)
 	}
@@ -230,7 +295,9 @@ func (c *SdkServerController) fetchRulesSince(since int64, rbsince int64, sets [
 	// perform a fetch to the BE using the supplied `since`, have the storage process it's response &, retry
 	// TODO(mredolatti): implement basic collapsing here to avoid flooding the BE with requests
 	fetchOptions := service.MakeFlagRequestParams().WithSpecVersion(common.StringRef(c.specVersion)).WithChangeNumber(since).WithChangeNumberRB(rbsince).WithFlagSetsFilter(strings.Join(sets, ",")) // at this point the sets have been sanitized & sorted
+	_, span = tracing.Start(ctx, "SplitFetcher.Fetch", attribute.Int64("split.since", since), attribute.Int64("split.rbSince", rbsince))
 	ruleChanges, err := c.fetcher.Fetch(fetchOptions)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -248,6 +315,26 @@ func (c *SdkServerController) fetchRulesSince(since int64, rbsince int64, sets [
 	}, nil
 }
 
+func (c *SdkServerController) fetchSegmentSince(ctx context.Context, name string, since int64) (*dtos.SegmentChangesDTO, error) {
+	_, span := tracing.Start(ctx, "SegmentFetcher.Fetch", attribute.String("split.segment", name), attribute.Int64("split.since", since))
+	payload, err := c.segmentFetcher.Fetch(name, service.MakeSegmentRequestParams().WithChangeNumber(since))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching segment changes from split servers: %w", err)
+	}
+	return payload, nil
+}
+
+// endStorageSpan finishes a span for a storage lookup. A `since` too old for the storage to answer is not
+// considered an error, since it's handled by fetching from split servers
+func endStorageSpan(span trace.Span, err error) {
+	if errors.Is(err, storage.ErrSinceParamTooOld) {
+		span.SetAttributes(attribute.Bool("split.sinceTooOld", true))
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
 func (c *SdkServerController) shouldOverrideSplitCondition(split *dtos.SplitDTO, version string) bool {
 	for _, condition := range split.Conditions {
 		for _, matcher := range condition.MatcherGroup.Matchers {
@@ -7,20 +7,27 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/airgap"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	psmocks "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/engine/evaluator/impressionlabels"
 	"github.com/splitio/go-split-commons/v9/engine/grammar"
 	"github.com/splitio/go-split-commons/v9/engine/grammar/constants"
+	cmnFlagsets "github.com/splitio/go-split-commons/v9/flagsets"
 	"github.com/splitio/go-split-commons/v9/service"
 	"github.com/splitio/go-split-commons/v9/service/api/specs"
 	"github.com/splitio/go-split-commons/v9/service/mocks"
 	cmnStorage "github.com/splitio/go-split-commons/v9/storage"
 	"github.com/splitio/go-toolkit/v5/common"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/gin-gonic/gin"
@@ -48,10 +55,12 @@ func TestSplitChangesImpressionsDisabled(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_3,
 	)
@@ -103,10 +112,12 @@ func TestSplitChangesRecentSince(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -135,6 +146,65 @@ func TestSplitChangesRecentSince(t *testing.T) {
 	splitFetcher.AssertExpectations(t)
 }
 
+func TestSplitChangesScopedClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var splitStorage psmocks.ProxySplitStorageMock
+	splitStorage.On("ChangesSince", int64(-1), []string{"s1", "s2"}).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{{Name: "f1", Status: "ACTIVE", Sets: []string{"s1"}}}}, nil).
+		Once()
+	splitStorage.On("ChangesSince", int64(-1), []string{"s2"}).
+		Return(&dtos.SplitChangesDTO{Since: -1, Till: 1, Splits: []dtos.SplitDTO{}}, nil).
+		Twice()
+	var rbsStorage psmocks.MockProxyRuleBasedSegmentStorage
+	rbsStorage.On("ChangesSince", int64(-1)).Return(&dtos.RuleBasedSegmentsDTO{})
+
+	splitFetcher := &mocks.MockSplitFetcher{}
+	var largeSegmentStorageMock largeSegmentStorageMock
+
+	resp := httptest.NewRecorder()
+	_, router := gin.CreateTestContext(resp)
+	group := router.Group("/api")
+	group.Use(middleware.NewAPIKeyValidator([]string{"scoped", "strict"}).AsMiddleware)
+	controller := NewSdkServerController(
+		logging.NewLogger(nil),
+		splitFetcher,
+		nil,
+		&splitStorage,
+		nil,
+		&rbsStorage,
+		flagsets.NewMatcher(false, nil),
+		flagsets.NewKeyScopes([]flagsets.ScopeConfig{
+			{Apikey: "scoped", FlagSets: []string{"s2", "s1"}},
+			{Apikey: "strict", FlagSets: []string{"s2"}, StrictMatching: true},
+		}, nil),
+		&largeSegmentStorageMock,
+		specs.FLAG_V1_2,
+	)
+	controller.Register(group)
+
+	doRequest := func(apikey string, query string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/splitChanges?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+apikey)
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// no sets requested, all the allowed ones are used
+	assert.Equal(t, 200, doRequest("scoped", "since=-1").Code)
+
+	// non-strict: sets outside of the scope are dropped, unless nothing remains
+	assert.Equal(t, 200, doRequest("scoped", "since=-1&sets=s2,s7").Code)
+	assert.Equal(t, 403, doRequest("scoped", "since=-1&sets=s7").Code)
+
+	// strict: sets outside of the scope cause the request to be rejected
+	assert.Equal(t, 200, doRequest("strict", "since=-1&sets=s2").Code)
+	assert.Equal(t, 403, doRequest("strict", "since=-1&sets=s2,s7").Code)
+
+	splitStorage.AssertExpectations(t)
+}
+
 func TestSplitChangesOlderSince(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -164,10 +234,12 @@ func TestSplitChangesOlderSince(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -223,10 +295,12 @@ func TestSplitChangesOlderSinceFetchFails(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -268,10 +342,12 @@ func TestSplitChangesWithFlagSets(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -322,10 +398,12 @@ func TestSplitChangesWithFlagSetsStrict(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(true, []string{"a", "c"}),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -394,10 +472,12 @@ func TestSplitChangesNewMatcherOldSpec(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -468,10 +548,12 @@ func TestSplitChangesNewMatcherNewSpec(t *testing.T) {
 	controller := NewSdkServerController(
 		logger,
 		splitFetcher,
+		nil,
 		&splitStorage,
 		nil,
 		&rbsStorage,
 		flagsets.NewMatcher(false, nil),
+		nil,
 		&largeSegmentStorageMock,
 		specs.FLAG_V1_2,
 	)
@@ -529,7 +611,7 @@ func TestSegmentChanges(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=-1", nil)
@@ -575,7 +657,7 @@ func TestSegmentChangesNotFound(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=-1", nil)
@@ -591,6 +673,125 @@ func TestSegmentChangesNotFound(t *testing.T) {
 	segmentStorage.AssertExpectations(t)
 }
 
+func TestSegmentChangesSinceTooOld(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	splitFetcher := &mocks.MockSplitFetcher{}
+	segmentFetcher := &mocks.MockSegmentFetcher{
+		FetchCall: func(name string, fetchOptions *service.SegmentRequestParams) (*dtos.SegmentChangesDTO, error) {
+			assert.Equal(t, "someSegment", name)
+			assert.Equal(t, int64(1000), fetchOptions.ChangeNumber())
+			return &dtos.SegmentChangesDTO{Name: "someSegment", Added: []string{"k2"}, Removed: []string{"k1"}, Since: 1000, Till: 1200}, nil
+		},
+	}
+	var splitStorage psmocks.ProxySplitStorageMock
+	var segmentStorage psmocks.ProxySegmentStorageMock
+	segmentStorage.On("ChangesSince", "someSegment", int64(1000)).
+		Return((*dtos.SegmentChangesDTO)(nil), storage.ErrSinceParamTooOld).
+		Once()
+	var rbsStorage psmocks.MockProxyRuleBasedSegmentStorage
+	var largeSegmentStorageMock largeSegmentStorageMock
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	group := router.Group("/api")
+	controller := NewSdkServerController(logging.NewLogger(nil), splitFetcher, segmentFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller.Register(group)
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=1000", nil)
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var s dtos.SegmentChangesDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &s))
+	assert.Equal(t, dtos.SegmentChangesDTO{Name: "someSegment", Added: []string{"k2"}, Removed: []string{"k1"}, Since: 1000, Till: 1200}, s)
+	segmentStorage.AssertExpectations(t)
+
+	// upstream failures are reported as such
+	segmentStorage.On("ChangesSince", "someSegment", int64(1000)).
+		Return((*dtos.SegmentChangesDTO)(nil), storage.ErrSinceParamTooOld).
+		Once()
+	segmentFetcher.FetchCall = func(name string, fetchOptions *service.SegmentRequestParams) (*dtos.SegmentChangesDTO, error) {
+		return nil, errors.New("something")
+	}
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 500, resp.Code)
+}
+
+func TestAirGappedChangesSinceTooOld(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	logger := logging.NewLogger(nil)
+
+	splitStorage := storage.NewProxySplitStorage(dbw, logger, cmnFlagsets.NewFlagSetFilter(nil), false, 0, 0)
+	splitStorage.Update([]dtos.SplitDTO{{Name: "s1", ChangeNumber: 10, Status: "ACTIVE"}, {Name: "s2", ChangeNumber: 10, Status: "ACTIVE"}}, nil, 10)
+	splitStorage.Update([]dtos.SplitDTO{{Name: "s3", ChangeNumber: 20, Status: "ACTIVE"}}, []dtos.SplitDTO{{Name: "s1", ChangeNumber: 20, Status: "ARCHIVED"}}, 20)
+
+	rbsStorage := storage.NewProxyRuleBasedSegmentsStorage(dbw, logger, false)
+	assert.Nil(t, rbsStorage.Update([]dtos.RuleBasedSegmentDTO{{Name: "rb1", ChangeNumber: 10, Status: "ACTIVE"}}, nil, 10))
+
+	segmentStorage := storage.NewProxySegmentStorage(dbw, logger, false, 100*time.Millisecond)
+	assert.Nil(t, segmentStorage.Update("someSegment", set.NewSet("k1", "k2"), set.NewSet(), 1000))
+	assert.Nil(t, segmentStorage.Update("someSegment", set.NewSet("k3"), set.NewSet("k1"), 1050))
+	assert.Nil(t, segmentStorage.Update("someSegment", set.NewSet("k4"), set.NewSet(), 1200))
+	segmentStorage.CompactRemovedKeys()
+
+	var largeSegmentStorageMock largeSegmentStorageMock
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+
+	group := router.Group("/api")
+	controller := NewSdkServerController(
+		logger,
+		airgap.SplitFetcher(splitStorage, rbsStorage),
+		airgap.SegmentFetcher(segmentStorage),
+		splitStorage,
+		segmentStorage,
+		rbsStorage,
+		flagsets.NewMatcher(false, nil),
+		nil,
+		&largeSegmentStorageMock,
+		specs.FLAG_V1_3,
+	)
+	controller.Register(group)
+
+	// sdks older than the local history get every active flag & rule-based segment, not an empty diff
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/splitChanges?s=1.3&since=5&rbSince=5", nil)
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var rules dtos.RuleChangesDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &rules))
+	assert.Equal(t, int64(20), rules.FeatureFlags.Till)
+	names := make([]string, 0, len(rules.FeatureFlags.Splits))
+	for _, split := range rules.FeatureFlags.Splits {
+		names = append(names, split.Name)
+	}
+	assert.ElementsMatch(t, []string{"s2", "s3"}, names)
+	assert.Equal(t, int64(10), rules.RuleBasedSegments.Till)
+	assert.Len(t, rules.RuleBasedSegments.RuleBasedSegments, 1)
+
+	// same for segments whose removed keys have been compacted
+	resp = httptest.NewRecorder()
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/segmentChanges/someSegment?since=1000", nil)
+	ctx.Request.Header.Set("Authorization", "Bearer someApiKey")
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var segment dtos.SegmentChangesDTO
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &segment))
+	assert.Equal(t, []string{"k2", "k3", "k4"}, segment.Added)
+	assert.Empty(t, segment.Removed)
+	assert.Equal(t, int64(1200), segment.Till)
+}
+
 func TestMySegments(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -612,7 +813,7 @@ func TestMySegments(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/mySegments/someKey", nil)
@@ -658,7 +859,7 @@ func TestMySegmentsError(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/mySegments/someKey", nil)
@@ -681,14 +882,14 @@ func TestMemberships(t *testing.T) {
 	var splitStorage psmocks.ProxySplitStorageMock
 	var segmentStorage psmocks.ProxySegmentStorageMock
 	segmentStorage.On("SegmentsFor", "keyTest").
-		Return([]string{"segment1", "segment2"}, nil).
+		Return([]string{"segment2", "segment1"}, nil).
 		Once()
 	var rbsStorage psmocks.MockProxyRuleBasedSegmentStorage
 	rbsStorage.On("ChangesSince", int64(-1)).Return(&dtos.RuleBasedSegmentsDTO{}).Once()
 
 	var largeSegmentStorageMock largeSegmentStorageMock
 	largeSegmentStorageMock.On("LargeSegmentsForUser", "keyTest").
-		Return([]string{"largeSegment1", "largeSegment2"}).
+		Return([]string{"largeSegment2", "largeSegment1"}).
 		Once()
 
 	resp := httptest.NewRecorder()
@@ -697,7 +898,7 @@ func TestMemberships(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/memberships/keyTest", nil)
@@ -724,6 +925,7 @@ func TestMemberships(t *testing.T) {
 		},
 	}
 	assert.Equal(t, expectedDTO, actualDTO)
+	assert.Equal(t, `"`+caching.MakeMembershipsETag([]string{"segment1", "segment2"}, []string{"largeSegment1", "largeSegment2"})+`"`, resp.Header().Get("ETag"))
 
 	splitStorage.AssertExpectations(t)
 	splitFetcher.AssertExpectations(t)
@@ -749,7 +951,7 @@ func TestMembershipsError(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	group := router.Group("/api")
-	controller := NewSdkServerController(logger, splitFetcher, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), &largeSegmentStorageMock, specs.FLAG_V1_2)
+	controller := NewSdkServerController(logger, splitFetcher, nil, &splitStorage, &segmentStorage, &rbsStorage, flagsets.NewMatcher(false, nil), nil, &largeSegmentStorageMock, specs.FLAG_V1_2)
 	controller.Register(group)
 
 	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/memberships/keyTest", nil)
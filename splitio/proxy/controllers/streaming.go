@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultKeepAlive = 30 * time.Second
+
+var keepAliveFrame = []byte(":keepalive\n\n")
+
+// StreamingServerController serves SSE connections from sdks using locally issued tokens
+type StreamingServerController struct {
+	logger      logging.LoggerInterface
+	hub         *streaming.Hub
+	tokenIssuer *streaming.TokenIssuer
+	keepAlive   time.Duration
+}
+
+// NewStreamingServerController returns a new streaming server controller
+func NewStreamingServerController(
+	logger logging.LoggerInterface,
+	hub *streaming.Hub,
+	tokenIssuer *streaming.TokenIssuer,
+	keepAlive time.Duration,
+) *StreamingServerController {
+	if keepAlive <= 0 {
+		keepAlive = defaultKeepAlive
+	}
+	return &StreamingServerController{
+		logger:      logger,
+		hub:         hub,
+		tokenIssuer: tokenIssuer,
+		keepAlive:   keepAlive,
+	}
+}
+
+// Register mounts the streaming endpoint onto the supplied router
+func (c *StreamingServerController) Register(router gin.IRouter) {
+	router.GET("/sse", c.SSE)
+}
+
+// SSE validates the access token and keeps the connection open, forwarding notifications from the hub
+func (c *StreamingServerController) SSE(ctx *gin.Context) {
+	allowed, err := c.tokenIssuer.Validate(ctx.Query("accessToken"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
+		return
+	}
+
+	channels := filterChannels(streaming.ParseChannels(ctx.Query("channels")), allowed)
+	if len(channels) == 0 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "no valid channels requested"})
+		return
+	}
+
+	flusher, ok := ctx.Writer.(http.Flusher)
+	if !ok {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	sub := c.hub.Subscribe(channels)
+	defer c.hub.Unsubscribe(sub)
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Status(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+	for {
+		var frame []byte
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case frame = <-sub.Messages():
+		case <-ticker.C:
+			frame = keepAliveFrame
+		}
+
+		if _, err := ctx.Writer.Write(frame); err != nil {
+			c.logger.Debug("error writing to streaming client: ", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func filterChannels(requested []string, allowed []string) []string {
+	allowedSet := make(map[string]struct{}, len(allowed))
+	for _, channel := range allowed {
+		allowedSet[channel] = struct{}{}
+	}
+
+	toRet := make([]string, 0, len(requested))
+	for _, channel := range requested {
+		if _, ok := allowedSet[channel]; ok {
+			toRet = append(toRet, channel)
+		}
+	}
+	return toRet
+}
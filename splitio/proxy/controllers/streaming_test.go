@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthStreamingDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	NewAuthServerController(logging.NewLogger(nil), nil).Register(router.Group("/api"))
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/v2/auth", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+	assert.JSONEq(t, `{"pushEnabled":false,"token":""}`, resp.Body.String())
+}
+
+func TestAuthStreamingEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuer, _ := streaming.NewTokenIssuer("secret", time.Hour, "123")
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	NewAuthServerController(logging.NewLogger(nil), issuer).Register(router.Group("/api"))
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/api/v2/auth", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 200, resp.Code)
+
+	var body struct {
+		PushEnabled bool   `json:"pushEnabled"`
+		Token       string `json:"token"`
+	}
+	assert.Nil(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.True(t, body.PushEnabled)
+	_, err := issuer.Validate(body.Token)
+	assert.Nil(t, err)
+
+	// client-side sdks keep using polling
+	resp = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v2/auth?users=key1", nil)
+	router.ServeHTTP(resp, req)
+	assert.JSONEq(t, `{"pushEnabled":false,"token":""}`, resp.Body.String())
+}
+
+func TestStreamingInvalidToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuer, _ := streaming.NewTokenIssuer("secret", time.Hour, "123")
+	hub := streaming.NewHub(logging.NewLogger(nil), "123", 10)
+
+	resp := httptest.NewRecorder()
+	ctx, router := gin.CreateTestContext(resp)
+	NewStreamingServerController(logging.NewLogger(nil), hub, issuer, time.Second).Register(router)
+
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/sse?channels=123_splits&accessToken=invalid", nil)
+	router.ServeHTTP(resp, ctx.Request)
+	assert.Equal(t, 401, resp.Code)
+	assert.Equal(t, 0, hub.Subscribers())
+}
+
+func TestStreamingNotifications(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := logging.NewLogger(nil)
+	issuer, _ := streaming.NewTokenIssuer("secret", time.Hour, "123")
+	hub := streaming.NewHub(logger, "123", 10)
+
+	router := gin.New()
+	NewStreamingServerController(logger, hub, issuer, 50*time.Millisecond).Register(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	token, _ := issuer.Issue()
+	query := url.Values{}
+	query.Set("accessToken", token)
+	query.Set("channels", "123_splits,[?occupancy=metrics.publishers]control_pri,unknown")
+	resp, err := http.Get(server.URL + "/sse?" + query.Encode())
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.Nil(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	assert.Contains(t, readEvent(), "[meta]occupancy")
+	assert.Equal(t, 1, hub.Subscribers())
+
+	hub.NotifySplitUpdate(123)
+	event := readEvent()
+	for strings.HasPrefix(event, ":keepalive") {
+		event = readEvent()
+	}
+	assert.Contains(t, event, "SPLIT_UPDATE")
+	assert.Contains(t, event, `"channel":"123_splits"`)
+
+	// keepalives are sent periodically
+	assert.Equal(t, ":keepalive\n", readEvent())
+}
@@ -0,0 +1,160 @@
+package flagsets
+
+import (
+	"errors"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// ErrFlagSetsNotAllowed is returned when a client key requests flag sets outside of it's scope
+var ErrFlagSetsNotAllowed = errors.New("requested flag sets are not allowed for this client key")
+
+// KeyScope restricts the flag sets that a specific client key is allowed to fetch
+type KeyScope struct {
+	id      string
+	strict  bool
+	allowed map[string]struct{}
+	sorted  []string
+}
+
+// NewKeyScope builds a scope allowing only the supplied flag sets.
+// In strict mode, requests including any set outside of the allowed ones are rejected.
+// Otherwise, such sets are silently dropped
+func NewKeyScope(id string, strict bool, allowed []string) *KeyScope {
+	scope := &KeyScope{
+		id:      id,
+		strict:  strict,
+		allowed: make(map[string]struct{}, len(allowed)),
+	}
+
+	for _, set := range allowed {
+		if !setContains(scope.allowed, set) {
+			scope.allowed[set] = struct{}{}
+			scope.sorted = append(scope.sorted, set)
+		}
+	}
+	slices.Sort(scope.sorted)
+	return scope
+}
+
+// ID returns a unique identifier for this scope, suitable for building cache keys
+func (s *KeyScope) ID() string {
+	return s.id
+}
+
+// AllowsAny returns true if at least one of the supplied sets is allowed by this scope
+func (s *KeyScope) AllowsAny(sets []string) bool {
+	for _, set := range sets {
+		if setContains(s.allowed, set) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sanitize sorts & dedupes the requested sets, and restricts them to the allowed ones.
+// If no sets are requested, all the allowed sets are returned.
+func (s *KeyScope) Sanitize(input []string) ([]string, error) {
+	if len(input) == 0 {
+		return slices.Clone(s.sorted), nil
+	}
+
+	seen := make(map[string]struct{}, len(input))
+	toRet := make([]string, 0, len(input))
+	for _, set := range input {
+		if setContains(seen, set) {
+			continue
+		}
+		seen[set] = struct{}{}
+
+		if !setContains(s.allowed, set) {
+			if s.strict {
+				return nil, ErrFlagSetsNotAllowed
+			}
+			continue
+		}
+		toRet = append(toRet, set)
+	}
+
+	if len(toRet) == 0 {
+		// never fall back to an empty list, since that would mean "all flags"
+		return nil, ErrFlagSetsNotAllowed
+	}
+
+	slices.Sort(toRet)
+	return toRet, nil
+}
+
+// KeyScopes maps client keys to their flag set scopes
+type KeyScopes struct {
+	scopes map[string]*KeyScope
+}
+
+// ScopeConfig is used to build a scope for a specific client key
+type ScopeConfig struct {
+	Apikey         string
+	FlagSets       []string
+	StrictMatching bool
+}
+
+// NewKeyScopes builds a set of per-client-key scopes. If the proxy is restricted to a global set of flag sets,
+// each scope is intersected with it, so that a scoped key can never request sets outside of the global filter
+func NewKeyScopes(configs []ScopeConfig, globalSets []string) *KeyScopes {
+	toRet := &KeyScopes{scopes: make(map[string]*KeyScope, len(configs))}
+	for idx := range configs {
+		toRet.scopes[configs[idx].Apikey] = NewKeyScope(
+			strconv.Itoa(idx),
+			configs[idx].StrictMatching,
+			Intersect(configs[idx].FlagSets, globalSets),
+		)
+	}
+	return toRet
+}
+
+// Intersect returns the sets in `scoped` that are also part of `global`. An empty global list means no
+// restriction and returns the scoped sets unchanged
+func Intersect(scoped []string, global []string) []string {
+	if len(global) == 0 {
+		return scoped
+	}
+
+	allowed := make(map[string]struct{}, len(global))
+	for _, set := range global {
+		allowed[set] = struct{}{}
+	}
+
+	toRet := make([]string, 0, len(scoped))
+	for _, set := range scoped {
+		if setContains(allowed, set) {
+			toRet = append(toRet, set)
+		}
+	}
+	return toRet
+}
+
+// For returns the scope associated to an apikey. A nil scope means the apikey is not restricted
+func (k *KeyScopes) For(apikey string) *KeyScope {
+	if k == nil {
+		return nil
+	}
+	return k.scopes[apikey]
+}
+
+// Empty returns true if no client key is scoped
+func (k *KeyScopes) Empty() bool {
+	return k == nil || len(k.scopes) == 0
+}
+
+// Apikeys returns the list of scoped client keys
+func (k *KeyScopes) Apikeys() []string {
+	if k == nil {
+		return nil
+	}
+
+	toRet := make([]string, 0, len(k.scopes))
+	for apikey := range k.scopes {
+		toRet = append(toRet, apikey)
+	}
+	return toRet
+}
@@ -0,0 +1,68 @@
+package flagsets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyScope(t *testing.T) {
+	s := NewKeyScope("0", false, []string{"s3", "s1", "s2", "s1"})
+	res, err := s.Sanitize(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s1", "s2", "s3"}, res)
+
+	res, err = s.Sanitize([]string{"s2", "s1", "s2"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s1", "s2"}, res)
+
+	res, err = s.Sanitize([]string{"s2", "s7"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s2"}, res)
+
+	_, err = s.Sanitize([]string{"s7"})
+	assert.ErrorIs(t, err, ErrFlagSetsNotAllowed)
+
+	s = NewKeyScope("1", true, []string{"s1", "s2"})
+	res, err = s.Sanitize([]string{"s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s1"}, res)
+
+	_, err = s.Sanitize([]string{"s1", "s7"})
+	assert.ErrorIs(t, err, ErrFlagSetsNotAllowed)
+}
+
+func TestKeyScopes(t *testing.T) {
+	var empty *KeyScopes
+	assert.True(t, empty.Empty())
+	assert.Nil(t, empty.For("k1"))
+
+	scopes := NewKeyScopes([]ScopeConfig{
+		{Apikey: "k1", FlagSets: []string{"s1"}},
+		{Apikey: "k2", FlagSets: []string{"s2"}, StrictMatching: true},
+	}, nil)
+	assert.False(t, scopes.Empty())
+	assert.ElementsMatch(t, []string{"k1", "k2"}, scopes.Apikeys())
+	assert.Nil(t, scopes.For("k3"))
+	assert.NotEqual(t, scopes.For("k1").ID(), scopes.For("k2").ID())
+}
+
+func TestKeyScopesIntersectGlobalFilter(t *testing.T) {
+	scopes := NewKeyScopes([]ScopeConfig{
+		{Apikey: "k1", FlagSets: []string{"s1", "s2", "s3"}},
+	}, []string{"s2", "s3", "s4"})
+
+	res, err := scopes.For("k1").Sanitize(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s2", "s3"}, res)
+
+	res, err = scopes.For("k1").Sanitize([]string{"s1", "s2"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"s2"}, res)
+
+	_, err = scopes.For("k1").Sanitize([]string{"s4"})
+	assert.ErrorIs(t, err, ErrFlagSetsNotAllowed)
+
+	assert.Equal(t, []string{"s1"}, Intersect([]string{"s1"}, nil))
+	assert.Empty(t, Intersect([]string{"s1"}, []string{"s2"}))
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -12,21 +13,34 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/admin"
 	adminCommon "github.com/splitio/split-synchronizer/v5/splitio/admin/common"
 	"github.com/splitio/split-synchronizer/v5/splitio/common"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
 	ssync "github.com/splitio/split-synchronizer/v5/splitio/common/sync"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/tracing"
 	hcApplication "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application"
 	hcAppCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/application/counter"
 	hcServices "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services"
 	hcServicesCounter "github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/airgap"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 	pconf "github.com/splitio/split-synchronizer/v5/splitio/proxy/conf"
+	pFlagsets "github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/localhost"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/ratelimit"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/snapshots"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
 	pTasks "github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 	"github.com/splitio/split-synchronizer/v5/splitio/util"
 
 	"github.com/splitio/go-split-commons/v9/conf"
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/engine"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
 	"github.com/splitio/go-split-commons/v9/engine/grammar"
 	"github.com/splitio/go-split-commons/v9/flagsets"
 	"github.com/splitio/go-split-commons/v9/service/api"
@@ -34,21 +48,53 @@ import (
 	"github.com/splitio/go-split-commons/v9/synchronizer"
 	"github.com/splitio/go-split-commons/v9/tasks"
 	"github.com/splitio/go-split-commons/v9/telemetry"
-	"github.com/splitio/go-toolkit/v5/backoff"
+	"github.com/splitio/go-toolkit/v5/asynctask"
 	"github.com/splitio/go-toolkit/v5/logging"
 )
 
 // Start initialize in proxy mode
 func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
-	clientKey, err := util.GetClientKey(cfg.Apikey)
+	// in localhost mode, flags & segments are read from a local file & no data is sent to Split
+	localhostMode := cfg.Localhost.File != ""
+
+	// in air-gapped mode, data is served from the startup snapshot & sdk data is recorded into bundles to be uploaded later
+	airGapped := cfg.AirGap.Enabled
+	if airGapped && (localhostMode || cfg.Initialization.Snapshot == "") {
+		return common.NewInitError(errors.New("air-gapped mode requires a snapshot & cannot be combined with localhost mode"), common.ExitInvalidConfiguration)
+	}
+	offline := localhostMode || airGapped
+
+	scopeConfigs, err := makeScopeConfigs(cfg.Server.ClientKeyScopes, cfg.FlagSetsFilter)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("invalid client key scopes: %w", err), common.ExitInvalidConfiguration)
+	}
+
+	var clientKey string
+	if !localhostMode {
+		if clientKey, err = util.GetClientKey(cfg.Apikey); err != nil {
+			return common.NewInitError(fmt.Errorf("error parsing client key from provided apikey: %w", err), common.ExitInvalidApikey)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(&cfg.Tracing, "split-proxy")
 	if err != nil {
-		return common.NewInitError(fmt.Errorf("error parsing client key from provided apikey: %w", err), common.ExitInvalidApikey)
+		return common.NewInitError(fmt.Errorf("error setting up tracing: %w", err), common.ExitInvalidConfiguration)
+	}
+
+	snapshotKeys, err := snapshot.LoadKeys(
+		cfg.SnapshotSecurity.SigningKeyFn,
+		cfg.SnapshotSecurity.VerifyingKeyFn,
+		cfg.SnapshotSecurity.EncryptionKeyFn,
+		"SPLIT_PROXY_SNAPSHOT_ENCRYPTION_KEY",
+	)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error loading snapshot keys: %w", err), common.ExitInvalidConfiguration)
 	}
 
 	// Initialization of DB
 	var dbpath = persistent.BoltInMemoryMode
 	if snapFile := cfg.Initialization.Snapshot; snapFile != "" {
-		snap, err := snapshot.DecodeFromFile(snapFile)
+		snap, err := snapshot.DecodeFromFile(snapFile, snapshotKeys)
 		if err != nil {
 			return fmt.Errorf("error parsing snapshot file: %w", err)
 		}
@@ -85,15 +131,72 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 	// FlagSetsFilter
 	flagSetsFilter := flagsets.NewFlagSetFilter(cfg.FlagSetsFilter)
 
-	// Setup fetchers & recorders
-	splitAPI := api.NewSplitAPI(cfg.Apikey, *advanced, logger, metadata)
-
 	// Proxy storages already implement the observable interface, so no need to wrap them
-	splitStorage := storage.NewProxySplitStorage(dbInstance, logger, flagsets.NewFlagSetFilter(cfg.FlagSetsFilter), cfg.Initialization.Snapshot != "")
+	splitStorage := storage.NewProxySplitStorage(
+		dbInstance,
+		logger,
+		flagsets.NewFlagSetFilter(cfg.FlagSetsFilter),
+		cfg.Initialization.Snapshot != "",
+		int(cfg.Storage.History.Capacity),
+		time.Duration(cfg.Storage.History.RetentionSecs)*time.Second,
+	)
 	ruleBasedStorage := storage.NewProxyRuleBasedSegmentsStorage(dbInstance, logger, cfg.Initialization.Snapshot != "")
-	segmentStorage := storage.NewProxySegmentStorage(dbInstance, logger, cfg.Initialization.Snapshot != "")
+	segmentStorage := storage.NewProxySegmentStorage(dbInstance, logger, cfg.Initialization.Snapshot != "",
+		time.Duration(cfg.Storage.Persistent.SegmentTombstoneRetentionSecs)*time.Second)
 	largeSegmentStorage := inmemory.NewLargeSegmentsStorage()
 
+	// Setup fetchers & recorders
+	splitAPI := api.NewSplitAPI(cfg.Apikey, *advanced, logger, metadata)
+	if cfg.Tracing.Enabled {
+		splitAPI = tracing.WrapSplitAPI(splitAPI)
+	}
+
+	splitFetcher := splitAPI.SplitFetcher
+	segmentFetcher := splitAPI.SegmentFetcher
+	splitPeriod := int(cfg.Sync.SplitRefreshRateMs / 1000)
+	segmentPeriod := int(cfg.Sync.SegmentRefreshRateMs / 1000)
+	largeSegmentFetcher := splitAPI.LargeSegmentFetcher
+	var eventsLocal, telemetryLocal pTasks.RawRecorder // set when sdk data is not posted to Split
+	if localhostMode {
+		source, err := localhost.NewSource(cfg.Localhost.File, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error reading localhost file: %w", err), common.ExitInvalidConfiguration)
+		}
+		splitFetcher = source.SplitFetcher()
+		segmentFetcher = source.SegmentFetcher()
+		splitPeriod = max(1, int(cfg.Localhost.RefreshRateMs/1000))
+		segmentPeriod = splitPeriod
+		advanced.StreamingEnabled = false
+
+		localRecorder, err := pTasks.NewFileRawRecorder(cfg.Localhost.OutputDir)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error setting up localhost output: %w", err), common.ExitInvalidConfiguration)
+		}
+		eventsLocal, telemetryLocal = localRecorder, localRecorder
+		logger.Info(fmt.Sprintf("Running in localhost mode. Serving definitions from '%s' & writing sdk data to '%s'", cfg.Localhost.File, cfg.Localhost.OutputDir))
+	}
+
+	var bundleWriter *airgap.Writer
+	if airGapped {
+		splitFetcher = airgap.SplitFetcher(splitStorage, ruleBasedStorage)
+		segmentFetcher = airgap.SegmentFetcher(segmentStorage)
+		largeSegmentFetcher = airgap.LargeSegmentFetcher()
+		advanced.StreamingEnabled = false
+
+		bundleWriter, err = airgap.NewWriter(logger, airgap.WriterConfig{
+			Directory:      cfg.AirGap.BundleDir,
+			SealPeriodSecs: int(cfg.AirGap.SealPeriodSecs),
+			MaxRecords:     int(cfg.AirGap.MaxRecords),
+			ApikeyHash:     strconv.Itoa(int(util.HashAPIKey(cfg.Apikey))),
+		})
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error setting up export bundles: %w", err), common.ExitInvalidConfiguration)
+		}
+		eventsLocal = bundleWriter.Recorder(airgap.ServiceEvents)
+		telemetryLocal = bundleWriter.Recorder(airgap.ServiceTelemetry)
+		logger.Info(fmt.Sprintf("Running in air-gapped mode. Serving data from '%s' & recording sdk data into bundles in '%s'", cfg.Initialization.Snapshot, cfg.AirGap.BundleDir))
+	}
+
 	// Local telemetry
 	tbufferSize := int(cfg.Sync.Advanced.TelemetryBuffer)
 	tworkers := int(cfg.Sync.Advanced.TelemetryWorkers)
@@ -107,10 +210,15 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 	// Healcheck Monitor
 	splitsConfig, segmentsConfig, lsConfig := getAppCounterConfigs()
 	appMonitor := hcApplication.NewMonitorImp(splitsConfig, segmentsConfig, &lsConfig, nil, logger)
-	servicesMonitor := hcServices.NewMonitorImp(getServicesCountersConfig(*advanced), logger)
+	var servicesConfig []hcServicesCounter.Config
+	if !offline {
+		servicesConfig = getServicesCountersConfig(*advanced)
+	}
+	servicesMonitor := hcServices.NewMonitorImp(servicesConfig, logger)
 
 	// Creating Workers and Tasks
-	telemetryRecorder := api.NewHTTPTelemetryRecorder(cfg.Apikey, *advanced, logger)
+	httpTimeout := time.Duration(cfg.Sync.Advanced.HTTPTimeoutMs) * time.Millisecond
+	telemetryRecorder := rawRecorder(cfg, telemetryLocal, advanced.TelemetryServiceURL, httpTimeout, logger)
 	telemetryConfigTask := pTasks.NewTelemetryConfigFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
 	telemetryUsageTask := pTasks.NewTelemetryUsageFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
 	telemetryKeysClientSideTask := pTasks.NewTelemetryKeysClientSideFlushTask(telemetryRecorder, logger, 1, tbufferSize, tworkers)
@@ -119,12 +227,49 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 	// impression bulks & counts - events
 	ibufferSize := int(cfg.Sync.Advanced.ImpressionsBuffer)
 	iworkers := int(cfg.Sync.Advanced.ImpressionsWorkers)
-	impressionRecorder := api.NewHTTPImpressionRecorder(cfg.Apikey, *advanced, logger)
+	impressionRecorder := rawRecorder(cfg, eventsLocal, advanced.EventsURL, httpTimeout, logger)
 	impressionTask := pTasks.NewImpressionsFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers)
 	impressionCountTask := pTasks.NewImpressionCountFlushTask(impressionRecorder, logger, 1, ibufferSize, iworkers)
-	eventsRecorder := api.NewHTTPEventsRecorder(cfg.Apikey, *advanced, logger)
+	eventsRecorder := rawRecorder(cfg, eventsLocal, advanced.EventsURL, httpTimeout, logger)
 	eventsTask := pTasks.NewEventsFlushTask(eventsRecorder, logger, 1, int(cfg.Sync.Advanced.EventsBuffer), int(cfg.Sync.Advanced.EventsWorkers))
 
+	forwarders := []struct {
+		name string
+		url  string
+		task *pTasks.DeferredRecordingTaskImpl
+	}{
+		{name: "impressions", url: advanced.EventsURL + "/testImpressions/bulk", task: impressionTask},
+		{name: "impression-counts", url: advanced.EventsURL + "/testImpressions/count", task: impressionCountTask},
+		{name: "events", url: advanced.EventsURL + "/events/bulk", task: eventsTask},
+		{name: "telemetry-config", url: advanced.TelemetryServiceURL + "/metrics/config", task: telemetryConfigTask},
+		{name: "telemetry-usage", url: advanced.TelemetryServiceURL + "/metrics/usage", task: telemetryUsageTask},
+		{name: "telemetry-keys-clientside", url: advanced.TelemetryServiceURL + "/keys/cs", task: telemetryKeysClientSideTask},
+		{name: "telemetry-keys-serverside", url: advanced.TelemetryServiceURL + "/keys/ss", task: telemetryKeysServerSideTask},
+	}
+
+	// workers forwarding to the same endpoint share a circuit breaker, which reports to the services healthcheck
+	breakerCfg := pTasks.BreakerConfig{
+		FailureThreshold: int(cfg.Sync.Breaker.FailureThreshold),
+		BaseBackoff:      time.Duration(cfg.Sync.Breaker.BaseBackoffMs) * time.Millisecond,
+		MaxBackoff:       time.Duration(cfg.Sync.Breaker.MaxBackoffMs) * time.Millisecond,
+	}
+	for _, fwd := range forwarders {
+		breaker := pTasks.NewCircuitBreaker(fwd.name, fwd.url, breakerCfg, logger)
+		fwd.task.ProtectWith(breaker)
+		servicesMonitor.AddCounter(breaker)
+	}
+
+	var spillStore *pTasks.SpillStore
+	if scfg := cfg.Storage.Spill; scfg.Filename != "" {
+		spillStore, err = pTasks.NewSpillStore(scfg.Filename, scfg.MaxBytes, time.Duration(scfg.MaxAgeSecs)*time.Second, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error setting up spill storage: %w", err), common.ExitTaskInitialization)
+		}
+		for _, fwd := range forwarders {
+			fwd.task.SpillTo(spillStore.Queue(fwd.name), localTelemetryStorage)
+		}
+	}
+
 	ruleBuilder := grammar.NewRuleBuilder(
 		segmentStorage,
 		ruleBasedStorage,
@@ -134,20 +279,40 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 		logger,
 		nil)
 
+	// local streaming hub, used to notify connected sdks when feature flags or segments change
+	var notifier streaming.Notifier
+	var streamingHub *streaming.Hub
+	var tokenIssuer *streaming.TokenIssuer
+	if scfg := cfg.Server.Streaming; scfg.Enabled {
+		channelPrefix := strconv.Itoa(int(util.HashAPIKey(cfg.Apikey)))
+		tokenIssuer, err = streaming.NewTokenIssuer(scfg.TokenSecret, time.Duration(scfg.TokenTTLSecs)*time.Second, channelPrefix)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error setting up streaming token issuer: %w", err), common.ExitTaskInitialization)
+		}
+		streamingHub = streaming.NewHub(logger, channelPrefix, int(scfg.QueueSize))
+		notifier = streamingHub
+	}
+
+	// proxy's own telemetry is not reported in localhost & air-gapped modes
+	var telemetrySync telemetry.TelemetrySynchronizer = &telemetry.NoOp{}
+	if !offline {
+		telemetrySync = telemetry.NewTelemetrySynchronizer(localTelemetryStorage, splitAPI.TelemetryRecorder, splitStorage, segmentStorage, logger,
+			metadata, localTelemetryStorage)
+	}
+
 	// setup feature flags, segments & local telemetry API interactions
 	workers := synchronizer.Workers{
-		SplitUpdater: caching.NewCacheAwareSplitSync(splitStorage, ruleBasedStorage, splitAPI.SplitFetcher, logger, localTelemetryStorage, httpCache, appMonitor, flagSetsFilter, advanced.FlagsSpecVersion, ruleBuilder),
-		SegmentUpdater: caching.NewCacheAwareSegmentSync(splitStorage, segmentStorage, ruleBasedStorage, splitAPI.SegmentFetcher, logger, localTelemetryStorage, httpCache,
-			appMonitor),
-		TelemetryRecorder: telemetry.NewTelemetrySynchronizer(localTelemetryStorage, telemetryRecorder, splitStorage, segmentStorage, logger,
-			metadata, localTelemetryStorage),
-		LargeSegmentUpdater: caching.NewCacheAwareLargeSegmentSync(splitStorage, largeSegmentStorage, splitAPI.LargeSegmentFetcher, logger, localTelemetryStorage, httpCache, appMonitor),
+		SplitUpdater: caching.NewCacheAwareSplitSync(splitStorage, ruleBasedStorage, splitFetcher, logger, localTelemetryStorage, httpCache, appMonitor, flagSetsFilter, advanced.FlagsSpecVersion, ruleBuilder, notifier),
+		SegmentUpdater: caching.NewCacheAwareSegmentSync(splitStorage, segmentStorage, ruleBasedStorage, segmentFetcher, logger, localTelemetryStorage, httpCache,
+			appMonitor, notifier),
+		TelemetryRecorder:   telemetrySync,
+		LargeSegmentUpdater: caching.NewCacheAwareLargeSegmentSync(splitStorage, largeSegmentStorage, largeSegmentFetcher, logger, localTelemetryStorage, httpCache, appMonitor),
 	}
 
 	// setup periodic tasks in case streaming is disabled or we need to fall back to polling
 	stasks := synchronizer.SplitTasks{
-		SplitSyncTask: tasks.NewFetchSplitsTask(workers.SplitUpdater, int(cfg.Sync.SplitRefreshRateMs/1000), logger),
-		SegmentSyncTask: tasks.NewFetchSegmentsTask(workers.SegmentUpdater, int(cfg.Sync.SegmentRefreshRateMs/1000), advanced.SegmentWorkers,
+		SplitSyncTask: tasks.NewFetchSplitsTask(workers.SplitUpdater, splitPeriod, logger),
+		SegmentSyncTask: tasks.NewFetchSegmentsTask(workers.SegmentUpdater, segmentPeriod, advanced.SegmentWorkers,
 			advanced.SegmentQueueSize, logger, appMonitor),
 		TelemetrySyncTask:        tasks.NewRecordTelemetryTask(workers.TelemetryRecorder, int(cfg.Sync.Advanced.InternalMetricsRateMs), logger),
 		ImpressionSyncTask:       impressionTask,
@@ -182,7 +347,7 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 	// health monitors are only started after successful init (otherwise they'll fail if the app doesn't sync correctly within the
 	/// specified refresh period)
 	before := time.Now()
-	err = startBGSync(syncManager, mstatus, cfg.Initialization.Snapshot != "", func() {
+	err = ssync.StartBGSync(syncManager, mstatus, cfg.Initialization.Snapshot != "", func() {
 		logger.Info("Synchronizer tasks started")
 		appMonitor.Start()
 		servicesMonitor.Start()
@@ -205,9 +370,9 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 		)
 	})
 	switch err {
-	case errRetrying:
+	case ssync.ErrRetrying:
 		logger.Warning("Failed to perform initial sync with Split servers but continuing from snapshot. Will keep retrying in BG")
-	case errUnrecoverable:
+	case ssync.ErrUnrecoverable:
 		logger.Error("Initial synchronization failed. Either Split is unreachable or the SDK key is incorrect. Aborting execution.")
 		return common.NewInitError(fmt.Errorf("error instantiating sync manager: %w", err), common.ExitTaskInitialization)
 	}
@@ -221,52 +386,31 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 		RuleBasedSegmentsStorage: ruleBasedStorage,
 	}
 
-	// --------------------------- ADMIN DASHBOARD ------------------------------
-	cfgForAdmin := *cfg
-	hash := util.HashAPIKey(cfgForAdmin.Apikey + cfg.FlagSpecVersion + strings.Join(cfg.FlagSetsFilter, "::"))
-	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
-
-	adminTLSConfig, err := util.TLSConfigForServer(&cfg.Admin.TLS)
+	tlsConfig, err := util.TLSConfigForServer(&cfg.Server.TLS)
 	if err != nil {
 		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
 	}
 
-	adminServer, err := admin.NewServer(&admin.Options{
-		Host:              cfg.Admin.Host,
-		Port:              int(cfg.Admin.Port),
-		Name:              "Split Proxy dashboard",
-		Proxy:             true,
-		Username:          cfg.Admin.Username,
-		Password:          cfg.Admin.Password,
-		Logger:            logger,
-		Storages:          storages,
-		Runtime:           rtm,
-		Snapshotter:       dbInstance,
-		HcAppMonitor:      appMonitor,
-		HcServicesMonitor: servicesMonitor,
-		FullConfig:        cfgForAdmin,
-		TLS:               adminTLSConfig,
-		FlagSpecVersion:   cfg.FlagSpecVersion,
-		Hash:              strconv.Itoa(int(hash)),
-	})
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error starting admin server: %w", err), common.ExitAdminError)
+	clientApikeys := cfg.Server.ClientApikeys
+	if cfg.Server.KeyRotation.File != "" {
+		if clientApikeys, err = apikeys.ReadFile(cfg.Server.KeyRotation.File); err != nil {
+			return common.NewInitError(fmt.Errorf("error reading client apikeys: %w", err), common.ExitInvalidConfiguration)
+		}
 	}
-	go adminServer.Start()
+	apikeysGracePeriod := time.Duration(cfg.Server.KeyRotation.GracePeriodSecs) * time.Second
 
-	tlsConfig, err := util.TLSConfigForServer(&cfg.Server.TLS)
-	if err != nil {
-		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
-	}
+	snapshotApplier := snapshots.NewApplier(logger, dbInstance, httpCache, sync, splitStorage, ruleBasedStorage, segmentStorage)
 
 	proxyOptions := &Options{
 		Logger:                      logger,
 		Host:                        cfg.Server.Host,
 		Port:                        int(cfg.Server.Port),
-		APIKeys:                     cfg.Server.ClientApikeys,
+		APIKeys:                     clientApikeys,
+		APIKeysFromFile:             cfg.Server.KeyRotation.File != "",
 		ImpressionListener:          nil,
 		DebugOn:                     strings.ToLower(cfg.Logging.Level) == "debug" || strings.ToLower(cfg.Logging.Level) == "verbose",
-		SplitFetcher:                splitAPI.SplitFetcher,
+		SplitFetcher:                splitFetcher,
+		SegmentFetcher:              segmentFetcher,
 		ProxySplitStorage:           splitStorage,
 		ProxySegmentStorage:         segmentStorage,
 		ProxyRBSegmentStorage:       ruleBasedStorage,
@@ -282,63 +426,244 @@ func Start(logger logging.LoggerInterface, cfg *pconf.Main) error {
 		TLSConfig:                   tlsConfig,
 		FlagSets:                    cfg.FlagSetsFilter,
 		FlagSetsStrictMatching:      cfg.FlagSetStrictMatching,
+		ClientKeyScopes:             scopeConfigs,
+		RegularRateLimiter:          makeRateLimiter(cfg.Server.RateLimit.RegularRps, cfg.Server.RateLimit.RegularBurst),
+		BeaconRateLimiter:           makeRateLimiter(cfg.Server.RateLimit.BeaconRps, cfg.Server.RateLimit.BeaconBurst),
+		CacheableRateLimiter:        makeRateLimiter(cfg.Server.RateLimit.CacheableRps, cfg.Server.RateLimit.CacheableBurst),
 		ProxyLargeSegmentStorage:    largeSegmentStorage,
 		SpecVersion:                 cfg.FlagSpecVersion,
+		StreamingHub:                streamingHub,
+		StreamingTokenIssuer:        tokenIssuer,
+		StreamingKeepAlive:          time.Duration(cfg.Server.Streaming.KeepAliveSecs) * time.Second,
+		Tracing:                     cfg.Tracing.Enabled,
+		StorageLock:                 snapshotApplier.ReadLocker(),
+	}
+
+	if cfg.Server.OFREP.Enabled {
+		proxyOptions.Evaluator = evaluator.NewEvaluator(
+			splitStorage,
+			segmentStorage,
+			ruleBasedStorage,
+			largeSegmentStorage,
+			engine.NewEngine(logger),
+			logger,
+			adminCommon.ProducerFeatureFlagsRules,
+			adminCommon.ProducerRuleBasedSegmentRules,
+			dtos.NewFallbackTreatmentCalculatorImp(nil))
+		proxyOptions.EvaluationFlags = splitStorage
+		proxyOptions.EvaluationImpressions = cfg.Server.OFREP.ImpressionsEnabled
 	}
 
 	if ilcfg := cfg.Integrations.ImpressionListener; ilcfg.Endpoint != "" {
 		var err error
-		proxyOptions.ImpressionListener, err = impressionlistener.NewImpressionBulkListener(ilcfg.Endpoint, int(ilcfg.QueueSize), nil)
+		proxyOptions.ImpressionListener, err = impressionlistener.FromConfig(&ilcfg, logger)
 		if err != nil {
 			return common.NewInitError(fmt.Errorf("error instantiating impression listener: %w", err), common.ExitTaskInitialization)
 		}
 		proxyOptions.ImpressionListener.Start()
 	}
 
+	if elcfg := cfg.Integrations.EventListener; elcfg.Endpoint != "" {
+		var err error
+		proxyOptions.EventListener, err = eventlistener.FromConfig(&elcfg, logger)
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error instantiating event listener: %w", err), common.ExitTaskInitialization)
+		}
+		proxyOptions.EventListener.Start()
+	}
+
+	if proxyOptions.DataSinks, err = datasink.FromConfig(&cfg.Integrations.DataSinks, metadata.SDKVersion, logger); err != nil {
+		return common.NewInitError(fmt.Errorf("error instantiating data sinks: %w", err), common.ExitTaskInitialization)
+	}
+	if proxyOptions.DataSinks != nil {
+		proxyOptions.DataSinks.Start()
+	}
+
 	proxyAPI := New(proxyOptions)
+
+	// --------------------------- ADMIN DASHBOARD ------------------------------
+	cfgForAdmin := *cfg
+	hash := util.HashAPIKey(cfgForAdmin.Apikey + cfg.FlagSpecVersion + strings.Join(cfg.FlagSetsFilter, "::"))
+	cfgForAdmin.Apikey = logging.ObfuscateAPIKey(cfgForAdmin.Apikey)
+	cfgForAdmin.Integrations.ImpressionListener.MaskSecrets()
+
+	// the feature flag change history is written to the db only when a snapshot is taken
+	snapshotter := snapshots.NewFlushingSnapshotter(dbInstance, splitStorage)
+
+	var snapshotWriter *snapshots.Writer
+	if scfg := cfg.Storage.Snapshots; scfg.Directory != "" {
+		snapshotWriter, err = snapshots.NewWriter(logger, snapshotter, snapshots.Config{
+			Directory:  scfg.Directory,
+			PeriodSecs: int(scfg.PeriodSecs),
+			Keep:       int(scfg.Keep),
+			Hash:       strconv.Itoa(int(hash)),
+			Keys:       snapshotKeys,
+		})
+		if err != nil {
+			return common.NewInitError(fmt.Errorf("error instantiating snapshot writer: %w", err), common.ExitTaskInitialization)
+		}
+	}
+
+	adminTLSConfig, err := util.TLSConfigForServer(&cfg.Admin.TLS)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error setting up proxy TLS config: %w", err), common.ExitTLSError)
+	}
+
+	adminOptions := &admin.Options{
+		Host:                   cfg.Admin.Host,
+		Port:                   int(cfg.Admin.Port),
+		Name:                   "Split Proxy dashboard",
+		Proxy:                  true,
+		Username:               cfg.Admin.Username,
+		Password:               cfg.Admin.Password,
+		Logger:                 logger,
+		Storages:               storages,
+		Runtime:                rtm,
+		Snapshotter:            snapshotter,
+		HcAppMonitor:           appMonitor,
+		HcServicesMonitor:      servicesMonitor,
+		FullConfig:             cfgForAdmin,
+		TLS:                    adminTLSConfig,
+		FlagSpecVersion:        cfg.FlagSpecVersion,
+		Hash:                   strconv.Itoa(int(hash)),
+		APIKeyUpdater:          proxyAPI,
+		APIKeysGracePeriod:     apikeysGracePeriod,
+		SnapshotApplier:        snapshotApplier,
+		SnapshotMaxUploadBytes: cfg.Storage.Snapshots.MaxUploadBytes,
+		SnapshotKeys:           snapshotKeys,
+	}
+	if snapshotWriter != nil {
+		adminOptions.LatestSnapshot = snapshotWriter
+	}
+	if bundleWriter != nil {
+		adminOptions.ExportBundles = bundleWriter
+	}
+
+	adminServer, err := admin.NewServer(adminOptions)
+	if err != nil {
+		return common.NewInitError(fmt.Errorf("error starting admin server: %w", err), common.ExitAdminError)
+	}
+	go adminServer.Start()
+
 	go proxyAPI.Start()
 
-	rtm.RegisterShutdownHandler()
-	rtm.Block()
-	return nil
-}
+	if snapshotWriter != nil {
+		snapshotWriter.Start()
+	}
 
-var (
-	errRetrying      = errors.New("error but snapshot available")
-	errUnrecoverable = errors.New("error and no snapshot available")
-)
+	if bundleWriter != nil {
+		bundleWriter.Start()
+	}
+
+	if cfg.Server.KeyRotation.File != "" {
+		watcher := apikeys.NewFileWatcher(logger, cfg.Server.KeyRotation.File, int(cfg.Server.KeyRotation.PollSecs), clientApikeys, func(keys []string) {
+			if err := proxyAPI.replaceAPIKeys(keys, apikeysGracePeriod); err != nil {
+				logger.Error(fmt.Sprintf("error replacing client apikeys read from '%s': %s", cfg.Server.KeyRotation.File, err))
+			}
+		})
+		watcher.Start()
+		rtm.OnShutdown(func() { watcher.Stop(false) })
+	}
+
+	if pcfg := cfg.Storage.Persistent; pcfg.SegmentTombstoneRetentionSecs > 0 {
+		// compacting walks every key of every segment, so it's kept off the segment update path
+		compaction := asynctask.NewAsyncTask("segment-tombstone-compaction", func(logging.LoggerInterface) error {
+			segmentStorage.CompactRemovedKeys()
+			return nil
+		}, int(pcfg.SegmentTombstoneCompactionSecs), nil, nil, logger)
+		compaction.Start()
+		rtm.OnShutdown(func() { compaction.Stop(false) })
+	}
 
-func startBGSync(m synchronizer.Manager, mstatus chan int, haveSnapshot bool, onReady func()) error {
-	attemptInit := func() bool {
-		go m.Start()
-		status := <-mstatus
-		switch status {
-		case synchronizer.Ready:
-			onReady()
-			return true
-		case synchronizer.Error:
-			return false
+	rtm.OnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutMs)*time.Millisecond)
+		defer cancel()
+		proxyAPI.Shutdown(ctx)
+		if snapshotWriter != nil {
+			snapshotWriter.Stop(true)
+			if cfg.Storage.Snapshots.OnShutdown {
+				if path, err := snapshotWriter.Write(); err != nil {
+					logger.Error("error writing shutdown snapshot: ", err)
+				} else {
+					logger.Info("shutdown snapshot written to ", path)
+				}
+			}
+		}
+		if bundleWriter != nil {
+			// data flushed after this point stays in the open bundle, and is sealed on the next startup
+			bundleWriter.Stop(true)
+			if path, err := bundleWriter.Seal(); err != nil {
+				logger.Error("error sealing export bundle: ", err)
+			} else if path != "" {
+				logger.Info("export bundle sealed into ", path)
+			}
+		}
+		if proxyOptions.ImpressionListener != nil {
+			proxyOptions.ImpressionListener.Stop(true)
+		}
+		if proxyOptions.EventListener != nil {
+			proxyOptions.EventListener.Stop(true)
+		}
+		if proxyOptions.DataSinks != nil {
+			proxyOptions.DataSinks.Stop(true)
+		}
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("error flushing pending spans: ", err)
 		}
-		return false // should not reach here TODO:LOG!
+	})
+
+	if spillStore != nil {
+		// closed once every pipeline has been flushed & stopped, so that no worker spills into a closed db
+		rtm.AfterStop(func() {
+			if err := spillStore.Close(); err != nil {
+				logger.Error("error closing spill storage: ", err)
+			}
+		})
 	}
 
-	if attemptInit() { // succeeeded at first try
-		return nil
+	rtm.RegisterShutdownHandler()
+	rtm.Block()
+	return nil
+}
+
+// rawRecorder builds the recorder used to forward sdk data to the supplied url, unless a local one is provided
+func rawRecorder(cfg *pconf.Main, local pTasks.RawRecorder, url string, timeout time.Duration, logger logging.LoggerInterface) pTasks.RawRecorder {
+	if local != nil {
+		return local
 	}
+	return traceRecorder(cfg.Tracing.Enabled, pTasks.NewHTTPRawRecorder(cfg.Apikey, url, timeout, logger))
+}
 
-	if !haveSnapshot {
-		return errUnrecoverable
+func traceRecorder(enabled bool, recorder pTasks.RawRecorder) pTasks.RawRecorder {
+	if !enabled {
+		return recorder
 	}
+	return tracing.WrapRawRecorder(recorder)
+}
 
-	go func() {
-		boff := backoff.New(2, 10*time.Minute)
-		for !attemptInit() {
-			time.Sleep(boff.Next())
+func makeScopeConfigs(scopes []pconf.ClientKeyScope, globalSets []string) ([]pFlagsets.ScopeConfig, error) {
+	toRet := make([]pFlagsets.ScopeConfig, 0, len(scopes))
+	for idx, scope := range scopes {
+		if len(scope.FlagSets) == 0 {
+			return nil, fmt.Errorf("scope #%d has no flag sets", idx)
 		}
-	}()
-
-	return errRetrying
+		if len(pFlagsets.Intersect(scope.FlagSets, globalSets)) == 0 {
+			return nil, fmt.Errorf("none of the flag sets in scope #%d are part of the global flag sets filter", idx)
+		}
+		toRet = append(toRet, pFlagsets.ScopeConfig{
+			Apikey:         scope.Apikey,
+			FlagSets:       scope.FlagSets,
+			StrictMatching: scope.StrictMatching,
+		})
+	}
+	return toRet, nil
+}
 
+func makeRateLimiter(rps int64, burst int64) *ratelimit.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	return ratelimit.New(float64(rps), int(burst))
 }
 
 func getAppCounterConfigs() (hcAppCounter.ThresholdConfig, hcAppCounter.ThresholdConfig, hcAppCounter.ThresholdConfig) {
@@ -0,0 +1,187 @@
+package localhost
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultTrafficType = "user"
+	defaultTreatment   = "off"
+)
+
+// ErrInvalidDefinitions is returned when the localhost file cannot be parsed or contains inconsistent definitions
+var ErrInvalidDefinitions = errors.New("invalid localhost definitions")
+
+// Definitions is the content of a localhost file. YAML & JSON are both accepted. Ie:
+//
+//	flags:
+//	  - name: new_checkout
+//	    trafficType: user        # defaults to 'user'
+//	    defaultTreatment: "off"  # defaults to 'off'. Served when no rule matches
+//	    sets: [checkout]
+//	    configurations:
+//	      "on": '{"color": "blue"}'
+//	    rules:                   # evaluated in order, first match wins
+//	      - treatment: "on"
+//	        keys: [user-1, user-2]
+//	      - treatment: "on"
+//	        segment: beta_testers
+//	      - treatment: "on"      # no keys nor segment: applies to everyone
+//	        percentage: 10       # defaults to 100. The rest get the default treatment
+//	segments:
+//	  beta_testers: [user-3, user-4]
+type Definitions struct {
+	Flags    []Flag              `yaml:"flags"`
+	Segments map[string][]string `yaml:"segments"`
+}
+
+// Flag is a simplified feature flag definition
+type Flag struct {
+	Name             string            `yaml:"name"`
+	TrafficType      string            `yaml:"trafficType"`
+	DefaultTreatment string            `yaml:"defaultTreatment"`
+	Killed           bool              `yaml:"killed"`
+	Sets             []string          `yaml:"sets"`
+	Configurations   map[string]string `yaml:"configurations"`
+	Rules            []Rule            `yaml:"rules"`
+}
+
+// Rule assigns a treatment to a list of keys, the members of a segment, or a percentage of all keys
+type Rule struct {
+	Treatment  string   `yaml:"treatment"`
+	Keys       []string `yaml:"keys"`
+	Segment    string   `yaml:"segment"`
+	Percentage *int     `yaml:"percentage"`
+}
+
+// parseDefinitions parses & validates the contents of a localhost file
+func parseDefinitions(raw []byte) (*Definitions, error) {
+	var defs Definitions
+	if err := yaml.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDefinitions, err)
+	}
+
+	names := make(map[string]struct{}, len(defs.Flags))
+	for _, flag := range defs.Flags {
+		if flag.Name == "" {
+			return nil, fmt.Errorf("%w: feature flags must have a name", ErrInvalidDefinitions)
+		}
+		if _, ok := names[flag.Name]; ok {
+			return nil, fmt.Errorf("%w: feature flag '%s' is defined more than once", ErrInvalidDefinitions, flag.Name)
+		}
+		names[flag.Name] = struct{}{}
+
+		for idx, rule := range flag.Rules {
+			if rule.Treatment == "" {
+				return nil, fmt.Errorf("%w: rule #%d of feature flag '%s' has no treatment", ErrInvalidDefinitions, idx, flag.Name)
+			}
+			if len(rule.Keys) > 0 && rule.Segment != "" {
+				return nil, fmt.Errorf("%w: rule #%d of feature flag '%s' has both keys & a segment", ErrInvalidDefinitions, idx, flag.Name)
+			}
+			if _, ok := defs.Segments[rule.Segment]; rule.Segment != "" && !ok {
+				return nil, fmt.Errorf("%w: feature flag '%s' references undefined segment '%s'", ErrInvalidDefinitions, flag.Name, rule.Segment)
+			}
+			if p := rule.Percentage; p != nil && (*p < 0 || *p > 100) {
+				return nil, fmt.Errorf("%w: rule #%d of feature flag '%s' has a percentage outside [0, 100]", ErrInvalidDefinitions, idx, flag.Name)
+			}
+		}
+	}
+
+	return &defs, nil
+}
+
+// toDTO builds a feature flag as served by Split servers. The change number is left for the caller to set
+func (f *Flag) toDTO() dtos.SplitDTO {
+	trafficType := f.TrafficType
+	if trafficType == "" {
+		trafficType = defaultTrafficType
+	}
+
+	defaultT := f.DefaultTreatment
+	if defaultT == "" {
+		defaultT = defaultTreatment
+	}
+
+	sets := f.Sets
+	if sets == nil {
+		sets = []string{}
+	}
+
+	seed := seedFor(f.Name)
+	conditions := make([]dtos.ConditionDTO, 0, len(f.Rules))
+	for _, rule := range f.Rules {
+		conditions = append(conditions, rule.toCondition(defaultT))
+	}
+
+	return dtos.SplitDTO{
+		TrafficTypeName:       trafficType,
+		Name:                  f.Name,
+		TrafficAllocation:     100,
+		TrafficAllocationSeed: seed,
+		Seed:                  seed,
+		Status:                "ACTIVE",
+		Killed:                f.Killed,
+		DefaultTreatment:      defaultT,
+		Algo:                  2, // murmur3
+		Conditions:            conditions,
+		Configurations:        f.Configurations,
+		Sets:                  sets,
+	}
+}
+
+func (r *Rule) toCondition(defaultTreatment string) dtos.ConditionDTO {
+	if len(r.Keys) > 0 {
+		return dtos.ConditionDTO{
+			ConditionType: "WHITELIST",
+			Label:         "whitelisted",
+			MatcherGroup: dtos.MatcherGroupDTO{
+				Combiner: "AND",
+				Matchers: []dtos.MatcherDTO{{
+					MatcherType: "WHITELIST",
+					Whitelist:   &dtos.WhitelistMatcherDataDTO{Whitelist: r.Keys},
+				}},
+			},
+			Partitions: []dtos.PartitionDTO{{Treatment: r.Treatment, Size: 100}},
+		}
+	}
+
+	percentage := 100
+	if r.Percentage != nil {
+		percentage = *r.Percentage
+	}
+	partitions := []dtos.PartitionDTO{{Treatment: r.Treatment, Size: percentage}}
+	if percentage < 100 && defaultTreatment != r.Treatment {
+		partitions = append(partitions, dtos.PartitionDTO{Treatment: defaultTreatment, Size: 100 - percentage})
+	}
+
+	matcher := dtos.MatcherDTO{MatcherType: "ALL_KEYS", KeySelector: &dtos.KeySelectorDTO{TrafficType: "user"}}
+	label := "default rule"
+	if r.Segment != "" {
+		matcher = dtos.MatcherDTO{
+			MatcherType:        "IN_SEGMENT",
+			KeySelector:        &dtos.KeySelectorDTO{TrafficType: "user"},
+			UserDefinedSegment: &dtos.UserDefinedSegmentMatcherDataDTO{SegmentName: r.Segment},
+		}
+		label = "in segment " + r.Segment
+	}
+
+	return dtos.ConditionDTO{
+		ConditionType: "ROLLOUT",
+		Label:         label,
+		MatcherGroup:  dtos.MatcherGroupDTO{Combiner: "AND", Matchers: []dtos.MatcherDTO{matcher}},
+		Partitions:    partitions,
+	}
+}
+
+// seedFor derives a stable seed from the flag name, so that rollouts are consistent across restarts & reloads
+func seedFor(name string) int64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int64(int32(h.Sum32()))
+}
@@ -0,0 +1,253 @@
+package localhost
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// Source serves feature flags & segments defined in a local file, mimicking the changes API of Split servers.
+// The file is re-read on every feature flag fetch, and a new change number is issued for every flag/segment that
+// changed since the previous read. Flags & segments removed from the file are archived/emptied rather than forgotten,
+// so that clients that already had them are notified
+type Source struct {
+	path         string
+	logger       logging.LoggerInterface
+	mutex        sync.RWMutex
+	lastHash     []byte
+	changeNumber int64
+	flags        map[string]dtos.SplitDTO
+	segments     map[string]*segment
+}
+
+type segment struct {
+	changeNumber int64
+	keys         map[string]struct{}
+	removed      map[string]struct{} // keys that were part of the segment at some point, but no longer are
+}
+
+// NewSource constructs a new localhost source and performs the initial read of the file
+func NewSource(path string, logger logging.LoggerInterface) (*Source, error) {
+	toRet := &Source{
+		path:         path,
+		logger:       logger,
+		changeNumber: -1,
+		flags:        make(map[string]dtos.SplitDTO),
+		segments:     make(map[string]*segment),
+	}
+
+	if _, err := toRet.Refresh(); err != nil {
+		return nil, err
+	}
+	return toRet, nil
+}
+
+// Refresh re-reads the file if its contents have changed, returning whether something was updated
+func (s *Source) Refresh() (bool, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("error reading localhost file: %w", err)
+	}
+
+	hash := sha256.Sum256(raw)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if bytes.Equal(hash[:], s.lastHash) {
+		return false, nil
+	}
+
+	defs, err := parseDefinitions(raw)
+	if err != nil {
+		return false, err
+	}
+
+	// change numbers are timestamps (as in Split servers), but always move forward even if the clock doesn't
+	cn := time.Now().UnixMilli()
+	if cn <= s.changeNumber {
+		cn = s.changeNumber + 1
+	}
+
+	updated := s.updateFlags(defs.Flags, cn)
+	updated = s.updateSegments(defs.Segments, cn) || updated
+	s.lastHash = hash[:]
+	if updated {
+		s.changeNumber = cn
+		s.logger.Info("localhost definitions updated from ", s.path)
+	}
+	return updated, nil
+}
+
+func (s *Source) updateFlags(flags []Flag, cn int64) bool {
+	var updated bool
+	seen := make(map[string]struct{}, len(flags))
+	for idx := range flags {
+		dto := flags[idx].toDTO()
+		seen[dto.Name] = struct{}{}
+		current, exists := s.flags[dto.Name]
+		dto.ChangeNumber = current.ChangeNumber
+		if exists && reflect.DeepEqual(current, dto) {
+			continue
+		}
+		dto.ChangeNumber = cn
+		s.flags[dto.Name] = dto
+		updated = true
+	}
+
+	for name, current := range s.flags {
+		if _, ok := seen[name]; ok || current.Status == "ARCHIVED" {
+			continue
+		}
+		current.Status = "ARCHIVED"
+		current.ChangeNumber = cn
+		s.flags[name] = current
+		updated = true
+	}
+	return updated
+}
+
+func (s *Source) updateSegments(segments map[string][]string, cn int64) bool {
+	var updated bool
+	for name, keys := range segments {
+		current, exists := s.segments[name]
+		if !exists {
+			current = &segment{changeNumber: -1, keys: make(map[string]struct{}), removed: make(map[string]struct{})}
+			s.segments[name] = current
+		}
+		if current.replaceKeys(keys) || !exists {
+			current.changeNumber = cn
+			updated = true
+		}
+	}
+
+	for name, current := range s.segments {
+		if _, ok := segments[name]; ok || len(current.keys) == 0 {
+			continue
+		}
+		current.replaceKeys(nil)
+		current.changeNumber = cn
+		updated = true
+	}
+	return updated
+}
+
+// replaceKeys sets the new list of keys, returning whether the membership changed
+func (s *segment) replaceKeys(keys []string) bool {
+	next := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		next[key] = struct{}{}
+	}
+
+	if reflect.DeepEqual(next, s.keys) {
+		return false
+	}
+
+	for key := range s.keys {
+		if _, ok := next[key]; !ok {
+			s.removed[key] = struct{}{}
+		}
+	}
+	for key := range next {
+		delete(s.removed, key)
+	}
+	s.keys = next
+	return true
+}
+
+// SplitFetcher returns a feature flag fetcher backed by this source. The file is checked for changes on each fetch
+func (s *Source) SplitFetcher() service.SplitFetcher {
+	return &splitFetcher{source: s}
+}
+
+// SegmentFetcher returns a segment fetcher backed by this source
+func (s *Source) SegmentFetcher() service.SegmentFetcher {
+	return &segmentFetcher{source: s}
+}
+
+func (s *Source) flagChanges(since int64) ([]dtos.SplitDTO, int64) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if since >= s.changeNumber {
+		return []dtos.SplitDTO{}, since
+	}
+
+	changes := make([]dtos.SplitDTO, 0, len(s.flags))
+	for _, flag := range s.flags {
+		if flag.ChangeNumber > since && (since != -1 || flag.Status == "ACTIVE") {
+			changes = append(changes, flag)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, s.changeNumber
+}
+
+func (s *Source) segmentChanges(name string, since int64) *dtos.SegmentChangesDTO {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	current, ok := s.segments[name]
+	if !ok || since >= current.changeNumber {
+		return &dtos.SegmentChangesDTO{Name: name, Added: []string{}, Removed: []string{}, Since: since, Till: since}
+	}
+
+	toRet := &dtos.SegmentChangesDTO{Name: name, Added: sortedKeys(current.keys), Removed: []string{}, Since: since, Till: current.changeNumber}
+	if since != -1 {
+		toRet.Removed = sortedKeys(current.removed)
+	}
+	return toRet
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type splitFetcher struct {
+	source *Source
+}
+
+// Fetch implements service.SplitFetcher
+func (f *splitFetcher) Fetch(fetchOptions *service.FlagRequestParams) (dtos.FFResponse, error) {
+	if _, err := f.source.Refresh(); err != nil {
+		// keep serving the last valid definitions
+		f.source.logger.Error("error refreshing localhost definitions: ", err)
+	}
+
+	since := fetchOptions.ChangeNumber()
+	flags, till := f.source.flagChanges(since)
+	rbSince := fetchOptions.ChangeNumberRB()
+	return &dtos.FFResponseV13{
+		SplitChanges: dtos.RuleChangesDTO{
+			FeatureFlags:      dtos.FeatureFlagsDTO{Since: since, Till: till, Splits: flags},
+			RuleBasedSegments: dtos.RuleBasedSegmentsDTO{Since: rbSince, Till: rbSince, RuleBasedSegments: []dtos.RuleBasedSegmentDTO{}},
+		},
+	}, nil
+}
+
+// IsProxy implements service.SplitFetcher
+func (f *splitFetcher) IsProxy() bool {
+	return false
+}
+
+type segmentFetcher struct {
+	source *Source
+}
+
+// Fetch implements service.SegmentFetcher
+func (f *segmentFetcher) Fetch(name string, fetchOptions *service.SegmentRequestParams) (*dtos.SegmentChangesDTO, error) {
+	return f.source.segmentChanges(name, fetchOptions.ChangeNumber()), nil
+}
+
+var _ service.SplitFetcher = (*splitFetcher)(nil)
+var _ service.SegmentFetcher = (*segmentFetcher)(nil)
@@ -0,0 +1,171 @@
+package localhost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+const initialDefinitions = `
+flags:
+  - name: f1
+    sets: [s1]
+    configurations:
+      "on": '{"color": "blue"}'
+    rules:
+      - treatment: "on"
+        keys: [k1, k2]
+      - treatment: "on"
+        segment: beta
+      - treatment: "v2"
+        percentage: 30
+  - name: f2
+    defaultTreatment: "on"
+segments:
+  beta: [k3, k4]
+  other: []
+`
+
+func fetchFlags(t *testing.T, source *Source, since int64) dtos.FFResponse {
+	t.Helper()
+	res, err := source.SplitFetcher().Fetch(service.MakeFlagRequestParams().WithChangeNumber(since).WithChangeNumberRB(-1))
+	assert.Nil(t, err)
+	return res
+}
+
+func fetchSegment(t *testing.T, source *Source, name string, since int64) *dtos.SegmentChangesDTO {
+	t.Helper()
+	res, err := source.SegmentFetcher().Fetch(name, service.MakeSegmentRequestParams().WithChangeNumber(since))
+	assert.Nil(t, err)
+	return res
+}
+
+func TestSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "split.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(initialDefinitions), 0644))
+
+	source, err := NewSource(path, logging.NewLogger(nil))
+	assert.Nil(t, err)
+
+	res := fetchFlags(t, source, -1)
+	assert.Equal(t, int64(-1), res.FFSince())
+	cn1 := res.FFTill()
+	assert.Greater(t, cn1, int64(0))
+	assert.Equal(t, int64(-1), res.RBTill())
+	flags := res.FeatureFlags()
+	assert.Len(t, flags, 2)
+
+	f1 := flags[0]
+	assert.Equal(t, "f1", f1.Name)
+	assert.Equal(t, cn1, f1.ChangeNumber)
+	assert.Equal(t, "user", f1.TrafficTypeName)
+	assert.Equal(t, "off", f1.DefaultTreatment)
+	assert.Equal(t, []string{"s1"}, f1.Sets)
+	assert.Equal(t, map[string]string{"on": `{"color": "blue"}`}, f1.Configurations)
+	assert.Len(t, f1.Conditions, 3)
+	assert.Equal(t, "WHITELIST", f1.Conditions[0].ConditionType)
+	assert.Equal(t, []string{"k1", "k2"}, f1.Conditions[0].MatcherGroup.Matchers[0].Whitelist.Whitelist)
+	assert.Equal(t, "beta", f1.Conditions[1].MatcherGroup.Matchers[0].UserDefinedSegment.SegmentName)
+	assert.Equal(t, []dtos.PartitionDTO{{Treatment: "on", Size: 100}}, f1.Conditions[1].Partitions)
+	assert.Equal(t, "ALL_KEYS", f1.Conditions[2].MatcherGroup.Matchers[0].MatcherType)
+	assert.Equal(t, []dtos.PartitionDTO{{Treatment: "v2", Size: 30}, {Treatment: "off", Size: 70}}, f1.Conditions[2].Partitions)
+	assert.Equal(t, "on", flags[1].DefaultTreatment)
+	assert.Empty(t, flags[1].Conditions)
+
+	// up to date
+	res = fetchFlags(t, source, cn1)
+	assert.Equal(t, cn1, res.FFSince())
+	assert.Equal(t, cn1, res.FFTill())
+	assert.Empty(t, res.FeatureFlags())
+
+	seg := fetchSegment(t, source, "beta", -1)
+	assert.Equal(t, []string{"k3", "k4"}, seg.Added)
+	assert.Equal(t, cn1, seg.Till)
+	seg = fetchSegment(t, source, "other", -1)
+	assert.Empty(t, seg.Added)
+	assert.Equal(t, cn1, seg.Till)
+	seg = fetchSegment(t, source, "nonexistent", -1)
+	assert.Equal(t, int64(-1), seg.Till)
+
+	// unchanged file, nothing happens
+	updated, err := source.Refresh()
+	assert.Nil(t, err)
+	assert.False(t, updated)
+
+	// f1 is untouched, f2 is removed, f3 is added, beta changes, other is removed
+	assert.Nil(t, os.WriteFile(path, []byte(`
+flags:
+  - name: f1
+    sets: [s1]
+    configurations:
+      "on": '{"color": "blue"}'
+    rules:
+      - treatment: "on"
+        keys: [k1, k2]
+      - treatment: "on"
+        segment: beta
+      - treatment: "v2"
+        percentage: 30
+  - name: f3
+    killed: true
+segments:
+  beta: [k4, k5]
+`), 0644))
+
+	res = fetchFlags(t, source, cn1)
+	cn2 := res.FFTill()
+	assert.Greater(t, cn2, cn1)
+	flags = res.FeatureFlags()
+	assert.Len(t, flags, 2)
+	assert.Equal(t, "f2", flags[0].Name)
+	assert.Equal(t, "ARCHIVED", flags[0].Status)
+	assert.Equal(t, cn2, flags[0].ChangeNumber)
+	assert.Equal(t, "f3", flags[1].Name)
+	assert.True(t, flags[1].Killed)
+
+	// archived flags are not returned to clients starting from scratch
+	res = fetchFlags(t, source, -1)
+	assert.Len(t, res.FeatureFlags(), 2)
+	for _, flag := range res.FeatureFlags() {
+		assert.Equal(t, "ACTIVE", flag.Status)
+	}
+
+	seg = fetchSegment(t, source, "beta", cn1)
+	assert.Equal(t, []string{"k4", "k5"}, seg.Added)
+	assert.Equal(t, []string{"k3"}, seg.Removed)
+	assert.Equal(t, cn2, seg.Till)
+	seg = fetchSegment(t, source, "beta", -1)
+	assert.Empty(t, seg.Removed)
+
+	// an invalid file is reported, and the last valid definitions are kept
+	assert.Nil(t, os.WriteFile(path, []byte("flags:\n  - name: f1\n    rules:\n      - segment: undefined\n        treatment: on\n"), 0644))
+	_, err = source.Refresh()
+	assert.ErrorIs(t, err, ErrInvalidDefinitions)
+	res = fetchFlags(t, source, cn2)
+	assert.Equal(t, cn2, res.FFTill())
+}
+
+func TestParseDefinitions(t *testing.T) {
+	// json is accepted as well
+	defs, err := parseDefinitions([]byte(`{"flags": [{"name": "f1", "rules": [{"treatment": "on", "keys": ["k1"]}]}], "segments": {"s1": ["k1"]}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "f1", defs.Flags[0].Name)
+	assert.Equal(t, []string{"k1"}, defs.Segments["s1"])
+
+	for _, invalid := range []string{
+		`flags: [{rules: []}]`,
+		`flags: [{name: f1}, {name: f1}]`,
+		`flags: [{name: f1, rules: [{keys: [k1]}]}]`,
+		`flags: [{name: f1, rules: [{treatment: "on", keys: [k1], segment: s1}]}]`,
+		`flags: [{name: f1, rules: [{treatment: "on", percentage: 101}]}]`,
+		`flags: {`,
+	} {
+		_, err := parseDefinitions([]byte(invalid))
+		assert.ErrorIs(t, err, ErrInvalidDefinitions, invalid)
+	}
+}
@@ -1,25 +1,34 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/datasink"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/eventlistener"
 	"github.com/splitio/split-synchronizer/v5/splitio/common/impressionlistener"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/apikeys"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/controllers/middleware"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/flagsets"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/ratelimit"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/streaming"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 
 	"github.com/splitio/gincache"
+	"github.com/splitio/go-split-commons/v9/engine/evaluator"
 	"github.com/splitio/go-split-commons/v9/service"
 	cmnStorage "github.com/splitio/go-split-commons/v9/storage"
 	"github.com/splitio/go-toolkit/v5/logging"
 
 	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,15 +46,27 @@ type Options struct {
 	// APIKeys used for authenticating proxy requests
 	APIKeys []string
 
+	// APIKeysFromFile is set when the apikeys are read from a watched file, which is then the only source allowed to replace them
+	APIKeysFromFile bool
+
 	// ImpressionListener to forward incoming impression bulks to
 	ImpressionListener impressionlistener.ImpressionBulkListener
 
+	// EventListener to forward incoming event bulks to
+	EventListener eventlistener.EventBulkListener
+
+	// DataSinks to write a copy of incoming impressions & events to
+	DataSinks datasink.Dispatcher
+
 	// Whether to do verbose logging in the gin framework
 	DebugOn bool
 
 	// used for on-demand feature flag changes fetching when a requested summary is not cached
 	SplitFetcher service.SplitFetcher
 
+	// used for on-demand segment changes fetching when the removed keys of a requested since have been compacted
+	SegmentFetcher service.SegmentFetcher
+
 	// used to resolve splitChanges requests
 	ProxySplitStorage storage.ProxySplitStorage
 
@@ -92,7 +113,40 @@ type Options struct {
 
 	FlagSetsStrictMatching bool
 
+	// rate limiters for each endpoint group. A nil limiter means no limit is enforced
+	RegularRateLimiter   *ratelimit.Limiter
+	BeaconRateLimiter    *ratelimit.Limiter
+	CacheableRateLimiter *ratelimit.Limiter
+
+	// per-client-key flag set restrictions
+	ClientKeyScopes []flagsets.ScopeConfig
+
 	SpecVersion string
+
+	// hub used to serve streaming notifications to sdks. If nil, sdks are told to use polling
+	StreamingHub *streaming.Hub
+
+	// used to issue & validate streaming tokens
+	StreamingTokenIssuer *streaming.TokenIssuer
+
+	// how often to send keepalive messages to connected sdks
+	StreamingKeepAlive time.Duration
+
+	// report spans for each middleware & controller. The tracer provider is expected to be set up globally
+	Tracing bool
+
+	// used to serve server-side flag evaluations. If nil, ofrep endpoints are not mounted
+	Evaluator evaluator.Interface
+
+	// used to list the feature flags to be evaluated in bulk evaluations
+	EvaluationFlags controllers.EvaluationFlags
+
+	// held while serving sdk payloads & evaluations, so that a snapshot is never applied to the storages mid-request.
+	// If nil, no lock is held
+	StorageLock sync.Locker
+
+	// whether to generate impressions for server-side evaluations
+	EvaluationImpressions bool
 }
 
 // API bundles all components required to answer API calls from Split sdks
@@ -101,6 +155,18 @@ type API struct {
 	sdkConroller        *controllers.SdkServerController
 	eventsConroller     *controllers.EventsServerController
 	telemetryController *controllers.TelemetryServerController
+	streamingHub        *streaming.Hub
+	sinks               map[string]tasks.DeferredRecordingTask
+	apikeyValidator     *middleware.APIKeyValidator
+	apikeysFromFile     bool
+	keyScopes           *flagsets.KeyScopes
+	logger              logging.LoggerInterface
+}
+
+// ShutdownReport contains the outcome of flushing each of the deferred sinks upon shutdown
+type ShutdownReport struct {
+	ServerError error
+	Sinks       map[string]tasks.FlushReport
 }
 
 // Start the Proxy service endpoints
@@ -111,51 +177,188 @@ func (s *API) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// Shutdown stops accepting new connections, waits for in-flight requests to complete and flushes all the data
+// that has been received but not yet posted to Split servers. Data still pending when the context is done is dropped
+func (s *API) Shutdown(ctx context.Context) *ShutdownReport {
+	if s.streamingHub != nil {
+		// streaming connections are never idle, so they need to be dropped for the server to shut down
+		s.streamingHub.Close()
+	}
+
+	report := &ShutdownReport{Sinks: make(map[string]tasks.FlushReport, len(s.sinks))}
+	if err := s.server.Shutdown(ctx); err != nil {
+		report.ServerError = err
+		s.logger.Error("error waiting for in-flight requests to complete: ", err)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for name, sink := range s.sinks {
+		wg.Add(1)
+		go func(name string, sink tasks.DeferredRecordingTask) {
+			defer wg.Done()
+			result := sink.Flush(ctx)
+			mutex.Lock()
+			report.Sinks[name] = result
+			mutex.Unlock()
+		}(name, sink)
+	}
+	wg.Wait()
+
+	for name, result := range report.Sinks {
+		if result.Dropped > 0 || result.Failed > 0 {
+			s.logger.Warning(fmt.Sprintf("%s: %d flushed, %d failed, %d dropped on shutdown", name, result.Flushed, result.Failed, result.Dropped))
+		} else {
+			s.logger.Info(fmt.Sprintf("%s: %d flushed on shutdown", name, result.Flushed))
+		}
+	}
+	return report
+}
+
+// ReplaceAPIKeys swaps the set of client apikeys accepted by the proxy. Replaced keys remain valid during the grace period.
+// Keys with flag set scopes are always accepted. If the apikeys are read from a watched file, the file is the only
+// source of truth, and this method fails with apikeys.ErrManagedByFile
+func (s *API) ReplaceAPIKeys(keys []string, grace time.Duration) error {
+	if s.apikeysFromFile {
+		return apikeys.ErrManagedByFile
+	}
+	return s.replaceAPIKeys(keys, grace)
+}
+
+func (s *API) replaceAPIKeys(keys []string, grace time.Duration) error {
+	if len(keys) == 0 {
+		return apikeys.ErrNoAPIKeys
+	}
+	s.apikeyValidator.Replace(append(s.keyScopes.Apikeys(), keys...), grace)
+	s.logger.Info(fmt.Sprintf("client apikeys replaced. %d keys active, grace period: %s", len(keys), grace))
+	return nil
+}
+
 // New instantiates a new Server
 func New(options *Options) *API {
 	if !options.DebugOn {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	apikeyValidator := middleware.NewAPIKeyValidator(options.APIKeys)
-	authController := controllers.NewAuthServerController()
-	sdkController := setupSdkController(options)
+	keyScopes := flagsets.NewKeyScopes(options.ClientKeyScopes, options.FlagSets)
+	apikeyValidator := middleware.NewAPIKeyValidator(append(keyScopes.Apikeys(), options.APIKeys...))
+	streamingEnabled := options.StreamingHub != nil && options.StreamingTokenIssuer != nil
+	var tokenIssuer *streaming.TokenIssuer
+	if streamingEnabled {
+		tokenIssuer = options.StreamingTokenIssuer
+	}
+	authController := controllers.NewAuthServerController(options.Logger, tokenIssuer)
+	sdkController := setupSdkController(options, keyScopes)
 	eventsController := setupEventsController(options, apikeyValidator)
 	telemetryController := setupTelemetryController(options, apikeyValidator)
 
+	// when tracing is disabled, middlewares are used as-is
+	traced := middleware.Tracer(options.Tracing)
+
 	router := gin.New()
 	router.Use(gin.Recovery())
+	if options.Tracing {
+		router.Use(middleware.TraceRequest)
+	}
 	router.Use(setupCorsMiddleware())
 	router.Use(middleware.SetEndpoint)
 	router.Use(middleware.NewProxyMetricsMiddleware(options.Telemetry).Track)
 
 	// split the main router into regular & beacon endpoints
 	regular := router.Group("/api")
-	regular.Use(apikeyValidator.AsMiddleware)
-	regular.Use(gzip.Gzip(gzip.DefaultCompression))
+	regular.Use(traced("APIKeyValidator", apikeyValidator.AsMiddleware))
+	if options.RegularRateLimiter != nil {
+		regular.Use(traced("RateLimit", middleware.NewRateLimitMiddleware(ratelimit.GroupRegular, options.RegularRateLimiter, options.Telemetry).Handle))
+	}
+	regular.Use(traced("Compress", middleware.Compress))
 
 	// Beacon endpoints group
 	beacon := router.Group("/api")
+	if options.BeaconRateLimiter != nil {
+		beacon.Use(traced("RateLimit", middleware.NewRateLimitMiddleware(ratelimit.GroupBeacon, options.BeaconRateLimiter, options.Telemetry).Handle))
+	}
 
 	var cacheableRouter gin.IRouter = regular
 	// If we got a cache in the options, fork the router, add the caching middleware,
 	// and pass it to Auth & Sdk controllers
 	if options.Cache != nil {
 		cacheableRouter = router.Group("/api")
-		cacheableRouter.Use(apikeyValidator.AsMiddleware)
-		cacheableRouter.Use(options.Cache.Handle)
-		cacheableRouter.Use(gzip.Gzip(gzip.DefaultCompression))
+		cacheableRouter.Use(traced("APIKeyValidator", apikeyValidator.AsMiddleware))
+		if options.CacheableRateLimiter != nil {
+			// limits are enforced before looking up the cache, so that cached responses count as well
+			cacheableRouter.Use(traced("RateLimit", middleware.NewRateLimitMiddleware(ratelimit.GroupCacheable, options.CacheableRateLimiter, options.Telemetry).Handle))
+		}
+		if !keyScopes.Empty() {
+			cacheableRouter.Use(traced("FlagSetScope", setFlagSetScope(keyScopes)))
+		}
+		if options.StorageLock != nil {
+			// held until the response is cached, so that responses built from the previous data are never
+			// stored after the cache is purged by a snapshot being applied
+			cacheableRouter.Use(middleware.HoldLock(options.StorageLock))
+		}
+		// wrap the cache so that full responses are still stored, while requests with a matching ETag get a 304
+		cacheableRouter.Use(traced("ConditionalGet", middleware.ConditionalGet))
+		cacheTracker := middleware.NewCacheTrackingMiddleware(options.Telemetry)
+		cacheableRouter.Use(cacheTracker.Track)
+		cacheableRouter.Use(traced("Cache", options.Cache.Handle))
+		cacheableRouter.Use(cacheTracker.MarkMiss)
+		cacheableRouter.Use(traced("Compress", middleware.Compress))
+	} else {
+		if options.StorageLock != nil {
+			cacheableRouter.Use(middleware.HoldLock(options.StorageLock))
+		}
+		cacheableRouter.Use(traced("ConditionalGet", middleware.ConditionalGet))
 	}
 	cacheableRouter.Use(func(c *gin.Context) {
 		c.Header("Harness-FME-Proxy-Version", splitio.Version)
 		c.Header("Harness-FME-FlagSpec", options.SpecVersion)
 		c.Next()
 	})
-	authController.Register(cacheableRouter)
+	if options.Tracing {
+		// must be the last middleware in each group, so that only the controller function is wrapped
+		regular.Use(middleware.TraceHandler)
+		beacon.Use(middleware.TraceHandler)
+		if options.Cache != nil {
+			cacheableRouter.Use(middleware.TraceHandler)
+		}
+	}
+	if streamingEnabled {
+		// tokens expire, so auth responses must not be served from the http cache
+		authController.Register(regular)
+		controllers.NewStreamingServerController(options.Logger, options.StreamingHub, options.StreamingTokenIssuer, options.StreamingKeepAlive).
+			Register(router)
+	} else {
+		authController.Register(cacheableRouter)
+	}
 	sdkController.Register(cacheableRouter)
 	eventsController.Register(regular, beacon)
 	telemetryController.Register(regular, beacon)
 
+	if options.Evaluator != nil {
+		ofrep := router.Group("/ofrep/v1")
+		ofrep.Use(traced("APIKeyValidator", apikeyValidator.AsMiddleware))
+		if options.RegularRateLimiter != nil {
+			ofrep.Use(traced("RateLimit", middleware.NewRateLimitMiddleware(ratelimit.GroupRegular, options.RegularRateLimiter, options.Telemetry).Handle))
+		}
+		ofrep.Use(traced("Compress", middleware.Compress))
+		if options.StorageLock != nil {
+			ofrep.Use(middleware.HoldLock(options.StorageLock))
+		}
+		if options.Tracing {
+			ofrep.Use(middleware.TraceHandler)
+		}
+		controllers.NewOFREPController(
+			options.Logger,
+			options.Evaluator,
+			options.EvaluationFlags,
+			keyScopes,
+			options.ImpressionsSink,
+			options.EvaluationImpressions,
+			options.ImpressionListener,
+			options.DataSinks,
+		).Register(ofrep)
+	}
+
 	return &API{
 		server: &http.Server{
 			Addr:      fmt.Sprintf("0.0.0.0:%d", options.Port),
@@ -165,17 +368,45 @@ func New(options *Options) *API {
 		sdkConroller:        sdkController,
 		eventsConroller:     eventsController,
 		telemetryController: telemetryController,
+		streamingHub:        options.StreamingHub,
+		sinks:               collectSinks(options),
+		apikeyValidator:     apikeyValidator,
+		apikeysFromFile:     options.APIKeysFromFile,
+		keyScopes:           keyScopes,
+		logger:              options.Logger,
 	}
 }
 
-func setupSdkController(options *Options) *controllers.SdkServerController {
+func collectSinks(options *Options) map[string]tasks.DeferredRecordingTask {
+	all := map[string]tasks.DeferredRecordingTask{
+		"impressions":               options.ImpressionsSink,
+		"impression-counts":         options.ImpressionCountSink,
+		"events":                    options.EventsSink,
+		"telemetry-config":          options.TelemetryConfigSink,
+		"telemetry-usage":           options.TelemetryUsageSink,
+		"telemetry-keys-clientside": options.TelemetryKeysClientSideSink,
+		"telemetry-keys-serverside": options.TelemetryKeysServerSideSink,
+	}
+
+	toRet := make(map[string]tasks.DeferredRecordingTask, len(all))
+	for name, sink := range all {
+		if sink != nil {
+			toRet[name] = sink
+		}
+	}
+	return toRet
+}
+
+func setupSdkController(options *Options, keyScopes *flagsets.KeyScopes) *controllers.SdkServerController {
 	return controllers.NewSdkServerController(
 		options.Logger,
 		options.SplitFetcher,
+		options.SegmentFetcher,
 		options.ProxySplitStorage,
 		options.ProxySegmentStorage,
 		options.ProxyRBSegmentStorage,
 		flagsets.NewMatcher(options.FlagSetsStrictMatching, options.FlagSets),
+		keyScopes,
 		options.ProxyLargeSegmentStorage,
 		options.SpecVersion,
 	)
@@ -188,6 +419,8 @@ func setupEventsController(options *Options, apikeyValidator *middleware.APIKeyV
 		options.ImpressionCountSink,
 		options.EventsSink,
 		options.ImpressionListener,
+		options.EventListener,
+		options.DataSinks,
 		apikeyValidator.IsValid,
 	)
 }
@@ -203,6 +436,17 @@ func setupTelemetryController(options *Options, apikeyValidator *middleware.APIK
 	)
 }
 
+// setFlagSetScope stores the scope of the requesting client key (if any) so that cached splitChanges responses
+// are not shared across client keys with different flag set restrictions
+func setFlagSetScope(keyScopes *flagsets.KeyScopes) func(*gin.Context) {
+	return func(ctx *gin.Context) {
+		if scope := keyScopes.For(ctx.GetString(middleware.APIKeyContextKey)); scope != nil {
+			ctx.Set(caching.FlagSetScopeContextKey, scope.ID())
+		}
+		ctx.Next()
+	}
+}
+
 func setupCorsMiddleware() func(*gin.Context) {
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowAllOrigins = true
@@ -215,6 +459,8 @@ func setupCorsMiddleware() func(*gin.Context) {
 		"SplitSDKVersion",
 		"SplitSDKImpressionsMode",
 		"Authorization",
+		"If-None-Match",
 	}
+	corsConfig.ExposeHeaders = []string{"ETag"}
 	return cors.New(corsConfig)
 }
@@ -1,11 +1,15 @@
 package proxy
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -14,12 +18,15 @@ import (
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/caching"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
 	pstorageMocks "github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/mocks"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
 	taskMocks "github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks/mocks"
 
 	"github.com/splitio/go-split-commons/v9/dtos"
 	serviceMocks "github.com/splitio/go-split-commons/v9/service/mocks"
 	"github.com/splitio/go-toolkit/v5/logging"
 
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -244,9 +251,9 @@ func TestSegmentChangesAndMySegmentsEndpoints(t *testing.T) {
 	assert.Equal(t, "application/json; charset=utf-8", headers.Get("Content-Type"))
 
 	// Same for mysegments
-	entries := caching.MakeMySegmentsEntries("k1")
-	opts.Cache.Evict(entries[0])
-	opts.Cache.Evict(entries[1])
+	for _, entry := range caching.MakeMySegmentsEntries("k1") {
+		opts.Cache.Evict(entry)
+	}
 	segmentStorage.On("SegmentsFor", "k1").Return([]string{}, nil).Once()
 	status, body, headers = get("mySegments/k1", opts.Port, map[string]string{"Authorization": "Bearer someApiKey"})
 	segments = toMySegments(body)
@@ -257,6 +264,116 @@ func TestSegmentChangesAndMySegmentsEndpoints(t *testing.T) {
 	assert.Equal(t, splitio.Version, headers.Get("Harness-FME-Proxy-Version"))
 }
 
+func TestConditionalRequests(t *testing.T) {
+	var segmentStorage pstorageMocks.ProxySegmentStorageMock
+	opts := makeOpts()
+	opts.ProxySegmentStorage = &segmentStorage
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	segmentStorage.On("ChangesSince", "segment1", int64(-1)).
+		Return(&dtos.SegmentChangesDTO{Since: -1, Till: 1, Name: "segment1", Added: []string{"k1"}, Removed: nil}, nil).
+		Twice() // once per encoding
+
+	status, body, headers := get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+		"Authorization":   "Bearer someApiKey",
+		"Accept-Encoding": "identity",
+	})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, int64(1), toSegmentChanges(body).Till)
+	assert.Equal(t, `"se-1"`, headers.Get("ETag"))
+
+	// matching etag, served from cache
+	status, body, headers = get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+		"Authorization":   "Bearer someApiKey",
+		"Accept-Encoding": "identity",
+		"If-None-Match":   `"se-0", "se-1"`,
+	})
+	assert.Equal(t, 304, status)
+	assert.Empty(t, body)
+	assert.Equal(t, `"se-1"`, headers.Get("ETag"))
+
+	// stale etag
+	status, body, _ = get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+		"Authorization":   "Bearer someApiKey",
+		"Accept-Encoding": "identity",
+		"If-None-Match":   `"se-0"`,
+	})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, int64(1), toSegmentChanges(body).Till)
+
+	// gzipped variant gets a different tag
+	status, _, headers = get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+		"Authorization":   "Bearer someApiKey",
+		"Accept-Encoding": "gzip",
+		"If-None-Match":   `"se-1"`,
+	})
+	assert.Equal(t, 200, status)
+	assert.Equal(t, `"se-1-gzip"`, headers.Get("ETag"))
+
+	status, body, _ = get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+		"Authorization":   "Bearer someApiKey",
+		"Accept-Encoding": "gzip",
+		"If-None-Match":   `"se-1-gzip"`,
+	})
+	assert.Equal(t, 304, status)
+	assert.Empty(t, body)
+
+	segmentStorage.AssertExpectations(t)
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	var segmentStorage pstorageMocks.ProxySegmentStorageMock
+	opts := makeOpts()
+	opts.ProxySegmentStorage = &segmentStorage
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	// one call per encoding. Subsequent requests are served from cache
+	segmentStorage.On("ChangesSince", "segment1", int64(-1)).
+		Return(&dtos.SegmentChangesDTO{Since: -1, Till: 1, Name: "segment1", Added: []string{"k1"}, Removed: nil}, nil).
+		Times(4)
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"":     func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"zstd": func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+
+	for _, tc := range []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"identity", ""},
+		{"gzip, deflate", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"zstd, gzip", "zstd"},
+	} {
+		for i := 0; i < 2; i++ {
+			status, body, headers := get("segmentChanges/segment1?since=-1", opts.Port, map[string]string{
+				"Authorization":   "Bearer someApiKey",
+				"Accept-Encoding": tc.acceptEncoding,
+			})
+			assert.Equal(t, 200, status)
+			assert.Equal(t, tc.expected, headers.Get("Content-Encoding"))
+			if tc.expected != "" {
+				assert.Equal(t, strconv.Itoa(len(body)), headers.Get("Content-Length"))
+			}
+
+			reader, err := decoders[tc.expected](bytes.NewReader(body))
+			assert.Nil(t, err)
+			decoded, err := io.ReadAll(reader)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"k1"}, toSegmentChanges(decoded).Added)
+		}
+	}
+
+	segmentStorage.AssertExpectations(t)
+}
+
 func TestMembershipEndpoint(t *testing.T) {
 	var segmentStorage pstorageMocks.ProxySegmentStorageMock
 	var lsStorage pstorageMocks.ProxyLargeSegmentStorageMock
@@ -294,6 +411,34 @@ func TestMembershipEndpoint(t *testing.T) {
 	assert.Equal(t, splitio.Version, headers.Get("Harness-FME-Proxy-Version"))
 }
 
+func TestShutdown(t *testing.T) {
+	opts := makeOpts()
+	opts.ImpressionsSink = &taskMocks.MockDeferredRecordingTask{
+		FlushCall: func(ctx context.Context) tasks.FlushReport { return tasks.FlushReport{Flushed: 3} },
+	}
+	opts.EventsSink = &taskMocks.MockDeferredRecordingTask{
+		FlushCall: func(ctx context.Context) tasks.FlushReport { return tasks.FlushReport{Flushed: 1, Dropped: 2} },
+	}
+	opts.ImpressionCountSink = nil
+	opts.TelemetryConfigSink = nil
+	opts.TelemetryUsageSink = nil
+	proxy := New(opts)
+	go proxy.Start()
+	time.Sleep(1 * time.Second) // Let the scheduler switch the current thread/gr and start the server
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	report := proxy.Shutdown(ctx)
+	assert.Nil(t, report.ServerError)
+	assert.Equal(t, map[string]tasks.FlushReport{
+		"impressions": {Flushed: 3},
+		"events":      {Flushed: 1, Dropped: 2},
+	}, report.Sinks)
+
+	_, err := http.Get(fmt.Sprintf("http://localhost:%d/api/splitChanges", opts.Port))
+	assert.NotNil(t, err)
+}
+
 func makeOpts() *Options {
 	return &Options{
 		Logger:              logging.NewLogger(nil),
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Endpoint groups subject to independent rate limits
+const (
+	GroupRegular   = "regular"
+	GroupBeacon    = "beacon"
+	GroupCacheable = "cacheable"
+)
+
+// buckets that have been idle (and are therefore full) for longer than this are removed
+const idleBucketTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// Limiter implements a per-key token bucket rate limiter
+type Limiter struct {
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	mutex     sync.Mutex
+}
+
+// New constructs a new limiter allowing `rate` requests per second with bursts of up to `burst` requests for each key.
+// If burst is smaller than 1, it will be set to the rate (rounded up)
+func New(rate float64, burst int) *Limiter {
+	b := float64(burst)
+	if b < 1 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+
+	return &Limiter{
+		rate:    rate,
+		burst:   b,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consumes a token for the supplied key. If no token is available, it returns false and the time
+// the caller should wait before retrying
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	missing := (1 - b.tokens) / l.rate
+	return false, time.Duration(missing * float64(time.Second))
+}
+
+// sweep removes idle buckets to keep memory usage bounded. Must be called with the lock acquired
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
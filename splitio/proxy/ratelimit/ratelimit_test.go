@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter := New(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("k1")
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := limiter.Allow("k1")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// other keys have their own bucket
+	allowed, _ = limiter.Allow("k2")
+	assert.True(t, allowed)
+
+	// tokens are replenished over time, without exceeding the burst
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("k1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("k1")
+	assert.False(t, allowed)
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("k1")
+		assert.True(t, allowed)
+	}
+	allowed, _ = limiter.Allow("k1")
+	assert.False(t, allowed)
+}
+
+func TestLimiterDefaultBurstAndSweep(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter := New(1.5, 0)
+	limiter.now = func() time.Time { return now }
+	assert.Equal(t, float64(2), limiter.burst)
+
+	limiter.Allow("k1")
+	now = now.Add(idleBucketTTL + time.Second)
+	limiter.Allow("k2")
+	assert.Equal(t, 1, len(limiter.buckets))
+	_, ok := limiter.buckets["k2"]
+	assert.True(t, ok)
+}
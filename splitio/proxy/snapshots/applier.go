@@ -0,0 +1,98 @@
+package snapshots
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// ErrUnsupportedStorage is returned when attempting to apply a snapshot not built from a boltdb storage
+var ErrUnsupportedStorage = errors.New("snapshot storage type not supported")
+
+// DBReplacer defines the interface of a db that can be swapped for the one in a file
+type DBReplacer interface {
+	Replace(path string) error
+}
+
+// Reloadable defines the interface of a storage that can rebuild its in-memory state from the db
+type Reloadable interface {
+	ReloadFromDisk()
+}
+
+// Syncer defines the interface of a component that brings the storages up to date with Split
+type Syncer interface {
+	SyncAll() error
+}
+
+// CacheEvicter defines the interface of a cache that can be purged
+type CacheEvicter interface {
+	EvictAll()
+}
+
+// Applier replaces the db of a running proxy with the contents of a snapshot and brings the in-memory
+// storages & http cache in line with it. All the storages are swapped while holding a lock exclusively,
+// which readers hold in shared mode (see ReadLocker), so that no one observes a half-applied snapshot.
+// The reloaded storages are replaced by the data fetched in their next sync, which is triggered right away
+type Applier struct {
+	logger   logging.LoggerInterface
+	db       DBReplacer
+	cache    CacheEvicter
+	syncer   Syncer
+	storages []Reloadable
+	mutex    sync.RWMutex
+}
+
+// NewApplier constructs a snapshot applier. The syncer is optional
+func NewApplier(logger logging.LoggerInterface, db DBReplacer, cache CacheEvicter, syncer Syncer, storages ...Reloadable) *Applier {
+	return &Applier{logger: logger, db: db, cache: cache, syncer: syncer, storages: storages}
+}
+
+// ReadLocker returns the lock to be held by readers of the storages (ie: while serving a request),
+// blocking the application of a snapshot until they're done
+func (a *Applier) ReadLocker() sync.Locker {
+	return a.mutex.RLocker()
+}
+
+// Apply swaps the db for the one contained in the snapshot, reloads the storages & purges the cache.
+// Validating that the snapshot matches the running config is up to the caller
+func (a *Applier) Apply(snap *snapshot.Snapshot) error {
+	if snap.Meta().Storage != snapshot.StorageBoltDB {
+		return ErrUnsupportedStorage
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	path, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		return fmt.Errorf("error writing temporary snapshot file: %w", err)
+	}
+
+	if err := a.db.Replace(path); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("error replacing db: %w", err)
+	}
+	a.logger.Debug("Database replaced with snapshot at", path)
+
+	for _, storage := range a.storages {
+		storage.ReloadFromDisk()
+	}
+
+	if a.cache != nil {
+		a.cache.EvictAll()
+	}
+
+	if a.syncer != nil {
+		go func() {
+			if err := a.syncer.SyncAll(); err != nil {
+				a.logger.Error("error syncing after applying snapshot. Will be retried on the next sync: ", err)
+			}
+		}()
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package snapshots
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbReplacerMock struct {
+	paths []string
+	err   error
+}
+
+func (m *dbReplacerMock) Replace(path string) error {
+	m.paths = append(m.paths, path)
+	return m.err
+}
+
+type reloadableMock struct{ reloads int }
+
+func (m *reloadableMock) ReloadFromDisk() { m.reloads++ }
+
+type syncerMock struct{ syncs chan struct{} }
+
+func (m *syncerMock) SyncAll() error {
+	m.syncs <- struct{}{}
+	return nil
+}
+
+type cacheEvicterMock struct{ evictions int }
+
+func (m *cacheEvicterMock) EvictAll() { m.evictions++ }
+
+func TestApplier(t *testing.T) {
+	db := &dbReplacerMock{}
+	cache := &cacheEvicterMock{}
+	s1, s2 := &reloadableMock{}, &reloadableMock{}
+	syncer := &syncerMock{syncs: make(chan struct{}, 1)}
+	applier := NewApplier(logging.NewLogger(nil), db, cache, syncer, s1, s2)
+
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: "123"}, []byte("some data"))
+	assert.Nil(t, err)
+	assert.Nil(t, applier.Apply(snap))
+
+	assert.Len(t, db.paths, 1)
+	data, err := os.ReadFile(db.paths[0])
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("some data"), data)
+	os.Remove(db.paths[0])
+	assert.Equal(t, 1, s1.reloads)
+	assert.Equal(t, 1, s2.reloads)
+	assert.Equal(t, 1, cache.evictions)
+
+	// the reloaded storages are synced right away
+	select {
+	case <-syncer.syncs:
+	case <-time.After(time.Second):
+		t.Error("a sync should be triggered after applying the snapshot")
+	}
+
+	// failing to replace the db leaves everything else untouched & removes the temporary file
+	db.err = errors.New("something")
+	assert.NotNil(t, applier.Apply(snap))
+	assert.Len(t, db.paths, 2)
+	_, err = os.Stat(db.paths[1])
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, 1, s1.reloads)
+	assert.Equal(t, 1, cache.evictions)
+
+	unsupported, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB + 1, Hash: "123"}, []byte("some data"))
+	assert.Nil(t, err)
+	assert.ErrorIs(t, applier.Apply(unsupported), ErrUnsupportedStorage)
+	assert.Len(t, db.paths, 2)
+}
+
+type blockingReloadableMock struct {
+	reloading chan struct{}
+	release   chan struct{}
+}
+
+func (m *blockingReloadableMock) ReloadFromDisk() {
+	close(m.reloading)
+	<-m.release
+}
+
+func TestApplierBlocksReaders(t *testing.T) {
+	db := &dbReplacerMock{}
+	blocking := &blockingReloadableMock{reloading: make(chan struct{}), release: make(chan struct{})}
+	applier := NewApplier(logging.NewLogger(nil), db, nil, nil, blocking)
+
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: "123"}, []byte("some data"))
+	assert.Nil(t, err)
+
+	applied := make(chan error, 1)
+	go func() { applied <- applier.Apply(snap) }()
+	<-blocking.reloading
+
+	// readers wait until every storage has been reloaded
+	read := make(chan struct{})
+	go func() {
+		reader := applier.ReadLocker()
+		reader.Lock()
+		close(read)
+		reader.Unlock()
+	}()
+
+	select {
+	case <-read:
+		t.Error("reader should be blocked while the snapshot is being applied")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(blocking.release)
+	assert.Nil(t, <-applied)
+	<-read
+	os.Remove(db.paths[0])
+
+	// a reader holding the lock delays the next snapshot
+	reader := applier.ReadLocker()
+	reader.Lock()
+	blocking.reloading, blocking.release = make(chan struct{}), make(chan struct{})
+	close(blocking.release)
+	go func() { applied <- applier.Apply(snap) }()
+	select {
+	case <-blocking.reloading:
+		t.Error("snapshot should not be applied while a reader holds the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	reader.Unlock()
+	assert.Nil(t, <-applied)
+	os.Remove(db.paths[1])
+}
+
+func TestApplierRepublishesSegmentsForUpToDateSDKs(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	segments := storage.NewProxySegmentStorage(db, logger, false, 0)
+	assert.Nil(t, segments.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 100))
+
+	// an older snapshot, where k2 isn't a member yet & k3 still is
+	older, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, persistent.NewSegmentChangesCollection(older, logger).Update("s1", set.NewSet("k1", "k3"), set.NewSet(), 50))
+	raw, err := older.GetRawSnapshot()
+	assert.Nil(t, err)
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB}, raw)
+	assert.Nil(t, err)
+
+	assert.Nil(t, NewApplier(logger, db, nil, nil, segments).Apply(snap))
+
+	// an sdk that synced before the snapshot was applied gets the new membership
+	changes, err := segments.ChangesSince("s1", 100)
+	assert.Nil(t, err)
+	assert.Greater(t, changes.Till, int64(100))
+	assert.Equal(t, []string{"k1", "k3"}, changes.Added)
+	assert.Equal(t, []string{"k2"}, changes.Removed)
+
+	memberships, _ := segments.SegmentsFor("k3")
+	assert.Equal(t, []string{"s1"}, memberships)
+	memberships, _ = segments.SegmentsFor("k2")
+	assert.Empty(t, memberships)
+}
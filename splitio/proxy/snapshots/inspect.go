@@ -0,0 +1,290 @@
+package snapshots
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Contents is a readable summary of the data stored in a proxy snapshot
+type Contents struct {
+	Metadata          Metadata           `json:"metadata"`
+	Flags             []FlagSummary      `json:"flags"`
+	RuleBasedSegments []RuleBasedSummary `json:"ruleBasedSegments"`
+	Segments          []SegmentSummary   `json:"segments"`
+
+	// full data, used when diffing
+	flags             map[string]dtos.SplitDTO
+	ruleBasedSegments map[string]dtos.RuleBasedSegmentDTO
+	segmentKeys       map[string]map[string]struct{}
+}
+
+// Metadata is the readable version of a snapshot's metadata
+type Metadata struct {
+	Version   uint64 `json:"version"`
+	Storage   uint64 `json:"storage"`
+	Hash      string `json:"hash"`
+	Signed    bool   `json:"signed"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// FlagSummary contains the most relevant properties of a feature flag
+type FlagSummary struct {
+	Name             string   `json:"name"`
+	ChangeNumber     int64    `json:"changeNumber"`
+	Status           string   `json:"status"`
+	TrafficType      string   `json:"trafficType"`
+	Killed           bool     `json:"killed"`
+	DefaultTreatment string   `json:"defaultTreatment"`
+	Sets             []string `json:"sets"`
+}
+
+// RuleBasedSummary contains the most relevant properties of a rule-based segment
+type RuleBasedSummary struct {
+	Name         string `json:"name"`
+	ChangeNumber int64  `json:"changeNumber"`
+	Status       string `json:"status"`
+}
+
+// SegmentSummary contains the name & key counts of a segment
+type SegmentSummary struct {
+	Name           string `json:"name"`
+	ChangeNumber   int64  `json:"changeNumber"`
+	Keys           int    `json:"keys"`
+	RemovedKeys    int    `json:"removedKeys"`
+	CompactedUntil int64  `json:"compactedUntil,omitempty"`
+}
+
+// Inspect opens the db contained in a snapshot and builds a summary of its contents
+func Inspect(snap *snapshot.Snapshot) (*Contents, error) {
+	if snap.Meta().Storage != snapshot.StorageBoltDB {
+		return nil, ErrUnsupportedStorage
+	}
+
+	path, err := snap.WriteDataToTmpFile()
+	if err != nil {
+		return nil, fmt.Errorf("error writing temporary snapshot file: %w", err)
+	}
+	defer os.Remove(path)
+
+	db, err := persistent.NewBoltWrapper(path, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	logger := logging.NewLogger(nil)
+	meta := snap.Meta()
+	contents := &Contents{
+		Metadata: Metadata{
+			Version:   meta.Version,
+			Storage:   meta.Storage,
+			Hash:      meta.Hash,
+			Signed:    len(meta.Signature) > 0,
+			Encrypted: meta.Encrypted,
+		},
+		Flags:             make([]FlagSummary, 0),
+		RuleBasedSegments: make([]RuleBasedSummary, 0),
+		Segments:          make([]SegmentSummary, 0),
+		flags:             make(map[string]dtos.SplitDTO),
+		ruleBasedSegments: make(map[string]dtos.RuleBasedSegmentDTO),
+		segmentKeys:       make(map[string]map[string]struct{}),
+	}
+
+	flags, err := persistent.NewSplitChangesCollection(db, logger).FetchAll()
+	if err != nil && !errors.Is(err, persistent.ErrorBucketNotFound) {
+		return nil, fmt.Errorf("error reading feature flags: %w", err)
+	}
+	for _, flag := range flags {
+		sets := flag.Sets
+		if sets == nil {
+			sets = []string{}
+		}
+		contents.Flags = append(contents.Flags, FlagSummary{
+			Name:             flag.Name,
+			ChangeNumber:     flag.ChangeNumber,
+			Status:           flag.Status,
+			TrafficType:      flag.TrafficTypeName,
+			Killed:           flag.Killed,
+			DefaultTreatment: flag.DefaultTreatment,
+			Sets:             sets,
+		})
+		if flag.Status == "ACTIVE" {
+			contents.flags[flag.Name] = flag
+		}
+	}
+
+	rbs, err := persistent.NewRBChangesCollection(db, logger).FetchAll()
+	if err != nil && !errors.Is(err, persistent.ErrorBucketNotFound) {
+		return nil, fmt.Errorf("error reading rule-based segments: %w", err)
+	}
+	for _, rb := range rbs {
+		contents.RuleBasedSegments = append(contents.RuleBasedSegments, RuleBasedSummary{
+			Name:         rb.Name,
+			ChangeNumber: rb.ChangeNumber,
+			Status:       rb.Status,
+		})
+		if rb.Status == "ACTIVE" {
+			contents.ruleBasedSegments[rb.Name] = rb
+		}
+	}
+
+	segments, err := persistent.NewSegmentChangesCollection(db, logger).FetchAll()
+	if err != nil && !errors.Is(err, persistent.ErrorBucketNotFound) {
+		return nil, fmt.Errorf("error reading segments: %w", err)
+	}
+	for _, segment := range segments {
+		summary := SegmentSummary{Name: segment.Name, ChangeNumber: segment.ChangeNumber, CompactedUntil: segment.CompactedUntil}
+		keys := make(map[string]struct{}, len(segment.Keys))
+		for _, key := range segment.Keys {
+			if key.Removed {
+				summary.RemovedKeys++
+				continue
+			}
+			summary.Keys++
+			keys[key.Name] = struct{}{}
+		}
+		contents.Segments = append(contents.Segments, summary)
+		contents.segmentKeys[segment.Name] = keys
+	}
+
+	sort.Slice(contents.Flags, func(i, j int) bool { return contents.Flags[i].Name < contents.Flags[j].Name })
+	sort.Slice(contents.RuleBasedSegments, func(i, j int) bool {
+		return contents.RuleBasedSegments[i].Name < contents.RuleBasedSegments[j].Name
+	})
+	sort.Slice(contents.Segments, func(i, j int) bool { return contents.Segments[i].Name < contents.Segments[j].Name })
+	return contents, nil
+}
+
+// Diff lists the differences between the contents of two snapshots
+type Diff struct {
+	Flags             EntityDiff   `json:"flags"`
+	RuleBasedSegments EntityDiff   `json:"ruleBasedSegments"`
+	Segments          SegmentsDiff `json:"segments"`
+	HashChanged       bool         `json:"hashChanged"`
+}
+
+// EntityDiff lists the names of added & removed (or archived) entities, along with the ones that were modified
+type EntityDiff struct {
+	Added   []string       `json:"added"`
+	Removed []string       `json:"removed"`
+	Changed []EntityChange `json:"changed"`
+}
+
+// EntityChange describes a modified feature flag or rule-based segment
+type EntityChange struct {
+	Name             string `json:"name"`
+	FromChangeNumber int64  `json:"fromChangeNumber"`
+	ToChangeNumber   int64  `json:"toChangeNumber"`
+}
+
+// SegmentsDiff lists the names of added & removed segments, along with membership changes in the rest
+type SegmentsDiff struct {
+	Added   []string        `json:"added"`
+	Removed []string        `json:"removed"`
+	Changed []SegmentChange `json:"changed"`
+}
+
+// SegmentChange lists the keys added to & removed from a segment
+type SegmentChange struct {
+	Name        string   `json:"name"`
+	AddedKeys   []string `json:"addedKeys"`
+	RemovedKeys []string `json:"removedKeys"`
+}
+
+// Compare builds the diff between an older & a newer snapshot. Only active flags & rule-based segments are considered
+func Compare(from *Contents, to *Contents) *Diff {
+	diff := &Diff{HashChanged: from.Metadata.Hash != to.Metadata.Hash}
+
+	diff.Flags.Added, diff.Flags.Removed = addedAndRemoved(from.flags, to.flags)
+	for _, name := range sortedKeys(to.flags) {
+		if old, ok := from.flags[name]; ok && !reflect.DeepEqual(old, to.flags[name]) {
+			diff.Flags.Changed = append(diff.Flags.Changed, EntityChange{
+				Name:             name,
+				FromChangeNumber: old.ChangeNumber,
+				ToChangeNumber:   to.flags[name].ChangeNumber,
+			})
+		}
+	}
+
+	diff.RuleBasedSegments.Added, diff.RuleBasedSegments.Removed = addedAndRemoved(from.ruleBasedSegments, to.ruleBasedSegments)
+	for _, name := range sortedKeys(to.ruleBasedSegments) {
+		if old, ok := from.ruleBasedSegments[name]; ok && !reflect.DeepEqual(old, to.ruleBasedSegments[name]) {
+			diff.RuleBasedSegments.Changed = append(diff.RuleBasedSegments.Changed, EntityChange{
+				Name:             name,
+				FromChangeNumber: old.ChangeNumber,
+				ToChangeNumber:   to.ruleBasedSegments[name].ChangeNumber,
+			})
+		}
+	}
+
+	diff.Segments.Added, diff.Segments.Removed = addedAndRemoved(from.segmentKeys, to.segmentKeys)
+	for _, name := range sortedKeys(to.segmentKeys) {
+		old, ok := from.segmentKeys[name]
+		if !ok {
+			continue
+		}
+		added, removed := addedAndRemoved(old, to.segmentKeys[name])
+		if len(added) > 0 || len(removed) > 0 {
+			diff.Segments.Changed = append(diff.Segments.Changed, SegmentChange{Name: name, AddedKeys: added, RemovedKeys: removed})
+		}
+	}
+
+	diff.normalize()
+	return diff
+}
+
+// normalize replaces nil slices with empty ones so that they're serialized as `[]`
+func (d *Diff) normalize() {
+	for _, names := range []*[]string{
+		&d.Flags.Added, &d.Flags.Removed,
+		&d.RuleBasedSegments.Added, &d.RuleBasedSegments.Removed,
+		&d.Segments.Added, &d.Segments.Removed,
+	} {
+		if *names == nil {
+			*names = []string{}
+		}
+	}
+	if d.Flags.Changed == nil {
+		d.Flags.Changed = []EntityChange{}
+	}
+	if d.RuleBasedSegments.Changed == nil {
+		d.RuleBasedSegments.Changed = []EntityChange{}
+	}
+	if d.Segments.Changed == nil {
+		d.Segments.Changed = []SegmentChange{}
+	}
+}
+
+func addedAndRemoved[T any](from map[string]T, to map[string]T) (added []string, removed []string) {
+	for _, name := range sortedKeys(to) {
+		if _, ok := from[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for _, name := range sortedKeys(from) {
+		if _, ok := to[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return added, removed
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,91 @@
+package snapshots
+
+import (
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildSnapshot(t *testing.T, hash string, flags []dtos.SplitDTO, segments map[string][2]*set.ThreadUnsafeSet) *snapshot.Snapshot {
+	t.Helper()
+	logger := logging.NewLogger(nil)
+	db, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	persistent.NewSplitChangesCollection(db, logger).Update(flags, nil, 10)
+	segmentsCollection := persistent.NewSegmentChangesCollection(db, logger)
+	for name, changes := range segments {
+		assert.Nil(t, segmentsCollection.Update(name, changes[0], changes[1], 10))
+	}
+
+	raw, err := db.GetRawSnapshot()
+	assert.Nil(t, err)
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: hash}, raw)
+	assert.Nil(t, err)
+	return snap
+}
+
+func TestInspectAndCompare(t *testing.T) {
+	older := buildSnapshot(t, "123",
+		[]dtos.SplitDTO{
+			{Name: "f1", ChangeNumber: 1, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off"},
+			{Name: "f2", ChangeNumber: 2, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off"},
+			{Name: "f3", ChangeNumber: 3, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off"},
+		},
+		map[string][2]*set.ThreadUnsafeSet{
+			"s1": {set.NewSet("k1", "k2"), set.NewSet("k3")},
+			"s2": {set.NewSet("k1"), set.NewSet()},
+		},
+	)
+
+	newer := buildSnapshot(t, "123",
+		[]dtos.SplitDTO{
+			{Name: "f1", ChangeNumber: 1, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off"},
+			{Name: "f2", ChangeNumber: 5, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "off", Killed: true},
+			{Name: "f3", ChangeNumber: 6, Status: "ARCHIVED", TrafficTypeName: "user", DefaultTreatment: "off"},
+			{Name: "f4", ChangeNumber: 7, Status: "ACTIVE", TrafficTypeName: "user", DefaultTreatment: "on", Sets: []string{"set1"}},
+		},
+		map[string][2]*set.ThreadUnsafeSet{
+			"s1": {set.NewSet("k2", "k4"), set.NewSet("k1")},
+			"s3": {set.NewSet("k9"), set.NewSet()},
+		},
+	)
+
+	from, err := Inspect(older)
+	assert.Nil(t, err)
+	assert.Equal(t, Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: "123"}, from.Metadata)
+	assert.Len(t, from.Flags, 3)
+	assert.Empty(t, from.RuleBasedSegments)
+	assert.Equal(t, []SegmentSummary{{Name: "s1", ChangeNumber: 10, Keys: 2, RemovedKeys: 1}, {Name: "s2", ChangeNumber: 10, Keys: 1}}, from.Segments)
+
+	to, err := Inspect(newer)
+	assert.Nil(t, err)
+	assert.Equal(t, FlagSummary{Name: "f4", ChangeNumber: 7, Status: "ACTIVE", TrafficType: "user", DefaultTreatment: "on", Sets: []string{"set1"}}, to.Flags[3])
+	assert.Equal(t, "ARCHIVED", to.Flags[2].Status)
+
+	diff := Compare(from, to)
+	assert.False(t, diff.HashChanged)
+	assert.Equal(t, []string{"f4"}, diff.Flags.Added)
+	assert.Equal(t, []string{"f3"}, diff.Flags.Removed)
+	assert.Equal(t, []EntityChange{{Name: "f2", FromChangeNumber: 2, ToChangeNumber: 5}}, diff.Flags.Changed)
+	assert.Equal(t, EntityDiff{Added: []string{}, Removed: []string{}, Changed: []EntityChange{}}, diff.RuleBasedSegments)
+	assert.Equal(t, []string{"s3"}, diff.Segments.Added)
+	assert.Equal(t, []string{"s2"}, diff.Segments.Removed)
+	assert.Equal(t, []SegmentChange{{Name: "s1", AddedKeys: []string{"k4"}, RemovedKeys: []string{"k1"}}}, diff.Segments.Changed)
+
+	diff = Compare(to, to)
+	assert.Empty(t, diff.Flags.Added)
+	assert.Empty(t, diff.Flags.Changed)
+	assert.Empty(t, diff.Segments.Changed)
+
+	unsupported, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB + 1}, []byte("some data"))
+	assert.Nil(t, err)
+	_, err = Inspect(unsupported)
+	assert.ErrorIs(t, err, ErrUnsupportedStorage)
+}
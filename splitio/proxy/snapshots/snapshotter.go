@@ -0,0 +1,31 @@
+package snapshots
+
+import (
+	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+)
+
+// Persistable defines the interface of a storage that keeps part of its state in memory & writes it to the db on demand
+type Persistable interface {
+	PersistToDisk()
+}
+
+// FlushingSnapshotter wraps a db so that the in-memory state of the storages is written to it before every snapshot
+type FlushingSnapshotter struct {
+	db       storage.Snapshotter
+	storages []Persistable
+}
+
+// NewFlushingSnapshotter constructs a snapshotter that persists the supplied storages before dumping the db
+func NewFlushingSnapshotter(db storage.Snapshotter, storages ...Persistable) *FlushingSnapshotter {
+	return &FlushingSnapshotter{db: db, storages: storages}
+}
+
+// GetRawSnapshot persists the storages & returns the contents of the db. Implements storage.Snapshotter
+func (f *FlushingSnapshotter) GetRawSnapshot() ([]byte, error) {
+	for _, s := range f.storages {
+		s.PersistToDisk()
+	}
+	return f.db.GetRawSnapshot()
+}
+
+var _ storage.Snapshotter = (*FlushingSnapshotter)(nil)
@@ -0,0 +1,24 @@
+package snapshots
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type persistableMock struct {
+	db        *snapshotterMock
+	persisted string
+}
+
+func (p *persistableMock) PersistToDisk() { p.db.data = append(p.db.data, p.persisted...) }
+
+func TestFlushingSnapshotter(t *testing.T) {
+	db := &snapshotterMock{data: []byte("flags")}
+	s1 := &persistableMock{db: db, persisted: "|history"}
+	s2 := &persistableMock{db: db, persisted: "|more"}
+
+	raw, err := NewFlushingSnapshotter(db, s1, s2).GetRawSnapshot()
+	assert.Nil(t, err)
+	assert.Equal(t, "flags|history|more", string(raw))
+}
@@ -0,0 +1,169 @@
+package snapshots
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+	"github.com/splitio/split-synchronizer/v5/splitio/common/storage"
+
+	"github.com/splitio/go-toolkit/v5/asynctask"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const (
+	filePrefix = "split.proxy."
+	fileSuffix = ".snapshot"
+)
+
+// ErrNoDirectory is returned when attempting to construct a writer without a target directory
+var ErrNoDirectory = errors.New("a directory is required")
+
+// Config bundles the options of a snapshot writer
+type Config struct {
+	Directory  string
+	PeriodSecs int
+	Keep       int            // number of snapshots to retain. older ones are deleted after each write (0 keeps all of them)
+	Hash       string         // hash of the config the snapshot is built with, used to validate it when loading
+	Keys       *snapshot.Keys // optional keys to sign and/or encrypt snapshots with
+}
+
+// Writer periodically dumps the proxy storage into snapshot files, keeping only the most recent ones
+type Writer struct {
+	logger logging.LoggerInterface
+	db     storage.Snapshotter
+	config Config
+	mutex  sync.Mutex
+	latest string
+	task   *asynctask.AsyncTask
+}
+
+// NewWriter constructs a snapshot writer, creating the target directory if necessary.
+// Snapshots already present in the directory are taken into account for retention & reporting the latest one
+func NewWriter(logger logging.LoggerInterface, db storage.Snapshotter, config Config) (*Writer, error) {
+	if config.Directory == "" {
+		return nil, ErrNoDirectory
+	}
+
+	if err := os.MkdirAll(config.Directory, 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshots directory '%s': %w", config.Directory, err)
+	}
+
+	existing, err := list(config.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	toRet := &Writer{logger: logger, db: db, config: config}
+	if len(existing) > 0 {
+		toRet.latest = existing[len(existing)-1]
+	}
+	toRet.task = asynctask.NewAsyncTask("snapshot-writer", func(logging.LoggerInterface) error {
+		_, err := toRet.Write()
+		return err
+	}, config.PeriodSecs, nil, nil, logger)
+	return toRet, nil
+}
+
+// Start begins writing snapshots periodically
+func (w *Writer) Start() {
+	w.task.Start()
+}
+
+// Stop stops the periodic task
+func (w *Writer) Stop(blocking bool) error {
+	return w.task.Stop(blocking)
+}
+
+// IsRunning returns whether the periodic task is active
+func (w *Writer) IsRunning() bool {
+	return w.task.IsRunning()
+}
+
+// Latest returns the path to the most recent snapshot, or an empty string if none has been written
+func (w *Writer) Latest() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.latest
+}
+
+// Write dumps the current storage contents into a new snapshot file & removes the ones exceeding the retention
+func (w *Writer) Write() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	raw, err := w.db.GetRawSnapshot()
+	if err != nil {
+		return "", fmt.Errorf("error reading storage contents: %w", err)
+	}
+
+	snap, err := snapshot.New(snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: w.config.Hash}, raw)
+	if err != nil {
+		return "", fmt.Errorf("error building snapshot: %w", err)
+	}
+
+	encoded, err := snap.Encode(w.config.Keys)
+	if err != nil {
+		return "", fmt.Errorf("error encoding snapshot: %w", err)
+	}
+
+	// the timestamp is zero-padded by nature (19 digits until 2286), so names sort chronologically
+	path := filepath.Join(w.config.Directory, fmt.Sprintf("%s%d%s", filePrefix, time.Now().UnixNano(), fileSuffix))
+
+	// write to a temporary file first, so that a crash never leaves a truncated snapshot behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encoded, 0644); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("error renaming snapshot: %w", err)
+	}
+
+	w.latest = path
+	w.logger.Debug("snapshot written to ", path)
+	w.prune()
+	return path, nil
+}
+
+func (w *Writer) prune() {
+	if w.config.Keep <= 0 {
+		return
+	}
+
+	existing, err := list(w.config.Directory)
+	if err != nil {
+		w.logger.Error("error listing snapshots for cleanup: ", err)
+		return
+	}
+
+	for idx := 0; idx < len(existing)-w.config.Keep; idx++ {
+		if err := os.Remove(existing[idx]); err != nil {
+			w.logger.Error(fmt.Sprintf("error removing old snapshot '%s': %s", existing[idx], err))
+		}
+	}
+}
+
+// list returns the paths of the snapshots in a directory, oldest first
+func list(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots directory '%s': %w", dir, err)
+	}
+
+	var toRet []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), filePrefix) && strings.HasSuffix(entry.Name(), fileSuffix) {
+			toRet = append(toRet, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(toRet)
+	return toRet, nil
+}
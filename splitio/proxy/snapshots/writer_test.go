@@ -0,0 +1,95 @@
+package snapshots
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/snapshot"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshotterMock struct {
+	data []byte
+	err  error
+}
+
+func (s *snapshotterMock) GetRawSnapshot() ([]byte, error) { return s.data, s.err }
+
+func TestWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	writer, err := NewWriter(logging.NewLogger(nil), &snapshotterMock{data: []byte("some data")}, Config{Directory: dir, PeriodSecs: 3600, Keep: 2, Hash: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, "", writer.Latest())
+
+	var written []string
+	for i := 0; i < 3; i++ {
+		path, err := writer.Write()
+		assert.Nil(t, err)
+		assert.Equal(t, path, writer.Latest())
+		written = append(written, path)
+	}
+
+	existing, err := list(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, written[1:], existing)
+
+	snap, err := snapshot.DecodeFromFile(writer.Latest(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, snapshot.Metadata{Version: 1, Storage: snapshot.StorageBoltDB, Hash: "123"}, snap.Meta())
+	data, err := snap.Data()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("some data"), data)
+
+	// unrelated files are left untouched & not considered snapshots
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "other.txt"), nil, 0644))
+
+	// a new writer picks up the latest snapshot from a previous run
+	writer, err = NewWriter(logging.NewLogger(nil), &snapshotterMock{}, Config{Directory: dir, Keep: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, written[2], writer.Latest())
+
+	_, err = writer.Write()
+	assert.Nil(t, err)
+	existing, err = list(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(existing))
+	_, err = os.Stat(filepath.Join(dir, "other.txt"))
+	assert.Nil(t, err)
+}
+
+func TestWriterError(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), &snapshotterMock{err: errors.New("some")}, Config{Directory: dir, Keep: 2})
+	assert.Nil(t, err)
+
+	_, err = writer.Write()
+	assert.NotNil(t, err)
+	assert.Equal(t, "", writer.Latest())
+
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	_, err = NewWriter(logging.NewLogger(nil), &snapshotterMock{}, Config{})
+	assert.ErrorIs(t, err, ErrNoDirectory)
+}
+
+func TestWriterPeriodic(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewWriter(logging.NewLogger(nil), &snapshotterMock{data: []byte("some data")}, Config{Directory: dir, PeriodSecs: 1, Keep: 5})
+	assert.Nil(t, err)
+
+	writer.Start()
+	time.Sleep(1500 * time.Millisecond)
+	assert.Nil(t, writer.Stop(true))
+
+	existing, err := list(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(existing))
+	assert.Equal(t, existing[0], writer.Latest())
+}
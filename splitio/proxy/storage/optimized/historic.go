@@ -1,6 +1,7 @@
 package optimized
 
 import (
+	"math"
 	"slices"
 	"sort"
 	"strings"
@@ -12,16 +13,29 @@ import (
 type HistoricChanges interface {
 	GetUpdatedSince(since int64, flagSets []string) []FeatureView
 	Update(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, newCN int64)
+	ReplaceAll(toAdd []dtos.SplitDTO, newCN int64)
+	Restore(views []FeatureView)
+	Views() []FeatureView
+	DiscardedUntil() int64
 }
 
+// HistoricChangesImpl keeps a view of every feature flag, sorted by the change number it was last updated in.
+// Archived flags are discarded (oldest first) when the number of views exceeds `capacity` or they're older than
+// `retention` (in change number units) relative to the newest view. Active flags are never discarded
 type HistoricChangesImpl struct {
-	data  []FeatureView
-	mutex sync.RWMutex
+	data           []FeatureView
+	capacity       int
+	retention      int64
+	discardedUntil int64
+	mutex          sync.RWMutex
 }
 
-func NewHistoricSplitChanges(capacity int) *HistoricChangesImpl {
+// NewHistoricSplitChanges constructs a history bounded by capacity & retention (0 means no limit for either)
+func NewHistoricSplitChanges(capacity int, retention int64) *HistoricChangesImpl {
 	return &HistoricChangesImpl{
-		data: make([]FeatureView, 0, capacity),
+		data:      make([]FeatureView, 0, capacity),
+		capacity:  capacity,
+		retention: retention,
 	}
 }
 
@@ -39,9 +53,53 @@ func (h *HistoricChangesImpl) Update(toAdd []dtos.SplitDTO, toRemove []dtos.Spli
 	h.updateFrom(toAdd)
 	h.updateFrom(toRemove)
 	sort.Slice(h.data, func(i, j int) bool { return h.data[i].LastUpdated < h.data[j].LastUpdated })
+	h.discardArchived()
 	h.mutex.Unlock()
 }
 
+// ReplaceAll discards all the tracked changes and starts over from the supplied feature flags
+func (h *HistoricChangesImpl) ReplaceAll(toAdd []dtos.SplitDTO, newCN int64) {
+	h.mutex.Lock()
+	h.data = make([]FeatureView, 0, cap(h.data))
+	h.discardedUntil = 0
+	h.updateFrom(toAdd)
+	sort.Slice(h.data, func(i, j int) bool { return h.data[i].LastUpdated < h.data[j].LastUpdated })
+	h.discardArchived()
+	h.mutex.Unlock()
+}
+
+// Restore replaces the tracked changes with previously exported views
+func (h *HistoricChangesImpl) Restore(views []FeatureView) {
+	h.mutex.Lock()
+	h.data = make([]FeatureView, 0, len(views))
+	for idx := range views {
+		h.data = append(h.data, views[idx].clone())
+	}
+	h.discardedUntil = 0
+	sort.Slice(h.data, func(i, j int) bool { return h.data[i].LastUpdated < h.data[j].LastUpdated })
+	h.discardArchived()
+	h.mutex.Unlock()
+}
+
+// Views returns a copy of all the tracked views, sorted by change number
+func (h *HistoricChangesImpl) Views() []FeatureView {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	toRet := make([]FeatureView, 0, len(h.data))
+	for idx := range h.data {
+		toRet = append(toRet, h.data[idx].clone())
+	}
+	return toRet
+}
+
+// DiscardedUntil returns the most recent change number of an archived flag that was discarded.
+// Diffs from older change numbers can no longer be computed accurately
+func (h *HistoricChangesImpl) DiscardedUntil() int64 {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.discardedUntil
+}
+
 // public interface ends here
 
 func (h *HistoricChangesImpl) updateFrom(source []dtos.SplitDTO) {
@@ -56,6 +114,40 @@ func (h *HistoricChangesImpl) updateFrom(source []dtos.SplitDTO) {
 	}
 }
 
+func (h *HistoricChangesImpl) discardArchived() {
+	// precondition: h.data is sorted by CN
+	if len(h.data) == 0 {
+		return
+	}
+
+	var cutoff int64 = math.MinInt64
+	if h.retention > 0 {
+		cutoff = h.data[len(h.data)-1].LastUpdated - h.retention
+	}
+
+	var excess int
+	if h.capacity > 0 {
+		excess = len(h.data) - h.capacity
+	}
+
+	if excess <= 0 && h.data[0].LastUpdated >= cutoff {
+		return
+	}
+
+	kept := h.data[:0]
+	for idx := range h.data {
+		if view := &h.data[idx]; !view.Active && (excess > 0 || view.LastUpdated < cutoff) {
+			excess--
+			if view.LastUpdated > h.discardedUntil {
+				h.discardedUntil = view.LastUpdated
+			}
+			continue
+		}
+		kept = append(kept, h.data[idx])
+	}
+	h.data = kept
+}
+
 func (h *HistoricChangesImpl) findByName(name string) *FeatureView {
 	// yes, it's linear search because features are sorted by CN, but it's only used
 	// when processing an update coming from the BE. it's off the critical path of incoming
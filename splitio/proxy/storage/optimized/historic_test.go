@@ -12,7 +12,7 @@ import (
 )
 
 func TestHistoricSplitStorage(t *testing.T) {
-	var historic HistoricChangesImpl
+	historic := NewHistoricSplitChanges(0, 0)
 	historic.Update([]dtos.SplitDTO{
 		{Name: "f1", Sets: []string{"s1", "s2"}, Status: "ACTIVE", ChangeNumber: 1, TrafficTypeName: "tt1"},
 	}, []dtos.SplitDTO{}, 1)
@@ -228,6 +228,42 @@ func TestHistoricSplitStorage(t *testing.T) {
 
 }
 
+func TestHistoricSplitStorageDiscardsArchived(t *testing.T) {
+	historic := NewHistoricSplitChanges(3, 100)
+	historic.Update([]dtos.SplitDTO{
+		{Name: "f1", TrafficTypeName: "tt1", ChangeNumber: 10, Status: "ACTIVE"},
+		{Name: "f2", TrafficTypeName: "tt1", ChangeNumber: 20, Status: "ACTIVE"},
+	}, []dtos.SplitDTO{
+		{Name: "f3", TrafficTypeName: "tt1", ChangeNumber: 30, Status: "ARCHIVED"},
+	}, 30)
+	assert.Equal(t, int64(0), historic.DiscardedUntil())
+	assert.Len(t, historic.Views(), 3)
+
+	// capacity exceeded: the oldest archived flag is dropped, active ones are kept even if older
+	historic.Update(nil, []dtos.SplitDTO{{Name: "f4", TrafficTypeName: "tt1", ChangeNumber: 40, Status: "ARCHIVED"}}, 40)
+	assert.Equal(t, int64(30), historic.DiscardedUntil())
+	assert.Equal(t, []string{"f1", "f2", "f4"}, viewNames(historic.Views()))
+
+	// retention exceeded: f4 is more than 100 older than the newest change
+	historic.Update([]dtos.SplitDTO{{Name: "f5", TrafficTypeName: "tt1", ChangeNumber: 150, Status: "ACTIVE"}}, nil, 150)
+	assert.Equal(t, int64(40), historic.DiscardedUntil())
+	assert.Equal(t, []string{"f1", "f2", "f5"}, viewNames(historic.Views()))
+
+	// restoring exported views starts over
+	restored := NewHistoricSplitChanges(0, 0)
+	restored.Restore(historic.Views())
+	assert.Equal(t, int64(0), restored.DiscardedUntil())
+	assert.Equal(t, historic.GetUpdatedSince(-1, nil), restored.GetUpdatedSince(-1, nil))
+}
+
+func viewNames(views []FeatureView) []string {
+	toRet := make([]string, 0, len(views))
+	for idx := range views {
+		toRet = append(toRet, views[idx].Name)
+	}
+	return toRet
+}
+
 // -- code below is for benchmarking random access using hashsets (map[string]struct{}) vs sorted slices + binary search
 
 func setupRandomData(flagsetLength int, flagsetCount int, splits int, flagSetsPerSplitMax int, userSets int) benchmarkDataSlices {
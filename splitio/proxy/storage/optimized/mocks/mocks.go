@@ -20,4 +20,24 @@ func (h *HistoricStorageMock) Update(toAdd []dtos.SplitDTO, toRemove []dtos.Spli
 	h.Called(toAdd, toRemove, newCN)
 }
 
+// ReplaceAll implements optimized.HistoricChanges
+func (h *HistoricStorageMock) ReplaceAll(toAdd []dtos.SplitDTO, newCN int64) {
+	h.Called(toAdd, newCN)
+}
+
+// Restore implements optimized.HistoricChanges
+func (h *HistoricStorageMock) Restore(views []optimized.FeatureView) {
+	h.Called(views)
+}
+
+// Views implements optimized.HistoricChanges
+func (h *HistoricStorageMock) Views() []optimized.FeatureView {
+	return h.Called().Get(0).([]optimized.FeatureView)
+}
+
+// DiscardedUntil implements optimized.HistoricChanges
+func (h *HistoricStorageMock) DiscardedUntil() int64 {
+	return h.Called().Get(0).(int64)
+}
+
 var _ optimized.HistoricChanges = (*HistoricStorageMock)(nil)
@@ -1,31 +1,81 @@
 package optimized
 
 import (
+	"encoding/binary"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
 )
 
+// Rough per-entry overheads used when estimating memory usage. They account for headers, map buckets & allocator
+// rounding on 64-bit platforms and are only meant to give an order of magnitude
+const (
+	keyEntryOverhead        = 48 // string header + membership id + map bucket share
+	membershipEntryOverhead = 96 // membership struct + slice header + lookup map entry
+	segmentIDSize           = 4  // each id is stored twice: in the membership & in its lookup key
+	segmentNameOverhead     = 40 // string header + lookup map entry
+)
+
 // MySegmentsCache defines the interface for a per-user optimized segment storage
 type MySegmentsCache interface {
 	Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet) error
 	SegmentsForUser(key string) []string
 	KeyCount() int
+	ReplaceAll(segments map[string]*set.ThreadUnsafeSet) error
+	KeysBySegment() map[string]*set.ThreadUnsafeSet
+}
+
+// MemoryStats summarizes the size & estimated memory footprint of a MySegmentsCache
+type MemoryStats struct {
+	Keys           int   `json:"keys"`
+	Segments       int   `json:"segments"`
+	Memberships    int   `json:"memberships"` // distinct combinations of segments keys belong to
+	EstimatedBytes int64 `json:"estimatedBytes"`
+}
+
+// MemoryReporter is implemented by caches that can estimate how much memory they're using
+type MemoryReporter interface {
+	MemoryStats() MemoryStats
+}
+
+// segmentID is the interned form of a segment name
+type segmentID uint32
+
+// membershipID identifies a distinct set of segments
+type membershipID uint32
+
+// membership is a sorted set of segments, shared by every key that belongs to exactly those segments
+type membership struct {
+	segments []segmentID
+	refs     int // keys pointing to this membership. 0 means the slot is free
 }
 
-// MySegmentsCacheImpl implements the MySegmentsCache interface
+// MySegmentsCacheImpl implements the MySegmentsCache interface.
+// Segment names are interned into integer ids, and each key points to a membership: a sorted set of segment ids.
+// Since the number of distinct combinations of segments is usually tiny compared to the number of keys, memberships
+// are deduplicated & reference counted, making the cost of each key its own string plus a 4-byte id
 type MySegmentsCacheImpl struct {
-	mySegments map[string][]string
-	mutex      *sync.RWMutex
+	keys        map[string]membershipID
+	memberships []membership
+	byContent   map[string]membershipID // membership lookup by its encoded segment ids
+	free        []membershipID          // slots of memberships no longer referenced, to be reused
+	names       []string                // segment names indexed by id
+	ids         map[string]segmentID
+	keyBytes    int64
+	idCount     int64 // total segment ids across live memberships
+	mutex       *sync.RWMutex
 }
 
 // NewMySegmentsCache constructs a new MySegments cache
 func NewMySegmentsCache() *MySegmentsCacheImpl {
 	return &MySegmentsCacheImpl{
-		mySegments: make(map[string][]string),
-		mutex:      &sync.RWMutex{},
+		keys:      make(map[string]membershipID),
+		byContent: make(map[string]membershipID),
+		ids:       make(map[string]segmentID),
+		mutex:     &sync.RWMutex{},
 	}
 }
 
@@ -33,18 +83,24 @@ func NewMySegmentsCache() *MySegmentsCacheImpl {
 func (m *MySegmentsCacheImpl) KeyCount() int {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
-	return len(m.mySegments)
+	return len(m.keys)
 }
 
 // SegmentsForUser returns the list of segments a certain user belongs to
 func (m *MySegmentsCacheImpl) SegmentsForUser(key string) []string {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
-	userSegments, ok := m.mySegments[key]
+	id, ok := m.keys[key]
 	if !ok {
 		return []string{}
 	}
-	return userSegments
+
+	segments := m.memberships[id].segments
+	toRet := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		toRet = append(toRet, m.names[segment])
+	}
+	return toRet
 }
 
 // Update adds and removes segments to keys
@@ -52,6 +108,7 @@ func (m *MySegmentsCacheImpl) Update(name string, toAdd *set.ThreadUnsafeSet, to
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	segment := m.intern(name)
 	invalidAdded := []string{}
 	invalidRemoved := []string{}
 	for _, addedKey := range toAdd.List() {
@@ -60,7 +117,7 @@ func (m *MySegmentsCacheImpl) Update(name string, toAdd *set.ThreadUnsafeSet, to
 			invalidAdded = append(invalidAdded, fmt.Sprintf("%T::%+v", addedKey, addedKey))
 			continue
 		}
-		m.addSegmentToUser(strKey, name)
+		m.addSegmentToUser(strKey, segment)
 	}
 
 	for _, removedKey := range toRemove.List() {
@@ -69,7 +126,7 @@ func (m *MySegmentsCacheImpl) Update(name string, toAdd *set.ThreadUnsafeSet, to
 			invalidRemoved = append(invalidRemoved, fmt.Sprintf("%T::%+v", removedKey, removedKey))
 			continue
 		}
-		m.removeSegmentForUser(strKey, name)
+		m.removeSegmentForUser(strKey, segment)
 	}
 
 	if len(invalidAdded) > 0 || len(invalidRemoved) > 0 {
@@ -80,41 +137,169 @@ func (m *MySegmentsCacheImpl) Update(name string, toAdd *set.ThreadUnsafeSet, to
 	return nil
 }
 
-func (m *MySegmentsCacheImpl) addSegmentToUser(key string, segment string) {
-	toAdd := []string{segment}
-	userSegments, ok := m.mySegments[key]
-	if ok {
-		if m.isInSegment(segment, userSegments) {
-			return
+// ReplaceAll atomically discards the current contents and loads the supplied keys for each segment
+func (m *MySegmentsCacheImpl) ReplaceAll(segments map[string]*set.ThreadUnsafeSet) error {
+	fresh := NewMySegmentsCache()
+	var errs []string
+	for name, keys := range segments {
+		if err := fresh.Update(name, keys, set.NewSet()); err != nil {
+			errs = append(errs, err.Error())
 		}
-		toAdd = append(userSegments, toAdd...)
 	}
-	m.mySegments[key] = toAdd
+
+	m.mutex.Lock()
+	m.keys = fresh.keys
+	m.memberships = fresh.memberships
+	m.byContent = fresh.byContent
+	m.free = fresh.free
+	m.names = fresh.names
+	m.ids = fresh.ids
+	m.keyBytes = fresh.keyBytes
+	m.idCount = fresh.idCount
+	m.mutex.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors replacing segments: %s", strings.Join(errs, " || "))
+	}
+	return nil
+}
+
+// KeysBySegment returns the keys of every segment that has at least one. It walks the whole cache
+func (m *MySegmentsCacheImpl) KeysBySegment() map[string]*set.ThreadUnsafeSet {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	toRet := make(map[string]*set.ThreadUnsafeSet, len(m.names))
+	for key, id := range m.keys {
+		for _, segment := range m.memberships[id].segments {
+			keys, ok := toRet[m.names[segment]]
+			if !ok {
+				keys = set.NewSet()
+				toRet[m.names[segment]] = keys
+			}
+			keys.Add(key)
+		}
+	}
+	return toRet
+}
+
+// MemoryStats implements MemoryReporter
+func (m *MySegmentsCacheImpl) MemoryStats() MemoryStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var nameBytes int64
+	for _, name := range m.names {
+		nameBytes += int64(len(name)) + segmentNameOverhead
+	}
+
+	live := len(m.memberships) - len(m.free)
+	return MemoryStats{
+		Keys:        len(m.keys),
+		Segments:    len(m.names),
+		Memberships: live,
+		EstimatedBytes: m.keyBytes + int64(len(m.keys))*keyEntryOverhead +
+			int64(len(m.memberships))*membershipEntryOverhead + m.idCount*2*segmentIDSize +
+			nameBytes,
+	}
+}
+
+func (m *MySegmentsCacheImpl) intern(name string) segmentID {
+	if id, ok := m.ids[name]; ok {
+		return id
+	}
+	id := segmentID(len(m.names))
+	m.names = append(m.names, name)
+	m.ids[name] = id
+	return id
 }
 
-func (m *MySegmentsCacheImpl) removeSegmentForUser(key string, segment string) {
-	userSegments, ok := m.mySegments[key]
+func (m *MySegmentsCacheImpl) addSegmentToUser(key string, segment segmentID) {
+	current, ok := m.keys[key]
 	if !ok {
+		m.keyBytes += int64(len(key))
+		m.keys[key] = m.acquire([]segmentID{segment})
 		return
 	}
-	toUpdate := make([]string, 0)
-	for _, s := range userSegments {
-		if s != segment {
-			toUpdate = append(toUpdate, s)
-		}
+
+	segments := m.memberships[current].segments
+	idx, found := slices.BinarySearch(segments, segment)
+	if found {
+		return
+	}
+
+	updated := slices.Insert(slices.Clone(segments), idx, segment)
+	m.release(current)
+	m.keys[key] = m.acquire(updated)
+}
+
+func (m *MySegmentsCacheImpl) removeSegmentForUser(key string, segment segmentID) {
+	current, ok := m.keys[key]
+	if !ok {
+		return
 	}
-	if len(toUpdate) == 0 {
-		delete(m.mySegments, key)
+
+	segments := m.memberships[current].segments
+	idx, found := slices.BinarySearch(segments, segment)
+	if !found {
 		return
 	}
-	m.mySegments[key] = toUpdate
+
+	if len(segments) == 1 {
+		m.release(current)
+		delete(m.keys, key)
+		m.keyBytes -= int64(len(key))
+		return
+	}
+
+	updated := slices.Delete(slices.Clone(segments), idx, idx+1)
+	m.release(current)
+	m.keys[key] = m.acquire(updated)
 }
 
-func (m *MySegmentsCacheImpl) isInSegment(segment string, segments []string) bool {
-	for _, s := range segments {
-		if s == segment {
-			return true
-		}
+// acquire returns the id of the membership for a sorted set of segments, creating it if necessary
+func (m *MySegmentsCacheImpl) acquire(segments []segmentID) membershipID {
+	content := encodeSegmentIDs(segments)
+	if id, ok := m.byContent[string(content)]; ok { // no allocation when converting for a lookup
+		m.memberships[id].refs++
+		return id
+	}
+
+	var id membershipID
+	if len(m.free) > 0 {
+		id = m.free[len(m.free)-1]
+		m.free = m.free[:len(m.free)-1]
+	} else {
+		id = membershipID(len(m.memberships))
+		m.memberships = append(m.memberships, membership{})
 	}
-	return false
+
+	m.memberships[id] = membership{segments: segments, refs: 1}
+	m.byContent[string(content)] = id
+	m.idCount += int64(len(segments))
+	return id
 }
+
+// release drops a reference to a membership, freeing its slot when no key points to it anymore
+func (m *MySegmentsCacheImpl) release(id membershipID) {
+	target := &m.memberships[id]
+	if target.refs--; target.refs > 0 {
+		return
+	}
+
+	delete(m.byContent, string(encodeSegmentIDs(target.segments)))
+	m.idCount -= int64(len(target.segments))
+	target.segments = nil
+	m.free = append(m.free, id)
+}
+
+func encodeSegmentIDs(segments []segmentID) []byte {
+	buf := make([]byte, 0, len(segments)*segmentIDSize)
+	for _, segment := range segments {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(segment))
+	}
+	return buf
+}
+
+var _ MySegmentsCache = (*MySegmentsCacheImpl)(nil)
+var _ MemoryReporter = (*MySegmentsCacheImpl)(nil)
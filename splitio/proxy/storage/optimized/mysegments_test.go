@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMySegmentsV2(t *testing.T) {
@@ -49,3 +50,58 @@ func TestMySegmentsV2(t *testing.T) {
 
 	storage.Update("one", set.NewSet(), set.NewSet("nonexistent"))
 }
+
+func TestMySegmentsSharedMemberships(t *testing.T) {
+	storage := NewMySegmentsCache()
+	storage.Update("one", set.NewSet("k1", "k2", "k3"), set.NewSet())
+	storage.Update("two", set.NewSet("k1", "k2"), set.NewSet())
+
+	assert.ElementsMatch(t, []string{"one", "two"}, storage.SegmentsForUser("k1"))
+	assert.ElementsMatch(t, []string{"one", "two"}, storage.SegmentsForUser("k2"))
+	assert.Equal(t, []string{"one"}, storage.SegmentsForUser("k3"))
+
+	// k1 & k2 share a membership, and k3 has another one
+	stats := storage.MemoryStats()
+	assert.Equal(t, 3, stats.Keys)
+	assert.Equal(t, 2, stats.Segments)
+	assert.Equal(t, 2, stats.Memberships)
+	assert.Greater(t, stats.EstimatedBytes, int64(0))
+
+	// the returned slice is a copy, modifying it doesn't affect other keys
+	segments := storage.SegmentsForUser("k1")
+	segments[0] = "modified"
+	assert.ElementsMatch(t, []string{"one", "two"}, storage.SegmentsForUser("k2"))
+
+	// unreferenced memberships are dropped & their slots reused
+	storage.Update("two", set.NewSet(), set.NewSet("k1", "k2"))
+	assert.Equal(t, 1, storage.MemoryStats().Memberships)
+	storage.Update("three", set.NewSet("k3"), set.NewSet())
+	assert.Equal(t, 2, storage.MemoryStats().Memberships)
+	assert.Len(t, storage.memberships, 2)
+	assert.ElementsMatch(t, []string{"one", "three"}, storage.SegmentsForUser("k3"))
+
+	storage.Update("one", set.NewSet(), set.NewSet("k1", "k2", "k3"))
+	storage.Update("three", set.NewSet(), set.NewSet("k3"))
+	stats = storage.MemoryStats()
+	assert.Equal(t, 0, stats.Keys)
+	assert.Equal(t, 0, stats.Memberships)
+	assert.Equal(t, int64(0), storage.keyBytes)
+	assert.Empty(t, storage.byContent)
+
+	assert.Nil(t, storage.ReplaceAll(map[string]*set.ThreadUnsafeSet{"four": set.NewSet("k4")}))
+	assert.Equal(t, []string{"four"}, storage.SegmentsForUser("k4"))
+	assert.Equal(t, MemoryStats{Keys: 1, Segments: 1, Memberships: 1, EstimatedBytes: storage.MemoryStats().EstimatedBytes}, storage.MemoryStats())
+}
+
+func TestMySegmentsKeysBySegment(t *testing.T) {
+	storage := NewMySegmentsCache()
+	storage.Update("one", set.NewSet("k1", "k2", "k3"), set.NewSet())
+	storage.Update("two", set.NewSet("k1"), set.NewSet())
+	storage.Update("three", set.NewSet("k4"), set.NewSet())
+	storage.Update("three", set.NewSet(), set.NewSet("k4"))
+
+	bySegment := storage.KeysBySegment()
+	assert.Len(t, bySegment, 2)
+	assert.True(t, bySegment["one"].IsEqual(set.NewSet("k1", "k2", "k3")))
+	assert.True(t, bySegment["two"].IsEqual(set.NewSet("k1")))
+}
@@ -10,6 +10,7 @@ import (
 type HistoricChangesRB interface {
 	GetUpdatedSince(since int64) []RBView
 	Update(toAdd []dtos.RuleBasedSegmentDTO, toRemove []dtos.RuleBasedSegmentDTO, newCN int64)
+	ReplaceAll(toAdd []dtos.RuleBasedSegmentDTO, newCN int64)
 }
 
 type HistoricChangesRBImpl struct {
@@ -39,6 +40,15 @@ func (h *HistoricChangesRBImpl) Update(toAdd []dtos.RuleBasedSegmentDTO, toRemov
 	h.mutex.Unlock()
 }
 
+// ReplaceAll discards all the tracked changes and starts over from the supplied rule-based segments
+func (h *HistoricChangesRBImpl) ReplaceAll(toAdd []dtos.RuleBasedSegmentDTO, newCN int64) {
+	h.mutex.Lock()
+	h.data = make([]RBView, 0, cap(h.data))
+	h.updateFrom(toAdd)
+	sort.Slice(h.data, func(i, j int) bool { return h.data[i].LastUpdated < h.data[j].LastUpdated })
+	h.mutex.Unlock()
+}
+
 // public interface ends here
 
 func (h *HistoricChangesRBImpl) updateFrom(source []dtos.RuleBasedSegmentDTO) {
@@ -38,18 +38,53 @@ type DBWrapper interface {
 type BoltDBWrapper struct {
 	wrapped *bolt.DB
 	mutex   sync.Mutex
+	swapMtx sync.RWMutex // guards `wrapped` against a concurrent Replace
 }
 
 // Update executes a RW function within a transaction
 func (b *BoltDBWrapper) Update(f func(tx *bolt.Tx) error) error {
+	b.swapMtx.RLock()
+	defer b.swapMtx.RUnlock()
 	return b.wrapped.Update(f)
 }
 
 // View executes a RO function wihtin a transaction
 func (b *BoltDBWrapper) View(f func(tx *bolt.Tx) error) error {
+	b.swapMtx.RLock()
+	defer b.swapMtx.RUnlock()
 	return b.wrapped.View(f)
 }
 
+// Replace opens the db file at `path` and makes it the wrapped one, once all in-flight transactions
+// are done. The previous db is closed and its file removed, since it's always a temporary one
+func (b *BoltDBWrapper) Replace(path string) error {
+	newDB, err := bolt.Open(path, 0644, nil)
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+
+	b.swapMtx.Lock()
+	old := b.wrapped
+	b.wrapped = newDB
+	b.swapMtx.Unlock()
+
+	oldPath := old.Path()
+	if err := old.Close(); err != nil {
+		return fmt.Errorf("error closing replaced db: %w", err)
+	}
+	if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing replaced db file: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying db file
+func (b *BoltDBWrapper) Close() error {
+	b.swapMtx.RLock()
+	defer b.swapMtx.RUnlock()
+	return b.wrapped.Close()
+}
+
 // Lock grants exclusive access to the referenced db
 func (b *BoltDBWrapper) Lock() {
 	b.mutex.Lock()
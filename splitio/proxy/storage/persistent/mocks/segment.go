@@ -26,3 +26,17 @@ func (s *SegmentChangesCollectionMock) ChangeNumber(segment string) int64 {
 func (s *SegmentChangesCollectionMock) SetChangeNumber(segment string, cn int64) {
 	s.Called(segment, cn)
 }
+
+func (s *SegmentChangesCollectionMock) FetchAll() ([]persistent.SegmentChangesItem, error) {
+	args := s.Called()
+	return args.Get(0).([]persistent.SegmentChangesItem), args.Error(1)
+}
+
+func (s *SegmentChangesCollectionMock) ResetChangeNumbers() {
+	s.Called()
+}
+
+func (s *SegmentChangesCollectionMock) Compact(name string, until int64) (int, error) {
+	args := s.Called(name, until)
+	return args.Int(0), args.Error(1)
+}
@@ -106,3 +106,10 @@ func (c *RBChangesCollection) ChangeNumber() int64 {
 	defer c.mutex.RUnlock()
 	return c.changeNumber
 }
+
+// ResetChangeNumber forgets the last processed change number. Used when the underlying db is replaced
+func (c *RBChangesCollection) ResetChangeNumber() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.changeNumber = 0
+}
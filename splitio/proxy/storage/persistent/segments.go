@@ -19,10 +19,15 @@ type SegmentKey struct {
 	Removed      bool
 }
 
-// SegmentChangesItem represents an SplitChanges service response
+// SegmentChangesItem holds the change history of a segment. The history is compacted per key: only the latest change
+// of each key (added or removed, and when) is kept, which is enough to build the diff from any change number onwards.
+// Tombstones (removed keys) can be discarded with `Compact`, in which case `CompactedUntil` records the most recent
+// change number lost, and diffs from older change numbers can no longer be computed accurately
 type SegmentChangesItem struct {
-	Name string
-	Keys map[string]SegmentKey
+	Name           string
+	Keys           map[string]SegmentKey
+	ChangeNumber   int64
+	CompactedUntil int64
 }
 
 type SegmentChangesCollection interface {
@@ -30,6 +35,9 @@ type SegmentChangesCollection interface {
 	Fetch(name string) (*SegmentChangesItem, error)
 	ChangeNumber(segment string) int64
 	SetChangeNumber(segment string, cn int64)
+	FetchAll() ([]SegmentChangesItem, error)
+	ResetChangeNumbers()
+	Compact(name string, until int64) (int, error)
 }
 
 // SegmentChangesCollectionImpl represents a collection of SplitChangesItem
@@ -62,6 +70,9 @@ func (c *SegmentChangesCollectionImpl) Update(name string, toAdd *set.ThreadUnsa
 		segmentItem.Name = name
 		segmentItem.Keys = make(map[string]SegmentKey, toAdd.Size()+toRemove.Size())
 	}
+	if cn > segmentItem.ChangeNumber {
+		segmentItem.ChangeNumber = cn
+	}
 
 	for _, removedKey := range toRemove.List() {
 		strKey, ok := removedKey.(string)
@@ -100,6 +111,38 @@ func (c *SegmentChangesCollectionImpl) Update(name string, toAdd *set.ThreadUnsa
 	return nil
 }
 
+// Compact discards the tombstones of keys removed at or before the `until` change number, returning how many were dropped
+func (c *SegmentChangesCollectionImpl) Compact(name string, until int64) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	segmentItem, err := c.fetch(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var dropped int
+	for key, sk := range segmentItem.Keys {
+		if !sk.Removed || sk.ChangeNumber > until {
+			continue
+		}
+		delete(segmentItem.Keys, key)
+		if sk.ChangeNumber > segmentItem.CompactedUntil {
+			segmentItem.CompactedUntil = sk.ChangeNumber
+		}
+		dropped++
+	}
+
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	if err := c.collection.SaveAs([]byte(name), segmentItem); err != nil {
+		return 0, fmt.Errorf("error saving compacted segment to bolt: %w", err)
+	}
+	return dropped, nil
+}
+
 // Fetch return a SegmentChangesItem
 func (c *SegmentChangesCollectionImpl) Fetch(name string) (*SegmentChangesItem, error) {
 	c.mutex.RLock()
@@ -173,4 +216,11 @@ func (c *SegmentChangesCollectionImpl) SetChangeNumber(segment string, cn int64)
 	c.segmentsTill[segment] = cn
 }
 
+// ResetChangeNumbers forgets the change numbers of all segments. Used when the underlying db is replaced
+func (c *SegmentChangesCollectionImpl) ResetChangeNumbers() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.segmentsTill = make(map[string]int64, 0)
+}
+
 var _ SegmentChangesCollection = (*SegmentChangesCollectionImpl)(nil)
@@ -56,3 +56,38 @@ func TestSegmentPersistentStorage(t *testing.T) {
 		t.Error("k1 should be removed")
 	}
 }
+
+func TestSegmentCompaction(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	if err != nil {
+		t.Error("error creating bolt wrapper: ", err)
+	}
+
+	segmentC := NewSegmentChangesCollection(dbw, logging.NewLogger(nil))
+	segmentC.Update("s1", set.NewSet("k1", "k2", "k3", "k4"), set.NewSet(), 1)
+	segmentC.Update("s1", set.NewSet(), set.NewSet("k1"), 2)
+	segmentC.Update("s1", set.NewSet(), set.NewSet("k2"), 3)
+	segmentC.Update("s1", set.NewSet(), set.NewSet("k3"), 4)
+
+	dropped, err := segmentC.Compact("s1", 3)
+	if err != nil || dropped != 2 {
+		t.Error("k1 & k2 should have been dropped: ", dropped, err)
+	}
+
+	forS1, _ := segmentC.Fetch("s1")
+	if len(forS1.Keys) != 2 || !forS1.Keys["k3"].Removed || forS1.Keys["k4"].Removed {
+		t.Error("only k3 (removed) & k4 should remain: ", forS1.Keys)
+	}
+
+	if forS1.ChangeNumber != 4 || forS1.CompactedUntil != 3 {
+		t.Error("unexpected change numbers: ", forS1.ChangeNumber, forS1.CompactedUntil)
+	}
+
+	if dropped, _ := segmentC.Compact("s1", 3); dropped != 0 {
+		t.Error("nothing else should be dropped")
+	}
+
+	if _, err := segmentC.Compact("s2", 3); err == nil {
+		t.Error("compacting an unknown segment should fail")
+	}
+}
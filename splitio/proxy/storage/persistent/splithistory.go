@@ -0,0 +1,57 @@
+package persistent
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const splitHistoryCollectionName = "SPLIT_HISTORY_COLLECTION"
+
+var splitHistoryKey = []byte("history")
+
+// SplitHistoryItem is the persisted splitChanges history: the views of every tracked feature flag, plus the oldest
+// change number diffs can be served from
+type SplitHistoryItem struct {
+	OldestChangeNumber int64
+	Views              []optimized.FeatureView
+}
+
+// SplitHistoryCollection persists the splitChanges history as a single item, so that views & oldest change number
+// are always consistent with each other
+type SplitHistoryCollection struct {
+	collection CollectionWrapper
+}
+
+// NewSplitHistoryCollection returns an instance of SplitHistoryCollection
+func NewSplitHistoryCollection(db DBWrapper, logger logging.LoggerInterface) *SplitHistoryCollection {
+	return &SplitHistoryCollection{
+		collection: &BoltDBCollectionWrapper{db: db, name: splitHistoryCollectionName, logger: logger},
+	}
+}
+
+// Save replaces the persisted history
+func (c *SplitHistoryCollection) Save(item *SplitHistoryItem) error {
+	if err := c.collection.SaveAs(splitHistoryKey, item); err != nil {
+		return fmt.Errorf("error saving split history to bolt: %w", err)
+	}
+	return nil
+}
+
+// Fetch returns the persisted history. ErrorBucketNotFound is returned if none has been saved yet
+func (c *SplitHistoryCollection) Fetch() (*SplitHistoryItem, error) {
+	raw, err := c.collection.FetchBy(splitHistoryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var item SplitHistoryItem
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&item); err != nil {
+		return nil, fmt.Errorf("error decoding split history: %w", err)
+	}
+	return &item, nil
+}
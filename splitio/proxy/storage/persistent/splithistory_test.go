@@ -0,0 +1,32 @@
+package persistent
+
+import (
+	"testing"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitHistoryCollection(t *testing.T) {
+	dbw, err := NewBoltWrapper(BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	history := NewSplitHistoryCollection(dbw, logging.NewLogger(nil))
+	_, err = history.Fetch()
+	assert.ErrorIs(t, err, ErrorBucketNotFound)
+
+	item := &SplitHistoryItem{
+		OldestChangeNumber: 5,
+		Views: []optimized.FeatureView{
+			{Name: "f1", Active: true, LastUpdated: 5, TrafficTypeName: "user", FlagSets: []optimized.FlagSetView{{Name: "s1", Active: false, LastUpdated: 5}}},
+			{Name: "f2", Active: false, LastUpdated: 7, TrafficTypeName: "user"},
+		},
+	}
+	assert.Nil(t, history.Save(item))
+
+	fetched, err := history.Fetch()
+	assert.Nil(t, err)
+	assert.Equal(t, item, fetched)
+}
@@ -106,3 +106,10 @@ func (c *SplitChangesCollection) ChangeNumber() int64 {
 	defer c.mutex.RUnlock()
 	return c.changeNumber
 }
+
+// ResetChangeNumber forgets the last processed change number. Used when the underlying db is replaced
+func (c *SplitChangesCollection) ResetChangeNumber() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.changeNumber = 0
+}
@@ -27,6 +27,7 @@ type ProxyRuleBasedSegmentsStorageImpl struct {
 	db            *persistent.RBChangesCollection
 	logger        logging.LoggerInterface
 	oldestKnownCN int64
+	resyncPending bool // set when reloaded from disk, until the rule-based segments are replaced by a full fetch
 	mtx           sync.Mutex
 	historic      optimized.HistoricChangesRB
 }
@@ -67,12 +68,23 @@ func snapshotFromDiskRB(
 	src *persistent.RBChangesCollection,
 	logger logging.LoggerInterface,
 ) int64 {
-	all, err := src.FetchAll()
+	filtered, cn, err := activeRBFromDisk(src)
 	if err != nil {
 		logger.Error("error parsing feature flags from snapshot. No data will be available!: ", err)
 		return -1
 	}
 
+	dst.Update(filtered, nil, cn)
+	historic.Update(filtered, nil, cn)
+	return cn
+}
+
+func activeRBFromDisk(src *persistent.RBChangesCollection) ([]dtos.RuleBasedSegmentDTO, int64, error) {
+	all, err := src.FetchAll()
+	if err != nil {
+		return nil, -1, err
+	}
+
 	var filtered []dtos.RuleBasedSegmentDTO
 	var cn = src.ChangeNumber()
 	for idx := range all {
@@ -85,10 +97,14 @@ func snapshotFromDiskRB(
 			filtered = append(filtered, all[idx])
 		}
 	}
+	return filtered, cn, nil
+}
 
-	dst.Update(filtered, nil, cn)
-	historic.Update(filtered, nil, cn)
-	return cn
+func withRBChangeNumber(rbs []dtos.RuleBasedSegmentDTO, cn int64) []dtos.RuleBasedSegmentDTO {
+	for idx := range rbs {
+		rbs[idx].ChangeNumber = cn
+	}
+	return rbs
 }
 
 func archivedRBDTOForView(view *optimized.RBView) dtos.RuleBasedSegmentDTO {
@@ -148,8 +164,15 @@ func (p *ProxyRuleBasedSegmentsStorageImpl) All() []dtos.RuleBasedSegmentDTO {
 	return p.snapshot.All()
 }
 
-// ChangeNumber returns the current change number
+// ChangeNumber returns the current change number, or -1 after reloading from disk & until the next update,
+// as with feature flags (see ProxySplitStorageImpl.ChangeNumber)
 func (p *ProxyRuleBasedSegmentsStorageImpl) ChangeNumber() (int64, error) {
+	p.mtx.Lock()
+	pending := p.resyncPending
+	p.mtx.Unlock()
+	if pending {
+		return -1, nil
+	}
 	return p.snapshot.ChangeNumber()
 }
 
@@ -188,21 +211,70 @@ func (p *ProxyRuleBasedSegmentsStorageImpl) SetChangeNumber(cn int64) error {
 	return p.snapshot.SetChangeNumber(cn)
 }
 
-// Update
+// Update. The first update after reloading from disk comes from a full fetch, so it replaces every rule-based segment instead
 func (p *ProxyRuleBasedSegmentsStorageImpl) Update(toAdd []dtos.RuleBasedSegmentDTO, toRemove []dtos.RuleBasedSegmentDTO, changeNumber int64) error {
 	// TODO Add the other logic
 	p.setStartingPoint(changeNumber) // will be executed only the first time this method is called
 
-	if len(toAdd) == 0 && len(toRemove) == 0 {
-		return nil
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	switch {
+	case p.resyncPending:
+		p.replace(toAdd, changeNumber)
+		p.resyncPending = false
+	case len(toAdd) == 0 && len(toRemove) == 0:
+	default:
+		p.snapshot.Update(toAdd, toRemove, changeNumber)
+		p.historic.Update(toAdd, toRemove, changeNumber)
+		p.db.Update(toAdd, toRemove, changeNumber)
 	}
+	return nil
+}
 
+// ReloadFromDisk discards the in-memory snapshot & change history and rebuilds them from the persistent storage.
+// Must be called after the underlying db has been replaced.
+// As with feature flags, the new data is published as a change newer than anything served so far, containing
+// every rule-based segment in it, and archiving the ones it doesn't have. It's replaced by the next sync
+func (p *ProxyRuleBasedSegmentsStorageImpl) ReloadFromDisk() {
 	p.mtx.Lock()
-	p.snapshot.Update(toAdd, toRemove, changeNumber)
-	p.historic.Update(toAdd, toRemove, changeNumber)
-	p.db.Update(toAdd, toRemove, changeNumber)
-	p.mtx.Unlock()
-	return nil
+	defer p.mtx.Unlock()
+
+	p.db.ResetChangeNumber()
+	filtered, cn, err := activeRBFromDisk(p.db)
+	if err != nil {
+		p.logger.Error("error parsing rule-based segments from replaced db. No data will be available!: ", err)
+	}
+
+	p.historic.ReplaceAll(nil, cn)
+	p.replace(filtered, cn)
+	p.oldestKnownCN = cn
+	p.resyncPending = true
+}
+
+// replace makes `active` the whole set of rule-based segments, published as a change newer than anything served so far,
+// and archives the ones missing from it. Must be called with the lock held
+func (p *ProxyRuleBasedSegmentsStorageImpl) replace(active []dtos.RuleBasedSegmentDTO, cn int64) {
+	servedCN, _ := p.snapshot.ChangeNumber()
+	replaceCN := max(cn, servedCN+1)
+	toAdd := withRBChangeNumber(active, replaceCN)
+
+	toKeep := make(map[string]struct{}, len(toAdd))
+	for idx := range toAdd {
+		toKeep[toAdd[idx].Name] = struct{}{}
+	}
+
+	var toArchive []dtos.RuleBasedSegmentDTO
+	for _, current := range p.snapshot.All() {
+		if _, ok := toKeep[current.Name]; !ok {
+			current.Status = constants.SplitStatusArchived
+			toArchive = append(toArchive, current)
+		}
+	}
+	toArchive = withRBChangeNumber(toArchive, replaceCN)
+
+	p.snapshot.Update(toAdd, toArchive, replaceCN)
+	p.historic.Update(toAdd, toArchive, replaceCN)
+	p.db.Update(toAdd, toArchive, replaceCN)
 }
 
 func (p *ProxyRuleBasedSegmentsStorageImpl) setStartingPoint(cn int64) {
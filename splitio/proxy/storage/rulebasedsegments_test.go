@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/persistent"
@@ -151,3 +153,62 @@ func TestRBSChangesSince(t *testing.T) {
 	}
 	assert.ElementsMatch(t, expectedChanges, changes.RuleBasedSegments)
 }
+
+func TestRBStorageReloadFromOlderDisk(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	rbsStorage := NewProxyRuleBasedSegmentsStorage(dbw, logger, false)
+	rbsStorage.Update([]dtos.RuleBasedSegmentDTO{
+		{Name: "rbs1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "user"},
+		{Name: "rbs2", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "user"},
+	}, nil, 20)
+
+	other, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	persistent.NewRBChangesCollection(other, logger).Update([]dtos.RuleBasedSegmentDTO{
+		{Name: "rbs1", ChangeNumber: 5, Status: "ACTIVE", TrafficTypeName: "account"},
+	}, nil, 5)
+	raw, err := other.GetRawSnapshot()
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "replacement.db")
+	assert.Nil(t, os.WriteFile(path, raw, 0644))
+	assert.Nil(t, dbw.Replace(path))
+
+	rbsStorage.ReloadFromDisk()
+
+	// syncing doesn't resume from the change number the reloaded data is published at
+	cn, _ := rbsStorage.ChangeNumber()
+	assert.Equal(t, int64(-1), cn)
+
+	changes, err := rbsStorage.ChangesSince(20)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(21), changes.Till)
+	statuses := make(map[string]string, len(changes.RuleBasedSegments))
+	for _, rbs := range changes.RuleBasedSegments {
+		statuses[rbs.Name] = rbs.Status
+	}
+	assert.Equal(t, map[string]string{"rbs1": "ACTIVE", "rbs2": "ARCHIVED"}, statuses)
+
+	rbs1, _ := rbsStorage.GetRuleBasedSegmentByName("rbs1")
+	assert.Equal(t, "account", rbs1.TrafficTypeName)
+
+	changes, err = rbsStorage.ChangesSince(21)
+	assert.Nil(t, err)
+	assert.Empty(t, changes.RuleBasedSegments)
+
+	// the next sync fetches every rule-based segment, replacing the reloaded ones
+	rbsStorage.Update([]dtos.RuleBasedSegmentDTO{{Name: "rbs3", ChangeNumber: 30, Status: "ACTIVE", TrafficTypeName: "user"}}, nil, 30)
+	cn, _ = rbsStorage.ChangeNumber()
+	assert.Equal(t, int64(30), cn)
+
+	changes, err = rbsStorage.ChangesSince(21)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), changes.Till)
+	statuses = make(map[string]string, len(changes.RuleBasedSegments))
+	for _, rbs := range changes.RuleBasedSegments {
+		statuses[rbs.Name] = rbs.Status
+	}
+	assert.Equal(t, map[string]string{"rbs1": "ARCHIVED", "rbs3": "ACTIVE"}, statuses)
+}
@@ -3,6 +3,9 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/observability"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
@@ -25,16 +28,25 @@ type ProxySegmentStorage interface {
 	CountRemovedKeys(segmentName string) int
 }
 
+// MySegmentsMemoryReporter is implemented by segment storages that can report the footprint of their key -> segments index
+type MySegmentsMemoryReporter interface {
+	MySegmentsMemory() optimized.MemoryStats
+}
+
 // ProxySegmentStorageImpl implements the ProxySegmentStorage interface
 type ProxySegmentStorageImpl struct {
-	logger         logging.LoggerInterface
-	nameCountCache *observability.ActiveSegmentTracker
-	db             persistent.SegmentChangesCollection
-	mysegments     optimized.MySegmentsCache
+	logger             logging.LoggerInterface
+	nameCountCache     *observability.ActiveSegmentTracker
+	db                 persistent.SegmentChangesCollection
+	mysegments         optimized.MySegmentsCache
+	tombstoneRetention int64               // in change number units (ms)
+	resyncPending      map[string]struct{} // segments reloaded from disk, until replaced by a full fetch (see ChangeNumber)
+	mtx                sync.Mutex
 }
 
-// NewProxySegmentStorage for proxy
-func NewProxySegmentStorage(db persistent.DBWrapper, logger logging.LoggerInterface, restoreFromBackup bool) *ProxySegmentStorageImpl {
+// NewProxySegmentStorage for proxy. Removed keys are remembered for `tombstoneRetention` after the segment's latest change
+// (0 keeps them forever), so that they can be reported to sdks that are behind. Older ones are discarded by CompactRemovedKeys
+func NewProxySegmentStorage(db persistent.DBWrapper, logger logging.LoggerInterface, restoreFromBackup bool, tombstoneRetention time.Duration) *ProxySegmentStorageImpl {
 	cache := optimized.NewMySegmentsCache()
 	disk := persistent.NewSegmentChangesCollection(db, logger)
 	nameCountCache := observability.NewActiveSegmentTracker(100) // just a guess, we don't know the size yet
@@ -42,17 +54,17 @@ func NewProxySegmentStorage(db persistent.DBWrapper, logger logging.LoggerInterf
 		populateCachesFromDisk(cache, nameCountCache, disk, logger)
 	}
 	return &ProxySegmentStorageImpl{
-		db:             disk,
-		mysegments:     cache,
-		logger:         logger,
-		nameCountCache: nameCountCache,
+		db:                 disk,
+		mysegments:         cache,
+		logger:             logger,
+		nameCountCache:     nameCountCache,
+		tombstoneRetention: tombstoneRetention.Milliseconds(),
 	}
 }
 
-// ChangesSince returns the `segmentChanges` like payload to from a certain CN to the last snapshot
-// This method has one drawback. ALL the historically removed keys are always returned as part of the `removed` array,
-// regardless whether the `since` parameter is old enough to require such removal or not.
-// We should eventually see if it's worth taking an approach similar to the one in feature flags or not
+// ChangesSince returns the `segmentChanges` like payload from a certain CN to the latest one: keys added or removed after `since`.
+// If `since` predates the retained history (tombstones have been compacted), ErrSinceParamTooOld is returned, since keys removed
+// back then can no longer be reported
 func (s *ProxySegmentStorageImpl) ChangesSince(name string, since int64) (*dtos.SegmentChangesDTO, error) {
 	item, err := s.db.Fetch(name)
 	if err != nil {
@@ -62,9 +74,16 @@ func (s *ProxySegmentStorageImpl) ChangesSince(name string, since int64) (*dtos.
 		return nil, fmt.Errorf("unexpected error when fetching segment '%s': %w", name, err)
 	}
 
+	if since != -1 && since < item.CompactedUntil {
+		return nil, ErrSinceParamTooOld
+	}
+
 	added := make([]string, 0)
 	removed := make([]string, 0)
 	till := since
+	if item.ChangeNumber > till {
+		till = item.ChangeNumber
+	}
 
 	// Horrible loop borrowed from sdk-api
 	for _, skey := range item.Keys {
@@ -91,6 +110,8 @@ func (s *ProxySegmentStorageImpl) ChangesSince(name string, since int64) (*dtos.
 		}
 	}
 
+	sort.Strings(added)
+	sort.Strings(removed)
 	return &dtos.SegmentChangesDTO{Name: name, Since: since, Till: till, Added: added, Removed: removed}, nil
 }
 
@@ -99,13 +120,25 @@ func (s *ProxySegmentStorageImpl) SegmentsFor(key string) ([]string, error) {
 	return s.mysegments.SegmentsForUser(key), nil
 }
 
+// MySegmentsMemory returns the size & estimated memory footprint of the index used to serve mySegments/memberships
+func (s *ProxySegmentStorageImpl) MySegmentsMemory() optimized.MemoryStats {
+	if asReporter, ok := s.mysegments.(optimized.MemoryReporter); ok {
+		return asReporter.MemoryStats()
+	}
+	return optimized.MemoryStats{Keys: s.mysegments.KeyCount()}
+}
+
 // SegmentKeysCount returns 0
 func (s *ProxySegmentStorageImpl) SegmentKeysCount() int64 {
 	return int64(s.mysegments.KeyCount())
 }
 
-// ChangeNumber storage
+// ChangeNumber returns the change number of a segment. After reloading from disk & until the segment's next update,
+// -1 is returned, so that the synchronizer fetches every key from Split instead of applying its changes on top of the reloaded ones
 func (s *ProxySegmentStorageImpl) ChangeNumber(segment string) (int64, error) {
+	if s.isResyncPending(segment) {
+		return -1, nil
+	}
 	return s.db.ChangeNumber(segment), nil
 }
 
@@ -139,8 +172,13 @@ func (s *ProxySegmentStorageImpl) SegmentContainsKey(segmentName string, key str
 	return false, nil
 }
 
-// Update method
+// Update method. The first update of a segment after reloading from disk comes from a full fetch (see ChangeNumber),
+// so it replaces the segment's keys instead
 func (s *ProxySegmentStorageImpl) Update(name string, toAdd *set.ThreadUnsafeSet, toRemove *set.ThreadUnsafeSet, changeNumber int64) error {
+	if s.takeResyncPending(name) {
+		return s.replace(name, toAdd, changeNumber)
+	}
+
 	errCache := s.mysegments.Update(name, toAdd, toRemove)
 	errDB := s.db.Update(name, toAdd, toRemove, changeNumber)
 	if errCache == nil && errDB == nil {
@@ -162,6 +200,25 @@ func (s *ProxySegmentStorageImpl) Update(name string, toAdd *set.ThreadUnsafeSet
 	return fmt.Errorf("errors updating cache: %s || errors updating db: %s", cacheErrMsg, dbErrMsg)
 }
 
+// CompactRemovedKeys drops the tombstones of every segment that fall outside the retention window (relative to each
+// segment's latest change). It walks every key of every segment, so it's meant to be run periodically & off the update path
+func (s *ProxySegmentStorageImpl) CompactRemovedKeys() {
+	if s.tombstoneRetention <= 0 {
+		return
+	}
+
+	for name := range s.nameCountCache.NamesAndCount() {
+		dropped, err := s.db.Compact(name, s.db.ChangeNumber(name)-s.tombstoneRetention)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("error compacting removed keys of segment '%s': %s", name, err))
+			continue
+		}
+		if dropped > 0 {
+			s.logger.Debug(fmt.Sprintf("%d removed keys of segment '%s' compacted", dropped, name))
+		}
+	}
+}
+
 // CountRemovedKeys method
 func (s *ProxySegmentStorageImpl) CountRemovedKeys(segmentName string) int {
 	segment, err := s.db.Fetch(segmentName)
@@ -189,30 +246,147 @@ func (s *ProxySegmentStorageImpl) NamesAndCount() map[string]int {
 	return s.nameCountCache.NamesAndCount()
 }
 
+// ReloadFromDisk discards the in-memory caches and rebuilds them from the persistent storage.
+// Must be called after the underlying db has been replaced.
+// SDKs may already be past the change number of the new data (ie: when rolling back to an older snapshot), in which
+// case they'd never get it. So, as with feature flags, each segment that was being served is republished as a change
+// newer than the one served: every key in the new data is added, and the ones that were members & no longer are, removed
+func (s *ProxySegmentStorageImpl) ReloadFromDisk() {
+	previous := s.mysegments.KeysBySegment()
+	all, err := s.db.FetchAll()
+	if err != nil {
+		s.logger.Error("error fetching segments from replaced db. No data will be available!: ", err)
+	}
+
+	// served change numbers are kept in memory, so they're still the ones prior to the db replacement
+	servedCNs := make(map[string]int64, len(previous)+len(all))
+	for name := range previous {
+		servedCNs[name] = s.db.ChangeNumber(name)
+	}
+	newCNs := make(map[string]int64, len(all))
+	for idx := range all {
+		servedCNs[all[idx].Name] = s.db.ChangeNumber(all[idx].Name)
+		newCNs[all[idx].Name] = all[idx].ChangeNumber
+	}
+
+	s.db.ResetChangeNumbers()
+	members := replaceCaches(s.mysegments, s.nameCountCache, all, s.logger)
+
+	pending := make(map[string]struct{}, len(servedCNs))
+	for name, servedCN := range servedCNs {
+		pending[name] = struct{}{}
+		if servedCN == -1 { // never served, so no sdk can be past it
+			continue
+		}
+
+		toAdd, ok := members[name]
+		if !ok {
+			toAdd = set.NewSet()
+		}
+		previousKeys, ok := previous[name]
+		if !ok {
+			previousKeys = set.NewSet()
+		}
+
+		if err := s.db.Update(name, toAdd, missingFrom(toAdd, previousKeys), max(newCNs[name], servedCN+1)); err != nil {
+			s.logger.Error(fmt.Sprintf("error republishing segment '%s' after reloading the db: %s", name, err))
+		}
+	}
+
+	// the change numbers published above don't exist in Split, so syncing can't resume from them
+	s.mtx.Lock()
+	s.resyncPending = pending
+	s.mtx.Unlock()
+}
+
+// replace makes `keys` the whole membership of a segment, published as a change newer than the one served,
+// and removes the keys missing from it
+func (s *ProxySegmentStorageImpl) replace(name string, keys *set.ThreadUnsafeSet, changeNumber int64) error {
+	previousKeys := set.NewSet()
+	if item, err := s.db.Fetch(name); err == nil {
+		for _, key := range item.Keys {
+			if !key.Removed {
+				previousKeys.Add(key.Name)
+			}
+		}
+	}
+
+	added := missingFrom(previousKeys, keys)
+	removed := missingFrom(keys, previousKeys)
+	if err := s.mysegments.Update(name, added, removed); err != nil {
+		return fmt.Errorf("errors updating cache: %w", err)
+	}
+	if err := s.db.Update(name, keys, removed, max(changeNumber, s.db.ChangeNumber(name)+1)); err != nil {
+		return fmt.Errorf("errors updating db: %w", err)
+	}
+	s.nameCountCache.Update(name, added.Size(), removed.Size())
+	return nil
+}
+
+func (s *ProxySegmentStorageImpl) isResyncPending(name string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.resyncPending[name]
+	return ok
+}
+
+func (s *ProxySegmentStorageImpl) takeResyncPending(name string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.resyncPending[name]
+	delete(s.resyncPending, name)
+	return ok
+}
+
+// missingFrom returns the keys in `keys` that aren't in `current`
+func missingFrom(current *set.ThreadUnsafeSet, keys *set.ThreadUnsafeSet) *set.ThreadUnsafeSet {
+	toRet := set.NewSet()
+	for _, key := range keys.List() {
+		if !current.Has(key) {
+			toRet.Add(key)
+		}
+	}
+	return toRet
+}
+
 func populateCachesFromDisk(
 	dst optimized.MySegmentsCache,
 	names *observability.ActiveSegmentTracker,
-	src *persistent.SegmentChangesCollectionImpl,
+	src persistent.SegmentChangesCollection,
 	logger logging.LoggerInterface,
 ) {
 	all, err := src.FetchAll()
 	if err != nil {
 		logger.Error("error popoulating segment cache from disk. Cache will be empty!: ", err)
-		return
 	}
+	replaceCaches(dst, names, all, logger)
+}
 
+// replaceCaches rebuilds the key -> segments index & the key counts from the supplied segments, returning their members
+func replaceCaches(
+	dst optimized.MySegmentsCache,
+	names *observability.ActiveSegmentTracker,
+	all []persistent.SegmentChangesItem,
+	logger logging.LoggerInterface,
+) map[string]*set.ThreadUnsafeSet {
+	keys := make(map[string]*set.ThreadUnsafeSet, len(all))
+	counts := make(map[string]int, len(all))
 	for idx := range all {
 		s := set.NewSet()
-		count := 0
 		for _, k := range all[idx].Keys {
 			if !k.Removed {
 				s.Add(k.Name)
-				count++
 			}
 		}
-		dst.Update(all[idx].Name, s, set.NewSet())
-		names.Update(all[idx].Name, count, 0)
+		keys[all[idx].Name] = s
+		counts[all[idx].Name] = s.Size()
+	}
+
+	if err := dst.ReplaceAll(keys); err != nil {
+		logger.Error("error popoulating segment cache from disk: ", err)
 	}
+	names.ReplaceAll(counts)
+	return keys
 }
 
 var _ storage.SegmentStorage = (*ProxySegmentStorageImpl)(nil)
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/splitio/go-toolkit/v5/datastructures/set"
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -88,10 +91,18 @@ func (m *mockMySegmentsCache) SegmentsForUser(key string) []string {
 	return []string{}
 }
 
+func (m *mockMySegmentsCache) ReplaceAll(segments map[string]*set.ThreadUnsafeSet) error {
+	return nil
+}
+
 func (m *mockMySegmentsCache) KeyCount() int {
 	return 0
 }
 
+func (m *mockMySegmentsCache) KeysBySegment() map[string]*set.ThreadUnsafeSet {
+	return nil
+}
+
 func TestSegmentStorageUpdateErrorHandling(t *testing.T) {
 	t.Run("db error only", func(t *testing.T) {
 		psm := &mocks.SegmentChangesCollectionMock{}
@@ -114,3 +125,144 @@ func TestSegmentStorageUpdateErrorHandling(t *testing.T) {
 		psm.AssertExpectations(t)
 	})
 }
+
+func TestSegmentStorageReloadFromDisk(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	ss := NewProxySegmentStorage(dbw, logger, false, 0)
+	assert.Nil(t, ss.Update("s1", set.NewSet("k1", "k2"), set.NewSet(), 10))
+	assert.Nil(t, ss.Update("s2", set.NewSet("k1"), set.NewSet(), 10))
+
+	other, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	otherSegments := persistent.NewSegmentChangesCollection(other, logger)
+	assert.Nil(t, otherSegments.Update("s1", set.NewSet("k3"), set.NewSet(), 5))
+	assert.Nil(t, otherSegments.Update("s3", set.NewSet("k1", "k4"), set.NewSet("k5"), 5))
+	raw, err := other.GetRawSnapshot()
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "replacement.db")
+	assert.Nil(t, os.WriteFile(path, raw, 0644))
+	assert.Nil(t, dbw.Replace(path))
+
+	ss.ReloadFromDisk()
+
+	segments, _ := ss.SegmentsFor("k1")
+	assert.ElementsMatch(t, []string{"s3"}, segments)
+	segments, _ = ss.SegmentsFor("k2")
+	assert.Empty(t, segments)
+	segments, _ = ss.SegmentsFor("k3")
+	assert.ElementsMatch(t, []string{"s1"}, segments)
+	assert.Equal(t, int64(3), ss.SegmentKeysCount())
+	assert.Equal(t, map[string]int{"s1": 1, "s3": 2}, ss.NamesAndCount())
+
+	// segments that were being served are republished above the served change number, against the previous members.
+	// Syncing doesn't resume from it
+	cn, _ := ss.ChangeNumber("s1")
+	assert.Equal(t, int64(-1), cn)
+	changes, err := ss.ChangesSince("s1", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k3"}, changes.Added)
+	assert.Equal(t, []string{"k1", "k2"}, changes.Removed)
+	assert.Equal(t, int64(11), changes.Till)
+
+	changes, err = ss.ChangesSince("s2", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, changes.Added)
+	assert.Equal(t, []string{"k1"}, changes.Removed)
+	assert.Equal(t, int64(11), changes.Till)
+
+	// the ones that weren't are left as in the new data
+	cn, _ = ss.ChangeNumber("s3")
+	assert.Equal(t, int64(-1), cn)
+	changes, err = ss.ChangesSince("s3", -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k1", "k4"}, changes.Added)
+	assert.Equal(t, int64(5), changes.Till)
+
+	// the next sync of each segment fetches every key, which replaces the reloaded ones
+	assert.Nil(t, ss.Update("s1", set.NewSet("k1", "k5"), set.NewSet(), 10))
+	cn, _ = ss.ChangeNumber("s1")
+	assert.Equal(t, int64(12), cn)
+	changes, err = ss.ChangesSince("s1", 11)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k1", "k5"}, changes.Added)
+	assert.Equal(t, []string{"k3"}, changes.Removed)
+	assert.Equal(t, int64(12), changes.Till)
+
+	assert.Nil(t, ss.Update("s3", set.NewSet("k4"), set.NewSet(), 8))
+	cn, _ = ss.ChangeNumber("s3")
+	assert.Equal(t, int64(8), cn)
+	changes, err = ss.ChangesSince("s3", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k4"}, changes.Added)
+	assert.Equal(t, []string{"k1"}, changes.Removed)
+
+	segments, _ = ss.SegmentsFor("k1")
+	assert.ElementsMatch(t, []string{"s1"}, segments)
+	segments, _ = ss.SegmentsFor("k5")
+	assert.ElementsMatch(t, []string{"s1"}, segments)
+	assert.Equal(t, map[string]int{"s1": 2, "s3": 1}, ss.NamesAndCount())
+
+	// and regular updates follow
+	assert.Nil(t, ss.Update("s1", set.NewSet(), set.NewSet("k5"), 20))
+	cn, _ = ss.ChangeNumber("s1")
+	assert.Equal(t, int64(20), cn)
+	segments, _ = ss.SegmentsFor("k1")
+	assert.ElementsMatch(t, []string{"s1"}, segments)
+}
+
+func TestSegmentStorageTombstoneCompaction(t *testing.T) {
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	ss := NewProxySegmentStorage(dbw, logging.NewLogger(nil), false, 150*time.Millisecond)
+	assert.Nil(t, ss.Update("s1", set.NewSet("k1", "k2", "k3", "k4"), set.NewSet(), 1000))
+	assert.Nil(t, ss.Update("s1", set.NewSet("k5"), set.NewSet("k1"), 1050))
+	assert.Nil(t, ss.Update("s1", set.NewSet(), set.NewSet("k2"), 1100))
+
+	// nothing compacted yet, diffs only contain what changed after since
+	changes, err := ss.ChangesSince("s1", 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k5"}, changes.Added)
+	assert.Equal(t, []string{"k1", "k2"}, changes.Removed)
+	assert.Equal(t, int64(1100), changes.Till)
+
+	changes, err = ss.ChangesSince("s1", 1050)
+	assert.Nil(t, err)
+	assert.Empty(t, changes.Added)
+	assert.Equal(t, []string{"k2"}, changes.Removed)
+
+	// compaction doesn't happen on updates, but when explicitly requested
+	assert.Nil(t, ss.Update("s1", set.NewSet(), set.NewSet("k3"), 1200))
+	assert.Equal(t, 3, ss.CountRemovedKeys("s1"))
+
+	// k1's tombstone (1050) falls outside the retention window
+	ss.CompactRemovedKeys()
+	assert.Equal(t, 2, ss.CountRemovedKeys("s1"))
+
+	// sdks within the retained history still get accurate diffs
+	changes, err = ss.ChangesSince("s1", 1050)
+	assert.Nil(t, err)
+	assert.Empty(t, changes.Added)
+	assert.Equal(t, []string{"k2", "k3"}, changes.Removed)
+	assert.Equal(t, int64(1200), changes.Till)
+
+	// older ones can't be told that k1 was removed, so they must be served from split servers
+	_, err = ss.ChangesSince("s1", 1000)
+	assert.ErrorIs(t, err, ErrSinceParamTooOld)
+
+	// initialization payloads never carry removed keys
+	changes, err = ss.ChangesSince("s1", -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k4", "k5"}, changes.Added)
+	assert.Empty(t, changes.Removed)
+
+	// up to date
+	changes, err = ss.ChangesSince("s1", 1200)
+	assert.Nil(t, err)
+	assert.Empty(t, changes.Added)
+	assert.Empty(t, changes.Removed)
+	assert.Equal(t, int64(1200), changes.Till)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/provisional/observability"
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
@@ -27,32 +28,50 @@ type ProxySplitStorage interface {
 	ChangesSince(since int64, flagSets []string) (*dtos.SplitChangesDTO, error)
 }
 
+// SplitHistoryReporter is implemented by storages that can report how far back splitChanges can be served from
+type SplitHistoryReporter interface {
+	OldestServableChangeNumber() int64
+}
+
 // ProxySplitStorageImpl implements the ProxySplitStorage interface and the SplitProducer interface
 type ProxySplitStorageImpl struct {
 	snapshot      mutexmap.MMSplitStorage
 	db            *persistent.SplitChangesCollection
+	history       *persistent.SplitHistoryCollection
 	flagSets      flagsets.FlagSetFilter
 	historic      optimized.HistoricChanges
 	logger        logging.LoggerInterface
 	oldestKnownCN int64
+	resyncPending bool // set when reloaded from disk, until the flags are replaced by a full fetch (see ChangeNumber)
 	mtx           sync.Mutex
 }
 
 // NewProxySplitStorage instantiates a new proxy storage that wraps an in-memory snapshot of the last known,
 // flag configuration, a changes summaries containing recipes to update SDKs with different CNs, and a persistent storage
-// for snapshot purposes
-func NewProxySplitStorage(db persistent.DBWrapper, logger logging.LoggerInterface, flagSets flagsets.FlagSetFilter, restoreBackup bool) *ProxySplitStorageImpl {
+// for snapshot purposes. The change history is bounded by `historyCapacity` (number of flags) & `historyRetention`
+// (how long archived flags are remembered), 0 meaning no limit, and is included in snapshots (see PersistToDisk),
+// so that it survives restarts from one
+func NewProxySplitStorage(
+	db persistent.DBWrapper,
+	logger logging.LoggerInterface,
+	flagSets flagsets.FlagSetFilter,
+	restoreBackup bool,
+	historyCapacity int,
+	historyRetention time.Duration,
+) *ProxySplitStorageImpl {
 	disk := persistent.NewSplitChangesCollection(db, logger)
+	history := persistent.NewSplitHistoryCollection(db, logger)
 	snapshot := mutexmap.NewMMSplitStorage(flagSets)
-	historic := optimized.NewHistoricSplitChanges(1000)
+	historic := optimized.NewHistoricSplitChanges(historyCapacity, historyRetention.Milliseconds())
 
 	var initialCN int64 = -1
 	if restoreBackup {
-		initialCN = snapshotFromDisk(snapshot, historic, disk, logger)
+		initialCN = snapshotFromDisk(snapshot, historic, disk, history, logger)
 	}
 	return &ProxySplitStorageImpl{
 		snapshot:      *snapshot,
 		db:            disk,
+		history:       history,
 		flagSets:      flagSets,
 		historic:      historic,
 		logger:        logger,
@@ -111,24 +130,48 @@ func (p *ProxySplitStorageImpl) KillLocally(splitName string, defaultTreatment s
 	p.snapshot.KillLocally(splitName, defaultTreatment, changeNumber)
 }
 
-// Update the storage atomically
+// Update the storage atomically. The first update after reloading from disk comes from a full fetch (see ChangeNumber),
+// so it replaces every flag instead
 func (p *ProxySplitStorageImpl) Update(toAdd []dtos.SplitDTO, toRemove []dtos.SplitDTO, changeNumber int64) {
 
 	p.setStartingPoint(changeNumber) // will be executed only the first time this method is called
 
-	if len(toAdd) == 0 && len(toRemove) == 0 {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	switch {
+	case p.resyncPending:
+		p.replace(toAdd, changeNumber)
+		p.resyncPending = false
+	case len(toAdd) == 0 && len(toRemove) == 0:
 		return
+	default:
+		p.snapshot.Update(toAdd, toRemove, changeNumber)
+		p.historic.Update(toAdd, toRemove, changeNumber)
+		p.db.Update(toAdd, toRemove, changeNumber)
+	}
+
+	if discarded := p.historic.DiscardedUntil(); discarded > p.oldestKnownCN {
+		p.oldestKnownCN = discarded
 	}
+}
 
+// OldestServableChangeNumber returns the oldest change number splitChanges can be computed from without
+// fetching from Split. -1 means no change has been processed yet
+func (p *ProxySplitStorageImpl) OldestServableChangeNumber() int64 {
 	p.mtx.Lock()
-	p.snapshot.Update(toAdd, toRemove, changeNumber)
-	p.historic.Update(toAdd, toRemove, changeNumber)
-	p.db.Update(toAdd, toRemove, changeNumber)
-	p.mtx.Unlock()
+	defer p.mtx.Unlock()
+	return p.oldestKnownCN
 }
 
-// ChangeNumber returns the current change number
+// ChangeNumber returns the current change number. After reloading from disk & until the next update, -1 is returned,
+// so that the synchronizer fetches every flag from Split instead of applying its changes on top of the reloaded ones
 func (p *ProxySplitStorageImpl) ChangeNumber() (int64, error) {
+	p.mtx.Lock()
+	pending := p.resyncPending
+	p.mtx.Unlock()
+	if pending {
+		return -1, nil
+	}
 	return p.snapshot.ChangeNumber()
 }
 
@@ -202,6 +245,67 @@ func (p *ProxySplitStorageImpl) RuleBasedSegmentNames() *set.ThreadUnsafeSet {
 	return p.snapshot.RuleBasedSegmentNames()
 }
 
+// ReloadFromDisk discards the in-memory snapshot & change history and rebuilds them from the persistent storage.
+// Must be called after the underlying db has been replaced.
+// SDKs may already be past the change number of the new data (ie: when rolling back to an older snapshot), in which
+// case they'd never get it. So the new data is published as a change newer than anything served so far, containing
+// every flag in it, and archiving the flags it doesn't have.
+// That change number doesn't exist in Split, so syncing can't resume from it. The next sync fetches every flag instead
+// (see ChangeNumber), which replaces the reloaded ones the same way. Until then, the reloaded data is served
+func (p *ProxySplitStorageImpl) ReloadFromDisk() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.db.ResetChangeNumber()
+	all, cn, err := splitsFromDisk(p.db)
+	if err != nil {
+		p.logger.Error("error parsing feature flags from replaced db. No data will be available!: ", err)
+	}
+
+	p.oldestKnownCN = historyFromDisk(p.historic, p.history, all, cn, p.logger)
+	p.replace(activeOnly(all), cn)
+	p.resyncPending = true
+}
+
+// replace makes `active` the whole set of flags, published as a change newer than anything served so far,
+// and archives the flags missing from it. Must be called with the lock held
+func (p *ProxySplitStorageImpl) replace(active []dtos.SplitDTO, cn int64) {
+	servedCN, _ := p.snapshot.ChangeNumber()
+	replaceCN := max(cn, servedCN+1)
+	toAdd := withChangeNumber(active, replaceCN)
+
+	toKeep := make(map[string]struct{}, len(toAdd))
+	for idx := range toAdd {
+		toKeep[toAdd[idx].Name] = struct{}{}
+	}
+
+	var toArchive []dtos.SplitDTO
+	for _, current := range p.snapshot.All() {
+		if _, ok := toKeep[current.Name]; !ok {
+			current.Status = constants.SplitStatusArchived
+			toArchive = append(toArchive, current)
+		}
+	}
+	toArchive = withChangeNumber(toArchive, replaceCN)
+
+	p.snapshot.Update(toAdd, toArchive, replaceCN)
+	p.historic.Update(toAdd, toArchive, replaceCN)
+	p.db.Update(toAdd, toArchive, replaceCN)
+}
+
+// PersistToDisk writes the change history to the persistent storage, so that it's included in snapshots of the db.
+// It's meant to be called right before taking a snapshot, rather than on every update, since the whole history is written
+func (p *ProxySplitStorageImpl) PersistToDisk() {
+	p.mtx.Lock()
+	item := &persistent.SplitHistoryItem{OldestChangeNumber: p.oldestKnownCN, Views: p.historic.Views()}
+	p.mtx.Unlock()
+
+	// a change processed in between is in the db but not in the history, which is brought up to date when restored
+	if err := p.history.Save(item); err != nil {
+		p.logger.Error("error persisting feature flag change history: ", err)
+	}
+}
+
 func (p *ProxySplitStorageImpl) sinceIsTooOld(since int64) bool {
 	if since == -1 {
 		return false
@@ -216,30 +320,75 @@ func snapshotFromDisk(
 	dst *mutexmap.MMSplitStorage,
 	historic optimized.HistoricChanges,
 	src *persistent.SplitChangesCollection,
+	history *persistent.SplitHistoryCollection,
 	logger logging.LoggerInterface,
 ) int64 {
-	all, err := src.FetchAll()
+	all, cn, err := splitsFromDisk(src)
 	if err != nil {
 		logger.Error("error parsing feature flags from snapshot. No data will be available!: ", err)
 		return -1
 	}
 
-	var filtered []dtos.SplitDTO
+	dst.Update(activeOnly(all), nil, cn)
+	return historyFromDisk(historic, history, all, cn, logger)
+}
+
+// historyFromDisk rebuilds the change history from the persisted one, bringing it up to date with the persisted
+// feature flags in case the db was dumped in between both writes. If there's no persisted history (ie: the db was
+// written by an older version), history starts at the db's change number.
+// Returns the oldest change number that can be served
+func historyFromDisk(
+	historic optimized.HistoricChanges,
+	src *persistent.SplitHistoryCollection,
+	all []dtos.SplitDTO,
+	cn int64,
+	logger logging.LoggerInterface,
+) int64 {
+	item, err := src.Fetch()
+	if err != nil {
+		if !errors.Is(err, persistent.ErrorBucketNotFound) && !errors.Is(err, persistent.ErrorKeyNotFound) {
+			logger.Error("error reading feature flag change history. Starting over from the current flags: ", err)
+		}
+		historic.ReplaceAll(activeOnly(all), cn)
+		return cn
+	}
+
+	historic.Restore(item.Views)
+	historic.Update(all, nil, cn)
+	return max(item.OldestChangeNumber, historic.DiscardedUntil())
+}
+
+func splitsFromDisk(src *persistent.SplitChangesCollection) ([]dtos.SplitDTO, int64, error) {
+	all, err := src.FetchAll()
+	if err != nil {
+		return nil, -1, err
+	}
+
+	// Make sure the CN matches is at least large as the payloads' max.
 	var cn = src.ChangeNumber()
 	for idx := range all {
-
-		// Make sure the CN matches is at least large as the payloads' max.
 		if thisCN := all[idx].ChangeNumber; thisCN > cn {
 			cn = thisCN
 		}
-		if all[idx].Status == "ACTIVE" {
-			filtered = append(filtered, all[idx])
-		}
 	}
+	return all, cn, nil
+}
+
+func withChangeNumber(splits []dtos.SplitDTO, cn int64) []dtos.SplitDTO {
+	for idx := range splits {
+		splits[idx].ChangeNumber = cn
+	}
+	return splits
+}
 
-	dst.Update(filtered, nil, cn)
-	historic.Update(filtered, nil, cn)
-	return cn
+func activeOnly(splits []dtos.SplitDTO) []dtos.SplitDTO {
+	var filtered []dtos.SplitDTO
+	for idx := range splits {
+		if splits[idx].Status == "ACTIVE" {
+			filtered = append(filtered, splits[idx])
+		}
+	}
+	return filtered
 }
 
 func archivedDTOForView(view *optimized.FeatureView) dtos.SplitDTO {
@@ -262,3 +411,4 @@ func archivedDTOForView(view *optimized.FeatureView) dtos.SplitDTO {
 var _ ProxySplitStorage = (*ProxySplitStorageImpl)(nil)
 var _ storage.SplitStorage = (*ProxySplitStorageImpl)(nil)
 var _ observability.ObservableSplitStorage = (*ProxySplitStorageImpl)(nil)
+var _ SplitHistoryReporter = (*ProxySplitStorageImpl)(nil)
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/storage/optimized"
@@ -34,9 +36,10 @@ func TestSplitStorage(t *testing.T) {
 
 	var historicMock mocks.HistoricStorageMock
 	historicMock.On("Update", toAdd2, []dtos.SplitDTO(nil), int64(3)).Once()
+	historicMock.On("DiscardedUntil").Return(int64(0))
 	historicMock.On("GetUpdatedSince", int64(2), []string(nil)).Once().Return([]optimized.FeatureView{})
 
-	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true, 0, 0)
 
 	// validate initial state of the historic cache & replace it with a mock for the next validations
 	assert.ElementsMatch(t,
@@ -114,7 +117,7 @@ func TestSplitStorageWithFlagsets(t *testing.T) {
 	splitC := persistent.NewSplitChangesCollection(dbw, logger)
 	splitC.Update(nil, []dtos.SplitDTO{{Name: "f0", ChangeNumber: 0, Status: "ARCHIVED", TrafficTypeName: "ttt"}}, 0)
 
-	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true, 0, 0)
 
 	pss.Update([]dtos.SplitDTO{
 		{Name: "f1", ChangeNumber: 1, Status: "ACTIVE", Sets: []string{"s1", "s2"}},
@@ -203,7 +206,7 @@ func TestGetNamesByFlagSets(t *testing.T) {
 	}
 	splitC.Update(flags, nil, 0)
 
-	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true, 0, 0)
 
 	namesBySets := pss.GetNamesByFlagSets([]string{"set_1", "set2"})
 
@@ -226,7 +229,7 @@ func TestChangesSince(t *testing.T) {
 	logger := logging.NewLogger(nil)
 
 	// Initialize storage with some test data
-	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true, 0, 0)
 
 	// Test case 1: since == -1 and no flagSets
 	{
@@ -363,7 +366,7 @@ func TestGetAllFlagSetNames(t *testing.T) {
 	}
 	splitC.Update(flags, nil, 0)
 
-	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true)
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), true, 0, 0)
 
 	setNames := pss.GetAllFlagSetNames()
 
@@ -371,3 +374,154 @@ func TestGetAllFlagSetNames(t *testing.T) {
 		t.Errorf("setNames len should be 4. Actual %v", len(setNames))
 	}
 }
+
+func TestSplitStorageReloadFromDisk(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false, 0, 0)
+	pss.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "ttt"},
+		{Name: "f2", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "ttt"},
+	}, nil, 20)
+
+	// build a replacement db with an older config & swap it
+	other, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+	persistent.NewSplitChangesCollection(other, logger).Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 5, Status: "ACTIVE", TrafficTypeName: "ttt", Killed: true},
+		{Name: "f3", ChangeNumber: 7, Status: "ACTIVE", TrafficTypeName: "ttt"},
+	}, nil, 7)
+	raw, err := other.GetRawSnapshot()
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "replacement.db")
+	assert.Nil(t, os.WriteFile(path, raw, 0644))
+	assert.Nil(t, dbw.Replace(path))
+
+	pss.ReloadFromDisk()
+
+	// the older config is published as a change newer than the ones already served. Syncing doesn't resume from it
+	cn, _ := pss.ChangeNumber()
+	assert.Equal(t, int64(-1), cn)
+	assert.ElementsMatch(t, []string{"f1", "f3"}, pss.SplitNames())
+	assert.True(t, pss.Split("f1").Killed)
+	assert.False(t, pss.TrafficTypeExists("nonexistent"))
+
+	changes, err := pss.ChangesSince(-1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(21), changes.Till)
+	assert.Len(t, changes.Splits, 2)
+
+	// sdks that were already up to date get the whole config, and the flags missing from it archived
+	changes, err = pss.ChangesSince(20, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(21), changes.Till)
+	byName := make(map[string]dtos.SplitDTO, len(changes.Splits))
+	for _, split := range changes.Splits {
+		byName[split.Name] = split
+	}
+	assert.Len(t, byName, 3)
+	assert.True(t, byName["f1"].Killed)
+	assert.Equal(t, "ACTIVE", byName["f3"].Status)
+	assert.Equal(t, "ARCHIVED", byName["f2"].Status)
+
+	changes, err = pss.ChangesSince(21, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(21), changes.Till)
+	assert.Empty(t, changes.Splits)
+
+	// the change is persisted, so that it survives snapshots of the db
+	persisted, err := persistent.NewSplitChangesCollection(dbw, logger).FetchAll()
+	assert.Nil(t, err)
+	assert.Len(t, persisted, 3)
+	for _, split := range persisted {
+		assert.Equal(t, int64(21), split.ChangeNumber)
+	}
+
+	// as when restoring a snapshot on startup, history older than the snapshot isn't available
+	_, err = pss.ChangesSince(5, nil)
+	assert.ErrorIs(t, err, ErrSinceParamTooOld)
+
+	// the next sync fetches every flag, which replaces the reloaded ones above the served change number
+	pss.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "ttt"},
+		{Name: "f2", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "ttt"},
+	}, nil, 20)
+	cn, _ = pss.ChangeNumber()
+	assert.Equal(t, int64(22), cn)
+	assert.ElementsMatch(t, []string{"f1", "f2"}, pss.SplitNames())
+	assert.False(t, pss.Split("f1").Killed)
+
+	changes, err = pss.ChangesSince(21, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(22), changes.Till)
+	byName = make(map[string]dtos.SplitDTO, len(changes.Splits))
+	for _, split := range changes.Splits {
+		byName[split.Name] = split
+	}
+	assert.Len(t, byName, 3)
+	assert.Equal(t, "ACTIVE", byName["f1"].Status)
+	assert.Equal(t, "ACTIVE", byName["f2"].Status)
+	assert.Equal(t, "ARCHIVED", byName["f3"].Status)
+
+	// and regular updates follow
+	pss.Update(nil, []dtos.SplitDTO{{Name: "f2", ChangeNumber: 30, Status: "ARCHIVED", TrafficTypeName: "ttt"}}, 30)
+	cn, _ = pss.ChangeNumber()
+	assert.Equal(t, int64(30), cn)
+	assert.ElementsMatch(t, []string{"f1"}, pss.SplitNames())
+}
+
+func TestSplitStorageHistoryRestore(t *testing.T) {
+	logger := logging.NewLogger(nil)
+	dbw, err := persistent.NewBoltWrapper(persistent.BoltInMemoryMode, nil)
+	assert.Nil(t, err)
+
+	pss := NewProxySplitStorage(dbw, logger, flagsets.NewFlagSetFilter(nil), false, 2, 0)
+	pss.Update([]dtos.SplitDTO{
+		{Name: "f1", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "ttt", Sets: []string{"s1"}},
+		{Name: "f2", ChangeNumber: 10, Status: "ACTIVE", TrafficTypeName: "ttt"},
+	}, nil, 10)
+	pss.Update([]dtos.SplitDTO{{Name: "f1", ChangeNumber: 20, Status: "ACTIVE", TrafficTypeName: "ttt", Sets: []string{"s1"}}}, nil, 20)
+	assert.Equal(t, int64(10), pss.OldestServableChangeNumber())
+
+	// archiving f2 & adding f3 exceeds the capacity, so the archival is forgotten and diffs can't start before it
+	pss.Update([]dtos.SplitDTO{{Name: "f3", ChangeNumber: 40, Status: "ACTIVE", TrafficTypeName: "ttt"}},
+		[]dtos.SplitDTO{{Name: "f2", ChangeNumber: 30, Status: "ARCHIVED", TrafficTypeName: "ttt"}}, 40)
+	assert.Equal(t, int64(30), pss.OldestServableChangeNumber())
+	_, err = pss.ChangesSince(20, nil)
+	assert.ErrorIs(t, err, ErrSinceParamTooOld)
+
+	// f1 leaves flag set s1
+	pss.Update([]dtos.SplitDTO{{Name: "f1", ChangeNumber: 50, Status: "ACTIVE", TrafficTypeName: "ttt"}}, nil, 50)
+
+	// updates don't write the history, it's persisted right before taking a snapshot
+	item, err := persistent.NewSplitHistoryCollection(dbw, logger).Fetch()
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+	pss.PersistToDisk()
+
+	// boot a new storage from a dump of the db, as when restoring a snapshot
+	raw, err := dbw.GetRawSnapshot()
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	assert.Nil(t, os.WriteFile(path, raw, 0644))
+	restoredDB, err := persistent.NewBoltWrapper(path, nil)
+	assert.Nil(t, err)
+
+	restored := NewProxySplitStorage(restoredDB, logger, flagsets.NewFlagSetFilter(nil), true, 2, 0)
+	assert.Equal(t, int64(30), restored.OldestServableChangeNumber())
+	assert.ElementsMatch(t, []string{"f1", "f3"}, restored.SplitNames())
+
+	changes, err := restored.ChangesSince(30, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(50), changes.Till)
+	assert.ElementsMatch(t, []string{"f1", "f3"}, []string{changes.Splits[0].Name, changes.Splits[1].Name})
+
+	// sdks filtering by s1 must get f1 after it left the set, which is only known thanks to the persisted history
+	changes, err = restored.ChangesSince(40, []string{"s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(50), changes.Till)
+	assert.Len(t, changes.Splits, 1)
+	assert.Equal(t, "f1", changes.Splits[0].Name)
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/splitio/go-split-commons/v9/storage"
@@ -32,6 +33,7 @@ const (
 	TelemetryKeysClientSideEndpoint
 	TelemetryKeysClientSideBeaconEndpoint
 	TelemetryKeysServerSideEndpoint
+	OFREPEvaluationEndpoint
 )
 
 type statusCodeMap struct {
@@ -83,6 +85,7 @@ type EndpointStatusCodes struct {
 	telemetryKeysClientSide       statusCodeMap
 	telemetryKeysClientSideBeacon statusCodeMap
 	telemetryKeysServerSide       statusCodeMap
+	ofrepEvaluation               statusCodeMap
 }
 
 // IncrEndpointStatus increments the count of a specific status code for a specific endpoint
@@ -130,6 +133,8 @@ func (e *EndpointStatusCodes) IncrEndpointStatus(endpoint int, status int) {
 		e.telemetryKeysClientSideBeacon.incr(status)
 	case TelemetryKeysServerSideEndpoint:
 		e.telemetryKeysServerSide.incr(status)
+	case OFREPEvaluationEndpoint:
+		e.ofrepEvaluation.incr(status)
 	}
 }
 
@@ -178,6 +183,8 @@ func (e *EndpointStatusCodes) PeekEndpointStatus(endpoint int) map[int]int64 {
 		return e.telemetryKeysClientSideBeacon.peek()
 	case TelemetryKeysServerSideEndpoint:
 		return e.telemetryKeysServerSide.peek()
+	case OFREPEvaluationEndpoint:
+		return e.ofrepEvaluation.peek()
 	}
 	return nil
 }
@@ -205,9 +212,110 @@ func newEndpointStatusCodes() EndpointStatusCodes {
 		telemetryKeysClientSide:       newStatusCodeMap(),
 		telemetryKeysClientSideBeacon: newStatusCodeMap(),
 		telemetryKeysServerSide:       newStatusCodeMap(),
+		ofrepEvaluation:               newStatusCodeMap(),
 	}
 }
 
+// RateLimitCounters keeps track of requests rejected due to rate limiting, grouped by endpoint group
+type RateLimitCounters struct {
+	counts map[string]int64
+	mutex  sync.Mutex
+}
+
+// IncrRateLimited increments the count of rate-limited requests for a specific endpoint group
+func (r *RateLimitCounters) IncrRateLimited(group string) {
+	r.mutex.Lock()
+	r.counts[group]++
+	r.mutex.Unlock()
+}
+
+// PeekRateLimited returns the count of rate-limited requests for each endpoint group
+func (r *RateLimitCounters) PeekRateLimited() map[string]int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	tmp := make(map[string]int64, len(r.counts))
+	for k, v := range r.counts {
+		tmp[k] = v
+	}
+	return tmp
+}
+
+func newRateLimitCounters() RateLimitCounters {
+	return RateLimitCounters{counts: make(map[string]int64)}
+}
+
+// SpillStats holds the counters of a single spill-to-disk queue
+type SpillStats struct {
+	Spilled  int64 `json:"spilled"`
+	Replayed int64 `json:"replayed"`
+	Expired  int64 `json:"expired"`
+}
+
+// SpillCounters keeps track of bulks spilled to disk, replayed & discarded due to their age, grouped by queue
+type SpillCounters struct {
+	stats map[string]SpillStats
+	mutex sync.Mutex
+}
+
+// IncrSpilled increments the count of bulks persisted to disk for a specific queue
+func (s *SpillCounters) IncrSpilled(queue string) {
+	s.update(queue, func(stats *SpillStats) { stats.Spilled++ })
+}
+
+// IncrReplayed increments the count of spilled bulks successfully posted for a specific queue
+func (s *SpillCounters) IncrReplayed(queue string) {
+	s.update(queue, func(stats *SpillStats) { stats.Replayed++ })
+}
+
+// IncrSpillExpired increments the count of spilled bulks discarded due to their age for a specific queue
+func (s *SpillCounters) IncrSpillExpired(queue string) {
+	s.update(queue, func(stats *SpillStats) { stats.Expired++ })
+}
+
+// PeekSpillStats returns the spill counters for each queue
+func (s *SpillCounters) PeekSpillStats() map[string]SpillStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tmp := make(map[string]SpillStats, len(s.stats))
+	for k, v := range s.stats {
+		tmp[k] = v
+	}
+	return tmp
+}
+
+func (s *SpillCounters) update(queue string, f func(*SpillStats)) {
+	s.mutex.Lock()
+	stats := s.stats[queue]
+	f(&stats)
+	s.stats[queue] = stats
+	s.mutex.Unlock()
+}
+
+func newSpillCounters() SpillCounters {
+	return SpillCounters{stats: make(map[string]SpillStats)}
+}
+
+// CacheCounters keeps track of hits & misses of the http response cache
+type CacheCounters struct {
+	hits   int64
+	misses int64
+}
+
+// IncrCacheHit increments the count of requests served from the cache
+func (c *CacheCounters) IncrCacheHit() {
+	atomic.AddInt64(&c.hits, 1)
+}
+
+// IncrCacheMiss increments the count of requests that couldn't be served from the cache
+func (c *CacheCounters) IncrCacheMiss() {
+	atomic.AddInt64(&c.misses, 1)
+}
+
+// PeekCacheStats returns the number of cache hits & misses
+func (c *CacheCounters) PeekCacheStats() (hits int64, misses int64) {
+	return atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+}
+
 // ProxyEndpointLatencies defines an interface to access proxy server endpoint latencies numbers
 type ProxyEndpointLatencies interface {
 	PeekEndpointLatency(endpoint int) []int64
@@ -237,6 +345,7 @@ type ProxyEndpointLatenciesImpl struct {
 	telemetryKeysClientSide       inmemory.AtomicInt64Slice
 	telemetryKeysClientSideBeacon inmemory.AtomicInt64Slice
 	telemetryKeysServerSide       inmemory.AtomicInt64Slice
+	ofrepEvaluation               inmemory.AtomicInt64Slice
 }
 
 // RecordEndpointLatency records a (bucketed) latency for a specific endpoint
@@ -285,6 +394,8 @@ func (p *ProxyEndpointLatenciesImpl) RecordEndpointLatency(endpoint int, latency
 		p.telemetryKeysClientSideBeacon.Incr(bucket)
 	case TelemetryKeysServerSideEndpoint:
 		p.telemetryKeysServerSide.Incr(bucket)
+	case OFREPEvaluationEndpoint:
+		p.ofrepEvaluation.Incr(bucket)
 	}
 }
 
@@ -333,6 +444,8 @@ func (p *ProxyEndpointLatenciesImpl) PeekEndpointLatency(endpoint int) []int64 {
 		return p.telemetryKeysClientSideBeacon.ReadAll()
 	case TelemetryKeysServerSideEndpoint:
 		return p.telemetryKeysServerSide.ReadAll()
+	case OFREPEvaluationEndpoint:
+		return p.ofrepEvaluation.ReadAll()
 	}
 	return nil
 }
@@ -366,6 +479,7 @@ func newProxyEndpointLatenciesImpl() ProxyEndpointLatenciesImpl {
 		telemetryKeysClientSide:       init(),
 		telemetryKeysClientSideBeacon: init(),
 		telemetryKeysServerSide:       init(),
+		ofrepEvaluation:               init(),
 	}
 }
 
@@ -373,6 +487,9 @@ func newProxyEndpointLatenciesImpl() ProxyEndpointLatenciesImpl {
 type ProxyTelemetryPeeker interface {
 	PeekEndpointLatency(resource int) []int64
 	PeekEndpointStatus(resource int) map[int]int64
+	PeekRateLimited() map[string]int64
+	PeekCacheStats() (hits int64, misses int64)
+	PeekSpillStats() map[string]SpillStats
 }
 
 // ProxyEndpointTelemetry defines the interface that endpoints use to capture latency & status codes
@@ -380,6 +497,12 @@ type ProxyEndpointTelemetry interface {
 	ProxyTelemetryPeeker
 	RecordEndpointLatency(endpoint int, latency time.Duration)
 	IncrEndpointStatus(endpoint int, status int)
+	IncrRateLimited(group string)
+	IncrCacheHit()
+	IncrCacheMiss()
+	IncrSpilled(queue string)
+	IncrReplayed(queue string)
+	IncrSpillExpired(queue string)
 }
 
 // ProxyTelemetryFacade defines the set of methods required to accept local telemetry as well as runtime telemetry
@@ -393,6 +516,9 @@ type ProxyTelemetryFacade interface {
 type ProxyTelemetryFacadeImpl struct {
 	ProxyEndpointLatenciesImpl
 	EndpointStatusCodes
+	RateLimitCounters
+	CacheCounters
+	SpillCounters
 	*inmemory.TelemetryStorage
 }
 
@@ -402,6 +528,8 @@ func NewProxyTelemetryFacade() *ProxyTelemetryFacadeImpl {
 	return &ProxyTelemetryFacadeImpl{
 		ProxyEndpointLatenciesImpl: newProxyEndpointLatenciesImpl(),
 		EndpointStatusCodes:        newEndpointStatusCodes(),
+		RateLimitCounters:          newRateLimitCounters(),
+		SpillCounters:              newSpillCounters(),
 		TelemetryStorage:           ts,
 	}
 }
@@ -62,6 +62,7 @@ func (t *TimeslicedProxyEndpointTelemetryImpl) TotalMetricsReport() map[string]F
 		"telemetryKeysClientSide":       newForResource(t.PeekEndpointLatency(TelemetryKeysClientSideEndpoint), t.PeekEndpointStatus(TelemetryKeysClientSideEndpoint)),
 		"telemetryKeysClientSideBeacon": newForResource(t.PeekEndpointLatency(TelemetryKeysClientSideBeaconEndpoint), t.PeekEndpointStatus(TelemetryKeysClientSideBeaconEndpoint)),
 		"telemetryKeysServerSide":       newForResource(t.PeekEndpointLatency(TelemetryKeysServerSideEndpoint), t.PeekEndpointStatus(TelemetryKeysServerSideEndpoint)),
+		"ofrepEvaluation":               newForResource(t.PeekEndpointLatency(OFREPEvaluationEndpoint), t.PeekEndpointStatus(OFREPEvaluationEndpoint)),
 	}
 }
 
@@ -204,6 +205,7 @@ func formatTimeSeriesData(data []*timeSliceTelemetry) TimeSliceData {
 				"telemetryKeysClientSide":       newForResource(ts.latencies.telemetryKeysClientSide.ReadAll(), ts.statusCodes.telemetryRuntime.peek()),
 				"telemetryKeysClientSideBeacon": newForResource(ts.latencies.telemetryKeysClientSideBeacon.ReadAll(), ts.statusCodes.telemetryRuntime.peek()),
 				"telemetryKeysServerSide":       newForResource(ts.latencies.telemetryKeysServerSide.ReadAll(), ts.statusCodes.telemetryRuntime.peek()),
+				"ofrepEvaluation":               newForResource(ts.latencies.ofrepEvaluation.ReadAll(), ts.statusCodes.ofrepEvaluation.peek()),
 			},
 		})
 	}
@@ -40,6 +40,7 @@ func TestHistoricProxyTelemetry(t *testing.T) {
 		TelemetryKeysClientSideEndpoint,
 		TelemetryKeysClientSideBeaconEndpoint,
 		TelemetryKeysServerSideEndpoint,
+		OFREPEvaluationEndpoint,
 	}
 
 	oldestTs := keyForTimeSlice(clk.base, 60) // store the oldest timeslice, so we can see it's no longet present after eviction
@@ -102,6 +103,7 @@ func TestHistoricProxyTelemetry(t *testing.T) {
 				"telemetryKeysClientSide":       {expectedLatencies, expectedStatusCodes, 2},
 				"telemetryKeysClientSideBeacon": {expectedLatencies, expectedStatusCodes, 2},
 				"telemetryKeysServerSide":       {expectedLatencies, expectedStatusCodes, 2},
+				"ofrepEvaluation":               {expectedLatencies, expectedStatusCodes, 2},
 			},
 		})
 	}
@@ -140,6 +142,7 @@ func TestHistoricProxyTelemetry(t *testing.T) {
 		"telemetryKeysClientSide":       {expectedLatencies, expectedStatusCodes, 12},
 		"telemetryKeysClientSideBeacon": {expectedLatencies, expectedStatusCodes, 12},
 		"telemetryKeysServerSide":       {expectedLatencies, expectedStatusCodes, 12},
+		"ofrepEvaluation":               {expectedLatencies, expectedStatusCodes, 12},
 	}
 
 	if gen := timesliced.TotalMetricsReport(); !reflect.DeepEqual(expectedTotalReport, gen) {
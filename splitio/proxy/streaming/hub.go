@@ -0,0 +1,230 @@
+package streaming
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+const occupancyEventName = "[meta]occupancy"
+
+// Notifier defines the interface for components that broadcast feature flag & segment changes to connected sdks
+type Notifier interface {
+	NotifySplitUpdate(changeNumber int64)
+	NotifySplitKill(splitName string, defaultTreatment string, changeNumber int64)
+	NotifyRuleBasedSegmentUpdate(changeNumber int64)
+	NotifySegmentUpdate(segmentName string, changeNumber int64)
+}
+
+// Subscriber represents an sdk connected to the streaming hub
+type Subscriber struct {
+	id       uint64
+	channels map[string]struct{}
+	messages chan []byte
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Messages returns a channel from which SSE-formatted frames should be read and written to the client
+func (s *Subscriber) Messages() <-chan []byte {
+	return s.messages
+}
+
+// Done returns a channel that will be closed when the hub drops this subscriber
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Hub keeps track of connected sdks and fans out notifications to them
+type Hub struct {
+	logger        logging.LoggerInterface
+	channelPrefix string
+	queueSize     int
+	subscribers   map[uint64]*Subscriber
+	nextID        uint64
+	messageSeq    uint64
+	mutex         sync.RWMutex
+}
+
+// NewHub constructs a new streaming hub
+func NewHub(logger logging.LoggerInterface, channelPrefix string, queueSize int) *Hub {
+	return &Hub{
+		logger:        logger,
+		channelPrefix: channelPrefix,
+		queueSize:     queueSize,
+		subscribers:   make(map[uint64]*Subscriber),
+	}
+}
+
+// Subscribe registers a new sdk connection listening to the provided channels.
+// An occupancy message is immediately queued for each control channel, so that the sdk
+// considers the connection as healthy
+func (h *Hub) Subscribe(channels []string) *Subscriber {
+	sub := &Subscriber{
+		channels: make(map[string]struct{}, len(channels)),
+		messages: make(chan []byte, h.queueSize),
+		done:     make(chan struct{}),
+	}
+
+	for _, channel := range channels {
+		sub.channels[channel] = struct{}{}
+		if channel == ControlPriChannel || channel == ControlSecChannel {
+			if frame, err := h.buildOccupancyFrame(channel, 1); err == nil {
+				select {
+				case sub.messages <- frame:
+				default:
+				}
+			}
+		}
+	}
+
+	h.mutex.Lock()
+	h.nextID++
+	sub.id = h.nextID
+	h.subscribers[sub.id] = sub
+	h.mutex.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber from the hub
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mutex.Lock()
+	delete(h.subscribers, sub.id)
+	h.mutex.Unlock()
+	sub.close()
+}
+
+// Subscribers returns the number of currently connected sdks
+func (h *Hub) Subscribers() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.subscribers)
+}
+
+// Close drops all the connected subscribers
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for id, sub := range h.subscribers {
+		sub.close()
+		delete(h.subscribers, id)
+	}
+}
+
+// NotifySplitUpdate broadcasts a SPLIT_UPDATE notification
+func (h *Hub) NotifySplitUpdate(changeNumber int64) {
+	h.publish(h.channelPrefix+splitsChannelSuffix, updateMessage{Type: dtos.UpdateTypeSplitChange, ChangeNumber: changeNumber})
+}
+
+// NotifySplitKill broadcasts a SPLIT_KILL notification
+func (h *Hub) NotifySplitKill(splitName string, defaultTreatment string, changeNumber int64) {
+	h.publish(h.channelPrefix+splitsChannelSuffix, updateMessage{
+		Type:             dtos.UpdateTypeSplitKill,
+		ChangeNumber:     changeNumber,
+		SplitName:        splitName,
+		DefaultTreatment: defaultTreatment,
+	})
+}
+
+// NotifyRuleBasedSegmentUpdate broadcasts a RB_SEGMENT_UPDATE notification
+func (h *Hub) NotifyRuleBasedSegmentUpdate(changeNumber int64) {
+	h.publish(h.channelPrefix+splitsChannelSuffix, updateMessage{Type: dtos.UpdateTypeRuleBasedChange, ChangeNumber: changeNumber})
+}
+
+// NotifySegmentUpdate broadcasts a SEGMENT_UPDATE notification
+func (h *Hub) NotifySegmentUpdate(segmentName string, changeNumber int64) {
+	h.publish(h.channelPrefix+segmentsChannelSuffix, updateMessage{
+		Type:         dtos.UpdateTypeSegmentChange,
+		ChangeNumber: changeNumber,
+		SegmentName:  segmentName,
+	})
+}
+
+func (h *Hub) publish(channel string, message updateMessage) {
+	frame, err := h.buildFrame(channel, "", message)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("error building streaming notification for channel %s: %s", channel, err))
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for id, sub := range h.subscribers {
+		if _, ok := sub.channels[channel]; !ok {
+			continue
+		}
+
+		select {
+		case sub.messages <- frame:
+		default:
+			// the client is not keeping up. Drop it so that it reconnects and performs a full sync
+			h.logger.Warning(fmt.Sprintf("streaming subscriber %d queue is full. dropping connection", id))
+			sub.close()
+			delete(h.subscribers, id)
+		}
+	}
+}
+
+func (h *Hub) buildOccupancyFrame(channel string, publishers int64) ([]byte, error) {
+	return h.buildFrame(occupancyPrefix+channel, occupancyEventName, occupancyMessage{Metrics: occupancyMetrics{Publishers: publishers}})
+}
+
+func (h *Hub) buildFrame(channel string, name string, message interface{}) ([]byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing message data: %w", err)
+	}
+
+	id := fmt.Sprintf("%d:0:0", atomic.AddUint64(&h.messageSeq, 1))
+	envelope, err := json.Marshal(messageEnvelope{
+		ID:        id,
+		ClientID:  "split-proxy",
+		Name:      name,
+		Timestamp: time.Now().UnixMilli(),
+		Encoding:  "json",
+		Channel:   channel,
+		Data:      string(data),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error serializing message envelope: %w", err)
+	}
+
+	return []byte(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", id, dtos.SSEEventTypeMessage, envelope)), nil
+}
+
+type messageEnvelope struct {
+	ID        string `json:"id"`
+	ClientID  string `json:"clientId"`
+	Name      string `json:"name,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	Encoding  string `json:"encoding"`
+	Channel   string `json:"channel"`
+	Data      string `json:"data"`
+}
+
+type updateMessage struct {
+	Type             string `json:"type"`
+	ChangeNumber     int64  `json:"changeNumber"`
+	SplitName        string `json:"splitName,omitempty"`
+	DefaultTreatment string `json:"defaultTreatment,omitempty"`
+	SegmentName      string `json:"segmentName,omitempty"`
+}
+
+type occupancyMessage struct {
+	Metrics occupancyMetrics `json:"metrics"`
+}
+
+type occupancyMetrics struct {
+	Publishers int64 `json:"publishers"`
+}
+
+var _ Notifier = (*Hub)(nil)
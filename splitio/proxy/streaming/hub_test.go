@@ -0,0 +1,89 @@
+package streaming
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFrame(t *testing.T, frame []byte) (messageEnvelope, map[string]interface{}) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(string(frame), "\n\n"), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "event: message", lines[1])
+
+	var envelope messageEnvelope
+	assert.Nil(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &envelope))
+	var data map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(envelope.Data), &data))
+	return envelope, data
+}
+
+func TestHubOccupancyOnSubscribe(t *testing.T) {
+	hub := NewHub(logging.NewLogger(nil), "123", 10)
+	sub := hub.Subscribe([]string{ControlPriChannel, ControlSecChannel, "123_splits"})
+	assert.Equal(t, 1, hub.Subscribers())
+	assert.Equal(t, 2, len(sub.Messages()))
+
+	envelope, data := parseFrame(t, <-sub.Messages())
+	assert.Equal(t, occupancyPrefix+ControlPriChannel, envelope.Channel)
+	assert.Equal(t, occupancyEventName, envelope.Name)
+	assert.Equal(t, map[string]interface{}{"metrics": map[string]interface{}{"publishers": float64(1)}}, data)
+
+	hub.Unsubscribe(sub)
+	assert.Equal(t, 0, hub.Subscribers())
+	<-sub.Done()
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := NewHub(logging.NewLogger(nil), "123", 10)
+	splitsSub := hub.Subscribe([]string{"123_splits"})
+	segmentsSub := hub.Subscribe([]string{"123_segments"})
+
+	hub.NotifySplitUpdate(10)
+	hub.NotifySplitKill("split1", "off", 11)
+	hub.NotifyRuleBasedSegmentUpdate(12)
+	hub.NotifySegmentUpdate("segment1", 13)
+
+	assert.Equal(t, 3, len(splitsSub.Messages()))
+	assert.Equal(t, 1, len(segmentsSub.Messages()))
+
+	envelope, data := parseFrame(t, <-splitsSub.Messages())
+	assert.Equal(t, "123_splits", envelope.Channel)
+	assert.Equal(t, dtos.UpdateTypeSplitChange, data["type"])
+	assert.Equal(t, float64(10), data["changeNumber"])
+
+	_, data = parseFrame(t, <-splitsSub.Messages())
+	assert.Equal(t, dtos.UpdateTypeSplitKill, data["type"])
+	assert.Equal(t, "split1", data["splitName"])
+	assert.Equal(t, "off", data["defaultTreatment"])
+
+	_, data = parseFrame(t, <-splitsSub.Messages())
+	assert.Equal(t, dtos.UpdateTypeRuleBasedChange, data["type"])
+
+	envelope, data = parseFrame(t, <-segmentsSub.Messages())
+	assert.Equal(t, "123_segments", envelope.Channel)
+	assert.Equal(t, dtos.UpdateTypeSegmentChange, data["type"])
+	assert.Equal(t, "segment1", data["segmentName"])
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub(logging.NewLogger(nil), "123", 1)
+	sub := hub.Subscribe([]string{"123_splits"})
+
+	hub.NotifySplitUpdate(1)
+	assert.Equal(t, 1, hub.Subscribers())
+	hub.NotifySplitUpdate(2)
+	assert.Equal(t, 0, hub.Subscribers())
+	<-sub.Done()
+
+	other := hub.Subscribe([]string{"123_splits"})
+	hub.Close()
+	assert.Equal(t, 0, hub.Subscribers())
+	<-other.Done()
+}
@@ -0,0 +1,168 @@
+package streaming
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	capabilitySubscribe = "subscribe"
+	capabilityPresence  = "channel-metadata:publishers"
+
+	// ControlPriChannel is the name of the primary control channel
+	ControlPriChannel = "control_pri"
+
+	// ControlSecChannel is the name of the secondary control channel
+	ControlSecChannel = "control_sec"
+
+	occupancyPrefix = "[?occupancy=metrics.publishers]"
+
+	splitsChannelSuffix   = "_splits"
+	segmentsChannelSuffix = "_segments"
+)
+
+// ErrInvalidToken is returned when a token cannot be parsed or it's signature doesn't match
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrExpiredToken is returned when a token is valid but has already expired
+var ErrExpiredToken = errors.New("token expired")
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+type tokenPayload struct {
+	Capabilities string `json:"x-ably-capability"`
+	ClientID     string `json:"x-ably-clientId"`
+	Exp          int64  `json:"exp"`
+	Iat          int64  `json:"iat"`
+}
+
+// TokenIssuer generates & validates locally signed streaming tokens, compatible with the ones
+// handed out by Split's auth service, so that sdks can subscribe to this proxy's streaming hub
+type TokenIssuer struct {
+	secret       []byte
+	ttl          time.Duration
+	capabilities string
+	channels     map[string]struct{}
+}
+
+// NewTokenIssuer constructs a new token issuer. If no secret is provided, a random one is generated,
+// which means that tokens will only be valid for this instance
+func NewTokenIssuer(secret string, ttl time.Duration, channelPrefix string) (*TokenIssuer, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("error generating random token secret: %w", err)
+		}
+	}
+
+	caps := map[string][]string{
+		ControlPriChannel:                     {capabilitySubscribe, capabilityPresence},
+		ControlSecChannel:                     {capabilitySubscribe, capabilityPresence},
+		channelPrefix + splitsChannelSuffix:   {capabilitySubscribe},
+		channelPrefix + segmentsChannelSuffix: {capabilitySubscribe},
+	}
+
+	serialized, err := json.Marshal(caps)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing token capabilities: %w", err)
+	}
+
+	channels := make(map[string]struct{}, len(caps))
+	for name := range caps {
+		channels[name] = struct{}{}
+	}
+
+	return &TokenIssuer{
+		secret:       key,
+		ttl:          ttl,
+		capabilities: string(serialized),
+		channels:     channels,
+	}, nil
+}
+
+// Issue builds & signs a new token
+func (i *TokenIssuer) Issue() (string, error) {
+	now := time.Now()
+	payload, err := json.Marshal(tokenPayload{
+		Capabilities: i.capabilities,
+		ClientID:     "split-proxy",
+		Iat:          now.Unix(),
+		Exp:          now.Add(i.ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error serializing token payload: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + i.sign(unsigned), nil
+}
+
+// Validate checks the token signature & expiration, and returns the list of channels it grants access to
+func (i *TokenIssuer) Validate(token string) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(i.sign(parts[0]+"."+parts[1])), []byte(parts[2])) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var payload tokenPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() > payload.Exp {
+		return nil, ErrExpiredToken
+	}
+
+	var caps map[string][]string
+	if err := json.Unmarshal([]byte(payload.Capabilities), &caps); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	channels := make([]string, 0, len(caps))
+	for name := range caps {
+		if _, ok := i.channels[name]; ok {
+			channels = append(channels, name)
+		}
+	}
+	return channels, nil
+}
+
+func (i *TokenIssuer) sign(data string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseChannels takes the `channels` query param sent by sdks and returns the plain channel names
+// (stripping the occupancy prefix when present)
+func ParseChannels(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+
+	parts := strings.Split(raw, ",")
+	toRet := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if name := strings.TrimPrefix(strings.TrimSpace(part), occupancyPrefix); name != "" {
+			toRet = append(toRet, name)
+		}
+	}
+	return toRet
+}
@@ -0,0 +1,75 @@
+package streaming
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenIssueAndValidate(t *testing.T) {
+	issuer, err := NewTokenIssuer("someSecret", time.Hour, "123")
+	assert.Nil(t, err)
+
+	token, err := issuer.Issue()
+	assert.Nil(t, err)
+
+	parts := strings.Split(token, ".")
+	assert.Equal(t, 3, len(parts))
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.Nil(t, err)
+	var payload tokenPayload
+	assert.Nil(t, json.Unmarshal(raw, &payload))
+
+	var caps map[string][]string
+	assert.Nil(t, json.Unmarshal([]byte(payload.Capabilities), &caps))
+	assert.Equal(t, []string{capabilitySubscribe, capabilityPresence}, caps[ControlPriChannel])
+	assert.Equal(t, []string{capabilitySubscribe}, caps["123_splits"])
+	assert.Equal(t, []string{capabilitySubscribe}, caps["123_segments"])
+
+	channels, err := issuer.Validate(token)
+	assert.Nil(t, err)
+	sort.Strings(channels)
+	assert.Equal(t, []string{"123_segments", "123_splits", ControlPriChannel, ControlSecChannel}, channels)
+}
+
+func TestTokenValidationErrors(t *testing.T) {
+	issuer, _ := NewTokenIssuer("someSecret", time.Hour, "123")
+	other, _ := NewTokenIssuer("otherSecret", time.Hour, "123")
+	expired, _ := NewTokenIssuer("someSecret", -time.Minute, "123")
+
+	token, _ := other.Issue()
+	_, err := issuer.Validate(token)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	_, err = issuer.Validate("garbage")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	token, _ = expired.Issue()
+	_, err = issuer.Validate(token)
+	assert.Equal(t, ErrExpiredToken, err)
+}
+
+func TestTokenRandomSecret(t *testing.T) {
+	i1, err := NewTokenIssuer("", time.Hour, "123")
+	assert.Nil(t, err)
+	i2, err := NewTokenIssuer("", time.Hour, "123")
+	assert.Nil(t, err)
+
+	token, _ := i1.Issue()
+	_, err = i2.Validate(token)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func TestParseChannels(t *testing.T) {
+	assert.Nil(t, ParseChannels(""))
+	assert.Equal(t,
+		[]string{"123_segments", "123_splits", ControlPriChannel, ControlSecChannel},
+		ParseChannels("123_segments,123_splits,[?occupancy=metrics.publishers]control_pri,[?occupancy=metrics.publishers]control_sec"),
+	)
+}
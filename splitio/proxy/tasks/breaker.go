@@ -0,0 +1,224 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/common/retry"
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// ErrCircuitOpen is returned when a post is abandoned while waiting for the circuit breaker to close
+var ErrCircuitOpen = errors.New("circuit breaker is open, post abandoned")
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// BreakerConfig defines when a circuit breaker opens & how long it stays open
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures required to open the circuit
+	BaseBackoff      time.Duration // time the circuit stays open after the first trip
+	MaxBackoff       time.Duration // upper bound for the exponential backoff
+}
+
+// CircuitBreaker is shared by all the workers forwarding data to a single upstream endpoint.
+// After a number of consecutive failures (or a 429 response) the circuit opens, and workers hold on to their payloads
+// until the backoff expires. A single probe is then let through: if it succeeds the circuit closes, otherwise it opens
+// again with an exponentially increasing (& jittered) backoff. The breaker reports its state to the services healthcheck
+type CircuitBreaker struct {
+	name         string
+	url          string
+	config       BreakerConfig
+	logger       logging.LoggerInterface
+	mutex        sync.Mutex
+	state        int
+	failures     int
+	trips        int
+	openUntil    time.Time
+	lastMessage  string
+	lastHit      *time.Time
+	healthySince *time.Time
+}
+
+// NewCircuitBreaker constructs a circuit breaker for the endpoint at `url`
+func NewCircuitBreaker(name string, url string, config BreakerConfig, logger logging.LoggerInterface) *CircuitBreaker {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = time.Second
+	}
+	if config.MaxBackoff < config.BaseBackoff {
+		config.MaxBackoff = config.BaseBackoff
+	}
+
+	now := time.Now()
+	return &CircuitBreaker{
+		name:         name,
+		url:          url,
+		config:       config,
+		logger:       logger,
+		healthySince: &now,
+	}
+}
+
+// Wait blocks until the breaker allows a request to be made. It returns false if `cancel` is closed before that happens
+func (b *CircuitBreaker) Wait(cancel <-chan struct{}) bool {
+	for {
+		wait := b.acquire()
+		if wait == 0 {
+			return true
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-cancel:
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// acquire returns 0 if a request can be made, or how long to wait before checking again
+func (b *CircuitBreaker) acquire() time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return 0
+	case breakerOpen:
+		if remaining := time.Until(b.openUntil); remaining > 0 {
+			return remaining
+		}
+		b.state = breakerHalfOpen // this caller becomes the probe
+		b.logger.Debug(fmt.Sprintf("circuit breaker for %s is half-open, probing", b.name))
+		return 0
+	default: // a probe is in flight, wait for its outcome
+		return b.config.BaseBackoff
+	}
+}
+
+// IsOpen returns true if requests to the endpoint are currently being held back
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state != breakerClosed
+}
+
+// Success records a successful post, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.success()
+}
+
+func (b *CircuitBreaker) success() {
+	now := time.Now()
+	b.lastHit = &now
+	b.failures = 0
+	b.trips = 0
+	if b.state != breakerClosed {
+		b.logger.Info(fmt.Sprintf("circuit breaker for %s closed, resuming posts", b.name))
+		b.state = breakerClosed
+		b.healthySince = &now
+		b.lastMessage = ""
+	}
+}
+
+// Failure records a failed post & returns whether it should be retried. Requests rejected by Split servers
+// due to the payload itself (4xx other than 408 & 429) are not retried and don't count as endpoint failures
+func (b *CircuitBreaker) Failure(err error) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !isRetryable(err) {
+		b.success() // the endpoint is healthy, the payload isn't
+		return false
+	}
+
+	now := time.Now()
+	b.lastHit = &now
+	b.lastMessage = err.Error()
+	b.failures++
+
+	var upstreamErr *UpstreamError
+	rateLimited := errors.As(err, &upstreamErr) && upstreamErr.Code == http.StatusTooManyRequests
+	if b.state == breakerClosed && !rateLimited && b.failures < b.config.FailureThreshold {
+		return true
+	}
+
+	b.trips++
+	backoff := b.backoff()
+	if rateLimited && upstreamErr.RetryAfter > 0 {
+		backoff = upstreamErr.RetryAfter
+	}
+
+	if b.state == breakerClosed {
+		b.logger.Warning(fmt.Sprintf("circuit breaker for %s opened after %d failures (last one: %s). Pausing posts for %s",
+			b.name, b.failures, err, backoff))
+	} else {
+		b.logger.Debug(fmt.Sprintf("circuit breaker for %s remains open. Pausing posts for %s", b.name, backoff))
+	}
+
+	b.state = breakerOpen
+	b.openUntil = now.Add(backoff)
+	b.healthySince = nil
+	return true
+}
+
+// backoff grows exponentially with the amount of consecutive trips
+func (b *CircuitBreaker) backoff() time.Duration {
+	return retry.Backoff{Base: b.config.BaseBackoff, Max: b.config.MaxBackoff}.Next(b.trips - 1)
+}
+
+// NotifyHit allows the breaker to be used as a services healthcheck counter
+func (b *CircuitBreaker) NotifyHit(statusCode int, message string) {
+	if statusCode >= 200 && statusCode < 300 {
+		b.Success()
+		return
+	}
+	b.Failure(&UpstreamError{Code: statusCode, Message: message})
+}
+
+// IsHealthy returns the state of the breaker, as expected by the services healthcheck.
+// An open circuit means data is being buffered rather than lost, so it's reported as degraded
+func (b *CircuitBreaker) IsHealthy() counter.HealthyResult {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return counter.HealthyResult{
+		URL:          b.url,
+		Severity:     counter.Degraded,
+		Healthy:      b.state == breakerClosed,
+		LastMessage:  b.lastMessage,
+		HealthySince: b.healthySince,
+		LastHit:      b.lastHit,
+	}
+}
+
+// Start is a no-op, since the breaker is fed by the forwarding workers
+func (b *CircuitBreaker) Start() {}
+
+// Stop is a no-op, since the breaker is fed by the forwarding workers
+func (b *CircuitBreaker) Stop() {}
+
+// isRetryable returns false for errors caused by the payload being rejected by Split servers
+func isRetryable(err error) bool {
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) {
+		return true
+	}
+	return retry.IsRetryableStatus(upstreamErr.Code)
+}
+
+var _ counter.ServicesCounterInterface = (*CircuitBreaker)(nil)
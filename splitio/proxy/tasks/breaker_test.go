@@ -0,0 +1,183 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/provisional/healthcheck/services/counter"
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerStates(t *testing.T) {
+	breaker := NewCircuitBreaker("events", "http://events/api/events/bulk", BreakerConfig{
+		FailureThreshold: 2,
+		BaseBackoff:      20 * time.Millisecond,
+		MaxBackoff:       time.Second,
+	}, logging.NewLogger(nil))
+
+	assert.True(t, breaker.Wait(nil))
+	assert.True(t, breaker.Failure(errors.New("some network error")))
+	assert.False(t, breaker.IsOpen())
+	assert.True(t, breaker.Failure(&UpstreamError{Code: 500, Message: "500 Internal Server Error"}))
+	assert.True(t, breaker.IsOpen())
+
+	health := breaker.IsHealthy()
+	assert.False(t, health.Healthy)
+	assert.Equal(t, counter.Degraded, health.Severity)
+	assert.Equal(t, "http://events/api/events/bulk", health.URL)
+	assert.Nil(t, health.HealthySince)
+
+	// a cancelled wait gives up
+	cancel := make(chan struct{})
+	close(cancel)
+	assert.False(t, breaker.Wait(cancel))
+
+	// once the backoff expires, a single probe goes through
+	before := time.Now()
+	assert.True(t, breaker.Wait(nil))
+	assert.GreaterOrEqual(t, time.Since(before), 5*time.Millisecond)
+	assert.Greater(t, breaker.acquire(), time.Duration(0))
+
+	// a failed probe opens the circuit again with a longer backoff
+	breaker.Failure(errors.New("still failing"))
+	assert.True(t, breaker.IsOpen())
+	assert.Equal(t, 2, breaker.trips)
+
+	breaker.mutex.Lock()
+	breaker.openUntil = time.Now()
+	breaker.mutex.Unlock()
+	assert.True(t, breaker.Wait(nil))
+	breaker.Success()
+	assert.False(t, breaker.IsOpen())
+	assert.True(t, breaker.IsHealthy().Healthy)
+	assert.NotNil(t, breaker.IsHealthy().HealthySince)
+	assert.Equal(t, 0, breaker.trips)
+}
+
+func TestCircuitBreakerRateLimiting(t *testing.T) {
+	breaker := NewCircuitBreaker("impressions", "", BreakerConfig{FailureThreshold: 10, BaseBackoff: time.Millisecond, MaxBackoff: time.Second}, logging.NewLogger(nil))
+
+	// a 429 opens the circuit right away, for as long as requested by the server
+	err := fmt.Errorf("error posting impressions: %w", &UpstreamError{Code: http.StatusTooManyRequests, RetryAfter: 30 * time.Second})
+	assert.True(t, breaker.Failure(err))
+	assert.True(t, breaker.IsOpen())
+	remaining := breaker.acquire()
+	assert.Greater(t, remaining, 29*time.Second)
+	assert.LessOrEqual(t, remaining, 30*time.Second)
+}
+
+func TestCircuitBreakerNonRetryable(t *testing.T) {
+	breaker := NewCircuitBreaker("events", "", BreakerConfig{FailureThreshold: 1, BaseBackoff: time.Second, MaxBackoff: time.Second}, logging.NewLogger(nil))
+	assert.False(t, breaker.Failure(&UpstreamError{Code: http.StatusBadRequest}))
+	assert.False(t, breaker.IsOpen())
+	assert.True(t, breaker.Failure(&UpstreamError{Code: http.StatusRequestTimeout}))
+	assert.True(t, breaker.IsOpen())
+}
+
+func TestCircuitBreakerBackoff(t *testing.T) {
+	breaker := NewCircuitBreaker("events", "", BreakerConfig{FailureThreshold: 1, BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, logging.NewLogger(nil))
+	for trips, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 100: time.Second} {
+		breaker.trips = trips
+		for i := 0; i < 20; i++ {
+			backoff := breaker.backoff()
+			assert.GreaterOrEqual(t, backoff, expected/2)
+			assert.LessOrEqual(t, backoff, expected)
+		}
+	}
+}
+
+type breakerTestWorker struct {
+	failures *int32 // amount of posts to fail before succeeding
+	posted   *int32
+}
+
+func (w *breakerTestWorker) Name() string       { return "breaker-test" }
+func (w *breakerTestWorker) OnError(e error)    {}
+func (w *breakerTestWorker) Cleanup() error     { return nil }
+func (w *breakerTestWorker) FailureTime() int64 { return 1 }
+func (w *breakerTestWorker) DoWork(message interface{}) error {
+	if atomic.AddInt32(w.failures, -1) >= 0 {
+		return &UpstreamError{Code: http.StatusServiceUnavailable}
+	}
+	atomic.AddInt32(w.posted, 1)
+	return nil
+}
+
+func TestDeferredTaskWithBreaker(t *testing.T) {
+	failures := int32(3)
+	var posted int32
+	factory := func() workerpool.Worker { return &breakerTestWorker{failures: &failures, posted: &posted} }
+
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 10, 2)
+	breaker := NewCircuitBreaker("events", "", BreakerConfig{FailureThreshold: 1, BaseBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}, logging.NewLogger(nil))
+	task.ProtectWith(breaker)
+	task.Start()
+	defer task.Stop(false)
+
+	// failed posts are retried once the circuit closes, instead of being dropped
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, task.Stage(&internal.RawData{}))
+	}
+	task.task.WakeUp()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&posted) == 4 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&task.counters.failed))
+	assert.False(t, breaker.IsOpen())
+
+	// a non-retryable rejection is not attempted again
+	var mutex sync.Mutex
+	attempts := 0
+	rejecting := &countingWorker{task: task, Worker: workerFunc(func(interface{}) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		attempts++
+		return &UpstreamError{Code: http.StatusBadRequest}
+	})}
+	atomic.AddInt64(&task.counters.inFlight, 1)
+	assert.Error(t, rejecting.DoWork(&internal.RawData{}))
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&task.counters.failed))
+}
+
+func TestDeferredTaskWithOpenBreakerHoldsData(t *testing.T) {
+	failures := int32(1)
+	var posted int32
+	factory := func() workerpool.Worker { return &breakerTestWorker{failures: &failures, posted: &posted} }
+
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 2, 1)
+	breaker := NewCircuitBreaker("events", "", BreakerConfig{FailureThreshold: 1, BaseBackoff: time.Hour, MaxBackoff: time.Hour}, logging.NewLogger(nil))
+	task.ProtectWith(breaker)
+	task.Start()
+	defer task.Stop(false)
+
+	// the first post fails & opens the circuit. The worker holds on to it
+	assert.Nil(t, task.Stage(&internal.RawData{}))
+	task.task.WakeUp()
+	assert.Eventually(t, breaker.IsOpen, time.Second, 5*time.Millisecond)
+
+	// while open, data stays in the queue until it fills up
+	assert.Nil(t, task.Stage(&internal.RawData{}))
+	assert.Nil(t, task.Stage(&internal.RawData{}))
+	task.task.WakeUp()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, len(task.queue))
+	assert.ErrorIs(t, task.Stage(&internal.RawData{}), ErrQueueFull)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&posted))
+}
+
+type workerFunc func(interface{}) error
+
+func (f workerFunc) Name() string                     { return "func" }
+func (f workerFunc) OnError(e error)                  {}
+func (f workerFunc) Cleanup() error                   { return nil }
+func (f workerFunc) FailureTime() int64               { return 1 }
+func (f workerFunc) DoWork(message interface{}) error { return f(message) }
@@ -1,9 +1,14 @@
 package tasks
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/splitio/go-split-commons/v9/dtos"
 	"github.com/splitio/go-split-commons/v9/tasks"
 	"github.com/splitio/go-toolkit/v5/asynctask"
 	"github.com/splitio/go-toolkit/v5/logging"
@@ -24,15 +29,34 @@ import (
 // ErrQueueFull is returned when attempting to add data to a full queue
 var ErrQueueFull = errors.New("queue is full, data not pushed")
 
+// how often a flush checks whether all the pending data has been posted
+const flushPollInterval = 10 * time.Millisecond
+
+// how long a flush waits for workers to give up on their posts once the deadline has expired
+const abortGracePeriod = 100 * time.Millisecond
+
 // DeferredRecordingTask defines the interface for a task that accepts POSTs and submits them asyncrhonously
 type DeferredRecordingTask interface {
 	Stage(rawData interface{}) error
+	Flush(ctx context.Context) FlushReport
 	tasks.Task
 }
 
+// FlushReport summarizes the outcome of a final flush
+type FlushReport struct {
+	Flushed int64 // items successfully posted during the flush
+	Failed  int64 // items that were attempted but could not be posted
+	Dropped int64 // items still pending when the deadline expired
+}
+
 // WorkerFactory defines the signature of a function for instantiating workers
 type WorkerFactory = func() workerpool.Worker
 
+// RawRecorder defines the interface used by workers to forward already-serialized payloads to Split servers
+type RawRecorder interface {
+	RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error
+}
+
 type genericQueue = chan interface{}
 
 // DeferredRecordingTaskImpl is in charge of fetching impressions from the queue and posting them to the Split server BE
@@ -41,36 +65,121 @@ type DeferredRecordingTaskImpl struct {
 	task            *asynctask.AsyncTask
 	drainInProgress *gtSync.AtomicBool
 	pool            *workerpool.WorkerAdmin
+	poolSize        int
 	queue           genericQueue
+	counters        *workCounters
 	mutex           sync.Mutex
+	spill           *SpillQueue
+	spillTelemetry  SpillTelemetry
+	upstreamFailing *gtSync.AtomicBool
+	replaying       sync.Map // items taken from the spill queue that are being posted -> *spilledEntry
+	breaker         *CircuitBreaker
+	abort           chan struct{}
+	abortOnce       sync.Once
+}
+
+// workCounters keep track of items handed to the worker pool
+type workCounters struct {
+	inFlight int64
+	posted   int64
+	failed   int64
+}
+
+// countingWorker wraps a worker notifying the task after each item is processed
+type countingWorker struct {
+	workerpool.Worker
+	task *DeferredRecordingTaskImpl
+}
+
+// DoWork forwards the message to the wrapped worker and reports the outcome to the task
+func (w *countingWorker) DoWork(message interface{}) error {
+	err := w.task.post(w.Worker, message)
+	w.task.processed(message, err)
+	return err
 }
 
 func newDeferredFlushTask(logger logging.LoggerInterface, wfactory WorkerFactory, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	drainFlag := gtSync.NewAtomicBool(false)
 	queue := make(genericQueue, queueSize)
 	pool := workerpool.NewWorkerAdmin(queueSize, logger)
+	toRet := &DeferredRecordingTaskImpl{
+		logger:          logger,
+		drainInProgress: drainFlag,
+		pool:            pool,
+		poolSize:        queueSize,
+		queue:           queue,
+		counters:        &workCounters{},
+		upstreamFailing: gtSync.NewAtomicBool(false),
+		abort:           make(chan struct{}),
+	}
+	for i := 0; i < threads; i++ {
+		pool.AddWorker(&countingWorker{Worker: wfactory(), task: toRet})
+	}
+
 	trigger := func(loger logging.LoggerInterface) error {
 		if !drainFlag.TestAndSet() {
 			logger.Warning("Impressions flush requested while another one is in progress. Ignoring.")
 			return nil
 		}
 		defer drainFlag.Unset() // clear the flag after we're done
+		if toRet.breaker != nil && toRet.breaker.IsOpen() {
+			return nil // keep the data queued until the endpoint recovers
+		}
 		for len(queue) > 0 {
-			pool.QueueMessage(<-queue)
+			if item := <-queue; !toRet.handOver(item) {
+				toRet.spillOrDrop(item)
+			}
 		}
+		toRet.replay()
 		return nil
 	}
+	toRet.task = asynctask.NewAsyncTask("impressions-recorder", trigger, period, nil, nil, logger)
+	return toRet
+}
 
-	for i := 0; i < threads; i++ {
-		pool.AddWorker(wfactory())
+// handOver pushes an item into the worker pool's queue, returning false if it was dropped
+func (t *DeferredRecordingTaskImpl) handOver(item interface{}) bool {
+	atomic.AddInt64(&t.counters.inFlight, 1)
+	if !t.pool.QueueMessage(item) {
+		atomic.AddInt64(&t.counters.inFlight, -1)
+		return false
 	}
+	return true
+}
 
-	return &DeferredRecordingTaskImpl{
-		logger:          logger,
-		task:            asynctask.NewAsyncTask("impressions-recorder", trigger, period, nil, nil, logger),
-		drainInProgress: drainFlag,
-		pool:            pool,
-		queue:           queue,
+// SpillTo makes the task persist data to the supplied queue when the in-memory one is full or Split servers
+// are failing. Spilled data is posted back in order once the in-memory queue has room & Split servers recover.
+// Must be called before the task is started
+func (t *DeferredRecordingTaskImpl) SpillTo(queue *SpillQueue, telemetry SpillTelemetry) {
+	t.spill = queue
+	t.spillTelemetry = telemetry
+}
+
+// ProtectWith makes the workers of this task share a circuit breaker. Posts are held back while the circuit is open,
+// and retried (instead of dropped) once it closes. Must be called before the task is started
+func (t *DeferredRecordingTaskImpl) ProtectWith(breaker *CircuitBreaker) {
+	t.breaker = breaker
+}
+
+// post hands a message to the worker, retrying it as dictated by the circuit breaker (if any)
+func (t *DeferredRecordingTaskImpl) post(worker workerpool.Worker, message interface{}) error {
+	if t.breaker == nil {
+		return worker.DoWork(message)
+	}
+
+	for {
+		if !t.breaker.Wait(t.abort) {
+			return ErrCircuitOpen
+		}
+
+		err := worker.DoWork(message)
+		if err == nil {
+			t.breaker.Success()
+			return nil
+		}
+		if !t.breaker.Failure(err) {
+			return err
+		}
 	}
 }
 
@@ -78,9 +187,18 @@ func newDeferredFlushTask(logger logging.LoggerInterface, wfactory WorkerFactory
 func (t *DeferredRecordingTaskImpl) Stage(data interface{}) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+
+	// once data has been spilled, new data goes to disk as well, so that it's posted in the order it was received
+	if t.spill != nil && (t.upstreamFailing.IsSet() || t.spill.Len() > 0) {
+		return t.pushToSpill(data)
+	}
+
 	select {
 	case t.queue <- data:
 	default:
+		if t.spill != nil {
+			return t.pushToSpill(data)
+		}
 		return ErrQueueFull
 	}
 
@@ -90,6 +208,96 @@ func (t *DeferredRecordingTaskImpl) Stage(data interface{}) error {
 	return nil
 }
 
+// processed updates the counters after an item has been handled by a worker. Items that could not be posted
+// are spilled (if enabled), so that they're retried once Split servers recover
+func (t *DeferredRecordingTaskImpl) processed(message interface{}, err error) {
+	var entry interface{}
+	var replayed bool
+	if t.spill != nil {
+		entry, replayed = t.replaying.LoadAndDelete(message)
+	}
+
+	if err != nil {
+		atomic.AddInt64(&t.counters.failed, 1)
+		if t.spill != nil && isRetryable(err) {
+			t.upstreamFailing.Set()
+			if replayed {
+				if rerr := t.spill.restore(entry.(*spilledEntry)); rerr != nil {
+					t.logger.Error(fmt.Sprintf("error restoring item into spill queue '%s': %s", t.spill.Name(), rerr))
+				}
+			} else {
+				t.spillOrDrop(message)
+			}
+		}
+	} else {
+		atomic.AddInt64(&t.counters.posted, 1)
+		t.upstreamFailing.Unset()
+		if replayed && t.spillTelemetry != nil {
+			t.spillTelemetry.IncrReplayed(t.spill.Name())
+		}
+	}
+	atomic.AddInt64(&t.counters.inFlight, -1)
+}
+
+// replay moves spilled items to the worker pool, as long as there's room for them.
+// While Split servers are failing, only one item is attempted per execution
+func (t *DeferredRecordingTaskImpl) replay() {
+	if t.spill == nil || t.spill.Len() == 0 {
+		return
+	}
+
+	max := t.poolSize - t.pool.QueueSize()
+	if t.upstreamFailing.IsSet() && max > 1 {
+		max = 1
+	}
+
+	entries, expired, err := t.spill.pop(max)
+	if err != nil {
+		t.logger.Error(err)
+		return
+	}
+	if expired > 0 {
+		t.logger.Warning(fmt.Sprintf("%d items discarded from spill queue '%s' due to their age", expired, t.spill.Name()))
+		if t.spillTelemetry != nil {
+			for i := 0; i < expired; i++ {
+				t.spillTelemetry.IncrSpillExpired(t.spill.Name())
+			}
+		}
+	}
+
+	for idx := range entries {
+		entry := &entries[idx]
+		t.replaying.Store(entry.item, entry)
+		if !t.handOver(entry.item) {
+			t.replaying.Delete(entry.item)
+			if err := t.spill.restore(entry); err != nil {
+				t.logger.Error(fmt.Sprintf("error restoring item into spill queue '%s': %s", t.spill.Name(), err))
+			}
+		}
+	}
+}
+
+// pushToSpill persists an item, returning ErrQueueFull if it cannot be stored
+func (t *DeferredRecordingTaskImpl) pushToSpill(item interface{}) error {
+	if err := t.spill.Push(item); err != nil {
+		if !errors.Is(err, ErrSpillFull) {
+			t.logger.Error(err)
+		}
+		return ErrQueueFull
+	}
+	if t.spillTelemetry != nil {
+		t.spillTelemetry.IncrSpilled(t.spill.Name())
+	}
+	return nil
+}
+
+// spillOrDrop persists an item if spilling is enabled, logging it as lost otherwise
+func (t *DeferredRecordingTaskImpl) spillOrDrop(item interface{}) {
+	if t.spill == nil || t.pushToSpill(item) != nil {
+		t.logger.Error("dropping item that could not be queued for posting")
+	}
+}
+
 // Start starts the flushing task
 func (t *DeferredRecordingTaskImpl) Start() {
 	t.task.Start()
@@ -100,6 +308,67 @@ func (t *DeferredRecordingTaskImpl) Stop(blocking bool) error {
 	return t.task.Stop(blocking)
 }
 
+// Flush stops the periodic task and hands all the pending data to the workers, waiting until it has been posted
+// or the context is done. Data that couldn't be posted in time is spilled if enabled, or reported as dropped otherwise
+func (t *DeferredRecordingTaskImpl) Flush(ctx context.Context) FlushReport {
+	t.task.Stop(true) // an error here only means the task was not running
+
+	postedBefore := atomic.LoadInt64(&t.counters.posted)
+	failedBefore := atomic.LoadInt64(&t.counters.failed)
+
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		// only move items while there's room in the worker pool, so that nothing is dropped
+		for len(t.queue) > 0 && t.pool.QueueSize() < t.poolSize {
+			if !t.handOver(<-t.queue) {
+				break
+			}
+		}
+
+		if len(t.queue) == 0 && atomic.LoadInt64(&t.counters.inFlight) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+		}
+	}
+
+	// workers waiting on an open circuit give up, so that their data can be spilled
+	if t.breaker != nil && atomic.LoadInt64(&t.counters.inFlight) > 0 {
+		t.abortOnce.Do(func() { close(t.abort) })
+		for deadline := time.Now().Add(abortGracePeriod); atomic.LoadInt64(&t.counters.inFlight) > 0 && time.Now().Before(deadline); {
+			time.Sleep(flushPollInterval)
+		}
+	}
+
+	// with spilling enabled, items still in flight end up on disk if their post fails, so they're waited for
+	// (http timeouts bound the wait). This way nothing touches the spill queue after the flush & it can be closed
+	if t.spill != nil {
+		for atomic.LoadInt64(&t.counters.inFlight) > 0 {
+			time.Sleep(flushPollInterval)
+		}
+	}
+
+	// data that couldn't be posted in time is kept on disk to be sent on the next run
+	var dropped int64
+	for len(t.queue) > 0 {
+		item := <-t.queue
+		if t.spill == nil || t.pushToSpill(item) != nil {
+			dropped++
+		}
+	}
+
+	return FlushReport{
+		Flushed: atomic.LoadInt64(&t.counters.posted) - postedBefore,
+		Failed:  atomic.LoadInt64(&t.counters.failed) - failedBefore,
+		Dropped: dropped + atomic.LoadInt64(&t.counters.inFlight),
+	}
+}
+
 // IsRunning returns whether the task is running
 func (t *DeferredRecordingTaskImpl) IsRunning() bool {
 	return t.task.IsRunning()
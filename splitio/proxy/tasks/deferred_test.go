@@ -0,0 +1,74 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type workerMock struct {
+	name    string
+	delay   time.Duration
+	handled *int64
+}
+
+func (w *workerMock) Name() string       { return w.name }
+func (w *workerMock) OnError(e error)    {}
+func (w *workerMock) Cleanup() error     { return nil }
+func (w *workerMock) FailureTime() int64 { return 1 }
+func (w *workerMock) DoWork(message interface{}) error {
+	time.Sleep(w.delay)
+	atomic.AddInt64(w.handled, 1)
+	if message == "fail" {
+		return errors.New("something")
+	}
+	return nil
+}
+
+func workerMockFactory(delay time.Duration, handled *int64) WorkerFactory {
+	var idx int
+	return func() workerpool.Worker {
+		idx++
+		return &workerMock{name: fmt.Sprintf("worker_%d", idx), delay: delay, handled: handled}
+	}
+}
+
+func TestDeferredTaskFlush(t *testing.T) {
+	var handled int64
+	task := newDeferredFlushTask(logging.NewLogger(nil), workerMockFactory(0, &handled), 3600, 10, 2)
+	task.Start()
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, task.Stage(i))
+	}
+	assert.Nil(t, task.Stage("fail"))
+
+	report := task.Flush(context.Background())
+	assert.Equal(t, FlushReport{Flushed: 5, Failed: 1, Dropped: 0}, report)
+	assert.Equal(t, int64(6), atomic.LoadInt64(&handled))
+	assert.False(t, task.IsRunning())
+}
+
+func TestDeferredTaskFlushDeadline(t *testing.T) {
+	var handled int64
+	task := newDeferredFlushTask(logging.NewLogger(nil), workerMockFactory(200*time.Millisecond, &handled), 3600, 10, 1)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, task.Stage(i))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	report := task.Flush(ctx)
+	assert.Equal(t, int64(10), report.Flushed+report.Dropped)
+	assert.Greater(t, report.Flushed, int64(0))
+	assert.Greater(t, report.Dropped, int64(0))
+	assert.Equal(t, int64(0), report.Failed)
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 
-	"github.com/splitio/go-split-commons/v9/service/api"
 	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
@@ -15,7 +14,7 @@ import (
 type EventWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPEventsRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -38,11 +37,13 @@ func (w *EventWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/events/bulk", asEvents.Payload, asEvents.Metadata, nil)
+	if err := w.recorder.RecordRaw("/events/bulk", asEvents.Payload, asEvents.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting events to Split servers: %w", err)
+	}
 	return nil
 }
 
-func newEventWorkerFactory(name string, recorder *api.HTTPEventsRecorder, logger logging.LoggerInterface) WorkerFactory {
+func newEventWorkerFactory(name string, recorder RawRecorder, logger logging.LoggerInterface) WorkerFactory {
 	var i *int = common.IntRef(0)
 	return func() workerpool.Worker {
 		defer func() { *i++ }()
@@ -51,6 +52,6 @@ func newEventWorkerFactory(name string, recorder *api.HTTPEventsRecorder, logger
 }
 
 // NewEventsFlushTask creates a new impressions flushing task
-func NewEventsFlushTask(recorder *api.HTTPEventsRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func NewEventsFlushTask(recorder RawRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(logger, newEventWorkerFactory("events-worker", recorder, logger), period, queueSize, threads)
 }
@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+)
+
+// FileRawRecorder appends payloads to local files (one per endpoint) instead of posting them to Split servers.
+// Each line holds the metadata of the sdk that submitted the data & its payload
+type FileRawRecorder struct {
+	directory string
+	mutex     sync.Mutex
+}
+
+type fileRecord struct {
+	Timestamp   int64             `json:"timestamp"`
+	SDKVersion  string            `json:"sdkVersion"`
+	MachineIP   string            `json:"machineIp"`
+	MachineName string            `json:"machineName"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     json.RawMessage   `json:"payload"`
+}
+
+// NewFileRawRecorder constructs a recorder writing to the supplied directory, creating it if necessary
+func NewFileRawRecorder(directory string) (*FileRawRecorder, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory '%s': %w", directory, err)
+	}
+	return &FileRawRecorder{directory: directory}, nil
+}
+
+// RecordRaw appends the payload to the file associated to the endpoint (ie: `/events/bulk` -> `events-bulk.ndjson`)
+func (r *FileRawRecorder) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	payload := json.RawMessage(data)
+	if !json.Valid(data) {
+		payload, _ = json.Marshal(string(data))
+	}
+
+	serialized, err := json.Marshal(fileRecord{
+		Timestamp:   time.Now().UnixMilli(),
+		SDKVersion:  metadata.SDKVersion,
+		MachineIP:   metadata.MachineIP,
+		MachineName: metadata.MachineName,
+		Headers:     extraHeaders,
+		Payload:     payload,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing record: %w", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	path := filepath.Join(r.directory, strings.ReplaceAll(strings.Trim(url, "/"), "/", "-")+".ndjson")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(serialized, '\n')); err != nil {
+		return fmt.Errorf("error writing to '%s': %w", path, err)
+	}
+	return nil
+}
+
+var _ RawRecorder = (*FileRawRecorder)(nil)
@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileRawRecorder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "output")
+	recorder, err := NewFileRawRecorder(dir)
+	assert.Nil(t, err)
+
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3", MachineIP: "1.2.3.4", MachineName: "host"}
+	assert.Nil(t, recorder.RecordRaw("/events/bulk", []byte(`[{"key":"k1"}]`), metadata, nil))
+	assert.Nil(t, recorder.RecordRaw("/events/bulk", []byte(`[{"key":"k2"}]`), metadata, nil))
+	assert.Nil(t, recorder.RecordRaw("/testImpressions/bulk", []byte(`[]`), metadata, map[string]string{"SDKImpressionsMode": "optimized"}))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "events-bulk.ndjson"))
+	assert.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	assert.Len(t, lines, 2)
+
+	var record fileRecord
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "go-1.2.3", record.SDKVersion)
+	assert.Equal(t, "1.2.3.4", record.MachineIP)
+	assert.Equal(t, "host", record.MachineName)
+	assert.JSONEq(t, `[{"key":"k2"}]`, string(record.Payload))
+
+	raw, err = os.ReadFile(filepath.Join(dir, "testImpressions-bulk.ndjson"))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(raw, &record))
+	assert.Equal(t, map[string]string{"SDKImpressionsMode": "optimized"}, record.Headers)
+}
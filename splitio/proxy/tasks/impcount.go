@@ -5,7 +5,6 @@ import (
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 
-	"github.com/splitio/go-split-commons/v9/service/api"
 	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
@@ -15,7 +14,7 @@ import (
 type ImpressionCountWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPImpressionRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -47,7 +46,7 @@ func (w *ImpressionCountWorker) DoWork(message interface{}) error {
 
 func newImpressionCountWorkerFactory(
 	name string,
-	recorder *api.HTTPImpressionRecorder,
+	recorder RawRecorder,
 	logger logging.LoggerInterface,
 ) WorkerFactory {
 	var i *int = common.IntRef(0)
@@ -59,7 +58,7 @@ func newImpressionCountWorkerFactory(
 
 // NewImpressionCountFlushTask creates a new impressions flushing task
 func NewImpressionCountFlushTask(
-	recorder *api.HTTPImpressionRecorder,
+	recorder RawRecorder,
 	logger logging.LoggerInterface,
 	period int,
 	queueSize int,
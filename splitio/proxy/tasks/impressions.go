@@ -5,7 +5,6 @@ import (
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 
-	"github.com/splitio/go-split-commons/v9/service/api"
 	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
@@ -15,7 +14,7 @@ import (
 type ImpressionWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPImpressionRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -49,7 +48,7 @@ func (w *ImpressionWorker) DoWork(message interface{}) error {
 
 func newImpressionWorkerFactory(
 	name string,
-	recorder *api.HTTPImpressionRecorder,
+	recorder RawRecorder,
 	logger logging.LoggerInterface,
 ) WorkerFactory {
 	var i *int = common.IntRef(0)
@@ -61,7 +60,7 @@ func newImpressionWorkerFactory(
 
 // NewImpressionsFlushTask creates a new impressions flushing task
 func NewImpressionsFlushTask(
-	recorder *api.HTTPImpressionRecorder,
+	recorder RawRecorder,
 	logger logging.LoggerInterface,
 	period int,
 	queueSize int,
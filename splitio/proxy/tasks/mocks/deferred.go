@@ -1,9 +1,16 @@
 package mocks
 
-import "github.com/stretchr/testify/mock"
+import (
+	"context"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/tasks"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type MockDeferredRecordingTask struct {
 	StageCall     func(rawData interface{}) error
+	FlushCall     func(ctx context.Context) tasks.FlushReport
 	StartCall     func()
 	StopCall      func(blocking bool) error
 	IsRunningCall func() bool
@@ -13,6 +20,10 @@ func (t *MockDeferredRecordingTask) Stage(rawData interface{}) error {
 	return t.StageCall(rawData)
 }
 
+func (t *MockDeferredRecordingTask) Flush(ctx context.Context) tasks.FlushReport {
+	return t.FlushCall(ctx)
+}
+
 func (t *MockDeferredRecordingTask) Start() {
 	t.StartCall()
 }
@@ -34,6 +45,11 @@ func (t *DeferredRecordingTaskMock) Stage(rawData interface{}) error {
 	return args.Error(1)
 }
 
+func (t *DeferredRecordingTaskMock) Flush(ctx context.Context) tasks.FlushReport {
+	args := t.Called(ctx)
+	return args.Get(0).(tasks.FlushReport)
+}
+
 func (t *DeferredRecordingTaskMock) Start() {
 	t.Called()
 }
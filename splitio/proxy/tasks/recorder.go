@@ -0,0 +1,98 @@
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-split-commons/v9/service/api"
+	"github.com/splitio/go-toolkit/v5/logging"
+)
+
+// UpstreamError is returned when Split servers reject a post. Unlike the errors returned by the commons http client,
+// it carries the delay requested by the server via the `Retry-After` header (if any)
+type UpstreamError struct {
+	Code       int
+	Message    string
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("POST failed with status code %d - %s", e.Code, e.Message)
+}
+
+// HTTPRawRecorder posts already-serialized payloads to Split servers
+type HTTPRawRecorder struct {
+	baseURL string
+	apikey  string
+	client  *http.Client
+	logger  logging.LoggerInterface
+}
+
+// NewHTTPRawRecorder constructs a recorder posting to the supplied base url
+func NewHTTPRawRecorder(apikey string, baseURL string, timeout time.Duration, logger logging.LoggerInterface) *HTTPRawRecorder {
+	return &HTTPRawRecorder{
+		baseURL: baseURL,
+		apikey:  apikey,
+		client:  &http.Client{Timeout: timeout},
+		logger:  logger,
+	}
+}
+
+// RecordRaw posts a payload to the specified endpoint
+func (r *HTTPRawRecorder) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, r.baseURL+url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range api.AddMetadataToHeaders(metadata, extraHeaders, nil) {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apikey)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting data to '%s': %w", req.URL.String(), err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // drain the body so that the connection can be reused
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	r.logger.Error(fmt.Sprintf("POST [%s] Status Code: %d - %s", req.URL.String(), resp.StatusCode, resp.Status))
+	return &UpstreamError{
+		Code:       resp.StatusCode,
+		Message:    resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: a number of seconds or an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if when, err := http.ParseTime(value); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+	return 0
+}
+
+var _ RawRecorder = (*HTTPRawRecorder)(nil)
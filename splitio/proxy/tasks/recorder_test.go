@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRawRecorder(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/events/bulk", r.URL.Path)
+		assert.Equal(t, "Bearer someApikey", r.Header.Get("Authorization"))
+		assert.Equal(t, "go-1.2.3", r.Header.Get("SplitSDKVersion"))
+		assert.Equal(t, "someValue", r.Header.Get("SomeHeader"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "[]", string(body))
+
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "120")
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	recorder := NewHTTPRawRecorder("someApikey", server.URL+"/api", time.Second, logging.NewLogger(nil))
+	metadata := dtos.Metadata{SDKVersion: "go-1.2.3"}
+	headers := map[string]string{"SomeHeader": "someValue"}
+	assert.Nil(t, recorder.RecordRaw("/events/bulk", []byte("[]"), metadata, headers))
+
+	status = http.StatusTooManyRequests
+	err := recorder.RecordRaw("/events/bulk", []byte("[]"), metadata, headers)
+	var upstreamErr *UpstreamError
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, http.StatusTooManyRequests, upstreamErr.Code)
+	assert.Equal(t, 120*time.Second, upstreamErr.RetryAfter)
+
+	status = http.StatusInternalServerError
+	err = recorder.RecordRaw("/events/bulk", []byte("[]"), metadata, headers)
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, http.StatusInternalServerError, upstreamErr.Code)
+	assert.Equal(t, time.Duration(0), upstreamErr.RetryAfter)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("garbage", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
@@ -0,0 +1,254 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrSpillFull is returned when the data on disk has reached the configured limit
+var ErrSpillFull = errors.New("spill storage is full")
+
+// size of the header prepended to every spilled record (creation timestamp in nanoseconds)
+const spillHeaderSize = 8
+
+// SpillTelemetry captures the activity of the spill-to-disk queues
+type SpillTelemetry interface {
+	IncrSpilled(queue string)
+	IncrReplayed(queue string)
+	IncrSpillExpired(queue string)
+}
+
+// SpillStore is an append-only, boltdb-backed store used to keep data that could not be posted to Split servers
+// (or couldn't fit in memory) across outages & restarts. Each queue is stored in a separate bucket
+type SpillStore struct {
+	db       *bolt.DB
+	maxBytes int64
+	maxAge   time.Duration
+	size     int64
+	logger   logging.LoggerInterface
+	queues   map[string]*SpillQueue
+	mutex    sync.Mutex
+}
+
+// NewSpillStore opens (or creates) the spill file. Data left by a previous run is kept and replayed
+func NewSpillStore(filename string, maxBytes int64, maxAge time.Duration, logger logging.LoggerInterface) (*SpillStore, error) {
+	db, err := bolt.Open(filename, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening spill file '%s': %w", filename, err)
+	}
+
+	store := &SpillStore{db: db, maxBytes: maxBytes, maxAge: maxAge, logger: logger, queues: make(map[string]*SpillQueue)}
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			queue := store.Queue(string(name))
+			return bucket.ForEach(func(_, value []byte) error {
+				store.size += int64(len(value))
+				queue.count++
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error reading spill file '%s': %w", filename, err)
+	}
+	return store, nil
+}
+
+// Queue returns the queue with the supplied name, creating it if necessary
+func (s *SpillStore) Queue(name string) *SpillQueue {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if queue, ok := s.queues[name]; ok {
+		return queue
+	}
+	queue := &SpillQueue{name: name, bucket: []byte(name), store: s}
+	s.queues[name] = queue
+	return queue
+}
+
+// Size returns the amount of bytes currently spilled across all queues
+func (s *SpillStore) Size() int64 {
+	return atomic.LoadInt64(&s.size)
+}
+
+// Close releases the underlying file
+func (s *SpillStore) Close() error {
+	return s.db.Close()
+}
+
+// SpillQueue is a durable FIFO queue holding serialized payloads for a single deferred recording task
+type SpillQueue struct {
+	name   string
+	bucket []byte
+	store  *SpillStore
+	count  int64
+}
+
+// spilledEntry is an item read from disk, along with the information required to put it back in place
+type spilledEntry struct {
+	key   uint64
+	value []byte
+	item  interface{}
+}
+
+// Name returns the name of the queue
+func (q *SpillQueue) Name() string {
+	return q.name
+}
+
+// Len returns the amount of items currently on disk
+func (q *SpillQueue) Len() int64 {
+	return atomic.LoadInt64(&q.count)
+}
+
+// Push serializes an item & appends it to the queue
+func (q *SpillQueue) Push(item interface{}) error {
+	encoded, err := encodeSpilled(item)
+	if err != nil {
+		return err
+	}
+
+	value := make([]byte, spillHeaderSize+len(encoded))
+	binary.BigEndian.PutUint64(value, uint64(time.Now().UnixNano()))
+	copy(value[spillHeaderSize:], encoded)
+	return q.put(0, value)
+}
+
+// restore puts back an entry previously popped under its original key, so that it keeps its position in the queue
+func (q *SpillQueue) restore(entry *spilledEntry) error {
+	return q.put(entry.key, entry.value)
+}
+
+func (q *SpillQueue) put(key uint64, value []byte) error {
+	if atomic.AddInt64(&q.store.size, int64(len(value))) > q.store.maxBytes {
+		atomic.AddInt64(&q.store.size, -int64(len(value)))
+		return ErrSpillFull
+	}
+
+	err := q.store.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(q.bucket)
+		if err != nil {
+			return err
+		}
+		if key == 0 {
+			if key, err = bucket.NextSequence(); err != nil {
+				return err
+			}
+		}
+		return bucket.Put(itob(key), value)
+	})
+	if err != nil {
+		atomic.AddInt64(&q.store.size, -int64(len(value)))
+		return fmt.Errorf("error writing to spill file: %w", err)
+	}
+	atomic.AddInt64(&q.count, 1)
+	return nil
+}
+
+// pop removes up to `max` items from the head of the queue. Items older than the configured max age are
+// discarded & reported separately
+func (q *SpillQueue) pop(max int) (entries []spilledEntry, expired int, err error) {
+	if max <= 0 || q.Len() == 0 {
+		return nil, 0, nil
+	}
+
+	threshold := time.Now().Add(-q.store.maxAge).UnixNano()
+	var removedBytes int64
+	err = q.store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(q.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		var toDelete [][]byte
+		cursor := bucket.Cursor()
+		for key, value := cursor.First(); key != nil && len(entries) < max; key, value = cursor.Next() {
+			toDelete = append(toDelete, append([]byte(nil), key...))
+			removedBytes += int64(len(value))
+			if len(value) < spillHeaderSize || int64(binary.BigEndian.Uint64(value)) < threshold {
+				expired++
+				continue
+			}
+
+			item, err := decodeSpilled(value[spillHeaderSize:])
+			if err != nil {
+				q.store.logger.Error(fmt.Sprintf("discarding unreadable item from spill queue '%s': %s", q.name, err))
+				expired++
+				continue
+			}
+			entries = append(entries, spilledEntry{key: binary.BigEndian.Uint64(key), value: append([]byte(nil), value...), item: item})
+		}
+
+		for _, key := range toDelete {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading from spill file: %w", err)
+	}
+
+	atomic.AddInt64(&q.store.size, -removedBytes)
+	atomic.AddInt64(&q.count, -int64(len(entries)+expired))
+	return entries, expired, nil
+}
+
+// spillRecord is the on-disk representation of the payloads accepted by deferred recording tasks
+type spillRecord struct {
+	Metadata    dtos.Metadata
+	Payload     []byte
+	Mode        string
+	Impressions bool
+}
+
+func encodeSpilled(item interface{}) ([]byte, error) {
+	var record spillRecord
+	switch typed := item.(type) {
+	case *internal.RawImpressions:
+		record = spillRecord{Metadata: typed.Metadata, Payload: typed.Payload, Mode: typed.Mode, Impressions: true}
+	case *internal.RawData:
+		record = spillRecord{Metadata: typed.Metadata, Payload: typed.Payload}
+	default:
+		return nil, fmt.Errorf("cannot spill items of type %T", item)
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(record); err != nil {
+		return nil, fmt.Errorf("error serializing item to be spilled: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeSpilled(raw []byte) (interface{}, error) {
+	var record spillRecord
+	if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&record); err != nil {
+		return nil, err
+	}
+
+	if record.Impressions {
+		return internal.NewRawImpressions(record.Metadata, record.Mode, record.Payload), nil
+	}
+	return &internal.RawData{Metadata: record.Metadata, Payload: record.Payload}, nil
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
@@ -0,0 +1,223 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
+
+	"github.com/splitio/go-split-commons/v9/dtos"
+	"github.com/splitio/go-toolkit/v5/logging"
+	"github.com/splitio/go-toolkit/v5/workerpool"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpillQueueOrderAndPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.db")
+	logger := logging.NewLogger(nil)
+	store, err := NewSpillStore(path, 1<<20, time.Hour, logger)
+	assert.Nil(t, err)
+
+	queue := store.Queue("events")
+	assert.Same(t, queue, store.Queue("events"))
+	for _, payload := range []string{"a", "b", "c"} {
+		assert.Nil(t, queue.Push(&internal.RawData{Metadata: dtos.Metadata{SDKVersion: "go-1.1.1"}, Payload: []byte(payload)}))
+	}
+	assert.Nil(t, store.Queue("impressions").Push(internal.NewRawImpressions(dtos.Metadata{}, "optimized", []byte("i"))))
+	assert.Equal(t, int64(3), queue.Len())
+	sizeBefore := store.Size()
+	assert.Nil(t, store.Close())
+
+	// data survives a restart
+	store, err = NewSpillStore(path, 1<<20, time.Hour, logger)
+	assert.Nil(t, err)
+	defer store.Close()
+	assert.Equal(t, sizeBefore, store.Size())
+	queue = store.Queue("events")
+	assert.Equal(t, int64(3), queue.Len())
+
+	entries, expired, err := queue.pop(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, expired)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, []byte("a"), entries[0].item.(*internal.RawData).Payload)
+	assert.Equal(t, "go-1.1.1", entries[0].item.(*internal.RawData).Metadata.SDKVersion)
+	assert.Equal(t, []byte("b"), entries[1].item.(*internal.RawData).Payload)
+	assert.Equal(t, int64(1), queue.Len())
+
+	// restored items keep their position
+	assert.Nil(t, queue.restore(&entries[1]))
+	entries, _, err = queue.pop(10)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, []byte("b"), entries[0].item.(*internal.RawData).Payload)
+	assert.Equal(t, []byte("c"), entries[1].item.(*internal.RawData).Payload)
+
+	imps, _, err := store.Queue("impressions").pop(10)
+	assert.Nil(t, err)
+	assert.Len(t, imps, 1)
+	assert.Equal(t, "optimized", imps[0].item.(*internal.RawImpressions).Mode)
+	assert.Equal(t, int64(0), store.Size())
+}
+
+func TestSpillQueueLimits(t *testing.T) {
+	store, err := NewSpillStore(filepath.Join(t.TempDir(), "spill.db"), 400, time.Hour, logging.NewLogger(nil))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	queue := store.Queue("events")
+	assert.Nil(t, queue.Push(&internal.RawData{Payload: make([]byte, 100)}))
+	assert.ErrorIs(t, queue.Push(&internal.RawData{Payload: make([]byte, 300)}), ErrSpillFull)
+	assert.Equal(t, int64(1), queue.Len())
+	assert.Error(t, queue.Push("unsupported"))
+
+	store.maxAge = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	entries, expired, err := queue.pop(10)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, int64(0), queue.Len())
+	assert.Equal(t, int64(0), store.Size())
+}
+
+type flakyWorker struct {
+	failing *int32
+	posted  *[]string
+	mutex   *sync.Mutex
+}
+
+func (w *flakyWorker) Name() string       { return "flaky" }
+func (w *flakyWorker) OnError(e error)    {}
+func (w *flakyWorker) Cleanup() error     { return nil }
+func (w *flakyWorker) FailureTime() int64 { return 0 }
+func (w *flakyWorker) DoWork(message interface{}) error {
+	if atomic.LoadInt32(w.failing) == 1 {
+		return errors.New("split is down")
+	}
+	w.mutex.Lock()
+	*w.posted = append(*w.posted, string(message.(*internal.RawData).Payload))
+	w.mutex.Unlock()
+	return nil
+}
+
+type spillTelemetryMock struct {
+	spilled, replayed, expired int64
+}
+
+func (m *spillTelemetryMock) IncrSpilled(string)      { atomic.AddInt64(&m.spilled, 1) }
+func (m *spillTelemetryMock) IncrReplayed(string)     { atomic.AddInt64(&m.replayed, 1) }
+func (m *spillTelemetryMock) IncrSpillExpired(string) { atomic.AddInt64(&m.expired, 1) }
+
+func TestDeferredTaskSpillAndReplay(t *testing.T) {
+	store, err := NewSpillStore(filepath.Join(t.TempDir(), "spill.db"), 1<<20, time.Hour, logging.NewLogger(nil))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	failing := int32(1)
+	var posted []string
+	var mutex sync.Mutex
+	factory := func() workerpool.Worker { return &flakyWorker{failing: &failing, posted: &posted, mutex: &mutex} }
+
+	telemetry := &spillTelemetryMock{}
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 2, 1)
+	task.SpillTo(store.Queue("events"), telemetry)
+	task.Start()
+	defer task.Stop(false)
+
+	// the in-memory queue holds 2 items, the rest goes to disk
+	for _, payload := range []string{"1", "2", "3", "4"} {
+		assert.Nil(t, task.Stage(&internal.RawData{Payload: []byte(payload)}))
+	}
+	assert.Equal(t, int64(2), task.spill.Len())
+
+	// posting fails, so everything ends up on disk
+	task.task.WakeUp()
+	assert.Eventually(t, func() bool { return task.spill.Len() == 4 && atomic.LoadInt64(&task.counters.inFlight) == 0 }, time.Second, 5*time.Millisecond)
+
+	// while split is down, new data goes straight to disk
+	assert.Nil(t, task.Stage(&internal.RawData{Payload: []byte("5")}))
+	assert.Equal(t, 0, len(task.queue))
+	assert.Equal(t, int64(5), task.spill.Len())
+
+	atomic.StoreInt32(&failing, 0)
+	assert.Eventually(t, func() bool {
+		task.task.WakeUp()
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(posted) == 5
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int64(0), task.spill.Len())
+	assert.Equal(t, int64(5), atomic.LoadInt64(&telemetry.replayed))
+	assert.False(t, task.upstreamFailing.IsSet())
+
+	// once recovered, data is kept in memory again
+	assert.Nil(t, task.Stage(&internal.RawData{Payload: []byte("6")}))
+	assert.Equal(t, 1, len(task.queue))
+	assert.Equal(t, int64(0), task.spill.Len())
+	report := task.Flush(context.Background())
+	assert.Equal(t, int64(1), report.Flushed)
+}
+
+func TestFailedPostsAreSpilled(t *testing.T) {
+	store, err := NewSpillStore(filepath.Join(t.TempDir(), "spill.db"), 1<<20, time.Hour, logging.NewLogger(nil))
+	assert.Nil(t, err)
+	defer store.Close()
+
+	recorder := &failingRawRecorder{}
+	for name, task := range map[string]*DeferredRecordingTaskImpl{
+		"events":           NewEventsFlushTask(recorder, logging.NewLogger(nil), 3600, 10, 1),
+		"telemetry-config": NewTelemetryConfigFlushTask(recorder, logging.NewLogger(nil), 3600, 10, 1),
+	} {
+		task.SpillTo(store.Queue(name), nil)
+		task.Start()
+		assert.Nil(t, task.Stage(&internal.RawData{Payload: []byte("{}")}))
+		task.task.WakeUp()
+		assert.Eventually(t, func() bool { return task.spill.Len() == 1 }, time.Second, 5*time.Millisecond, name)
+		task.Stop(false)
+	}
+}
+
+type failingRawRecorder struct{}
+
+func (r *failingRawRecorder) RecordRaw(url string, data []byte, metadata dtos.Metadata, extraHeaders map[string]string) error {
+	return errors.New("split servers unavailable")
+}
+
+type slowFailingWorker struct{ delay time.Duration }
+
+func (w *slowFailingWorker) Name() string       { return "slow" }
+func (w *slowFailingWorker) OnError(e error)    {}
+func (w *slowFailingWorker) Cleanup() error     { return nil }
+func (w *slowFailingWorker) FailureTime() int64 { return 0 }
+func (w *slowFailingWorker) DoWork(message interface{}) error {
+	time.Sleep(w.delay)
+	return errors.New("split is down")
+}
+
+func TestFlushWaitsForInFlightPostsBeforeSpillCanBeClosed(t *testing.T) {
+	store, err := NewSpillStore(filepath.Join(t.TempDir(), "spill.db"), 1<<20, time.Hour, logging.NewLogger(nil))
+	assert.Nil(t, err)
+
+	factory := func() workerpool.Worker { return &slowFailingWorker{delay: 200 * time.Millisecond} }
+	task := newDeferredFlushTask(logging.NewLogger(nil), factory, 3600, 10, 1)
+	task.SpillTo(store.Queue("events"), nil)
+	task.Start()
+	assert.Nil(t, task.Stage(&internal.RawData{Payload: []byte("1")}))
+
+	// the deadline expires while the post is in flight, but the flush only returns once it has been spilled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report := task.Flush(ctx)
+	assert.Equal(t, int64(0), report.Dropped)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&task.counters.inFlight))
+	assert.Equal(t, int64(1), task.spill.Len())
+	assert.Nil(t, store.Close())
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/splitio/split-synchronizer/v5/splitio/proxy/internal"
 
-	"github.com/splitio/go-split-commons/v9/service/api"
 	"github.com/splitio/go-toolkit/v5/common"
 	"github.com/splitio/go-toolkit/v5/logging"
 	"github.com/splitio/go-toolkit/v5/workerpool"
@@ -17,7 +16,7 @@ import (
 type TelemetryConfigWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPTelemetryRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -40,11 +39,13 @@ func (w *TelemetryConfigWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/metrics/config", asTelemetryConfig.Payload, asTelemetryConfig.Metadata, nil)
+	if err := w.recorder.RecordRaw("/metrics/config", asTelemetryConfig.Payload, asTelemetryConfig.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting telemetry config to Split servers: %w", err)
+	}
 	return nil
 }
 
-func newTelemetryConfigWorkerFactory(name string, recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface) WorkerFactory {
+func newTelemetryConfigWorkerFactory(name string, recorder RawRecorder, logger logging.LoggerInterface) WorkerFactory {
 	var i *int = common.IntRef(0)
 	return func() workerpool.Worker {
 		defer func() { *i++ }()
@@ -53,7 +54,7 @@ func newTelemetryConfigWorkerFactory(name string, recorder *api.HTTPTelemetryRec
 }
 
 // NewTelemetryConfigFlushTask creates a new impressions flushing task
-func NewTelemetryConfigFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func NewTelemetryConfigFlushTask(recorder RawRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(logger, newTelemetryConfigWorkerFactory("telemetry-config-worker", recorder, logger), period, queueSize, threads)
 }
 
@@ -63,7 +64,7 @@ func NewTelemetryConfigFlushTask(recorder *api.HTTPTelemetryRecorder, logger log
 type TelemetryUsageWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPTelemetryRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -86,11 +87,13 @@ func (w *TelemetryUsageWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/metrics/usage", asTelemetryUsage.Payload, asTelemetryUsage.Metadata, nil)
+	if err := w.recorder.RecordRaw("/metrics/usage", asTelemetryUsage.Payload, asTelemetryUsage.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting telemetry usage to Split servers: %w", err)
+	}
 	return nil
 }
 
-func newTelemetryUsageWorkerFactory(name string, recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface) WorkerFactory {
+func newTelemetryUsageWorkerFactory(name string, recorder RawRecorder, logger logging.LoggerInterface) WorkerFactory {
 	var i *int = common.IntRef(0)
 	return func() workerpool.Worker {
 		defer func() { *i++ }()
@@ -99,7 +102,7 @@ func newTelemetryUsageWorkerFactory(name string, recorder *api.HTTPTelemetryReco
 }
 
 // NewTelemetryUsageFlushTask creates a new impressions flushing task
-func NewTelemetryUsageFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func NewTelemetryUsageFlushTask(recorder RawRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(logger, newTelemetryUsageWorkerFactory("telemetry-config-worker", recorder, logger), period, queueSize, threads)
 }
 
@@ -109,7 +112,7 @@ func NewTelemetryUsageFlushTask(recorder *api.HTTPTelemetryRecorder, logger logg
 type TelemetryKeysClientSideWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPTelemetryRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -132,11 +135,13 @@ func (w *TelemetryKeysClientSideWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/keys/cs", asTelemetryKeysClientSide.Payload, asTelemetryKeysClientSide.Metadata, nil)
+	if err := w.recorder.RecordRaw("/keys/cs", asTelemetryKeysClientSide.Payload, asTelemetryKeysClientSide.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting client-side keys to Split servers: %w", err)
+	}
 	return nil
 }
 
-func newTelemetryKeysClientSideWorkerFactory(name string, recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface) WorkerFactory {
+func newTelemetryKeysClientSideWorkerFactory(name string, recorder RawRecorder, logger logging.LoggerInterface) WorkerFactory {
 	var i *int = common.IntRef(0)
 	return func() workerpool.Worker {
 		defer func() { *i++ }()
@@ -145,7 +150,7 @@ func newTelemetryKeysClientSideWorkerFactory(name string, recorder *api.HTTPTele
 }
 
 // NewTelemetryKeysClientSideFlushTask creates a new flushing task
-func NewTelemetryKeysClientSideFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func NewTelemetryKeysClientSideFlushTask(recorder RawRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(logger, newTelemetryKeysClientSideWorkerFactory("telemetry-keys-client-side-worker", recorder, logger), period, queueSize, threads)
 }
 
@@ -155,7 +160,7 @@ func NewTelemetryKeysClientSideFlushTask(recorder *api.HTTPTelemetryRecorder, lo
 type TelemetryKeysServerSideWorker struct {
 	name     string
 	logger   logging.LoggerInterface
-	recorder *api.HTTPTelemetryRecorder
+	recorder RawRecorder
 }
 
 // Name returns the name of the worker
@@ -178,11 +183,13 @@ func (w *TelemetryKeysServerSideWorker) DoWork(message interface{}) error {
 		return nil
 	}
 
-	w.recorder.RecordRaw("/keys/ss", asTelemetryKeysServerSide.Payload, asTelemetryKeysServerSide.Metadata, nil)
+	if err := w.recorder.RecordRaw("/keys/ss", asTelemetryKeysServerSide.Payload, asTelemetryKeysServerSide.Metadata, nil); err != nil {
+		return fmt.Errorf("error posting server-side keys to Split servers: %w", err)
+	}
 	return nil
 }
 
-func newTelemetryKeysServerSideWorkerWorkerFactory(name string, recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface) WorkerFactory {
+func newTelemetryKeysServerSideWorkerWorkerFactory(name string, recorder RawRecorder, logger logging.LoggerInterface) WorkerFactory {
 	var i *int = common.IntRef(0)
 	return func() workerpool.Worker {
 		defer func() { *i++ }()
@@ -191,6 +198,6 @@ func newTelemetryKeysServerSideWorkerWorkerFactory(name string, recorder *api.HT
 }
 
 // NewTelemetryKeysServerSideFlushTask creates a new flushing task
-func NewTelemetryKeysServerSideFlushTask(recorder *api.HTTPTelemetryRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
+func NewTelemetryKeysServerSideFlushTask(recorder RawRecorder, logger logging.LoggerInterface, period int, queueSize int, threads int) *DeferredRecordingTaskImpl {
 	return newDeferredFlushTask(logger, newTelemetryKeysServerSideWorkerWorkerFactory("telemetry-keys-server-side-worker", recorder, logger), period, queueSize, threads)
 }